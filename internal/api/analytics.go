@@ -3,10 +3,13 @@ package api
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/patrickvassell/cks-weight-room/internal/database"
+	"github.com/patrickvassell/cks-weight-room/internal/trends"
 )
 
 // AnalyticsData represents comprehensive analytics data
@@ -19,9 +22,20 @@ type AnalyticsData struct {
 	PersonalBestsSet       int                    `json:"personalBestsSet"`
 	MockExamsTaken         int                    `json:"mockExamsTaken"`
 	MockExamsPassed        int                    `json:"mockExamsPassed"`
+	CurriculumVersion      string                 `json:"curriculumVersion"`
 	ProgressByDomain       []DetailedDomain       `json:"progressByDomain"`
 	PersonalBests          []PersonalBest         `json:"personalBests"`
 	PracticeTimeBreakdown  PracticeTimeBreakdown  `json:"practiceTimeBreakdown"`
+	HintUsage              HintUsage              `json:"hintUsage"`
+	TimeSeries             *trends.Report         `json:"timeSeries"`
+}
+
+// HintUsage summarises how often hints were needed
+type HintUsage struct {
+	HintsRevealed     int     `json:"hintsRevealed"`     // Across validated attempts
+	AttemptsWithHints int     `json:"attemptsWithHints"`
+	HintRate          float64 `json:"hintRate"`          // Percentage of attempts that used hints
+	PenaltyPoints     int     `json:"penaltyPoints"`     // Score lost to hint penalties
 }
 
 // DetailedDomain represents progress for a domain with individual scenarios
@@ -33,6 +47,15 @@ type DetailedDomain struct {
 	TotalCount          int                `json:"totalCount"`
 	CompletionPercentage float64           `json:"completionPercentage"`
 	Scenarios           []ScenarioProgress `json:"scenarios"`
+	Competencies        []CompetencyProgress `json:"competencies"`
+}
+
+// CompetencyProgress represents progress on one sub-competency of a domain
+type CompetencyProgress struct {
+	Slug           string `json:"slug"`
+	Name           string `json:"name"`
+	ExerciseCount  int    `json:"exerciseCount"` // Exercises tagged with it
+	CompletedCount int    `json:"completedCount"`
 }
 
 // ScenarioProgress represents progress for a single scenario
@@ -44,6 +67,7 @@ type ScenarioProgress struct {
 	Attempts        int    `json:"attempts"`
 	LastPracticed   string `json:"lastPracticed"` // ISO timestamp or empty
 	Status          string `json:"status"` // "not-started", "in-progress", "completed"
+	HintsUsed       int    `json:"hintsUsed"` // Across all attempts
 }
 
 // PersonalBest represents a personal best time for a scenario
@@ -67,7 +91,17 @@ type PracticeTimeBreakdown struct {
 	LongestSessionTime  int `json:"longestSessionTime"` // in seconds
 }
 
-// GetAnalytics handles GET /api/analytics
+// Limits of the time series query parameters
+const (
+	defaultSeriesDays = 90
+	maxSeriesDays     = 731
+	maxWindowDays     = 90
+)
+
+// GetAnalytics handles GET /api/analytics. The time series covers
+// ?from=YYYY-MM-DD to ?to=YYYY-MM-DD (the last 90 days by default), bucketed
+// by calendar day in ?tz (an IANA zone, the server's by default), with a
+// rolling average over ?window days (7 by default).
 func GetAnalytics(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -79,20 +113,41 @@ func GetAnalytics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	seriesOpts, err := parseSeriesOptions(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	curriculum, err := database.GetActiveCurriculum()
+	if err != nil {
+		http.Error(w, "Failed to load curriculum", http.StatusInternalServerError)
+		return
+	}
+	seriesOpts.Domains = curriculum.Domains
+
+	// Analytics cover the active profile
+	profileID, err := database.ActiveProfileID()
+	if err != nil {
+		http.Error(w, "Failed to load profile", http.StatusInternalServerError)
+		return
+	}
+
 	data := AnalyticsData{
-		ProgressByDomain: []DetailedDomain{},
-		PersonalBests:    []PersonalBest{},
+		CurriculumVersion: curriculum.Version,
+		ProgressByDomain:  []DetailedDomain{},
+		PersonalBests:     []PersonalBest{},
 	}
 
 	// Get total scenarios count
 	database.DB.QueryRow("SELECT COUNT(*) FROM exercises").Scan(&data.TotalScenarios)
 
 	// Get completed scenarios count
-	database.DB.QueryRow("SELECT COUNT(*) FROM progress WHERE status = 'completed'").Scan(&data.ScenariosCompleted)
+	database.DB.QueryRow("SELECT COUNT(*) FROM progress WHERE profile_id = ? AND status = 'completed'", profileID).Scan(&data.ScenariosCompleted)
 
 	// Get total practice time (sum of all attempts)
 	var totalSeconds sql.NullInt64
-	database.DB.QueryRow("SELECT COALESCE(SUM(duration_seconds), 0) FROM attempts").Scan(&totalSeconds)
+	database.DB.QueryRow("SELECT COALESCE(SUM(duration_seconds), 0) FROM attempts WHERE profile_id = ?", profileID).Scan(&totalSeconds)
 	if totalSeconds.Valid {
 		data.TotalPracticeSeconds = int(totalSeconds.Int64)
 	}
@@ -102,8 +157,8 @@ func GetAnalytics(w http.ResponseWriter, r *http.Request) {
 	database.DB.QueryRow(`
 		SELECT AVG(duration_seconds)
 		FROM attempts
-		WHERE passed = 1 AND duration_seconds > 0
-	`).Scan(&avgTime)
+		WHERE profile_id = ? AND passed = 1 AND duration_seconds > 0
+	`, profileID).Scan(&avgTime)
 	if avgTime.Valid {
 		data.AverageCompletionTime = int(avgTime.Float64)
 	}
@@ -113,38 +168,27 @@ func GetAnalytics(w http.ResponseWriter, r *http.Request) {
 	database.DB.QueryRow(`
 		SELECT AVG(CAST(score AS FLOAT) / CAST(max_score AS FLOAT) * 100)
 		FROM attempts
-		WHERE max_score > 0
-	`).Scan(&avgScore)
+		WHERE profile_id = ? AND max_score > 0
+	`, profileID).Scan(&avgScore)
 	if avgScore.Valid {
 		data.AverageScore = avgScore.Float64
 	}
 
 	// Get personal bests count
-	database.DB.QueryRow("SELECT COUNT(*) FROM progress WHERE personal_best_seconds IS NOT NULL").Scan(&data.PersonalBestsSet)
+	database.DB.QueryRow("SELECT COUNT(*) FROM progress WHERE profile_id = ? AND personal_best_seconds IS NOT NULL", profileID).Scan(&data.PersonalBestsSet)
 
 	// Get mock exams stats
-	database.DB.QueryRow("SELECT COUNT(*) FROM mock_exams").Scan(&data.MockExamsTaken)
-	database.DB.QueryRow("SELECT COUNT(*) FROM mock_exams WHERE passed = 1").Scan(&data.MockExamsPassed)
+	database.DB.QueryRow("SELECT COUNT(*) FROM mock_exams WHERE profile_id = ?", profileID).Scan(&data.MockExamsTaken)
+	database.DB.QueryRow("SELECT COUNT(*) FROM mock_exams WHERE profile_id = ? AND passed = 1", profileID).Scan(&data.MockExamsPassed)
 
 	// Get detailed progress by domain
-	domains := map[string]struct {
-		displayName string
-		weight      int
-	}{
-		"cluster-setup":                       {"Cluster Setup", 10},
-		"cluster-hardening":                   {"Cluster Hardening", 15},
-		"system-hardening":                    {"System Hardening", 15},
-		"minimize-microservice-vulnerabilities": {"Minimize Microservice Vulnerabilities", 20},
-		"supply-chain-security":               {"Supply Chain Security", 20},
-		"monitoring-logging-runtime-security": {"Monitoring, Logging & Runtime Security", 20},
-	}
-
-	for domain, info := range domains {
+	for _, domain := range curriculum.Domains {
 		detailedDomain := DetailedDomain{
-			Domain:      domain,
-			DisplayName: info.displayName,
-			Weight:      info.weight,
-			Scenarios:   []ScenarioProgress{},
+			Domain:      domain.Slug,
+			DisplayName: domain.DisplayName,
+			Weight:       domain.Weight,
+			Scenarios:    []ScenarioProgress{},
+			Competencies: []CompetencyProgress{},
 		}
 
 		// Get all scenarios for this domain
@@ -156,12 +200,13 @@ func GetAnalytics(w http.ResponseWriter, r *http.Request) {
 				COALESCE(p.personal_best_seconds, 0) as personal_best,
 				COALESCE(p.attempts, 0) as attempts,
 				COALESCE(p.completed_at, '') as last_practiced,
-				COALESCE(p.status, 'not-started') as status
+				COALESCE(p.status, 'not-started') as status,
+				(SELECT COALESCE(SUM(a.hints_used), 0) FROM attempts a WHERE a.exercise_id = e.id AND a.profile_id = ?) as hints_used
 			FROM exercises e
-			LEFT JOIN progress p ON e.id = p.exercise_id
+			LEFT JOIN progress p ON e.id = p.exercise_id AND p.profile_id = ?
 			WHERE e.category = ?
 			ORDER BY e.id
-		`, domain)
+		`, profileID, profileID, domain.Slug)
 
 		if err == nil {
 			defer rows.Close()
@@ -176,6 +221,7 @@ func GetAnalytics(w http.ResponseWriter, r *http.Request) {
 					&scenario.Attempts,
 					&lastPracticed,
 					&scenario.Status,
+					&scenario.HintsUsed,
 				)
 				if lastPracticed.Valid {
 					scenario.LastPracticed = lastPracticed.String
@@ -188,6 +234,18 @@ func GetAnalytics(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		// Sub-competency coverage, counting exercises tagged with each
+		for _, competency := range domain.Competencies {
+			progress := CompetencyProgress{Slug: competency.Slug, Name: competency.Name}
+			database.DB.QueryRow(`
+				SELECT COUNT(*), COALESCE(SUM(CASE WHEN p.status = 'completed' THEN 1 ELSE 0 END), 0)
+				FROM exercise_competencies ec
+				LEFT JOIN progress p ON p.exercise_id = ec.exercise_id AND p.profile_id = ?
+				WHERE ec.competency = ?
+			`, profileID, competency.Slug).Scan(&progress.ExerciseCount, &progress.CompletedCount)
+			detailedDomain.Competencies = append(detailedDomain.Competencies, progress)
+		}
+
 		if detailedDomain.TotalCount > 0 {
 			detailedDomain.CompletionPercentage = float64(detailedDomain.CompletedCount) / float64(detailedDomain.TotalCount) * 100
 		}
@@ -207,9 +265,9 @@ func GetAnalytics(w http.ResponseWriter, r *http.Request) {
 			COALESCE(p.completed_at, '') as last_practiced
 		FROM progress p
 		JOIN exercises e ON p.exercise_id = e.id
-		WHERE p.personal_best_seconds IS NOT NULL
+		WHERE p.profile_id = ? AND p.personal_best_seconds IS NOT NULL
 		ORDER BY p.personal_best_seconds ASC
-	`)
+	`, profileID)
 
 	if err == nil {
 		defer rows.Close()
@@ -227,8 +285,8 @@ func GetAnalytics(w http.ResponseWriter, r *http.Request) {
 				&lastPracticed,
 			)
 			pb.Domain = domain
-			if info, ok := domains[domain]; ok {
-				pb.DomainDisplay = info.displayName
+			if d, ok := curriculum.Domain(domain); ok {
+				pb.DomainDisplay = d.DisplayName
 			}
 			if lastPracticed.Valid {
 				pb.LastPracticed = lastPracticed.String
@@ -247,8 +305,8 @@ func GetAnalytics(w http.ResponseWriter, r *http.Request) {
 	database.DB.QueryRow(`
 		SELECT COALESCE(SUM(duration_seconds), 0)
 		FROM attempts
-		WHERE datetime(completed_at) >= datetime(?)
-	`, oneWeekAgo.Format("2006-01-02 15:04:05")).Scan(&thisWeek)
+		WHERE profile_id = ? AND datetime(completed_at) >= datetime(?)
+	`, profileID, oneWeekAgo.Format("2006-01-02 15:04:05")).Scan(&thisWeek)
 	if thisWeek.Valid {
 		data.PracticeTimeBreakdown.ThisWeekSeconds = int(thisWeek.Int64)
 	}
@@ -258,8 +316,8 @@ func GetAnalytics(w http.ResponseWriter, r *http.Request) {
 	database.DB.QueryRow(`
 		SELECT COALESCE(SUM(duration_seconds), 0)
 		FROM attempts
-		WHERE datetime(completed_at) >= datetime(?)
-	`, oneMonthAgo.Format("2006-01-02 15:04:05")).Scan(&thisMonth)
+		WHERE profile_id = ? AND datetime(completed_at) >= datetime(?)
+	`, profileID, oneMonthAgo.Format("2006-01-02 15:04:05")).Scan(&thisMonth)
 	if thisMonth.Valid {
 		data.PracticeTimeBreakdown.ThisMonthSeconds = int(thisMonth.Int64)
 	}
@@ -269,18 +327,84 @@ func GetAnalytics(w http.ResponseWriter, r *http.Request) {
 
 	// Average session time
 	var avgSession sql.NullFloat64
-	database.DB.QueryRow("SELECT AVG(duration_seconds) FROM attempts WHERE duration_seconds > 0").Scan(&avgSession)
+	database.DB.QueryRow("SELECT AVG(duration_seconds) FROM attempts WHERE profile_id = ? AND duration_seconds > 0", profileID).Scan(&avgSession)
 	if avgSession.Valid {
 		data.PracticeTimeBreakdown.AverageSessionTime = int(avgSession.Float64)
 	}
 
 	// Longest session
 	var longest sql.NullInt64
-	database.DB.QueryRow("SELECT MAX(duration_seconds) FROM attempts").Scan(&longest)
+	database.DB.QueryRow("SELECT MAX(duration_seconds) FROM attempts WHERE profile_id = ?", profileID).Scan(&longest)
 	if longest.Valid {
 		data.PracticeTimeBreakdown.LongestSessionTime = int(longest.Int64)
 	}
 
+	// Hint usage
+	var attemptCount int
+	database.DB.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(hints_used), 0), COALESCE(SUM(CASE WHEN hints_used > 0 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(hint_penalty), 0)
+		FROM attempts
+		WHERE profile_id = ?
+	`, profileID).Scan(&attemptCount, &data.HintUsage.HintsRevealed, &data.HintUsage.AttemptsWithHints, &data.HintUsage.PenaltyPoints)
+	if attemptCount > 0 {
+		data.HintUsage.HintRate = float64(data.HintUsage.AttemptsWithHints) / float64(attemptCount) * 100
+	}
+
+	// Time series
+	series, err := trends.Current(seriesOpts, time.Now())
+	if err != nil {
+		http.Error(w, "Failed to build time series", http.StatusInternalServerError)
+		return
+	}
+	data.TimeSeries = &series
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(data)
 }
+
+// parseSeriesOptions reads the time series query parameters
+func parseSeriesOptions(r *http.Request, now time.Time) (trends.Options, error) {
+	query := r.URL.Query()
+	opts := trends.Options{Location: time.Local, WindowDays: trends.DefaultWindowDays}
+
+	if tz := query.Get("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return opts, fmt.Errorf("unknown time zone: %s", tz)
+		}
+		opts.Location = loc
+	}
+
+	opts.To = trends.Day(now, opts.Location)
+	if to := query.Get("to"); to != "" {
+		day, err := time.Parse(trends.DateLayout, to)
+		if err != nil {
+			return opts, fmt.Errorf("to must be a date (YYYY-MM-DD)")
+		}
+		opts.To = day
+	}
+	opts.From = opts.To.AddDate(0, 0, 1-defaultSeriesDays)
+	if from := query.Get("from"); from != "" {
+		day, err := time.Parse(trends.DateLayout, from)
+		if err != nil {
+			return opts, fmt.Errorf("from must be a date (YYYY-MM-DD)")
+		}
+		opts.From = day
+	}
+	if opts.From.After(opts.To) {
+		return opts, fmt.Errorf("from must not be after to")
+	}
+	if opts.To.Sub(opts.From).Hours()/24 >= maxSeriesDays {
+		return opts, fmt.Errorf("the range can span at most %d days", maxSeriesDays)
+	}
+
+	if window := query.Get("window"); window != "" {
+		n, err := strconv.Atoi(window)
+		if err != nil || n < 1 || n > maxWindowDays {
+			return opts, fmt.Errorf("window must be between 1 and %d days", maxWindowDays)
+		}
+		opts.WindowDays = n
+	}
+	return opts, nil
+}
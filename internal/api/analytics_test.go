@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseSeriesOptions(t *testing.T) {
+	now := time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC)
+
+	opts, err := parseSeriesOptions(httptest.NewRequest("GET", "/api/analytics?tz=UTC", nil), now)
+	if err != nil {
+		t.Fatalf("parseSeriesOptions failed: %v", err)
+	}
+	if opts.To.Format("2006-01-02") != "2026-03-10" || opts.From.Format("2006-01-02") != "2025-12-11" || opts.WindowDays != 7 {
+		t.Errorf("unexpected defaults %+v", opts)
+	}
+
+	// It is already the 11th in Tokyo
+	if _, err := time.LoadLocation("Asia/Tokyo"); err == nil {
+		opts, err = parseSeriesOptions(httptest.NewRequest("GET", "/api/analytics?tz=Asia/Tokyo&window=14", nil), now)
+		if err != nil || opts.To.Format("2006-01-02") != "2026-03-11" || opts.WindowDays != 14 {
+			t.Errorf("unexpected options in Tokyo %+v (%v)", opts, err)
+		}
+	}
+
+	for _, query := range []string{
+		"tz=Nowhere/City",
+		"from=03-01-2026",
+		"from=2026-03-05&to=2026-03-01",
+		"from=2020-01-01&to=2026-01-01",
+		"window=0",
+		"window=week",
+	} {
+		if _, err := parseSeriesOptions(httptest.NewRequest("GET", "/api/analytics?"+query, nil), now); err == nil {
+			t.Errorf("expected %q to be rejected", query)
+		}
+	}
+}
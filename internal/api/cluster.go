@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/patrickvassell/cks-weight-room/internal/cluster"
 	cerrors "github.com/patrickvassell/cks-weight-room/internal/errors"
+	"github.com/patrickvassell/cks-weight-room/internal/lifecycle"
 )
 
 // ClusterResponse represents the API response for cluster operations
@@ -244,6 +246,18 @@ func DeleteCluster(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Tear down sessions and helpers while the nodes still exist.
+	// ?reset=true marks a delete that is followed by re-provisioning.
+	event := lifecycle.Event{Type: lifecycle.ClusterDeleted, Slug: slug}
+	if r.URL.Query().Get("reset") == "true" {
+		event.Type = lifecycle.ExerciseReset
+	}
+	cleanupCtx, cancelCleanup := context.WithTimeout(r.Context(), 20*time.Second)
+	if err := lifecycle.Emit(cleanupCtx, event); err != nil {
+		log.Printf("Cleanup before deleting cluster for %s was incomplete: %v", slug, err)
+	}
+	cancelCleanup()
+
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+// CurriculumResponse is the response of the curriculum endpoints
+type CurriculumResponse struct {
+	Success      bool                  `json:"success"`
+	Curriculum   *database.Curriculum  `json:"curriculum,omitempty"`
+	Versions     []database.Curriculum `json:"versions,omitempty"`
+	Competencies []database.Competency `json:"competencies,omitempty"`
+	ErrorCode    string                `json:"errorCode,omitempty"`
+	Error        string                `json:"error,omitempty"`
+}
+
+// ExerciseCompetenciesRequest retags an exercise
+type ExerciseCompetenciesRequest struct {
+	Competencies []string `json:"competencies"`
+}
+
+// HandleCurriculum handles the curriculum registry API:
+//
+//	GET  /api/curriculum                      the active curriculum
+//	POST /api/curriculum                      add a version ({"version": ..., "domains": [...], "active": true})
+//	GET  /api/curriculum/versions             every version
+//	GET  /api/curriculum/{version}            one version
+//	POST /api/curriculum/{version}/activate   make a version the active one
+//	GET  /api/curriculum/exercises/{slug}     an exercise's competencies
+//	PUT  /api/curriculum/exercises/{slug}     retag it ({"competencies": [...]})
+//
+// Stats, exam selection, the practice schedule and readiness all use the
+// active version's domains and weights.
+func HandleCurriculum(w http.ResponseWriter, r *http.Request) {
+	if database.DB == nil {
+		writeCurriculumResponse(w, http.StatusInternalServerError, CurriculumResponse{Error: "Database not initialized"})
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/curriculum"), "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		curriculum, err := database.GetActiveCurriculum()
+		if err != nil {
+			writeCurriculumError(w, err)
+			return
+		}
+		writeCurriculumResponse(w, http.StatusOK, CurriculumResponse{Success: true, Curriculum: curriculum})
+
+	case path == "" && r.Method == http.MethodPost:
+		var curriculum database.Curriculum
+		if err := json.NewDecoder(r.Body).Decode(&curriculum); err != nil {
+			writeCurriculumResponse(w, http.StatusBadRequest, CurriculumResponse{Error: "Invalid request body"})
+			return
+		}
+		if err := database.SaveCurriculum(&curriculum); err != nil {
+			writeCurriculumError(w, err)
+			return
+		}
+		saved, err := database.GetCurriculum(curriculum.Version)
+		if err != nil {
+			writeCurriculumError(w, err)
+			return
+		}
+		log.Printf("Added curriculum %s (active: %v)", saved.Version, saved.Active)
+		writeCurriculumResponse(w, http.StatusCreated, CurriculumResponse{Success: true, Curriculum: saved})
+
+	case path == "versions" && r.Method == http.MethodGet:
+		versions, err := database.ListCurricula()
+		if err != nil {
+			writeCurriculumError(w, err)
+			return
+		}
+		writeCurriculumResponse(w, http.StatusOK, CurriculumResponse{Success: true, Versions: versions})
+
+	case len(parts) == 2 && parts[0] == "exercises" && r.Method == http.MethodGet:
+		competencies, err := database.GetExerciseCompetencies(parts[1])
+		if err != nil {
+			writeCurriculumError(w, err)
+			return
+		}
+		writeCurriculumResponse(w, http.StatusOK, CurriculumResponse{Success: true, Competencies: competencies})
+
+	case len(parts) == 2 && parts[0] == "exercises" && r.Method == http.MethodPut:
+		var req ExerciseCompetenciesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeCurriculumResponse(w, http.StatusBadRequest, CurriculumResponse{Error: "Invalid request body"})
+			return
+		}
+		if err := database.SetExerciseCompetencies(parts[1], req.Competencies); err != nil {
+			writeCurriculumError(w, err)
+			return
+		}
+		competencies, err := database.GetExerciseCompetencies(parts[1])
+		if err != nil {
+			writeCurriculumError(w, err)
+			return
+		}
+		writeCurriculumResponse(w, http.StatusOK, CurriculumResponse{Success: true, Competencies: competencies})
+
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		curriculum, err := database.GetCurriculum(parts[0])
+		if err != nil {
+			writeCurriculumError(w, err)
+			return
+		}
+		writeCurriculumResponse(w, http.StatusOK, CurriculumResponse{Success: true, Curriculum: curriculum})
+
+	case len(parts) == 2 && parts[1] == "activate" && r.Method == http.MethodPost:
+		if err := database.ActivateCurriculum(parts[0]); err != nil {
+			writeCurriculumError(w, err)
+			return
+		}
+		curriculum, err := database.GetCurriculum(parts[0])
+		if err != nil {
+			writeCurriculumError(w, err)
+			return
+		}
+		log.Printf("Activated curriculum %s", curriculum.Version)
+		writeCurriculumResponse(w, http.StatusOK, CurriculumResponse{Success: true, Curriculum: curriculum})
+
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// writeCurriculumError maps a database error to a curriculum response
+func writeCurriculumError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	response := CurriculumResponse{Error: err.Error()}
+
+	var dbErr *database.DatabaseError
+	if errors.As(err, &dbErr) {
+		response.ErrorCode = dbErr.Code
+		response.Error = dbErr.Message
+		switch dbErr.Code {
+		case database.ErrCodeCurriculumNotFound, database.ErrCodeExerciseNotFound:
+			status = http.StatusNotFound
+		case database.ErrCodeCurriculumExists:
+			status = http.StatusConflict
+		case database.ErrCodeInvalidCurriculum:
+			status = http.StatusBadRequest
+		}
+	}
+	writeCurriculumResponse(w, status, response)
+}
+
+// writeCurriculumResponse writes a curriculum response as JSON
+func writeCurriculumResponse(w http.ResponseWriter, status int, response CurriculumResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
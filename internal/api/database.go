@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 
 	"github.com/patrickvassell/cks-weight-room/internal/database"
@@ -61,6 +62,12 @@ func InitializeDatabase(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Bring the new schema up to date so seeding can tag exercises against
+	// the curriculum
+	if err := database.ApplyMigrations(); err != nil {
+		log.Printf("Failed to apply migrations: %v", err)
+	}
+
 	// Mark first launch as completed
 	database.SetConfig("first_launch_completed", "true")
 
@@ -0,0 +1,358 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/cluster"
+	"github.com/patrickvassell/cks-weight-room/internal/exam"
+	"github.com/patrickvassell/cks-weight-room/internal/lifecycle"
+	"github.com/patrickvassell/cks-weight-room/internal/timer"
+)
+
+// examGradingTimeout bounds grading every question of an exam
+const examGradingTimeout = 10 * time.Minute
+
+// examManager runs the mock exams; there is one shared exam environment
+var examManager = exam.NewManager(clusterExamEnvironment{}, gradeExamQuestion, timerService)
+
+// clusterExamEnvironment provisions exam clusters with KIND
+type clusterExamEnvironment struct{}
+
+// examKubeconfigPath returns the merged kubeconfig the exam terminal uses
+func examKubeconfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", "data", "exam", "kubeconfig")
+	}
+	return filepath.Join(home, ".cks-weight-room", "exam", "kubeconfig")
+}
+
+// Provision creates fresh exam clusters in parallel, runs every question's
+// exercise setup in its cluster, adds the question contexts and writes the
+// merged kubeconfig for the exam terminal
+func (clusterExamEnvironment) Provision(ctx context.Context, clusters []exam.ClusterSpec, questions []exam.Question, progress func(string)) error {
+	errs := make([]error, len(clusters))
+	var wg sync.WaitGroup
+	for i, spec := range clusters {
+		wg.Add(1)
+		go func(i int, spec exam.ClusterSpec) {
+			defer wg.Done()
+			errs[i] = provisionExamCluster(ctx, spec, progress)
+		}(i, spec)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	contexts := make(map[string]string)
+	for _, q := range questions {
+		clusterName := cluster.GetClusterName(q.ClusterSlug)
+		progress(fmt.Sprintf("Setting up question %d of %d (%s)...", q.Number, len(questions), q.Title))
+		if err := cluster.SetupExercise(ctx, q.Slug, clusterName); err != nil {
+			return fmt.Errorf("setup for %s failed: %w", q.Slug, err)
+		}
+		contexts[q.Context] = clusterName
+	}
+
+	// Validators use the default kubeconfig; the terminal gets only the exam contexts
+	for name, clusterName := range contexts {
+		if err := cluster.CreateContext(ctx, name, clusterName, ""); err != nil {
+			return err
+		}
+	}
+	if err := cluster.WriteMergedKubeconfig(ctx, examKubeconfigPath(), contexts); err != nil {
+		return err
+	}
+
+	// Re-capture baselines so workspace diffs cover every question's setup
+	for _, spec := range clusters {
+		if err := cluster.CaptureWorkspaceBaseline(ctx, cluster.GetClusterName(spec.Slug)); err != nil {
+			log.Printf("Failed to capture workspace baseline for %s: %v", spec.Slug, err)
+		}
+	}
+	return nil
+}
+
+// provisionExamCluster creates one exam cluster, replacing any leftover
+func provisionExamCluster(ctx context.Context, spec exam.ClusterSpec, progress func(string)) error {
+	// Start from a clean cluster so leftovers from an earlier exam can't leak in
+	if exists, err := cluster.ClusterExists(ctx, cluster.GetClusterName(spec.Slug)); err == nil && exists {
+		progress(fmt.Sprintf("[%s] Removing previous exam environment...", spec.Slug))
+		removeExamCluster(ctx, spec.Slug)
+	}
+
+	sshBasePort := spec.SSHBasePort
+	if sshBasePort == 0 {
+		sshBasePort = cluster.DefaultSSHBasePort
+	}
+
+	progressChan := make(chan string)
+	done := make(chan struct{})
+	go func() {
+		for msg := range progressChan {
+			progress(fmt.Sprintf("[%s] %s", spec.Slug, msg))
+		}
+		close(done)
+	}()
+	_, err := cluster.ProvisionClusterWithSSHPorts(ctx, spec.Slug, sshBasePort, progressChan)
+	close(progressChan)
+	<-done
+	return err
+}
+
+// Teardown removes the question contexts, the merged kubeconfig and the
+// exam clusters
+func (clusterExamEnvironment) Teardown(ctx context.Context, clusters []exam.ClusterSpec, questions []exam.Question) error {
+	removed := make(map[string]bool)
+	for _, q := range questions {
+		if removed[q.Context] {
+			continue
+		}
+		removed[q.Context] = true
+		if err := cluster.DeleteContext(ctx, q.Context); err != nil {
+			log.Printf("Failed to delete exam context %s: %v", q.Context, err)
+		}
+	}
+	if err := os.Remove(examKubeconfigPath()); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove exam kubeconfig: %v", err)
+	}
+
+	var errs []error
+	for _, spec := range clusters {
+		errs = append(errs, removeExamCluster(ctx, spec.Slug))
+	}
+	return errors.Join(errs...)
+}
+
+// removeExamCluster tears down sessions on an exam cluster and deletes it
+func removeExamCluster(ctx context.Context, slug string) error {
+	if err := lifecycle.Emit(ctx, lifecycle.Event{Type: lifecycle.ClusterDeleted, Slug: slug}); err != nil {
+		log.Printf("Cleanup before deleting exam cluster %s was incomplete: %v", slug, err)
+	}
+	return cluster.DeleteCluster(ctx, cluster.GetClusterName(slug))
+}
+
+// examTerminalKubeconfig returns the merged kubeconfig when slug is the
+// running exam's terminal, so the shell sees every exam context and none
+// is selected for the user
+func examTerminalKubeconfig(slug string) (string, bool) {
+	if slug != exam.EnvironmentSlug {
+		return "", false
+	}
+	active, ok := examManager.Active()
+	if !ok || active.Status == exam.StatusProvisioning {
+		return "", false
+	}
+	path := examKubeconfigPath()
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// gradeExamQuestion runs an exercise's validator against a question context
+func gradeExamQuestion(ctx context.Context, slug, kubeContext string) exam.Grade {
+	result := validateExercise(slug, kubeContext)
+	return exam.Grade{
+		Passed:   result.Passed,
+		Feedback: result.Feedback,
+		Details:  result.Details,
+	}
+}
+
+// ExamResponse represents the state of a mock exam
+type ExamResponse struct {
+	Success bool              `json:"success"`
+	Exam    *exam.Exam        `json:"exam,omitempty"`
+	Types   []exam.Definition `json:"types,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// StartExamRequest represents a request to start a mock exam
+type StartExamRequest struct {
+	Type exam.Type `json:"type"`
+	Mode exam.Mode `json:"mode,omitempty"` // "shared" (default) or "multi-cluster"
+}
+
+// QuestionMarkRequest sets or clears a flag/skip mark
+type QuestionMarkRequest struct {
+	Value bool `json:"value"`
+}
+
+// HandleExams handles the mock exam API:
+//
+//	GET    /api/exams                           exam types and the active exam
+//	POST   /api/exams                           start an exam ({"type": "full-mock-exam", "mode": "multi-cluster"})
+//	GET    /api/exams/{id}                      exam state and remaining time
+//	POST   /api/exams/{id}/questions/{n}/flag   flag or unflag a question ({"value": true})
+//	POST   /api/exams/{id}/questions/{n}/skip   skip or unskip a question ({"value": true})
+//	POST   /api/exams/{id}/pause                pause the countdown ({"reason": "environment-issue"})
+//	POST   /api/exams/{id}/resume               resume the countdown
+//	POST   /api/exams/{id}/finish               end the exam and grade every question
+//	DELETE /api/exams/{id}                      abandon the exam and delete its environment
+func HandleExams(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/exams"), "/")
+	if path == "" {
+		switch r.Method {
+		case http.MethodGet:
+			listExams(w)
+		case http.MethodPost:
+			startExam(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	parts := strings.Split(path, "/")
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		writeExamResponse(w, http.StatusBadRequest, ExamResponse{Error: "invalid exam id"})
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		e, err := examManager.Get(id)
+		writeExamResult(w, e, err)
+
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+		defer cancel()
+		if err := examManager.Close(ctx, id); err != nil && !errors.Is(err, exam.ErrNotFound) {
+			writeExamResponse(w, http.StatusInternalServerError, ExamResponse{Error: err.Error()})
+			return
+		}
+		writeExamResponse(w, http.StatusOK, ExamResponse{Success: true, Message: "Exam environment removed"})
+
+	case len(parts) == 2 && parts[1] == "finish" && r.Method == http.MethodPost:
+		ctx, cancel := context.WithTimeout(r.Context(), examGradingTimeout)
+		defer cancel()
+		e, err := examManager.Finish(ctx, id)
+		writeExamResult(w, e, err)
+
+	case len(parts) == 2 && parts[1] == "pause" && r.Method == http.MethodPost:
+		var req PauseTimerRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeExamResponse(w, http.StatusBadRequest, ExamResponse{Error: "Invalid request body"})
+			return
+		}
+		e, err := examManager.Pause(id, req.Reason)
+		writeExamResult(w, e, err)
+
+	case len(parts) == 2 && parts[1] == "resume" && r.Method == http.MethodPost:
+		e, err := examManager.Resume(id)
+		writeExamResult(w, e, err)
+
+	case len(parts) == 4 && parts[1] == "questions" && r.Method == http.MethodPost:
+		markQuestion(w, r, id, parts[2], parts[3])
+
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// listExams returns the available exam types and the active exam, if any
+func listExams(w http.ResponseWriter) {
+	response := ExamResponse{Success: true, Types: exam.Definitions}
+	if active, ok := examManager.Active(); ok {
+		response.Exam = &active
+	}
+	writeExamResponse(w, http.StatusOK, response)
+}
+
+// startExam builds a new exam and starts provisioning its environment
+func startExam(w http.ResponseWriter, r *http.Request) {
+	var req StartExamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeExamResponse(w, http.StatusBadRequest, ExamResponse{Error: "Invalid request body"})
+		return
+	}
+
+	e, err := examManager.Start(req.Type, req.Mode)
+	if err != nil {
+		writeExamResult(w, e, err)
+		return
+	}
+	writeExamResponse(w, http.StatusAccepted, ExamResponse{
+		Success: true,
+		Exam:    &e,
+		Message: "Provisioning exam environment; the countdown starts when it is ready",
+	})
+}
+
+// markQuestion flags or skips a question
+func markQuestion(w http.ResponseWriter, r *http.Request, id int64, numberParam, mark string) {
+	number, err := strconv.Atoi(numberParam)
+	if err != nil {
+		writeExamResponse(w, http.StatusBadRequest, ExamResponse{Error: "invalid question number"})
+		return
+	}
+
+	req := QuestionMarkRequest{Value: true}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeExamResponse(w, http.StatusBadRequest, ExamResponse{Error: "Invalid request body"})
+			return
+		}
+	}
+
+	var e exam.Exam
+	switch mark {
+	case "flag":
+		e, err = examManager.SetFlagged(id, number, req.Value)
+	case "skip":
+		e, err = examManager.SetSkipped(id, number, req.Value)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	writeExamResult(w, e, err)
+}
+
+// writeExamResult writes an exam, mapping manager errors to status codes
+func writeExamResult(w http.ResponseWriter, e exam.Exam, err error) {
+	if err == nil {
+		writeExamResponse(w, http.StatusOK, ExamResponse{Success: true, Exam: &e})
+		return
+	}
+
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, exam.ErrNotFound), errors.Is(err, exam.ErrUnknownQuestion):
+		status = http.StatusNotFound
+	case errors.Is(err, exam.ErrUnknownType), errors.Is(err, exam.ErrUnknownMode), errors.Is(err, timer.ErrPauseNotAllowed):
+		status = http.StatusBadRequest
+	case errors.Is(err, exam.ErrExamActive), errors.Is(err, exam.ErrNotInProgress), errors.Is(err, exam.ErrTimeUp),
+		errors.Is(err, timer.ErrNotRunning), errors.Is(err, timer.ErrNotPaused), errors.Is(err, timer.ErrPauseLimit):
+		status = http.StatusConflict
+	case errors.Is(err, exam.ErrNoExercises):
+		status = http.StatusUnprocessableEntity
+	}
+
+	response := ExamResponse{Error: err.Error()}
+	if e.ID != 0 {
+		response.Exam = &e
+	}
+	writeExamResponse(w, status, response)
+}
+
+// writeExamResponse writes an exam response as JSON
+func writeExamResponse(w http.ResponseWriter, status int, response ExamResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/patrickvassell/cks-weight-room/internal/database"
@@ -56,6 +57,11 @@ func GetExercises(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for i := range exercises {
+		exercises[i].HintCount = len(exercises[i].Hints)
+		exercises[i].Hints = []string{}
+	}
+
 	response := ExercisesResponse{
 		Success:   true,
 		Exercises: exercises,
@@ -65,6 +71,98 @@ func GetExercises(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// ExerciseSearchResponse represents the API response for an exercise search
+type ExerciseSearchResponse struct {
+	Success bool `json:"success"`
+	*database.ExerciseSearchResult
+	ErrorCode string `json:"errorCode,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// SearchExercises handles GET /api/exercises/search:
+//
+//	q           words to find in titles, descriptions and hints
+//	domain      domain slugs                    (repeat or separate with commas)
+//	difficulty  easy, medium, hard
+//	duration    up-to-15, 16-30, over-30        (estimated minutes)
+//	tool        falco, trivy, apparmor, ...
+//	status      not-started, in-progress, completed
+//	sort        relevance, title, difficulty, duration, points or domain; "-" reverses
+//	page, pageSize
+//
+// Every facet comes back with the count of results each of its values would
+// give with the other facets applied.
+func SearchExercises(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	search := database.ExerciseSearch{
+		Text:         query.Get("q"),
+		Domains:      queryList(query["domain"]),
+		Difficulties: queryList(query["difficulty"]),
+		Durations:    queryList(query["duration"]),
+		Tools:        queryList(query["tool"]),
+		Statuses:     queryList(query["status"]),
+		Sort:         query.Get("sort"),
+	}
+	for name, value := range map[string]*int{"page": &search.Page, "pageSize": &search.PageSize} {
+		if s := query.Get(name); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				writeExerciseSearch(w, http.StatusBadRequest, ExerciseSearchResponse{
+					ErrorCode: database.ErrCodeInvalidSearch,
+					Message:   name + " must be a number",
+				})
+				return
+			}
+			*value = n
+		}
+	}
+
+	result, err := database.SearchExercises(search)
+	if err != nil {
+		response := ExerciseSearchResponse{ErrorCode: "UNKNOWN_ERROR", Message: err.Error()}
+		status := http.StatusInternalServerError
+		if dbErr, ok := err.(*database.DatabaseError); ok {
+			response.ErrorCode = dbErr.Code
+			response.Message = dbErr.Message
+			if dbErr.Code == database.ErrCodeInvalidSearch {
+				status = http.StatusBadRequest
+			}
+		}
+		writeExerciseSearch(w, status, response)
+		return
+	}
+
+	for i := range result.Exercises {
+		result.Exercises[i].HintCount = len(result.Exercises[i].Hints)
+		result.Exercises[i].Hints = []string{}
+	}
+	writeExerciseSearch(w, http.StatusOK, ExerciseSearchResponse{Success: true, ExerciseSearchResult: result})
+}
+
+// queryList splits repeated and comma-separated query values
+func queryList(values []string) []string {
+	list := []string{}
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				list = append(list, v)
+			}
+		}
+	}
+	return list
+}
+
+func writeExerciseSearch(w http.ResponseWriter, status int, response ExerciseSearchResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
 // GetExerciseBySlug handles the /api/exercises/{slug} endpoint
 func GetExerciseBySlug(w http.ResponseWriter, r *http.Request) {
 	// Only allow GET requests
@@ -109,6 +207,8 @@ func GetExerciseBySlug(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	withRevealedHints(exercise)
+
 	response := ExercisesResponse{
 		Success:  true,
 		Exercise: exercise,
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+func TestSearchExercisesAPI(t *testing.T) {
+	setupImportDB(t)
+	database.DB.Exec(`UPDATE exercises SET estimated_minutes = 10, hints = '["check the audit-policy file"]' WHERE id = 7`)
+
+	search := func(url string) (int, ExerciseSearchResponse) {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+		SearchExercises(w, req)
+		var response ExerciseSearchResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response
+	}
+
+	code, response := search("/api/exercises/search?q=policy&difficulty=easy,hard&duration=up-to-15")
+	if code != http.StatusOK || response.ExerciseSearchResult == nil || len(response.Exercises) != 1 {
+		t.Fatalf("unexpected search (%d): %+v", code, response)
+	}
+	if hit := response.Exercises[0]; len(hit.Hints) != 0 || hit.HintCount != 1 {
+		t.Errorf("expected the hints to stay hidden, got %+v", hit)
+	}
+	if len(response.Facets.Status) != 3 || response.Facets.Status[0].Value != "not-started" || response.Facets.Status[0].Count != 1 {
+		t.Errorf("unexpected status facet %+v", response.Facets.Status)
+	}
+
+	for _, url := range []string{
+		"/api/exercises/search?page=two",
+		"/api/exercises/search?sort=newest",
+		"/api/exercises/search?duration=forever",
+	} {
+		if code, response := search(url); code != http.StatusBadRequest || response.ErrorCode != database.ErrCodeInvalidSearch {
+			t.Errorf("%s: expected 400, got %d: %+v", url, code, response)
+		}
+	}
+}
@@ -2,55 +2,116 @@ package api
 
 import (
 	"database/sql"
-	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/patrickvassell/cks-weight-room/internal/database"
 )
 
+// ExportSchemaVersion is the version of the export format. Version 1 files
+// (no schema_version) identify exercises by numeric ID and title only;
+// version 2 adds slugs and the raw timestamps and points for import;
+// version 3 adds notes and bookmarks.
+const ExportSchemaVersion = 3
+
 // ExportData represents all exportable progress data
 type ExportData struct {
+	SchemaVersion           int                 `json:"schema_version"`
+	Profile                 string              `json:"profile,omitempty"` // Slug of the exported profile
 	ExportDate              string              `json:"export_date"`
 	TotalPracticeTimeMinutes int                 `json:"total_practice_time_minutes"`
 	ScenariosCompleted      int                 `json:"scenarios_completed"`
 	Attempts                []ExportAttempt     `json:"attempts"`
 	PersonalBests           []ExportPersonalBest `json:"personal_bests"`
 	MockExams               []ExportMockExam    `json:"mock_exams"`
+	HintReveals             []ExportHintReveal  `json:"hint_reveals"`
+	Notes                   []ExportNote        `json:"notes"`
+	Bookmarks               []ExportBookmark    `json:"bookmarks"`
+}
+
+// ExportNote represents an exercise's Markdown note for export
+type ExportNote struct {
+	ScenarioID   int    `json:"scenario_id"`
+	ScenarioSlug string `json:"scenario_slug"`
+	ScenarioName string `json:"scenario_name"`
+	Domain       string `json:"domain,omitempty"`
+	Notes        string `json:"notes"`
+	UpdatedAt    string `json:"updated_at,omitempty"`
+}
+
+// ExportBookmark represents a bookmarked exercise for export
+type ExportBookmark struct {
+	ScenarioID   int      `json:"scenario_id"`
+	ScenarioSlug string   `json:"scenario_slug"`
+	ScenarioName string   `json:"scenario_name"`
+	Domain       string   `json:"domain,omitempty"`
+	Tags         []string `json:"tags"`
+	CreatedAt    string   `json:"created_at"`
+}
+
+// ExportHintReveal represents one revealed hint for export
+type ExportHintReveal struct {
+	ScenarioID   int    `json:"scenario_id"`
+	ScenarioSlug string `json:"scenario_slug,omitempty"`
+	Domain       string `json:"domain,omitempty"`
+	AttemptID    *int   `json:"attempt_id"` // null while the attempt is still open
+	HintIndex    int    `json:"hint_index"`
+	RevealedAt   string `json:"revealed_at"`
 }
 
 // ExportAttempt represents a single attempt for export
 type ExportAttempt struct {
 	AttemptID             int     `json:"attempt_id"`
 	ScenarioID            int     `json:"scenario_id"`
+	ScenarioSlug          string  `json:"scenario_slug,omitempty"`
 	ScenarioName          string  `json:"scenario_name"`
+	Domain                string  `json:"domain,omitempty"`
 	Timestamp             string  `json:"timestamp"`
+	StartedAt             string  `json:"started_at,omitempty"`
+	CompletedAt           string  `json:"completed_at,omitempty"` // empty while the attempt is open
 	CompletionTimeSeconds int     `json:"completion_time_seconds"`
-	Score                 float64 `json:"score"`
+	Score                 float64 `json:"score"`  // Ratio of max_score
+	Points                int     `json:"points"` // Points earned
 	MaxScore              int     `json:"max_score"`
 	Status                string  `json:"status"`
 	Feedback              string  `json:"feedback,omitempty"`
+	Details               string  `json:"details,omitempty"` // JSON array of check details
+	HintsUsed             int     `json:"hints_used"`
+	HintPenalty           int     `json:"hint_penalty"`
 }
 
 // ExportPersonalBest represents a personal best for export
 type ExportPersonalBest struct {
 	ScenarioID      int    `json:"scenario_id"`
+	ScenarioSlug    string `json:"scenario_slug,omitempty"`
 	ScenarioName    string `json:"scenario_name"`
+	Domain          string `json:"domain,omitempty"`
 	BestTimeSeconds int    `json:"best_time_seconds"`
 	AchievedAt      string `json:"achieved_at"`
 }
 
 // ExportMockExam represents a mock exam for export
 type ExportMockExam struct {
-	ExamID           int     `json:"exam_id"`
-	ExamType         string  `json:"exam_type"`
-	Timestamp        string  `json:"timestamp"`
-	TotalTimeSeconds int     `json:"total_time_seconds"`
-	OverallScore     float64 `json:"overall_score"`
-	Result           string  `json:"result"`
+	ExamID             int     `json:"exam_id"`
+	ExamType           string  `json:"exam_type"`
+	Timestamp          string  `json:"timestamp"`
+	StartedAt          string  `json:"started_at,omitempty"`
+	CompletedAt        string  `json:"completed_at,omitempty"` // empty for an unfinished exam
+	TotalTimeSeconds   int     `json:"total_time_seconds"`
+	OverallScore       float64 `json:"overall_score"` // Ratio of max_score
+	OverallPoints      int     `json:"overall_points"`
+	MaxScore           int     `json:"max_score"`
+	ExercisesCompleted int     `json:"exercises_completed"`
+	ExercisesTotal     int     `json:"exercises_total"`
+	Result             string  `json:"result"`
+	Results            string  `json:"results,omitempty"` // JSON array of graded questions
 }
 
-// GetExportData handles GET /api/export
+// GetExportData handles GET /api/export. The format comes from ?format=
+// (json, csv, markdown, html or junit) or the Accept header, and ?from=,
+// ?to=, ?tz= and ?domain= narrow what is exported; see writeExport.
+// ?profile= exports another profile than the active one.
 func GetExportData(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -62,59 +123,107 @@ func GetExportData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	exportData := ExportData{
+	format := exportFormat(r)
+	if format == "" {
+		http.Error(w, "Unknown export format", http.StatusBadRequest)
+		return
+	}
+	filter, err := parseExportFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	profile, err := requestProfile(r.URL.Query().Get("profile"))
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+
+	exportData := loadExportData(profile)
+	filter.apply(exportData)
+	writeExport(w, r, format, filter, exportData)
+}
+
+// loadExportData reads all exportable progress data of a profile
+func loadExportData(profile *database.Profile) *ExportData {
+	exportData := &ExportData{
+		SchemaVersion: ExportSchemaVersion,
+		Profile:       profile.Slug,
 		ExportDate: time.Now().UTC().Format(time.RFC3339),
 		Attempts:   []ExportAttempt{},
 		PersonalBests: []ExportPersonalBest{},
 		MockExams:  []ExportMockExam{},
+		HintReveals: []ExportHintReveal{},
+		Notes:       []ExportNote{},
+		Bookmarks:   []ExportBookmark{},
 	}
 
 	// Get total practice time
 	var totalSeconds sql.NullInt64
-	database.DB.QueryRow("SELECT COALESCE(SUM(duration_seconds), 0) FROM attempts").Scan(&totalSeconds)
+	database.DB.QueryRow("SELECT COALESCE(SUM(duration_seconds), 0) FROM attempts WHERE profile_id = ?", profile.ID).Scan(&totalSeconds)
 	if totalSeconds.Valid {
 		exportData.TotalPracticeTimeMinutes = int(totalSeconds.Int64 / 60)
 	}
 
 	// Get scenarios completed
-	database.DB.QueryRow("SELECT COUNT(*) FROM progress WHERE status = 'completed'").Scan(&exportData.ScenariosCompleted)
+	database.DB.QueryRow("SELECT COUNT(*) FROM progress WHERE profile_id = ? AND status = 'completed'", profile.ID).Scan(&exportData.ScenariosCompleted)
 
 	// Get all attempts
 	rows, err := database.DB.Query(`
 		SELECT
 			a.id,
 			a.exercise_id,
+			e.slug,
 			e.title,
+			e.category,
 			COALESCE(a.completed_at, a.started_at) as timestamp,
+			a.started_at,
+			a.completed_at,
 			COALESCE(a.duration_seconds, 0) as duration,
 			CAST(a.score AS FLOAT) / CAST(a.max_score AS FLOAT) as score_ratio,
+			a.score,
 			a.max_score,
 			CASE WHEN a.passed = 1 THEN 'completed' ELSE 'failed' END as status,
-			COALESCE(a.feedback, '') as feedback
+			COALESCE(a.feedback, '') as feedback,
+			COALESCE(a.details, '') as details,
+			a.hints_used,
+			a.hint_penalty
 		FROM attempts a
 		JOIN exercises e ON a.exercise_id = e.id
+		WHERE a.profile_id = ?
 		ORDER BY a.id
-	`)
+	`, profile.ID)
 
 	if err == nil {
 		defer rows.Close()
 		for rows.Next() {
 			var attempt ExportAttempt
-			var timestamp sql.NullString
+			var timestamp, startedAt, completedAt sql.NullString
 			rows.Scan(
 				&attempt.AttemptID,
 				&attempt.ScenarioID,
+				&attempt.ScenarioSlug,
 				&attempt.ScenarioName,
+				&attempt.Domain,
 				&timestamp,
+				&startedAt,
+				&completedAt,
 				&attempt.CompletionTimeSeconds,
 				&attempt.Score,
+				&attempt.Points,
 				&attempt.MaxScore,
 				&attempt.Status,
 				&attempt.Feedback,
+				&attempt.Details,
+				&attempt.HintsUsed,
+				&attempt.HintPenalty,
 			)
 			if timestamp.Valid {
 				attempt.Timestamp = timestamp.String
 			}
+			attempt.StartedAt = startedAt.String
+			attempt.CompletedAt = completedAt.String
 			exportData.Attempts = append(exportData.Attempts, attempt)
 		}
 	}
@@ -123,14 +232,16 @@ func GetExportData(w http.ResponseWriter, r *http.Request) {
 	rows, err = database.DB.Query(`
 		SELECT
 			p.exercise_id,
+			e.slug,
 			e.title,
+			e.category,
 			p.personal_best_seconds,
 			COALESCE(p.completed_at, '') as achieved_at
 		FROM progress p
 		JOIN exercises e ON p.exercise_id = e.id
-		WHERE p.personal_best_seconds IS NOT NULL
+		WHERE p.profile_id = ? AND p.personal_best_seconds IS NOT NULL
 		ORDER BY p.personal_best_seconds
-	`)
+	`, profile.ID)
 
 	if err == nil {
 		defer rows.Close()
@@ -139,7 +250,9 @@ func GetExportData(w http.ResponseWriter, r *http.Request) {
 			var achievedAt sql.NullString
 			rows.Scan(
 				&pb.ScenarioID,
+				&pb.ScenarioSlug,
 				&pb.ScenarioName,
+				&pb.Domain,
 				&pb.BestTimeSeconds,
 				&achievedAt,
 			)
@@ -156,33 +269,135 @@ func GetExportData(w http.ResponseWriter, r *http.Request) {
 			id,
 			exam_type,
 			COALESCE(completed_at, started_at) as timestamp,
+			started_at,
+			completed_at,
 			COALESCE(total_duration_seconds, 0) as duration,
 			CAST(overall_score AS FLOAT) / CAST(max_score AS FLOAT) as score_ratio,
-			CASE WHEN passed = 1 THEN 'passed' ELSE 'failed' END as result
+			overall_score,
+			max_score,
+			exercises_completed,
+			exercises_total,
+			CASE WHEN passed = 1 THEN 'passed' ELSE 'failed' END as result,
+			COALESCE(results, '') as results
 		FROM mock_exams
+		WHERE profile_id = ?
 		ORDER BY id
-	`)
+	`, profile.ID)
 
 	if err == nil {
 		defer rows.Close()
 		for rows.Next() {
 			var exam ExportMockExam
-			var timestamp sql.NullString
+			var timestamp, startedAt, completedAt sql.NullString
 			rows.Scan(
 				&exam.ExamID,
 				&exam.ExamType,
 				&timestamp,
+				&startedAt,
+				&completedAt,
 				&exam.TotalTimeSeconds,
 				&exam.OverallScore,
+				&exam.OverallPoints,
+				&exam.MaxScore,
+				&exam.ExercisesCompleted,
+				&exam.ExercisesTotal,
 				&exam.Result,
+				&exam.Results,
 			)
 			if timestamp.Valid {
 				exam.Timestamp = timestamp.String
 			}
+			exam.StartedAt = startedAt.String
+			exam.CompletedAt = completedAt.String
 			exportData.MockExams = append(exportData.MockExams, exam)
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(exportData)
+	// Get hint reveals
+	rows, err = database.DB.Query(`
+		SELECT h.exercise_id, e.slug, e.category, h.attempt_id, h.hint_index, h.revealed_at
+		FROM hint_reveals h
+		JOIN exercises e ON h.exercise_id = e.id
+		WHERE h.profile_id = ?
+		ORDER BY h.id
+	`, profile.ID)
+
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var reveal ExportHintReveal
+			var attemptID sql.NullInt64
+			rows.Scan(
+				&reveal.ScenarioID,
+				&reveal.ScenarioSlug,
+				&reveal.Domain,
+				&attemptID,
+				&reveal.HintIndex,
+				&reveal.RevealedAt,
+			)
+			if attemptID.Valid {
+				id := int(attemptID.Int64)
+				reveal.AttemptID = &id
+			}
+			exportData.HintReveals = append(exportData.HintReveals, reveal)
+		}
+	}
+
+	// Get notes
+	rows, err = database.DB.Query(`
+		SELECT p.exercise_id, e.slug, e.title, e.category, p.notes, COALESCE(p.notes_updated_at, '')
+		FROM progress p
+		JOIN exercises e ON p.exercise_id = e.id
+		WHERE p.profile_id = ? AND p.notes IS NOT NULL AND p.notes != ''
+		ORDER BY e.slug
+	`, profile.ID)
+
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var note ExportNote
+			rows.Scan(
+				&note.ScenarioID,
+				&note.ScenarioSlug,
+				&note.ScenarioName,
+				&note.Domain,
+				&note.Notes,
+				&note.UpdatedAt,
+			)
+			exportData.Notes = append(exportData.Notes, note)
+		}
+	}
+
+	// Get bookmarks
+	rows, err = database.DB.Query(`
+		SELECT b.exercise_id, e.slug, e.title, e.category, b.created_at,
+			COALESCE((SELECT group_concat(tag, char(31)) FROM (SELECT tag FROM bookmark_tags WHERE bookmark_id = b.id ORDER BY tag)), '')
+		FROM bookmarks b
+		JOIN exercises e ON b.exercise_id = e.id
+		WHERE b.profile_id = ?
+		ORDER BY b.id
+	`, profile.ID)
+
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var bookmark ExportBookmark
+			var tags string
+			rows.Scan(
+				&bookmark.ScenarioID,
+				&bookmark.ScenarioSlug,
+				&bookmark.ScenarioName,
+				&bookmark.Domain,
+				&bookmark.CreatedAt,
+				&tags,
+			)
+			bookmark.Tags = []string{}
+			if tags != "" {
+				bookmark.Tags = strings.Split(tags, "\x1f")
+			}
+			exportData.Bookmarks = append(exportData.Bookmarks, bookmark)
+		}
+	}
+
+	return exportData
 }
@@ -0,0 +1,730 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+	"github.com/patrickvassell/cks-weight-room/internal/exam"
+	"github.com/patrickvassell/cks-weight-room/internal/trends"
+)
+
+// Export formats
+const (
+	ExportFormatJSON     = "json"
+	ExportFormatCSV      = "csv"      // One CSV per table, zipped unless ?table= picks one
+	ExportFormatMarkdown = "markdown" // Study report
+	ExportFormatHTML     = "html"     // Study report
+	ExportFormatJUnit    = "junit"    // Validation checks of each attempt
+)
+
+// maxWeakAreas is how many weak exercises the study report lists
+const maxWeakAreas = 5
+
+// exportMediaTypes maps Accept header media types to export formats
+var exportMediaTypes = map[string]string{
+	"application/json": ExportFormatJSON,
+	"text/csv":         ExportFormatCSV,
+	"application/zip":  ExportFormatCSV,
+	"text/markdown":    ExportFormatMarkdown,
+	"text/html":        ExportFormatHTML,
+	"application/xml":  ExportFormatJUnit,
+	"text/xml":         ExportFormatJUnit,
+}
+
+// exportFormat picks the export format from ?format= or else the first
+// supported type in the Accept header; "" means an unknown ?format=
+func exportFormat(r *http.Request) string {
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		switch format {
+		case ExportFormatJSON, ExportFormatCSV, ExportFormatMarkdown, ExportFormatHTML, ExportFormatJUnit:
+			return format
+		case "md":
+			return ExportFormatMarkdown
+		case "xml":
+			return ExportFormatJUnit
+		}
+		return ""
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(accept, ";")
+		if format, ok := exportMediaTypes[strings.TrimSpace(mediaType)]; ok {
+			return format
+		}
+	}
+	return ExportFormatJSON
+}
+
+// exportFilter narrows an export to calendar days and curriculum domains
+type exportFilter struct {
+	From, To   time.Time // Inclusive days, zero when open
+	Location   *time.Location
+	Domains    map[string]bool // Empty for every domain
+	curriculum *database.Curriculum
+}
+
+// parseExportFilter reads ?from=YYYY-MM-DD, ?to=YYYY-MM-DD, ?tz= and
+// ?domain= (repeated or comma separated)
+func parseExportFilter(r *http.Request) (exportFilter, error) {
+	query := r.URL.Query()
+	filter := exportFilter{Location: time.Local, Domains: map[string]bool{}}
+
+	if tz := query.Get("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return filter, fmt.Errorf("unknown time zone: %s", tz)
+		}
+		filter.Location = loc
+	}
+	for name, day := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(trends.DateLayout, value)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be a date (YYYY-MM-DD)", name)
+			}
+			*day = parsed
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return filter, fmt.Errorf("from must not be after to")
+	}
+
+	curriculum, err := database.GetActiveCurriculum()
+	if err != nil {
+		return filter, fmt.Errorf("failed to load curriculum: %w", err)
+	}
+	filter.curriculum = curriculum
+	for _, value := range query["domain"] {
+		for _, domain := range strings.Split(value, ",") {
+			if domain = strings.TrimSpace(domain); domain == "" {
+				continue
+			}
+			if _, ok := curriculum.Domain(domain); !ok {
+				return filter, fmt.Errorf("unknown domain: %s", domain)
+			}
+			filter.Domains[domain] = true
+		}
+	}
+	return filter, nil
+}
+
+// active reports whether the filter leaves anything out
+func (f exportFilter) active() bool {
+	return !f.From.IsZero() || !f.To.IsZero() || len(f.Domains) > 0
+}
+
+// includesTime reports whether a timestamp falls on a day in the range
+func (f exportFilter) includesTime(timestamp string) bool {
+	if f.From.IsZero() && f.To.IsZero() {
+		return true
+	}
+	normalised, ok := importTimestamp(timestamp)
+	if !ok {
+		return false
+	}
+	at, _ := time.Parse(importTime, normalised)
+	day := trends.Day(at, f.Location)
+	return !day.Before(f.From) && (f.To.IsZero() || !day.After(f.To))
+}
+
+func (f exportFilter) includes(timestamp, domain string) bool {
+	return (len(f.Domains) == 0 || f.Domains[domain]) && f.includesTime(timestamp)
+}
+
+// includesExam keeps a mock exam with a graded question in one of the
+// domains
+func (f exportFilter) includesExam(m ExportMockExam) bool {
+	if !f.includesTime(m.Timestamp) {
+		return false
+	}
+	if len(f.Domains) == 0 {
+		return true
+	}
+	var graded []exam.QuestionResult
+	json.Unmarshal([]byte(m.Results), &graded)
+	for _, q := range graded {
+		if f.Domains[q.Domain] {
+			return true
+		}
+	}
+	return false
+}
+
+// apply drops what the filter leaves out and recomputes the totals
+func (f exportFilter) apply(data *ExportData) {
+	if !f.active() {
+		return
+	}
+
+	attempts := data.Attempts[:0]
+	seconds := 0
+	completed := map[string]bool{}
+	for _, a := range data.Attempts {
+		if f.includes(a.Timestamp, a.Domain) {
+			attempts = append(attempts, a)
+			seconds += a.CompletionTimeSeconds
+			if a.Status == "completed" {
+				completed[a.ScenarioSlug] = true
+			}
+		}
+	}
+	data.Attempts = attempts
+	data.TotalPracticeTimeMinutes = seconds / 60
+	data.ScenariosCompleted = len(completed)
+
+	bests := data.PersonalBests[:0]
+	for _, pb := range data.PersonalBests {
+		if f.includes(pb.AchievedAt, pb.Domain) {
+			bests = append(bests, pb)
+		}
+	}
+	data.PersonalBests = bests
+
+	exams := data.MockExams[:0]
+	for _, m := range data.MockExams {
+		if f.includesExam(m) {
+			exams = append(exams, m)
+		}
+	}
+	data.MockExams = exams
+
+	reveals := data.HintReveals[:0]
+	for _, h := range data.HintReveals {
+		if f.includes(h.RevealedAt, h.Domain) {
+			reveals = append(reveals, h)
+		}
+	}
+	data.HintReveals = reveals
+
+	notes := data.Notes[:0]
+	for _, n := range data.Notes {
+		if f.includes(n.UpdatedAt, n.Domain) {
+			notes = append(notes, n)
+		}
+	}
+	data.Notes = notes
+
+	bookmarks := data.Bookmarks[:0]
+	for _, b := range data.Bookmarks {
+		if f.includes(b.CreatedAt, b.Domain) {
+			bookmarks = append(bookmarks, b)
+		}
+	}
+	data.Bookmarks = bookmarks
+}
+
+// writeExport writes export data in the requested format
+func writeExport(w http.ResponseWriter, r *http.Request, format string, filter exportFilter, data *ExportData) {
+	filename := "cks-weight-room-progress-" + time.Now().Format(trends.DateLayout)
+
+	var buf bytes.Buffer
+	var contentType string
+	var err error
+	switch format {
+	case ExportFormatJSON:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(data)
+		return
+
+	case ExportFormatCSV:
+		tables := exportTables(data)
+		table := r.URL.Query().Get("table")
+		if table == "" {
+			contentType, filename = "application/zip", filename+".zip"
+			err = writeExportZip(&buf, tables)
+			break
+		}
+		found := false
+		for _, t := range tables {
+			if t.name == table {
+				contentType, filename = "text/csv; charset=utf-8", filename+"-"+table+".csv"
+				err = t.write(&buf)
+				found = true
+			}
+		}
+		if !found {
+			http.Error(w, "Unknown table: "+table, http.StatusBadRequest)
+			return
+		}
+
+	case ExportFormatMarkdown:
+		contentType, filename = "text/markdown; charset=utf-8", filename+".md"
+		err = markdownReport.Execute(&buf, buildStudyReport(data, filter))
+
+	case ExportFormatHTML:
+		contentType, filename = "text/html; charset=utf-8", filename+".html"
+		err = htmlReport.Execute(&buf, buildStudyReport(data, filter))
+
+	case ExportFormatJUnit:
+		contentType, filename = "application/xml; charset=utf-8", filename+"-junit.xml"
+		buf.WriteString(xml.Header)
+		encoder := xml.NewEncoder(&buf)
+		encoder.Indent("", "  ")
+		err = encoder.Encode(buildJUnitReport(data))
+	}
+
+	if err != nil {
+		log.Printf("Failed to write %s export: %v", format, err)
+		http.Error(w, "Failed to write export", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Write(buf.Bytes())
+}
+
+// exportTable is one table of the CSV export
+type exportTable struct {
+	name   string
+	header []string
+	rows   [][]string
+}
+
+func (t exportTable) write(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write(t.header)
+	cw.WriteAll(t.rows)
+	return cw.Error()
+}
+
+// exportTables lays out export data as CSV tables, with the JSON field
+// names as column headers
+func exportTables(data *ExportData) []exportTable {
+	itoa := strconv.Itoa
+	ratio := func(f float64) string { return strconv.FormatFloat(f, 'f', 4, 64) }
+
+	attempts := exportTable{name: "attempts", header: []string{
+		"attempt_id", "scenario_id", "scenario_slug", "scenario_name", "domain", "timestamp", "started_at", "completed_at",
+		"completion_time_seconds", "score", "points", "max_score", "status", "feedback", "hints_used", "hint_penalty",
+	}}
+	for _, a := range data.Attempts {
+		attempts.rows = append(attempts.rows, []string{
+			itoa(a.AttemptID), itoa(a.ScenarioID), a.ScenarioSlug, a.ScenarioName, a.Domain, a.Timestamp, a.StartedAt, a.CompletedAt,
+			itoa(a.CompletionTimeSeconds), ratio(a.Score), itoa(a.Points), itoa(a.MaxScore), a.Status, a.Feedback, itoa(a.HintsUsed), itoa(a.HintPenalty),
+		})
+	}
+
+	bests := exportTable{name: "personal_bests", header: []string{
+		"scenario_id", "scenario_slug", "scenario_name", "domain", "best_time_seconds", "achieved_at",
+	}}
+	for _, pb := range data.PersonalBests {
+		bests.rows = append(bests.rows, []string{
+			itoa(pb.ScenarioID), pb.ScenarioSlug, pb.ScenarioName, pb.Domain, itoa(pb.BestTimeSeconds), pb.AchievedAt,
+		})
+	}
+
+	exams := exportTable{name: "mock_exams", header: []string{
+		"exam_id", "exam_type", "timestamp", "started_at", "completed_at", "total_time_seconds", "overall_score",
+		"overall_points", "max_score", "exercises_completed", "exercises_total", "result",
+	}}
+	for _, m := range data.MockExams {
+		exams.rows = append(exams.rows, []string{
+			itoa(m.ExamID), m.ExamType, m.Timestamp, m.StartedAt, m.CompletedAt, itoa(m.TotalTimeSeconds), ratio(m.OverallScore),
+			itoa(m.OverallPoints), itoa(m.MaxScore), itoa(m.ExercisesCompleted), itoa(m.ExercisesTotal), m.Result,
+		})
+	}
+
+	reveals := exportTable{name: "hint_reveals", header: []string{
+		"scenario_id", "scenario_slug", "domain", "attempt_id", "hint_index", "revealed_at",
+	}}
+	for _, h := range data.HintReveals {
+		attemptID := ""
+		if h.AttemptID != nil {
+			attemptID = itoa(*h.AttemptID)
+		}
+		reveals.rows = append(reveals.rows, []string{
+			itoa(h.ScenarioID), h.ScenarioSlug, h.Domain, attemptID, itoa(h.HintIndex), h.RevealedAt,
+		})
+	}
+
+	notes := exportTable{name: "notes", header: []string{
+		"scenario_id", "scenario_slug", "scenario_name", "domain", "notes", "updated_at",
+	}}
+	for _, n := range data.Notes {
+		notes.rows = append(notes.rows, []string{
+			itoa(n.ScenarioID), n.ScenarioSlug, n.ScenarioName, n.Domain, n.Notes, n.UpdatedAt,
+		})
+	}
+
+	bookmarks := exportTable{name: "bookmarks", header: []string{
+		"scenario_id", "scenario_slug", "scenario_name", "domain", "tags", "created_at",
+	}}
+	for _, b := range data.Bookmarks {
+		bookmarks.rows = append(bookmarks.rows, []string{
+			itoa(b.ScenarioID), b.ScenarioSlug, b.ScenarioName, b.Domain, strings.Join(b.Tags, " "), b.CreatedAt,
+		})
+	}
+
+	return []exportTable{attempts, bests, exams, reveals, notes, bookmarks}
+}
+
+// writeExportZip writes every table as a CSV file in a zip archive
+func writeExportZip(w io.Writer, tables []exportTable) error {
+	zw := zip.NewWriter(w)
+	for _, t := range tables {
+		f, err := zw.Create(t.name + ".csv")
+		if err != nil {
+			return err
+		}
+		if err := t.write(f); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// studyReport is what the Markdown and HTML reports show
+type studyReport struct {
+	Generated     string
+	Range         string
+	Domains       []reportDomain
+	Attempts      int
+	Passed        int
+	PracticeTime  string
+	WeakAreas     []reportExercise
+	PersonalBests []reportExercise
+	MockExams     []ExportMockExam
+	PassingScore  int
+}
+
+type reportDomain struct {
+	DisplayName string
+	Weight      int
+	Attempts    int
+	PassRate    int // Percent
+	AvgScore    int // Percent
+	Exercises   int // Practised
+	Weak        bool
+}
+
+type reportExercise struct {
+	Title       string
+	Domain      string
+	Attempts    int
+	AvgScore    int // Percent
+	BestTime    string
+	LastAttempt string
+}
+
+// buildStudyReport summarises the exported attempts by domain and exercise
+func buildStudyReport(data *ExportData, filter exportFilter) studyReport {
+	report := studyReport{
+		Generated:    time.Now().In(filter.Location).Format("2006-01-02 15:04 MST"),
+		Range:        "all time",
+		PracticeTime: formatReportDuration(data.TotalPracticeTimeMinutes * 60),
+		MockExams:    data.MockExams,
+		PassingScore: exam.PassingPercentage,
+	}
+	switch {
+	case !filter.From.IsZero() && !filter.To.IsZero():
+		report.Range = filter.From.Format(trends.DateLayout) + " to " + filter.To.Format(trends.DateLayout)
+	case !filter.From.IsZero():
+		report.Range = "since " + filter.From.Format(trends.DateLayout)
+	case !filter.To.IsZero():
+		report.Range = "until " + filter.To.Format(trends.DateLayout)
+	}
+
+	type tally struct {
+		attempts, passed int
+		score            float64
+		best             int
+		last             string
+		title, domain    string
+	}
+	domains := map[string]*tally{}
+	exercises := map[string]*tally{}
+	practised := map[string]map[string]bool{}
+	var order []string
+	for _, a := range data.Attempts {
+		report.Attempts++
+		d := domains[a.Domain]
+		if d == nil {
+			d = &tally{}
+			domains[a.Domain] = d
+			practised[a.Domain] = map[string]bool{}
+		}
+		e := exercises[a.ScenarioSlug]
+		if e == nil {
+			e = &tally{title: a.ScenarioName, domain: a.Domain}
+			exercises[a.ScenarioSlug] = e
+			order = append(order, a.ScenarioSlug)
+		}
+		practised[a.Domain][a.ScenarioSlug] = true
+		for _, t := range []*tally{d, e} {
+			t.attempts++
+			t.score += a.Score
+			if a.Status == "completed" {
+				t.passed++
+			}
+		}
+		if a.Status == "completed" {
+			report.Passed++
+			if e.best == 0 || a.CompletionTimeSeconds < e.best {
+				e.best = a.CompletionTimeSeconds
+			}
+		}
+		e.last = a.Timestamp
+	}
+
+	for _, cd := range filter.curriculum.Domains {
+		if len(filter.Domains) > 0 && !filter.Domains[cd.Slug] {
+			continue
+		}
+		row := reportDomain{DisplayName: cd.DisplayName, Weight: cd.Weight}
+		if d := domains[cd.Slug]; d != nil {
+			row.Attempts = d.attempts
+			row.PassRate = d.passed * 100 / d.attempts
+			row.AvgScore = int(d.score * 100 / float64(d.attempts))
+			row.Exercises = len(practised[cd.Slug])
+			row.Weak = row.AvgScore < exam.PassingPercentage
+		}
+		report.Domains = append(report.Domains, row)
+	}
+
+	displayName := func(slug string) string {
+		if d, ok := filter.curriculum.Domain(slug); ok {
+			return d.DisplayName
+		}
+		return slug
+	}
+	for _, slug := range order {
+		e := exercises[slug]
+		row := reportExercise{
+			Title:       e.title,
+			Domain:      displayName(e.domain),
+			Attempts:    e.attempts,
+			AvgScore:    int(e.score * 100 / float64(e.attempts)),
+			LastAttempt: e.last,
+		}
+		if e.best > 0 {
+			row.BestTime = formatReportDuration(e.best)
+			report.PersonalBests = append(report.PersonalBests, row)
+		}
+		if row.AvgScore < exam.PassingPercentage {
+			report.WeakAreas = append(report.WeakAreas, row)
+		}
+	}
+	sort.SliceStable(report.WeakAreas, func(i, j int) bool { return report.WeakAreas[i].AvgScore < report.WeakAreas[j].AvgScore })
+	if len(report.WeakAreas) > maxWeakAreas {
+		report.WeakAreas = report.WeakAreas[:maxWeakAreas]
+	}
+	sort.SliceStable(report.PersonalBests, func(i, j int) bool { return report.PersonalBests[i].Title < report.PersonalBests[j].Title })
+	return report
+}
+
+// formatReportDuration formats seconds as 1h 5m, 4m 10s or 30s
+func formatReportDuration(seconds int) string {
+	d := time.Duration(seconds) * time.Second
+	switch {
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm %ds", int(d.Minutes()), seconds%60)
+	}
+	return fmt.Sprintf("%ds", seconds)
+}
+
+// markdownCell escapes a value for a Markdown table cell
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+var markdownReport = template.Must(template.New("report").Funcs(template.FuncMap{"cell": markdownCell, "percent": reportPercent}).Parse(
+	`# CKS Weight Room Study Report
+
+Generated {{.Generated}}, covering {{.Range}}.
+
+## Summary
+
+- Attempts: {{.Attempts}} ({{.Passed}} passed)
+- Practice time: {{.PracticeTime}}
+- Mock exams: {{len .MockExams}}
+
+## Domains
+
+| Domain | Weight | Attempts | Pass rate | Avg score | Exercises |
+|---|---|---|---|---|---|
+{{range .Domains}}| {{cell .DisplayName}}{{if .Weak}} ⚠{{end}} | {{.Weight}}% | {{.Attempts}} | {{if .Attempts}}{{.PassRate}}%{{else}}—{{end}} | {{if .Attempts}}{{.AvgScore}}%{{else}}—{{end}} | {{.Exercises}} |
+{{end}}
+## Weak Areas
+
+{{if .WeakAreas}}Exercises averaging under the {{.PassingScore}}% passing score:
+
+| Exercise | Domain | Attempts | Avg score | Last attempt |
+|---|---|---|---|---|
+{{range .WeakAreas}}| {{cell .Title}} | {{cell .Domain}} | {{.Attempts}} | {{.AvgScore}}% | {{.LastAttempt}} |
+{{end}}{{else}}No exercise averages under the {{.PassingScore}}% passing score.
+{{end}}
+## Personal Bests
+
+{{if .PersonalBests}}| Exercise | Domain | Best time | Attempts |
+|---|---|---|---|
+{{range .PersonalBests}}| {{cell .Title}} | {{cell .Domain}} | {{.BestTime}} | {{.Attempts}} |
+{{end}}{{else}}No passed attempts yet.
+{{end}}
+## Mock Exams
+
+{{if .MockExams}}| Date | Type | Score | Result |
+|---|---|---|---|
+{{range .MockExams}}| {{.Timestamp}} | {{.ExamType}} | {{printf "%.0f" (percent .OverallScore)}}% | {{.Result}} |
+{{end}}{{else}}No mock exams taken.
+{{end}}`))
+
+var htmlReport = htmltemplate.Must(htmltemplate.New("report").Funcs(htmltemplate.FuncMap{"percent": reportPercent}).Parse(
+	`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>CKS Weight Room Study Report</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; color: #111827; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5rem; }
+th, td { border: 1px solid #e5e7eb; padding: 0.4rem 0.6rem; text-align: left; }
+th { background: #f9fafb; }
+.weak { color: #b91c1c; }
+</style>
+</head>
+<body>
+<h1>CKS Weight Room Study Report</h1>
+<p>Generated {{.Generated}}, covering {{.Range}}.</p>
+
+<h2>Summary</h2>
+<ul>
+<li>Attempts: {{.Attempts}} ({{.Passed}} passed)</li>
+<li>Practice time: {{.PracticeTime}}</li>
+<li>Mock exams: {{len .MockExams}}</li>
+</ul>
+
+<h2>Domains</h2>
+<table>
+<tr><th>Domain</th><th>Weight</th><th>Attempts</th><th>Pass rate</th><th>Avg score</th><th>Exercises</th></tr>
+{{range .Domains}}<tr{{if .Weak}} class="weak"{{end}}><td>{{.DisplayName}}</td><td>{{.Weight}}%</td><td>{{.Attempts}}</td><td>{{if .Attempts}}{{.PassRate}}%{{else}}—{{end}}</td><td>{{if .Attempts}}{{.AvgScore}}%{{else}}—{{end}}</td><td>{{.Exercises}}</td></tr>
+{{end}}</table>
+
+<h2>Weak Areas</h2>
+{{if .WeakAreas}}<p>Exercises averaging under the {{.PassingScore}}% passing score:</p>
+<table>
+<tr><th>Exercise</th><th>Domain</th><th>Attempts</th><th>Avg score</th><th>Last attempt</th></tr>
+{{range .WeakAreas}}<tr><td>{{.Title}}</td><td>{{.Domain}}</td><td>{{.Attempts}}</td><td>{{.AvgScore}}%</td><td>{{.LastAttempt}}</td></tr>
+{{end}}</table>
+{{else}}<p>No exercise averages under the {{.PassingScore}}% passing score.</p>
+{{end}}
+<h2>Personal Bests</h2>
+{{if .PersonalBests}}<table>
+<tr><th>Exercise</th><th>Domain</th><th>Best time</th><th>Attempts</th></tr>
+{{range .PersonalBests}}<tr><td>{{.Title}}</td><td>{{.Domain}}</td><td>{{.BestTime}}</td><td>{{.Attempts}}</td></tr>
+{{end}}</table>
+{{else}}<p>No passed attempts yet.</p>
+{{end}}
+<h2>Mock Exams</h2>
+{{if .MockExams}}<table>
+<tr><th>Date</th><th>Type</th><th>Score</th><th>Result</th></tr>
+{{range .MockExams}}<tr><td>{{.Timestamp}}</td><td>{{.ExamType}}</td><td>{{printf "%.0f" (percent .OverallScore)}}%</td><td>{{.Result}}</td></tr>
+{{end}}</table>
+{{else}}<p>No mock exams taken.</p>
+{{end}}</body>
+</html>
+`))
+
+// reportPercent turns a score ratio into a percentage
+func reportPercent(ratio float64) float64 {
+	return ratio * 100
+}
+
+// JUnit XML, as read by CI dashboards: a test suite per attempt with a test
+// case per validation check
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     int              `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	ID         int             `xml:"id,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Time       int             `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	TestCases  []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// buildJUnitReport turns each attempt's check details into test cases. A
+// check passes when it is marked ✓ or the attempt passed; an attempt
+// without details is a single "validation" case.
+func buildJUnitReport(data *ExportData) junitTestSuites {
+	report := junitTestSuites{Name: "CKS Weight Room"}
+	for _, a := range data.Attempts {
+		suite := junitTestSuite{
+			Name: a.ScenarioSlug,
+			ID:   a.AttemptID,
+			Time: a.CompletionTimeSeconds,
+			Properties: []junitProperty{
+				{Name: "title", Value: a.ScenarioName},
+				{Name: "domain", Value: a.Domain},
+				{Name: "score", Value: fmt.Sprintf("%d/%d", a.Points, a.MaxScore)},
+				{Name: "hints_used", Value: strconv.Itoa(a.HintsUsed)},
+			},
+		}
+		if normalised, ok := importTimestamp(a.Timestamp); ok {
+			at, _ := time.Parse(importTime, normalised)
+			suite.Timestamp = at.Format("2006-01-02T15:04:05")
+		}
+
+		var checks []string
+		json.Unmarshal([]byte(a.Details), &checks)
+		if len(checks) == 0 {
+			checks = []string{"validation"}
+		}
+		passed := a.Status == "completed"
+		for _, check := range checks {
+			name := strings.TrimSpace(strings.TrimLeft(check, "✓✗ "))
+			tc := junitTestCase{Name: name, ClassName: a.Domain + "." + a.ScenarioSlug}
+			if !passed && !strings.HasPrefix(check, "✓") {
+				tc.Failure = &junitFailure{Message: a.Feedback, Text: check}
+				suite.Failures++
+			}
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+		suite.Tests = len(suite.TestCases)
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Time += suite.Time
+		report.Suites = append(report.Suites, suite)
+	}
+	return report
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+func setupExportHistory(t *testing.T) {
+	t.Helper()
+	setupImportDB(t)
+	_, err := database.DB.Exec(`
+		INSERT INTO exercises (id, slug, title, description, category, difficulty, points)
+		VALUES (8, 'apparmor', 'AppArmor | Profiles', 'test', 'system-hardening', 'medium', 10);
+		INSERT INTO attempts (exercise_id, started_at, completed_at, duration_seconds, score, max_score, passed, feedback, details)
+		VALUES (7, '2026-03-01 09:00:00', '2026-03-01 09:10:00', 600, 10, 10, 1, 'ok', '["✓ Audit policy configured", "✓ Log path set"]'),
+			(8, '2026-03-02 09:00:00', '2026-03-02 09:05:00', 300, 3, 10, 0, 'profile not loaded', '["✓ Profile file exists", "Load the profile with apparmor_parser"]'),
+			(8, '2026-04-01 09:00:00', '2026-04-01 09:05:00', 300, 10, 10, 1, 'ok', NULL);
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert history: %v", err)
+	}
+}
+
+func export(t *testing.T, url string, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	GetExportData(w, req)
+	return w
+}
+
+func TestExportFilters(t *testing.T) {
+	setupExportHistory(t)
+	defer database.Close()
+
+	var data ExportData
+	json.NewDecoder(export(t, "/api/export?from=2026-03-01&to=2026-03-31&tz=UTC", "").Body).Decode(&data)
+	if len(data.Attempts) != 2 || data.TotalPracticeTimeMinutes != 15 || data.ScenariosCompleted != 1 {
+		t.Errorf("expected March's two attempts, got %d (%d min, %d completed)", len(data.Attempts), data.TotalPracticeTimeMinutes, data.ScenariosCompleted)
+	}
+
+	data = ExportData{}
+	json.NewDecoder(export(t, "/api/export?domain=system-hardening", "").Body).Decode(&data)
+	if len(data.Attempts) != 2 || data.Attempts[0].Domain != "system-hardening" {
+		t.Errorf("expected the system hardening attempts, got %+v", data.Attempts)
+	}
+
+	for _, query := range []string{"format=pdf", "domain=nope", "from=2026-04-01&to=2026-03-01", "from=March", "format=csv&table=nope"} {
+		if w := export(t, "/api/export?"+query, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("expected %q to be rejected, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	setupExportHistory(t)
+	defer database.Close()
+
+	w := export(t, "/api/export?format=csv&table=attempts&to=2026-03-31", "")
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if w.Header().Get("Content-Type") != "text/csv; charset=utf-8" || len(lines) != 3 || !strings.HasPrefix(lines[0], "attempt_id,") {
+		t.Errorf("unexpected attempts CSV (%s):\n%s", w.Header().Get("Content-Type"), w.Body.String())
+	}
+	if !strings.Contains(lines[2], "AppArmor | Profiles") {
+		t.Errorf("expected the exercise title in the row, got %s", lines[2])
+	}
+
+	// Without a table, every table is zipped; the Accept header picks CSV
+	w = export(t, "/api/export", "text/csv")
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("expected a zip archive: %v", err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if strings.Join(names, ",") != "attempts.csv,personal_bests.csv,mock_exams.csv,hint_reveals.csv,notes.csv,bookmarks.csv" {
+		t.Errorf("unexpected archive contents %v", names)
+	}
+	f, _ := zr.File[0].Open()
+	body, _ := io.ReadAll(f)
+	if strings.Count(string(body), "\n") != 4 {
+		t.Errorf("expected a header and three attempts, got:\n%s", body)
+	}
+}
+
+func TestExportReports(t *testing.T) {
+	setupExportHistory(t)
+	defer database.Close()
+
+	markdown := export(t, "/api/export?format=markdown&to=2026-03-31", "").Body.String()
+	for _, want := range []string{
+		"covering until 2026-03-31",
+		"| Cluster Setup | 10% | 1 | 100% | 100% | 1 |",
+		"| System Hardening ⚠ | 15% | 1 | 0% | 30% | 1 |",
+		`| AppArmor \| Profiles | System Hardening | 1 | 30% |`,
+		"| Audit Logs | Cluster Setup | 10m 0s | 1 |",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("expected %q in the report:\n%s", want, markdown)
+		}
+	}
+
+	html := export(t, "/api/export", "text/html,application/xhtml+xml").Body.String()
+	if !strings.Contains(html, "<h2>Weak Areas</h2>") || !strings.Contains(html, "AppArmor | Profiles") || !strings.Contains(html, `class="weak"`) {
+		t.Errorf("unexpected HTML report:\n%s", html)
+	}
+	if strings.Contains(html, "30%") {
+		t.Errorf("expected the later pass to lift AppArmor out of the weak areas:\n%s", html)
+	}
+}
+
+func TestExportJUnit(t *testing.T) {
+	setupExportHistory(t)
+	defer database.Close()
+
+	w := export(t, "/api/export?format=junit", "")
+	var report junitTestSuites
+	if err := xml.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JUnit XML: %v\n%s", err, w.Body.String())
+	}
+	if len(report.Suites) != 3 || report.Tests != 5 || report.Failures != 1 || report.Time != 1200 {
+		t.Fatalf("unexpected totals %+v", report)
+	}
+	failed := report.Suites[1]
+	if failed.Name != "apparmor" || failed.Timestamp != "2026-03-02T09:05:00" || failed.Failures != 1 {
+		t.Errorf("unexpected suite %+v", failed)
+	}
+	if failed.TestCases[0].Name != "Profile file exists" || failed.TestCases[0].Failure != nil {
+		t.Errorf("expected the ticked check to pass, got %+v", failed.TestCases[0])
+	}
+	if f := failed.TestCases[1].Failure; f == nil || f.Message != "profile not loaded" {
+		t.Errorf("expected the unticked check to fail with the feedback, got %+v", failed.TestCases[1])
+	}
+	if report.Suites[2].TestCases[0].Name != "validation" {
+		t.Errorf("expected an attempt without details to be one case, got %+v", report.Suites[2].TestCases)
+	}
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+// HintResponse is the response of the hint endpoints
+type HintResponse struct {
+	Success        bool                  `json:"success"`
+	Hint           *database.HintReveal  `json:"hint,omitempty"` // The hint just revealed
+	Revealed       []database.HintReveal `json:"revealed,omitempty"`
+	Total          int                   `json:"total"`
+	PenaltyPercent int                   `json:"penaltyPercent"` // Score taken off per hint at validation
+	ErrorCode      string                `json:"errorCode,omitempty"`
+	Error          string                `json:"error,omitempty"`
+}
+
+// HintSettingsRequest updates the hint penalty
+type HintSettingsRequest struct {
+	PenaltyPercent int `json:"penaltyPercent"`
+}
+
+// HandleHints handles the hint API:
+//
+//	GET  /api/hints/settings       the score penalty per hint
+//	PUT  /api/hints/settings       set it ({"penaltyPercent": 10}, 0 turns it off)
+//	GET  /api/hints/{slug}         hints revealed for the current attempt
+//	POST /api/hints/{slug}/reveal  reveal the next hint
+//
+// Reveals count against the current attempt and are assigned to it when
+// the exercise is next validated.
+func HandleHints(w http.ResponseWriter, r *http.Request) {
+	if database.DB == nil {
+		writeHintResponse(w, http.StatusInternalServerError, HintResponse{Error: "Database not initialized"})
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/hints"), "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case path == "settings" && r.Method == http.MethodGet:
+		percent, err := database.GetHintPenaltyPercent()
+		if err != nil {
+			writeHintError(w, err)
+			return
+		}
+		writeHintResponse(w, http.StatusOK, HintResponse{Success: true, PenaltyPercent: percent})
+
+	case path == "settings" && r.Method == http.MethodPut:
+		var req HintSettingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeHintResponse(w, http.StatusBadRequest, HintResponse{Error: "Invalid request body"})
+			return
+		}
+		if req.PenaltyPercent < 0 || req.PenaltyPercent > 100 {
+			writeHintResponse(w, http.StatusBadRequest, HintResponse{Error: "penaltyPercent must be between 0 and 100"})
+			return
+		}
+		if err := database.SetHintPenaltyPercent(req.PenaltyPercent); err != nil {
+			writeHintError(w, err)
+			return
+		}
+		writeHintResponse(w, http.StatusOK, HintResponse{Success: true, PenaltyPercent: req.PenaltyPercent})
+
+	case len(parts) == 1 && path != "" && r.Method == http.MethodGet:
+		revealed, total, err := database.RevealedHints(parts[0])
+		if err != nil {
+			writeHintError(w, err)
+			return
+		}
+		percent, _ := database.GetHintPenaltyPercent()
+		writeHintResponse(w, http.StatusOK, HintResponse{Success: true, Revealed: revealed, Total: total, PenaltyPercent: percent})
+
+	case len(parts) == 2 && parts[1] == "reveal" && r.Method == http.MethodPost:
+		hint, total, err := database.RevealNextHint(parts[0])
+		if err != nil {
+			writeHintError(w, err)
+			return
+		}
+		revealed, _, err := database.RevealedHints(parts[0])
+		if err != nil {
+			writeHintError(w, err)
+			return
+		}
+		percent, _ := database.GetHintPenaltyPercent()
+		log.Printf("Revealed hint %d of %d for %s", hint.Index+1, total, parts[0])
+		writeHintResponse(w, http.StatusOK, HintResponse{Success: true, Hint: hint, Revealed: revealed, Total: total, PenaltyPercent: percent})
+
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// hintPenalty returns the points to take off score for hintsUsed hints
+// at percent each, never more than the score itself
+func hintPenalty(score, hintsUsed, percent int) int {
+	if score <= 0 || hintsUsed <= 0 || percent <= 0 {
+		return 0
+	}
+	penalty := (score*percent*hintsUsed + 50) / 100
+	if penalty > score {
+		penalty = score
+	}
+	return penalty
+}
+
+// withRevealedHints replaces an exercise's hints with the ones revealed for
+// the current attempt, so hints are only handed out through the reveal API
+func withRevealedHints(ex *database.Exercise) {
+	ex.HintCount = len(ex.Hints)
+	ex.Hints = []string{}
+	revealed, _, err := database.RevealedHints(ex.Slug)
+	if err != nil {
+		return
+	}
+	for _, r := range revealed {
+		ex.Hints = append(ex.Hints, r.Hint)
+	}
+}
+
+// writeHintError maps a database error to a hint response
+func writeHintError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	response := HintResponse{Error: err.Error()}
+
+	var dbErr *database.DatabaseError
+	if errors.As(err, &dbErr) {
+		response.ErrorCode = dbErr.Code
+		response.Error = dbErr.Message
+		switch dbErr.Code {
+		case database.ErrCodeNoMoreHints:
+			status = http.StatusConflict
+		case database.ErrCodeExerciseNotFound:
+			status = http.StatusNotFound
+		}
+	}
+	writeHintResponse(w, status, response)
+}
+
+// writeHintResponse writes a hint response as JSON
+func writeHintResponse(w http.ResponseWriter, status int, response HintResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
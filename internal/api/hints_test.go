@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+func TestHintRevealAndPenalty(t *testing.T) {
+	if err := database.Initialize(database.Config{Path: filepath.Join(t.TempDir(), "test.db")}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer database.Close()
+	if err := database.ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+	_, err := database.DB.Exec(`
+		INSERT INTO exercises (slug, title, description, category, difficulty, points, estimated_minutes, prerequisites, hints, solution)
+		VALUES ('hinted', 'Hinted', 'test', 'cluster-setup', 'easy', 10, 10, '[]', '["first", "second"]', '')
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert exercise: %v", err)
+	}
+
+	call := func(method, path, body string) (int, HintResponse) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		HandleHints(w, req)
+		var response HintResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response
+	}
+
+	if code, _ := call(http.MethodPut, "/api/hints/settings", `{"penaltyPercent": 10}`); code != http.StatusOK {
+		t.Fatalf("setting the penalty returned %d", code)
+	}
+
+	code, response := call(http.MethodPost, "/api/hints/hinted/reveal", "")
+	if code != http.StatusOK || response.Hint == nil || response.Hint.Hint != "first" || response.Total != 2 {
+		t.Fatalf("unexpected first reveal (%d): %+v", code, response)
+	}
+	call(http.MethodPost, "/api/hints/hinted/reveal", "")
+	if code, _ := call(http.MethodPost, "/api/hints/hinted/reveal", ""); code != http.StatusConflict {
+		t.Errorf("expected 409 once every hint is revealed, got %d", code)
+	}
+	if code, _ := call(http.MethodPost, "/api/hints/missing/reveal", ""); code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown exercise, got %d", code)
+	}
+
+	// The exercise only carries the revealed hints
+	req := httptest.NewRequest(http.MethodGet, "/api/exercises/hinted", nil)
+	w := httptest.NewRecorder()
+	GetExerciseBySlug(w, req)
+	var exercise ExercisesResponse
+	json.NewDecoder(w.Body).Decode(&exercise)
+	if exercise.Exercise == nil || exercise.Exercise.HintCount != 2 || len(exercise.Exercise.Hints) != 2 {
+		t.Fatalf("unexpected exercise: %+v", exercise.Exercise)
+	}
+
+	// Validation charges both hints to the attempt: 10% each off 10 points
+	result := validateAndRecord("hinted")
+	if result.HintsUsed != 2 || result.HintPenalty != 2 || result.Score != 8 {
+		t.Errorf("expected 2 hints costing 2 points, got %+v", result)
+	}
+	var hintsUsed, penalty, claimed int
+	database.DB.QueryRow("SELECT hints_used, hint_penalty FROM attempts WHERE id = ?", result.AttemptID).Scan(&hintsUsed, &penalty)
+	database.DB.QueryRow("SELECT COUNT(*) FROM hint_reveals WHERE attempt_id = ?", result.AttemptID).Scan(&claimed)
+	if hintsUsed != 2 || penalty != 2 || claimed != 2 {
+		t.Errorf("expected the attempt to record 2 hints and a 2 point penalty, got %d, %d and %d reveals", hintsUsed, penalty, claimed)
+	}
+
+	// The next attempt starts without hints
+	if _, response := call(http.MethodGet, "/api/hints/hinted", ""); len(response.Revealed) != 0 {
+		t.Errorf("expected no hints revealed for the next attempt, got %d", len(response.Revealed))
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
@@ -13,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/patrickvassell/cks-weight-room/internal/cluster"
 	"github.com/patrickvassell/cks-weight-room/internal/security"
 )
 
@@ -24,6 +26,9 @@ type IDESession struct {
 	ProcessPID    int       // PID of code-server process
 	StartedAt     time.Time
 	LastAccess    time.Time
+
+	tunnel    *cluster.NodeTunnel // Carries connections to code-server in the node
+	transport *http.Transport     // Pools tunnelled connections for the proxy
 }
 
 // IDEHandler manages code-server sessions
@@ -31,7 +36,7 @@ type IDEHandler struct {
 	sessions      map[string]*IDESession // key: "slug-nodeName"
 	mu            sync.RWMutex
 	commandFilter *security.CommandFilter
-	nextPort      int       // Next available port (starts at 8081)
+	ports         *portPool // code-server ports (8081-8180), reused after sessions stop
 }
 
 // NewIDEHandler creates a new IDE handler
@@ -39,21 +44,25 @@ func NewIDEHandler() *IDEHandler {
 	handler := &IDEHandler{
 		sessions:      make(map[string]*IDESession),
 		commandFilter: security.NewCommandFilter(),
-		nextPort:      8081, // Start allocating ports from 8081
+		ports:         newPortPool(idePortMin, idePortMax),
 	}
 
+	// Adopt code-server instances left running by a previous server process
+	go handler.recoverSessions()
+
 	// Start cleanup goroutine for idle sessions
 	go handler.cleanupIdleSessions()
 
 	return handler
 }
 
-// allocatePort returns the next available port and increments the counter
+// allocatePort reserves the lowest free port that nothing in the node is
+// already listening on
 // NOTE: Caller must already hold h.mu lock
-func (h *IDEHandler) allocatePort() int {
-	port := h.nextPort
-	h.nextPort++
-	return port
+func (h *IDEHandler) allocatePort(ctx context.Context, sessionKey, nodeName string) (int, error) {
+	return h.ports.Acquire(sessionKey, func(port int) bool {
+		return cluster.PortInUse(ctx, nodeName, port)
+	})
 }
 
 // HandleIDEProxy proxies HTTP requests to code-server in KIND node
@@ -136,8 +145,13 @@ func (h *IDEHandler) getOrCreateSession(slug, nodeName string) (*IDESession, err
 	defer h.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if session, exists := h.sessions[sessionKey]; exists && h.isSessionHealthy(session) {
-		return session, nil
+	if session, exists := h.sessions[sessionKey]; exists {
+		if h.isSessionHealthy(session) {
+			return session, nil
+		}
+		// Free the dead session's port before starting a replacement
+		h.stopSession(session)
+		delete(h.sessions, sessionKey)
 	}
 
 	log.Printf("Creating new IDE session for %s", sessionKey)
@@ -163,7 +177,17 @@ func (h *IDEHandler) startCodeServer(slug, nodeName string) (*IDESession, error)
 	defer cancel()
 
 	// Allocate a unique port for this code-server instance
-	port := h.allocatePort()
+	sessionKey := fmt.Sprintf("%s-%s", slug, nodeName)
+	port, err := h.allocatePort(ctx, sessionKey, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	started := false
+	defer func() {
+		if !started {
+			h.ports.Release(port)
+		}
+	}()
 	bindAddr := fmt.Sprintf("0.0.0.0:%d", port)
 
 	log.Printf("Starting code-server in node: %s on port %d", nodeName, port)
@@ -203,58 +227,38 @@ func (h *IDEHandler) startCodeServer(slug, nodeName string) (*IDESession, error)
 		}
 	}
 
-	// Get container's IP address
-	log.Printf("Getting container IP address for %s", nodeName)
-	ipCmd := exec.CommandContext(ctx, "docker", "inspect", "-f", "{{range.NetworkSettings.Networks}}{{.IPAddress}}{{end}}", nodeName)
-	ipOutput, err := ipCmd.Output()
+	// Connect through an in-process tunnel instead of publishing the port:
+	// direct to the node IP where the kind network is routable, otherwise
+	// over a docker exec stream
+	tunnel, err := cluster.NewNodeTunnel(ctx, nodeName, port)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get container IP: %w", err)
-	}
-	containerIP := strings.TrimSpace(string(ipOutput))
-	if containerIP == "" {
-		return nil, fmt.Errorf("container IP address is empty")
-	}
-	log.Printf("Container %s IP: %s", nodeName, containerIP)
-
-	// On macOS, container IPs aren't accessible from host
-	// Start a lightweight proxy container to bridge the connection
-	localPort := 9000 + (port - 8080) // Map 8081->9001, 8082->9002, etc.
-	proxyName := fmt.Sprintf("ide-proxy-%s-%d", slug, port)
-	target := fmt.Sprintf("%s:%d", containerIP, port)
-
-	log.Printf("Starting proxy container %s: localhost:%d -> %s", proxyName, localPort, target)
-
-	// Remove existing proxy container if it exists (from previous session)
-	exec.Command("docker", "rm", "-f", proxyName).Run() // Ignore errors if container doesn't exist
-
-	// Use alpine/socat to proxy from host port to container IP:port
-	proxyCmd := exec.CommandContext(context.Background(), "docker", "run", "-d",
-		"--name", proxyName,
-		"--rm",
-		"--network", "kind",
-		"-p", fmt.Sprintf("127.0.0.1:%d:%d", localPort, localPort),
-		"alpine/socat",
-		fmt.Sprintf("TCP-LISTEN:%d,fork,reuseaddr", localPort),
-		fmt.Sprintf("TCP:%s", target))
-
-	output, err = proxyCmd.CombinedOutput()
-	if err != nil {
-		log.Printf("Warning: failed to start proxy container: %v - %s", err, string(output))
-		log.Printf("Attempting direct connection to %s", target)
-	} else {
-		log.Printf("Proxy container started: %s", strings.TrimSpace(string(output)))
-		time.Sleep(500 * time.Millisecond) // Give proxy time to start
+		return nil, fmt.Errorf("failed to open tunnel to %s: %w", nodeName, err)
 	}
+	log.Printf("Tunnel to %s:%d ready (direct: %v)", nodeName, port, tunnel.Direct())
 
+	started = true
+	return newIDESession(nodeName, port, tunnel), nil
+}
+
+// newIDESession builds a session whose proxy transport dials code-server
+// through tunnel
+func newIDESession(nodeName string, port int, tunnel *cluster.NodeTunnel) *IDESession {
 	session := &IDESession{
 		NodeName:    nodeName,
-		Port:        localPort, // Use local port exposed by proxy container
-		ContainerIP: "127.0.0.1",
+		Port:        port,
+		ContainerIP: tunnel.NodeIP,
 		StartedAt:   time.Now(),
 		LastAccess:  time.Now(),
+		tunnel:      tunnel,
 	}
-
-	return session, nil
+	session.transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return tunnel.DialContext(ctx, port)
+		},
+		MaxIdleConnsPerHost: 8,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return session
 }
 
 // isSessionHealthy checks if code-server process is still running
@@ -262,8 +266,8 @@ func (h *IDEHandler) isSessionHealthy(session *IDESession) bool {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Check if code-server process is running
-	checkCmd := exec.CommandContext(ctx, "docker", "exec", session.NodeName, "pgrep", "-f", "code-server")
+	// Check if this session's code-server process is running
+	checkCmd := exec.CommandContext(ctx, "docker", "exec", session.NodeName, "pgrep", "-f", fmt.Sprintf("code-server.*:%d", session.Port))
 	if err := checkCmd.Run(); err != nil {
 		log.Printf("Session unhealthy for %s: code-server not running", session.NodeName)
 		return false
@@ -274,9 +278,9 @@ func (h *IDEHandler) isSessionHealthy(session *IDESession) bool {
 
 // proxyToCodeServer proxies HTTP/WebSocket requests to code-server
 func (h *IDEHandler) proxyToCodeServer(w http.ResponseWriter, r *http.Request, session *IDESession, slug string) {
-	// Construct target URL - proxy directly to container IP:port
-	// Each code-server runs on its own unique port
-	targetURL := fmt.Sprintf("http://%s:%d", session.ContainerIP, session.Port)
+	// Construct target URL - the session transport dials through the node
+	// tunnel, so the host part only labels the upstream
+	targetURL := fmt.Sprintf("http://%s:%d", session.NodeName, session.Port)
 	target, err := url.Parse(targetURL)
 	if err != nil {
 		log.Printf("Failed to parse target URL: %v", err)
@@ -288,6 +292,7 @@ func (h *IDEHandler) proxyToCodeServer(w http.ResponseWriter, r *http.Request, s
 
 	// Create reverse proxy
 	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = session.transport
 
 	// Customize director to preserve WebSocket headers and strip path prefix
 	originalDirector := proxy.Director
@@ -391,7 +396,9 @@ func (h *IDEHandler) proxyToCodeServer(w http.ResponseWriter, r *http.Request, s
 	proxy.ServeHTTP(w, r)
 }
 
-// stopSession terminates code-server process in container
+// stopSession terminates code-server process in container and returns its
+// port to the pool
+// NOTE: Caller must already hold h.mu lock
 func (h *IDEHandler) stopSession(session *IDESession) {
 	log.Printf("Stopping IDE session for %s on port %d", session.NodeName, session.Port)
 
@@ -399,8 +406,13 @@ func (h *IDEHandler) stopSession(session *IDESession) {
 	defer cancel()
 
 	// Kill the specific code-server instance running on this port
-	killCmd := fmt.Sprintf("pkill -f 'code-server.*:%d'", session.Port)
-	exec.CommandContext(ctx, "docker", "exec", session.NodeName, "sh", "-c", killCmd).Run()
+	cluster.StopCodeServer(ctx, session.NodeName, session.Port)
+	h.ports.Release(session.Port)
+
+	// Drop pooled tunnel connections
+	if session.transport != nil {
+		session.transport.CloseIdleConnections()
+	}
 }
 
 // cleanupIdleSessions removes sessions idle for > 30 minutes
@@ -435,3 +447,99 @@ func (h *IDEHandler) CleanupClusterSessions(slug string) {
 		}
 	}
 }
+
+// recoverSessions finds code-server instances still running in KIND nodes
+// after a restart. One instance per node in the IDE port range is adopted
+// as that node's session; duplicates, out-of-range instances and leftover
+// socat proxies are cleaned up. The docker calls run without h.mu, which is
+// only held to reserve a port and register a session, so IDE requests made
+// right after startup aren't held up.
+func (h *IDEHandler) recoverSessions() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	if removed, err := cluster.RemoveLegacyIDEProxies(ctx); err != nil {
+		log.Printf("Failed to remove legacy IDE proxy containers: %v", err)
+	} else if removed > 0 {
+		log.Printf("Removed %d legacy IDE proxy containers", removed)
+	}
+
+	nodes, err := cluster.ListRunningNodes(ctx)
+	if err != nil {
+		log.Printf("Skipping IDE session recovery: %v", err)
+		return
+	}
+
+	for _, node := range nodes {
+		slug := node.ExerciseSlug()
+		if slug == "" {
+			continue
+		}
+
+		if err := cluster.StopSocatProxies(ctx, node.Name); err != nil {
+			log.Printf("Failed to stop socat proxies in %s: %v", node.Name, err)
+		}
+
+		ports, err := cluster.FindCodeServerPorts(ctx, node.Name)
+		if err != nil {
+			log.Printf("Failed to inspect code-server in %s: %v", node.Name, err)
+			continue
+		}
+
+		sessionKey := fmt.Sprintf("%s-%s", slug, node.Name)
+		for _, port := range ports {
+			if h.adoptSession(ctx, sessionKey, node.Name, port) {
+				continue
+			}
+			log.Printf("Stopping orphaned code-server in %s on port %d", node.Name, port)
+			cluster.StopCodeServer(ctx, node.Name, port)
+		}
+	}
+}
+
+// adoptSession registers a running code-server as the session for
+// sessionKey, unless the key already has one. It takes h.mu itself, and
+// not while the tunnel is set up.
+func (h *IDEHandler) adoptSession(ctx context.Context, sessionKey, nodeName string, port int) bool {
+	h.mu.Lock()
+	if _, exists := h.sessions[sessionKey]; exists {
+		h.mu.Unlock()
+		return false
+	}
+	if err := h.ports.Reserve(port, sessionKey); err != nil {
+		h.mu.Unlock()
+		log.Printf("Not adopting code-server in %s: %v", nodeName, err)
+		return false
+	}
+	h.mu.Unlock()
+
+	tunnel, err := cluster.NewNodeTunnel(ctx, nodeName, port)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil {
+		log.Printf("Not adopting code-server in %s: %v", nodeName, err)
+		h.ports.Release(port)
+		return false
+	}
+	if _, exists := h.sessions[sessionKey]; exists {
+		// An IDE request started a session while the tunnel was being set up
+		h.ports.Release(port)
+		return false
+	}
+	h.sessions[sessionKey] = newIDESession(nodeName, port, tunnel)
+	log.Printf("Adopted running code-server for %s on port %d", sessionKey, port)
+	return true
+}
+
+// StopAllSessions stops every code-server session, used on shutdown
+func (h *IDEHandler) StopAllSessions() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for key, session := range h.sessions {
+		log.Printf("Stopping IDE session on shutdown: %s", key)
+		h.stopSession(session)
+		delete(h.sessions, key)
+	}
+}
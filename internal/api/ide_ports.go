@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+)
+
+// IDE code-server port range inside KIND nodes
+const (
+	idePortMin = 8081
+	idePortMax = 8180
+)
+
+// portPool hands out code-server ports, preferring the lowest free port so
+// ports are reused after sessions stop. It is not safe for concurrent use;
+// IDEHandler guards it with its own mutex.
+type portPool struct {
+	min, max int
+	owners   map[int]string // port -> session key
+}
+
+// newPortPool creates a pool covering [min, max]
+func newPortPool(min, max int) *portPool {
+	return &portPool{
+		min:    min,
+		max:    max,
+		owners: make(map[int]string),
+	}
+}
+
+// Acquire reserves the lowest port that isn't already assigned and that
+// occupied doesn't report as busy (e.g. something else listening in the node)
+func (p *portPool) Acquire(owner string, occupied func(port int) bool) (int, error) {
+	for port := p.min; port <= p.max; port++ {
+		if _, taken := p.owners[port]; taken {
+			continue
+		}
+		if occupied != nil && occupied(port) {
+			continue
+		}
+		p.owners[port] = owner
+		return port, nil
+	}
+	return 0, fmt.Errorf("no free IDE ports between %d and %d", p.min, p.max)
+}
+
+// Reserve marks port as assigned to owner, as when adopting a running
+// code-server. It fails if the port is outside the pool or already taken.
+func (p *portPool) Reserve(port int, owner string) error {
+	if !p.Contains(port) {
+		return fmt.Errorf("port %d is outside the IDE port range %d-%d", port, p.min, p.max)
+	}
+	if current, taken := p.owners[port]; taken && current != owner {
+		return fmt.Errorf("port %d is already assigned to %s", port, current)
+	}
+	p.owners[port] = owner
+	return nil
+}
+
+// Release returns port to the pool
+func (p *portPool) Release(port int) {
+	delete(p.owners, port)
+}
+
+// Contains reports whether port falls inside the pool's range
+func (p *portPool) Contains(port int) bool {
+	return port >= p.min && port <= p.max
+}
+
+// InUse returns the assigned ports in ascending order
+func (p *portPool) InUse() []int {
+	ports := make([]int, 0, len(p.owners))
+	for port := range p.owners {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	return ports
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPortPoolReusesReleasedPorts(t *testing.T) {
+	pool := newPortPool(8081, 8083)
+
+	first, _ := pool.Acquire("a", nil)
+	second, _ := pool.Acquire("b", nil)
+	if first != 8081 || second != 8082 {
+		t.Fatalf("expected 8081 and 8082, got %d and %d", first, second)
+	}
+
+	pool.Release(first)
+	reused, err := pool.Acquire("c", nil)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if reused != 8081 {
+		t.Errorf("expected released port 8081 to be reused, got %d", reused)
+	}
+}
+
+func TestPortPoolSkipsOccupiedPorts(t *testing.T) {
+	pool := newPortPool(8081, 8085)
+
+	busy := map[int]bool{8081: true, 8082: true}
+	port, err := pool.Acquire("a", func(p int) bool { return busy[p] })
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if port != 8083 {
+		t.Errorf("expected 8083, got %d", port)
+	}
+}
+
+func TestPortPoolExhausted(t *testing.T) {
+	pool := newPortPool(8081, 8082)
+	pool.Acquire("a", nil)
+	pool.Acquire("b", nil)
+
+	if _, err := pool.Acquire("c", nil); err == nil {
+		t.Error("expected error when the pool is exhausted")
+	}
+}
+
+func TestPortPoolReserve(t *testing.T) {
+	pool := newPortPool(8081, 8090)
+
+	if err := pool.Reserve(8085, "adopted"); err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+	if err := pool.Reserve(8085, "other"); err == nil {
+		t.Error("expected error reserving a port owned by another session")
+	}
+	if err := pool.Reserve(9001, "legacy"); err == nil {
+		t.Error("expected error reserving a port outside the range")
+	}
+
+	// Acquire must skip the adopted port
+	for i := 0; i < 5; i++ {
+		pool.Acquire("s", nil)
+	}
+	if got := pool.InUse(); !reflect.DeepEqual(got, []int{8081, 8082, 8083, 8084, 8085, 8086}) {
+		t.Errorf("unexpected ports in use: %v", got)
+	}
+}
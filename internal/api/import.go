@@ -0,0 +1,681 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+	"github.com/patrickvassell/cks-weight-room/internal/exam"
+)
+
+// Import modes
+const (
+	ImportModeMerge   = "merge"   // Add what isn't there yet
+	ImportModeReplace = "replace" // Clear the practice history first
+)
+
+// maxImportBytes bounds the size of an uploaded export file
+const maxImportBytes = 64 << 20
+
+// importTime matches the format SQLite's datetime() produces
+const importTime = "2006-01-02 15:04:05"
+
+// ErrInvalidImport marks an export file that can't be imported
+var ErrInvalidImport = errors.New("invalid import file")
+
+// ImportEnvelope wraps export data with the schema version it was written
+// with. A bare export file is read as its own envelope.
+type ImportEnvelope struct {
+	SchemaVersion int         `json:"schema_version"`
+	Data          *ExportData `json:"data"`
+}
+
+// ImportResult summarises an import
+type ImportResult struct {
+	Success               bool     `json:"success"`
+	Mode                  string   `json:"mode,omitempty"`
+	Profile               string   `json:"profile,omitempty"`
+	SchemaVersion         int      `json:"schemaVersion,omitempty"`
+	AttemptsImported      int      `json:"attemptsImported"`
+	AttemptsSkipped       int      `json:"attemptsSkipped"` // Already recorded
+	PersonalBestsImported int      `json:"personalBestsImported"`
+	MockExamsImported     int      `json:"mockExamsImported"`
+	MockExamsSkipped      int      `json:"mockExamsSkipped"`
+	HintRevealsImported   int      `json:"hintRevealsImported"`
+	NotesImported         int      `json:"notesImported"`
+	BookmarksImported     int      `json:"bookmarksImported"`
+	UnknownExercises      []string `json:"unknownExercises,omitempty"` // Not in this install; their records were left out
+	Error                 string   `json:"error,omitempty"`
+}
+
+// ImportProgressData handles POST /api/import?mode=merge|replace with an
+// export file as the body. It imports into the active profile, or the one
+// given with ?profile=.
+func ImportProgressData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if database.DB == nil {
+		writeImportResponse(w, http.StatusInternalServerError, ImportResult{Error: "Database not initialized"})
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = ImportModeMerge
+	}
+
+	profile, err := requestProfile(r.URL.Query().Get("profile"))
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		writeImportResponse(w, http.StatusRequestEntityTooLarge, ImportResult{Error: "Import file is too large"})
+		return
+	}
+
+	result, err := ImportProgress(body, mode, profile)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidImport) {
+			status = http.StatusBadRequest
+		}
+		writeImportResponse(w, status, ImportResult{Error: err.Error()})
+		return
+	}
+	log.Printf("Imported progress into %s (%s): %d attempts, %d mock exams", profile.Slug, mode, result.AttemptsImported, result.MockExamsImported)
+	writeImportResponse(w, http.StatusOK, *result)
+}
+
+// ParseImport reads an export file, bare or wrapped in an envelope, and
+// returns its data and schema version
+func ParseImport(body []byte) (*ExportData, int, error) {
+	var envelope ImportEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	data := envelope.Data
+	if data == nil {
+		data = &ExportData{}
+		if err := json.Unmarshal(body, data); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+	}
+
+	version := envelope.SchemaVersion
+	if version == 0 {
+		version = data.SchemaVersion
+	}
+	if version == 0 {
+		version = 1 // Files from before the schema was versioned
+	}
+	if version > ExportSchemaVersion {
+		return nil, 0, fmt.Errorf("%w: schema version %d is newer than this version of the app supports (%d)",
+			ErrInvalidImport, version, ExportSchemaVersion)
+	}
+	return data, version, nil
+}
+
+// ImportProgress imports an export file into a profile in one transaction.
+// Exercises are matched by slug, or by title for version 1 files. An attempt
+// already recorded for the exercise with the same completion time and
+// duration is skipped, as is a finished mock exam of the same type and
+// time, so importing a file twice changes nothing. Progress rows are rebuilt
+// from the attempts of the exercises touched, keeping the better personal
+// best. Replace only clears the profile's own history.
+func ImportProgress(body []byte, mode string, profile *database.Profile) (*ImportResult, error) {
+	if database.DB == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if mode != ImportModeMerge && mode != ImportModeReplace {
+		return nil, fmt.Errorf("%w: mode must be %q or %q", ErrInvalidImport, ImportModeMerge, ImportModeReplace)
+	}
+	data, version, err := ParseImport(body)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	im, err := newImporter(tx, profile.ID, version)
+	if err != nil {
+		return nil, err
+	}
+	if mode == ImportModeReplace {
+		if err := im.clear(); err != nil {
+			return nil, err
+		}
+	}
+	if err := im.attempts(data.Attempts); err != nil {
+		return nil, err
+	}
+	if err := im.hintReveals(data.HintReveals); err != nil {
+		return nil, err
+	}
+	if err := im.progress(data.PersonalBests); err != nil {
+		return nil, err
+	}
+	if err := im.mockExams(data.MockExams); err != nil {
+		return nil, err
+	}
+	if err := im.notes(data.Notes); err != nil {
+		return nil, err
+	}
+	if err := im.bookmarks(data.Bookmarks); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+	im.result.Success = true
+	im.result.Mode = mode
+	im.result.Profile = profile.Slug
+	im.result.SchemaVersion = version
+	return &im.result, nil
+}
+
+// importer carries the lookups shared by the steps of an import
+type importer struct {
+	tx        *sql.Tx
+	profileID int64
+	version   int
+	result    ImportResult
+
+	bySlug     map[string]int
+	byTitle    map[string]int
+	byOldID    map[int]int   // The file's scenario_id to this install's exercise id
+	attemptIDs map[int]int64 // The file's attempt_id to this install's attempt id
+	added      map[int64]bool
+	touched    map[int]bool // Exercises whose progress needs rebuilding
+	unknown    map[string]bool
+}
+
+func newImporter(tx *sql.Tx, profileID int64, version int) (*importer, error) {
+	im := &importer{
+		tx:         tx,
+		profileID:  profileID,
+		version:    version,
+		bySlug:     map[string]int{},
+		byTitle:    map[string]int{},
+		byOldID:    map[int]int{},
+		attemptIDs: map[int]int64{},
+		added:      map[int64]bool{},
+		touched:    map[int]bool{},
+		unknown:    map[string]bool{},
+	}
+
+	rows, err := tx.Query("SELECT id, slug, title FROM exercises")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exercises: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var slug, title string
+		if err := rows.Scan(&id, &slug, &title); err != nil {
+			return nil, fmt.Errorf("failed to read exercise: %w", err)
+		}
+		im.bySlug[slug] = id
+		im.byTitle[title] = id
+	}
+	return im, rows.Err()
+}
+
+// exercise resolves a record's exercise, by slug when the file has one
+func (im *importer) exercise(oldID int, slug, title string) (int, bool) {
+	var id int
+	var ok bool
+	switch {
+	case slug != "":
+		id, ok = im.bySlug[slug]
+	case title != "":
+		id, ok = im.byTitle[title]
+	default:
+		id, ok = im.byOldID[oldID]
+	}
+	if !ok {
+		name := slug
+		if name == "" {
+			name = title
+		}
+		if name == "" {
+			name = fmt.Sprintf("#%d", oldID)
+		}
+		if !im.unknown[name] {
+			im.unknown[name] = true
+			im.result.UnknownExercises = append(im.result.UnknownExercises, name)
+		}
+		return 0, false
+	}
+	im.byOldID[oldID] = id
+	return id, true
+}
+
+// clear removes the profile's practice history for a replace
+func (im *importer) clear() error {
+	for _, stmt := range []string{
+		"DELETE FROM hint_reveals WHERE profile_id = ?",
+		"DELETE FROM attempts WHERE profile_id = ?",
+		"DELETE FROM progress WHERE profile_id = ?",
+		"DELETE FROM bookmarks WHERE profile_id = ?",
+		"DELETE FROM mock_exams WHERE profile_id = ? AND completed_at IS NOT NULL", // Running exams stay resumable
+	} {
+		if _, err := im.tx.Exec(stmt, im.profileID); err != nil {
+			return fmt.Errorf("failed to clear history: %w", err)
+		}
+	}
+	return nil
+}
+
+func (im *importer) attempts(attempts []ExportAttempt) error {
+	for _, a := range attempts {
+		exerciseID, ok := im.exercise(a.ScenarioID, a.ScenarioSlug, a.ScenarioName)
+		if !ok {
+			continue
+		}
+
+		// Version 1 files only have one timestamp and the score as a ratio
+		completedAt, finished := importTimestamp(a.CompletedAt)
+		points := a.Points
+		if im.version == 1 {
+			completedAt, finished = importTimestamp(a.Timestamp)
+			points = int(math.Round(a.Score * float64(a.MaxScore)))
+		}
+		startedAt, started := importTimestamp(a.StartedAt)
+		if !started {
+			if !finished {
+				return fmt.Errorf("%w: attempt %d has no timestamp", ErrInvalidImport, a.AttemptID)
+			}
+			startedAt = shiftTimestamp(completedAt, -a.CompletionTimeSeconds)
+		}
+		var completed any
+		if finished {
+			completed = completedAt
+		}
+
+		var existing int64
+		err := im.tx.QueryRow(`
+			SELECT id FROM attempts
+			WHERE profile_id = ? AND exercise_id = ? AND datetime(COALESCE(completed_at, started_at)) = ? AND COALESCE(duration_seconds, 0) = ?
+			LIMIT 1
+		`, im.profileID, exerciseID, coalesce(completedAt, startedAt), a.CompletionTimeSeconds).Scan(&existing)
+		if err == nil {
+			im.attemptIDs[a.AttemptID] = existing
+			im.result.AttemptsSkipped++
+			continue
+		}
+		if err != sql.ErrNoRows {
+			return fmt.Errorf("failed to look up attempt: %w", err)
+		}
+
+		res, err := im.tx.Exec(`
+			INSERT INTO attempts (profile_id, exercise_id, started_at, completed_at, duration_seconds, score, max_score, passed, feedback, details, hints_used, hint_penalty)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, im.profileID, exerciseID, startedAt, completed, a.CompletionTimeSeconds, points, a.MaxScore, a.Status == "completed",
+			nullIfEmpty(a.Feedback), nullIfEmpty(a.Details), a.HintsUsed, a.HintPenalty)
+		if err != nil {
+			return fmt.Errorf("failed to import attempt: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to import attempt: %w", err)
+		}
+		im.attemptIDs[a.AttemptID] = id
+		im.added[id] = true
+		im.touched[exerciseID] = true
+		im.result.AttemptsImported++
+	}
+	return nil
+}
+
+// hintReveals imports the reveals of imported attempts and the open ones
+func (im *importer) hintReveals(reveals []ExportHintReveal) error {
+	for _, h := range reveals {
+		exerciseID, ok := im.exercise(h.ScenarioID, h.ScenarioSlug, "")
+		if !ok {
+			continue
+		}
+		var attemptID any
+		if h.AttemptID != nil {
+			id, ok := im.attemptIDs[*h.AttemptID]
+			if !ok || !im.added[id] {
+				continue // Its attempt was skipped or already had its reveals
+			}
+			attemptID = id
+		}
+		revealedAt, ok := importTimestamp(h.RevealedAt)
+		if !ok {
+			return fmt.Errorf("%w: hint reveal without a timestamp", ErrInvalidImport)
+		}
+
+		var exists int
+		err := im.tx.QueryRow(`
+			SELECT COUNT(*) FROM hint_reveals WHERE profile_id = ? AND exercise_id = ? AND hint_index = ? AND attempt_id IS ?
+		`, im.profileID, exerciseID, h.HintIndex, attemptID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to look up hint reveal: %w", err)
+		}
+		if exists > 0 {
+			continue
+		}
+		if _, err := im.tx.Exec(`
+			INSERT INTO hint_reveals (profile_id, exercise_id, attempt_id, hint_index, revealed_at) VALUES (?, ?, ?, ?, ?)
+		`, im.profileID, exerciseID, attemptID, h.HintIndex, revealedAt); err != nil {
+			return fmt.Errorf("failed to import hint reveal: %w", err)
+		}
+		im.result.HintRevealsImported++
+	}
+	return nil
+}
+
+// progress rebuilds the progress rows of the exercises touched from their
+// attempts and the imported personal bests
+func (im *importer) progress(bests []ExportPersonalBest) error {
+	imported := map[int]int{}
+	for _, pb := range bests {
+		exerciseID, ok := im.exercise(pb.ScenarioID, pb.ScenarioSlug, pb.ScenarioName)
+		if !ok || pb.BestTimeSeconds <= 0 {
+			continue
+		}
+		if best, seen := imported[exerciseID]; !seen || pb.BestTimeSeconds < best {
+			imported[exerciseID] = pb.BestTimeSeconds
+		}
+		im.touched[exerciseID] = true
+	}
+
+	for exerciseID := range im.touched {
+		var extra sql.NullInt64
+		pb, hasPB := imported[exerciseID]
+		if hasPB {
+			extra = sql.NullInt64{Int64: int64(pb), Valid: true}
+		}
+		won, err := rebuildProgress(im.tx, im.profileID, exerciseID, extra, true)
+		if err != nil {
+			return err
+		}
+		if won {
+			im.result.PersonalBestsImported++
+		}
+	}
+	return nil
+}
+
+// notes imports exercise notes. An exercise that already has a different
+// note keeps whichever was edited last.
+func (im *importer) notes(notes []ExportNote) error {
+	for _, n := range notes {
+		exerciseID, ok := im.exercise(n.ScenarioID, n.ScenarioSlug, n.ScenarioName)
+		if !ok || strings.TrimSpace(n.Notes) == "" {
+			continue
+		}
+		updatedAt, ok := importTimestamp(n.UpdatedAt)
+		if !ok {
+			updatedAt = time.Now().UTC().Format(importTime)
+		}
+
+		var current, currentAt sql.NullString
+		err := im.tx.QueryRow(`
+			SELECT notes, notes_updated_at FROM progress WHERE profile_id = ? AND exercise_id = ?
+		`, im.profileID, exerciseID).Scan(&current, &currentAt)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to look up note: %w", err)
+		}
+		if current.String == n.Notes || (current.String != "" && currentAt.String >= updatedAt) {
+			continue
+		}
+
+		if _, err := im.tx.Exec(`
+			INSERT INTO progress (profile_id, exercise_id, status, notes, notes_updated_at)
+			VALUES (?, ?, 'not-started', ?, ?)
+			ON CONFLICT(profile_id, exercise_id) DO UPDATE SET
+				notes = excluded.notes,
+				notes_updated_at = excluded.notes_updated_at
+		`, im.profileID, exerciseID, n.Notes, updatedAt); err != nil {
+			return fmt.Errorf("failed to import note: %w", err)
+		}
+		im.result.NotesImported++
+	}
+	return nil
+}
+
+// bookmarks imports bookmarks, adding their tags to those of an exercise
+// that is already bookmarked
+func (im *importer) bookmarks(bookmarks []ExportBookmark) error {
+	for _, b := range bookmarks {
+		exerciseID, ok := im.exercise(b.ScenarioID, b.ScenarioSlug, b.ScenarioName)
+		if !ok {
+			continue
+		}
+		tags, err := database.NormalizeBookmarkTags(b.Tags)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+		createdAt, ok := importTimestamp(b.CreatedAt)
+		if !ok {
+			createdAt = time.Now().UTC().Format(importTime)
+		}
+
+		var id int64
+		err = im.tx.QueryRow(`
+			SELECT id FROM bookmarks WHERE profile_id = ? AND exercise_id = ?
+		`, im.profileID, exerciseID).Scan(&id)
+		switch {
+		case err == sql.ErrNoRows:
+			res, err := im.tx.Exec(`
+				INSERT INTO bookmarks (profile_id, exercise_id, created_at) VALUES (?, ?, ?)
+			`, im.profileID, exerciseID, createdAt)
+			if err != nil {
+				return fmt.Errorf("failed to import bookmark: %w", err)
+			}
+			if id, err = res.LastInsertId(); err != nil {
+				return fmt.Errorf("failed to import bookmark: %w", err)
+			}
+			im.result.BookmarksImported++
+		case err != nil:
+			return fmt.Errorf("failed to look up bookmark: %w", err)
+		}
+
+		for _, tag := range tags {
+			if _, err := im.tx.Exec(`
+				INSERT OR IGNORE INTO bookmark_tags (bookmark_id, tag) VALUES (?, ?)
+			`, id, tag); err != nil {
+				return fmt.Errorf("failed to import bookmark tag: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// rebuildProgress recomputes a profile's progress row for an exercise from
+// its attempts.
+// extra is a personal best from outside the attempts, such as an import or
+// a reset snapshot; with keepBest the row's current personal best counts
+// too. It reports whether extra became the personal best. An exercise left
+// without attempts or a best goes back to not-started; other columns, such
+// as notes, are kept.
+func rebuildProgress(tx *sql.Tx, profileID int64, exerciseID int, extra sql.NullInt64, keepBest bool) (bool, error) {
+	var count, seconds, passed int
+	var best sql.NullInt64
+	var completedAt sql.NullString
+	err := tx.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(duration_seconds), 0), COALESCE(SUM(passed), 0),
+			MIN(CASE WHEN passed = 1 THEN duration_seconds END), MAX(CASE WHEN passed = 1 THEN completed_at END)
+		FROM attempts WHERE profile_id = ? AND exercise_id = ?
+	`, profileID, exerciseID).Scan(&count, &seconds, &passed, &best, &completedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to summarise attempts: %w", err)
+	}
+
+	var progressID int
+	var current sql.NullInt64
+	err = tx.QueryRow(`
+		SELECT id, personal_best_seconds FROM progress WHERE profile_id = ? AND exercise_id = ?
+	`, profileID, exerciseID).Scan(&progressID, &current)
+	found := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to load progress: %w", err)
+	}
+
+	won := false
+	if extra.Valid && (!best.Valid || extra.Int64 < best.Int64) {
+		best, won = extra, true
+	}
+	if keepBest && current.Valid && (!best.Valid || current.Int64 <= best.Int64) {
+		best, won = current, false
+	}
+	status := "not-started"
+	switch {
+	case passed > 0 || best.Valid:
+		status = "completed"
+	case count > 0:
+		status = "in-progress"
+	}
+
+	switch {
+	case !found && status == "not-started":
+		return false, nil
+	case !found:
+		_, err = tx.Exec(`
+			INSERT INTO progress (profile_id, exercise_id, status, completed_at, attempts, time_spent_seconds, personal_best_seconds)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, profileID, exerciseID, status, completedAt, count, seconds, best)
+	default:
+		_, err = tx.Exec(`
+			UPDATE progress SET status = ?,
+				completed_at = CASE WHEN ? = 'completed' THEN COALESCE(?, completed_at) END,
+				attempts = ?, time_spent_seconds = ?, personal_best_seconds = ?, updated_at = datetime('now')
+			WHERE id = ?
+		`, status, status, completedAt, count, seconds, best, progressID)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to save progress: %w", err)
+	}
+	return won, nil
+}
+
+// mockExams imports finished mock exams, pointing their graded questions at
+// this install's exercises
+func (im *importer) mockExams(exams []ExportMockExam) error {
+	for _, m := range exams {
+		completedAt, finished := importTimestamp(m.CompletedAt)
+		score, maxScore := m.OverallPoints, m.MaxScore
+		if im.version == 1 {
+			completedAt, finished = importTimestamp(m.Timestamp)
+			score, maxScore = int(math.Round(m.OverallScore*100)), 100
+		}
+		if !finished {
+			continue // An unfinished exam can't be resumed elsewhere
+		}
+		startedAt, started := importTimestamp(m.StartedAt)
+		if !started {
+			startedAt = shiftTimestamp(completedAt, -m.TotalTimeSeconds)
+		}
+
+		var exists int
+		err := im.tx.QueryRow(`
+			SELECT COUNT(*) FROM mock_exams WHERE profile_id = ? AND exam_type = ? AND datetime(completed_at) = ?
+		`, im.profileID, m.ExamType, completedAt).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to look up mock exam: %w", err)
+		}
+		if exists > 0 {
+			im.result.MockExamsSkipped++
+			continue
+		}
+
+		results, err := im.examResults(m.Results)
+		if err != nil {
+			return err
+		}
+		if _, err := im.tx.Exec(`
+			INSERT INTO mock_exams (profile_id, exam_type, started_at, completed_at, total_duration_seconds, overall_score, max_score, passed, exercises_completed, exercises_total, results)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, im.profileID, m.ExamType, startedAt, completedAt, m.TotalTimeSeconds, score, maxScore, m.Result == "passed",
+			m.ExercisesCompleted, m.ExercisesTotal, results); err != nil {
+			return fmt.Errorf("failed to import mock exam: %w", err)
+		}
+		im.result.MockExamsImported++
+	}
+	return nil
+}
+
+// examResults maps the exercise ids in an exam's graded questions by slug
+func (im *importer) examResults(results string) (any, error) {
+	if results == "" {
+		return nil, nil
+	}
+	var graded []exam.QuestionResult
+	if err := json.Unmarshal([]byte(results), &graded); err != nil {
+		return results, nil // Results predating the exam engine are kept as they are
+	}
+	for i, q := range graded {
+		if id, ok := im.bySlug[q.Slug]; ok {
+			graded[i].ExerciseID = id
+		}
+	}
+	encoded, err := json.Marshal(graded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode exam results: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// importTimestamp normalises an exported timestamp to SQLite's format
+func importTimestamp(s string) (string, bool) {
+	if s == "" {
+		return "", false
+	}
+	for _, layout := range []string{time.RFC3339Nano, importTime, "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC().Format(importTime), true
+		}
+	}
+	return "", false
+}
+
+// shiftTimestamp moves a normalised timestamp by a number of seconds
+func shiftTimestamp(s string, seconds int) string {
+	t, _ := time.Parse(importTime, s)
+	return t.Add(time.Duration(seconds) * time.Second).Format(importTime)
+}
+
+func coalesce(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// writeImportResponse writes an import result as JSON
+func writeImportResponse(w http.ResponseWriter, status int, result ImportResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+func setupImportDB(t *testing.T) {
+	t.Helper()
+	if err := database.Initialize(database.Config{Path: filepath.Join(t.TempDir(), "test.db")}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := database.ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+	// Numeric ids differ between installs; slugs don't
+	_, err := database.DB.Exec(`
+		INSERT INTO exercises (id, slug, title, description, category, difficulty, points)
+		VALUES (7, 'audit-logs', 'Audit Logs', 'test', 'cluster-setup', 'easy', 10)
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert exercise: %v", err)
+	}
+}
+
+func countRows(t *testing.T, table string) int {
+	t.Helper()
+	var n int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+		t.Fatalf("failed to count %s: %v", table, err)
+	}
+	return n
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	setupImportDB(t)
+	_, err := database.DB.Exec(`
+		INSERT INTO attempts (exercise_id, started_at, completed_at, duration_seconds, score, max_score, passed, feedback, hints_used)
+		VALUES (7, '2026-03-01 09:00:00', '2026-03-01 09:10:00', 600, 4, 10, 0, 'missed a check', 1),
+			(7, '2026-03-02 09:00:00', '2026-03-02 09:05:00', 300, 10, 10, 1, 'all good', 0);
+		INSERT INTO hint_reveals (exercise_id, attempt_id, hint_index, revealed_at) VALUES (7, 1, 0, '2026-03-01 09:02:00');
+		INSERT INTO progress (exercise_id, status, completed_at, attempts, time_spent_seconds, personal_best_seconds)
+		VALUES (7, 'completed', '2026-03-02 09:05:00', 2, 900, 300);
+		INSERT INTO mock_exams (exam_type, started_at, completed_at, total_duration_seconds, overall_score, max_score, passed, exercises_completed, exercises_total, results)
+		VALUES ('quick-practice', '2026-03-03 10:00:00', '2026-03-03 11:00:00', 3600, 8, 10, 1, 1, 1,
+			'[{"exercise_id": 7, "slug": "audit-logs", "domain": "cluster-setup", "score": 8, "max_score": 10, "passed": true, "number": 1}]');
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert history: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	GetExportData(w, httptest.NewRequest(http.MethodGet, "/api/export", nil))
+	export := w.Body.String()
+
+	// Importing into the same install adds nothing
+	result, err := ImportProgress([]byte(export), ImportModeMerge, activeProfile(t))
+	if err != nil {
+		t.Fatalf("ImportProgress failed: %v", err)
+	}
+	if result.AttemptsImported != 0 || result.AttemptsSkipped != 2 || result.MockExamsSkipped != 1 || result.HintRevealsImported != 0 {
+		t.Errorf("expected everything to be skipped, got %+v", result)
+	}
+	database.Close()
+
+	// A new install numbers the exercise differently
+	setupImportDB(t)
+	defer database.Close()
+	if _, err := database.DB.Exec("UPDATE exercises SET id = 3 WHERE slug = 'audit-logs'"); err != nil {
+		t.Fatalf("failed to renumber exercise: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/import?mode=merge", strings.NewReader(export))
+	w = httptest.NewRecorder()
+	ImportProgressData(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("import returned %d: %s", w.Code, w.Body.String())
+	}
+	if countRows(t, "attempts WHERE exercise_id = 3") != 2 || countRows(t, "hint_reveals WHERE exercise_id = 3 AND attempt_id IS NOT NULL") != 1 {
+		t.Error("expected the attempts and their hint reveal on the renumbered exercise")
+	}
+	var status string
+	var best, attempts int
+	database.DB.QueryRow("SELECT status, personal_best_seconds, attempts FROM progress WHERE exercise_id = 3").Scan(&status, &best, &attempts)
+	if status != "completed" || best != 300 || attempts != 2 {
+		t.Errorf("unexpected progress %s, best %d, %d attempts", status, best, attempts)
+	}
+	var score int
+	var results string
+	database.DB.QueryRow("SELECT overall_score, results FROM mock_exams").Scan(&score, &results)
+	if score != 8 || !strings.Contains(results, `"exercise_id":3`) {
+		t.Errorf("expected the mock exam to point at the renumbered exercise, got %d %s", score, results)
+	}
+
+	// Replace starts over from the file
+	database.DB.Exec(`
+		INSERT INTO attempts (exercise_id, started_at, completed_at, duration_seconds, score, max_score, passed)
+		VALUES (3, '2026-04-01 09:00:00', '2026-04-01 09:01:00', 60, 10, 10, 1)
+	`)
+	result, err = ImportProgress([]byte(export), ImportModeReplace, activeProfile(t))
+	if err != nil {
+		t.Fatalf("replace failed: %v", err)
+	}
+	if result.AttemptsImported != 2 || countRows(t, "attempts") != 2 || countRows(t, "mock_exams") != 1 {
+		t.Errorf("expected only the file's history after replace, got %+v", result)
+	}
+}
+
+func TestImportLegacyFile(t *testing.T) {
+	setupImportDB(t)
+	defer database.Close()
+
+	// Version 1 exports have no slugs, one timestamp and the score as a ratio
+	legacy := `{
+		"export_date": "2026-01-10T00:00:00Z",
+		"attempts": [
+			{"attempt_id": 1, "scenario_id": 12, "scenario_name": "Audit Logs", "timestamp": "2026-01-05T10:00:00Z",
+				"completion_time_seconds": 420, "score": 0.8, "max_score": 10, "status": "completed"},
+			{"attempt_id": 2, "scenario_id": 99, "scenario_name": "Retired Exercise", "timestamp": "2026-01-06T10:00:00Z",
+				"completion_time_seconds": 60, "score": 1, "max_score": 10, "status": "completed"}
+		],
+		"personal_bests": [{"scenario_id": 12, "scenario_name": "Audit Logs", "best_time_seconds": 400}],
+		"mock_exams": [{"exam_id": 1, "exam_type": "full-mock-exam", "timestamp": "2026-01-07T12:00:00Z",
+			"total_time_seconds": 7200, "overall_score": 0.7, "result": "passed"}],
+		"hint_reveals": [{"scenario_id": 12, "attempt_id": 1, "hint_index": 0, "revealed_at": "2026-01-05T09:55:00Z"}]
+	}`
+
+	result, err := ImportProgress([]byte(legacy), ImportModeMerge, activeProfile(t))
+	if err != nil {
+		t.Fatalf("ImportProgress failed: %v", err)
+	}
+	if result.SchemaVersion != 1 || result.AttemptsImported != 1 || result.HintRevealsImported != 1 || result.PersonalBestsImported != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if len(result.UnknownExercises) != 1 || result.UnknownExercises[0] != "Retired Exercise" {
+		t.Errorf("expected the retired exercise to be reported, got %v", result.UnknownExercises)
+	}
+
+	var score int
+	var startedAt string
+	database.DB.QueryRow("SELECT score, strftime('%Y-%m-%d %H:%M:%S', started_at) FROM attempts").Scan(&score, &startedAt)
+	if score != 8 || startedAt != "2026-01-05 09:53:00" {
+		t.Errorf("unexpected attempt: score %d, started %s", score, startedAt)
+	}
+	var best int
+	database.DB.QueryRow("SELECT personal_best_seconds FROM progress WHERE exercise_id = 7").Scan(&best)
+	if best != 400 {
+		t.Errorf("expected the imported personal best, got %d", best)
+	}
+}
+
+func TestImportRejectsBadFiles(t *testing.T) {
+	setupImportDB(t)
+	defer database.Close()
+
+	for name, body := range map[string]string{
+		"not json":       "attempts",
+		"future version": `{"schema_version": 99, "data": {"attempts": []}}`,
+	} {
+		if _, err := ImportProgress([]byte(body), ImportModeMerge, activeProfile(t)); !errors.Is(err, ErrInvalidImport) {
+			t.Errorf("%s: expected an invalid import, got %v", name, err)
+		}
+	}
+	if _, err := ImportProgress([]byte(`{"schema_version": 2, "data": {"attempts": []}}`), "append", activeProfile(t)); !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("expected an unknown mode to be rejected, got %v", err)
+	}
+
+	// A version 2 envelope is accepted
+	result, err := ImportProgress([]byte(`{"schema_version": 2, "data": {"attempts": []}}`), ImportModeMerge, activeProfile(t))
+	if err != nil || result.SchemaVersion != 2 {
+		t.Errorf("expected an empty envelope to import, got %+v (%v)", result, err)
+	}
+}
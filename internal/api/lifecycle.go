@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/patrickvassell/cks-weight-room/internal/lifecycle"
+	"github.com/patrickvassell/cks-weight-room/internal/realism"
+	"github.com/patrickvassell/cks-weight-room/internal/terminal"
+	"github.com/patrickvassell/cks-weight-room/internal/timer"
+)
+
+// RegisterLifecycleHooks subscribes the API's per-exercise resources to
+// lifecycle events. Subscribers run in this order for cluster deletion and
+// exercise reset, and in reverse on shutdown:
+//  1. IDE sessions (code-server in the nodes, pooled tunnel connections)
+//  2. Terminal sessions (close frames sent, shells and their containers cleaned up)
+//  3. Leftover per-session terminal containers
+//  4. The exercise's timer (cluster deletion and reset only)
+//  5. Docs mirror (shutdown only)
+//  6. Timer callbacks (shutdown only, so stopped first, before the database closes)
+//
+// ide may be nil when the IDE proxy is disabled.
+func RegisterLifecycleHooks(ide *IDEHandler) {
+	if ide != nil {
+		lifecycle.Subscribe("ide-sessions", func(ctx context.Context, event lifecycle.Event) error {
+			if event.Type == lifecycle.Shutdown {
+				ide.StopAllSessions()
+			} else {
+				ide.CleanupClusterSessions(event.Slug)
+			}
+			return nil
+		})
+	}
+
+	lifecycle.Subscribe("terminal-sessions", func(ctx context.Context, event lifecycle.Event) error {
+		reason := lifecycleCloseReason(event.Type)
+		closed := terminalSessions.CloseAll(event.Slug, reason)
+		if closed == 0 {
+			return nil
+		}
+		log.Printf("Closed %d terminal sessions (%s)", closed, reason)
+
+		// Wait for handlers to run their deferred cleanup
+		if !terminalSessions.Wait(ctx, event.Slug) {
+			return fmt.Errorf("terminal sessions still open after %s", reason)
+		}
+		return nil
+	})
+
+	lifecycle.Subscribe("terminal-containers", func(ctx context.Context, event lifecycle.Event) error {
+		removed, err := removeTerminalContainers(ctx, event.Slug)
+		if removed > 0 {
+			log.Printf("Removed %d leftover terminal containers", removed)
+		}
+		return err
+	})
+
+	lifecycle.Subscribe("exercise-timers", func(ctx context.Context, event lifecycle.Event) error {
+		timerService.Remove(timer.ExerciseTimerID(event.Slug))
+		return nil
+	}, lifecycle.ClusterDeleted, lifecycle.ExerciseReset)
+
+	lifecycle.Subscribe("docs-mirror", func(ctx context.Context, event lifecycle.Event) error {
+		return realism.StopMirror(ctx)
+	}, lifecycle.Shutdown)
+
+	// Registered last so it runs first on shutdown: timer callbacks write
+	// to the database, which is closed after the hooks
+	lifecycle.Subscribe("timers", func(ctx context.Context, event lifecycle.Event) error {
+		return timerService.Shutdown(ctx)
+	}, lifecycle.Shutdown)
+}
+
+// lifecycleCloseReason is the message shown in terminals closed by an event
+func lifecycleCloseReason(t lifecycle.EventType) string {
+	switch t {
+	case lifecycle.ExerciseReset:
+		return "exercise is being reset"
+	case lifecycle.Shutdown:
+		return terminal.ReasonShutdown
+	default:
+		return "cluster was deleted"
+	}
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+// defaultNoteSearchLimit and maxNoteSearchLimit bound ?limit= of a search
+const (
+	defaultNoteSearchLimit = 20
+	maxNoteSearchLimit     = 100
+)
+
+// NotesResponse is the response of the note and bookmark endpoints
+type NotesResponse struct {
+	Success   bool                   `json:"success"`
+	Note      *database.Note         `json:"note,omitempty"`
+	Notes     []database.Note        `json:"notes,omitempty"`
+	Matches   []database.NoteMatch   `json:"matches,omitempty"`
+	Bookmark  *database.Bookmark     `json:"bookmark,omitempty"`
+	Bookmarks []database.Bookmark    `json:"bookmarks,omitempty"`
+	Tags      []database.BookmarkTag `json:"tags,omitempty"`
+	ErrorCode string                 `json:"errorCode,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// SaveNoteRequest sets an exercise's note
+type SaveNoteRequest struct {
+	Notes string `json:"notes"` // Markdown; empty deletes the note
+}
+
+// SaveBookmarkRequest bookmarks an exercise
+type SaveBookmarkRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// HandleNotes handles the notes API:
+//
+//	GET    /api/notes                  every note, most recently edited first
+//	GET    /api/notes?q=apparmor       full-text search (?limit=, default 20)
+//	GET    /api/notes/{slug}           an exercise's note
+//	PUT    /api/notes/{slug}           set it ({"notes": "Markdown"}; empty deletes it)
+//	DELETE /api/notes/{slug}           delete it
+//
+// Notes are Markdown, one per exercise, and belong to the active profile.
+func HandleNotes(w http.ResponseWriter, r *http.Request) {
+	if database.DB == nil {
+		writeNotesResponse(w, http.StatusInternalServerError, NotesResponse{Error: "Database not initialized"})
+		return
+	}
+
+	slug := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/notes"), "/")
+
+	switch {
+	case slug == "" && r.Method == http.MethodGet:
+		query := r.URL.Query().Get("q")
+		if query == "" {
+			notes, err := database.ListNotes()
+			if err != nil {
+				writeNotesError(w, err)
+				return
+			}
+			writeNotesResponse(w, http.StatusOK, NotesResponse{Success: true, Notes: notes})
+			return
+		}
+		limit := defaultNoteSearchLimit
+		if s := r.URL.Query().Get("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 || n > maxNoteSearchLimit {
+				writeNotesResponse(w, http.StatusBadRequest, NotesResponse{Error: "limit must be between 1 and 100"})
+				return
+			}
+			limit = n
+		}
+		matches, err := database.SearchNotes(query, limit)
+		if err != nil {
+			writeNotesError(w, err)
+			return
+		}
+		writeNotesResponse(w, http.StatusOK, NotesResponse{Success: true, Matches: matches})
+
+	case slug != "" && !strings.Contains(slug, "/") && r.Method == http.MethodGet:
+		note, err := database.GetNote(slug)
+		if err != nil {
+			writeNotesError(w, err)
+			return
+		}
+		writeNotesResponse(w, http.StatusOK, NotesResponse{Success: true, Note: note})
+
+	case slug != "" && !strings.Contains(slug, "/") && r.Method == http.MethodPut:
+		var req SaveNoteRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4*database.MaxNoteLength)).Decode(&req); err != nil {
+			writeNotesResponse(w, http.StatusBadRequest, NotesResponse{Error: "Invalid request body"})
+			return
+		}
+		note, err := database.SaveNote(slug, req.Notes)
+		if err != nil {
+			writeNotesError(w, err)
+			return
+		}
+		writeNotesResponse(w, http.StatusOK, NotesResponse{Success: true, Note: note})
+
+	case slug != "" && !strings.Contains(slug, "/") && r.Method == http.MethodDelete:
+		if err := database.DeleteNote(slug); err != nil {
+			writeNotesError(w, err)
+			return
+		}
+		writeNotesResponse(w, http.StatusOK, NotesResponse{Success: true})
+
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// HandleBookmarks handles the bookmarks API:
+//
+//	GET    /api/bookmarks              every bookmark, newest first (?tag= narrows)
+//	GET    /api/bookmarks/tags         the tags in use, with counts
+//	GET    /api/bookmarks/{slug}       an exercise's bookmark
+//	PUT    /api/bookmarks/{slug}       bookmark it, or retag it ({"tags": ["weak-spot"]})
+//	DELETE /api/bookmarks/{slug}       remove the bookmark
+//
+// Bookmarks belong to the active profile.
+func HandleBookmarks(w http.ResponseWriter, r *http.Request) {
+	if database.DB == nil {
+		writeNotesResponse(w, http.StatusInternalServerError, NotesResponse{Error: "Database not initialized"})
+		return
+	}
+
+	slug := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/bookmarks"), "/")
+
+	switch {
+	case slug == "" && r.Method == http.MethodGet:
+		bookmarks, err := database.ListBookmarks(r.URL.Query().Get("tag"))
+		if err != nil {
+			writeNotesError(w, err)
+			return
+		}
+		writeNotesResponse(w, http.StatusOK, NotesResponse{Success: true, Bookmarks: bookmarks})
+
+	case slug == "tags" && r.Method == http.MethodGet:
+		tags, err := database.ListBookmarkTags()
+		if err != nil {
+			writeNotesError(w, err)
+			return
+		}
+		writeNotesResponse(w, http.StatusOK, NotesResponse{Success: true, Tags: tags})
+
+	case slug != "" && !strings.Contains(slug, "/") && r.Method == http.MethodGet:
+		bookmark, err := database.GetBookmark(slug)
+		if err != nil {
+			writeNotesError(w, err)
+			return
+		}
+		writeNotesResponse(w, http.StatusOK, NotesResponse{Success: true, Bookmark: bookmark})
+
+	case slug != "" && !strings.Contains(slug, "/") && r.Method == http.MethodPut:
+		var req SaveBookmarkRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeNotesResponse(w, http.StatusBadRequest, NotesResponse{Error: "Invalid request body"})
+				return
+			}
+		}
+		bookmark, err := database.SaveBookmark(slug, req.Tags)
+		if err != nil {
+			writeNotesError(w, err)
+			return
+		}
+		writeNotesResponse(w, http.StatusOK, NotesResponse{Success: true, Bookmark: bookmark})
+
+	case slug != "" && !strings.Contains(slug, "/") && r.Method == http.MethodDelete:
+		if err := database.DeleteBookmark(slug); err != nil {
+			writeNotesError(w, err)
+			return
+		}
+		writeNotesResponse(w, http.StatusOK, NotesResponse{Success: true})
+
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// writeNotesError maps a database error to a notes response
+func writeNotesError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	response := NotesResponse{Error: err.Error()}
+
+	var dbErr *database.DatabaseError
+	if errors.As(err, &dbErr) {
+		response.ErrorCode = dbErr.Code
+		response.Error = dbErr.Message
+		switch dbErr.Code {
+		case database.ErrCodeExerciseNotFound, database.ErrCodeNoteNotFound, database.ErrCodeBookmarkNotFound:
+			status = http.StatusNotFound
+		case database.ErrCodeInvalidNote, database.ErrCodeInvalidBookmark:
+			status = http.StatusBadRequest
+		}
+	}
+	writeNotesResponse(w, status, response)
+}
+
+// writeNotesResponse writes a notes response as JSON
+func writeNotesResponse(w http.ResponseWriter, status int, response NotesResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
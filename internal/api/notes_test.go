@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+func notesRequest(t *testing.T, handler http.HandlerFunc, method, url, body string) (int, NotesResponse) {
+	t.Helper()
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+	var response NotesResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	return w.Code, response
+}
+
+func TestNotesAndBookmarksAPI(t *testing.T) {
+	setupImportDB(t)
+
+	code, response := notesRequest(t, HandleNotes, http.MethodPut, "/api/notes/audit-logs", `{"notes": "Set --audit-log-maxage on the API server"}`)
+	if code != http.StatusOK || response.Note == nil || response.Note.Domain != "cluster-setup" {
+		t.Fatalf("unexpected save (%d): %+v", code, response)
+	}
+	if code, response := notesRequest(t, HandleNotes, http.MethodGet, "/api/notes?q=maxage", ""); code != http.StatusOK || len(response.Matches) != 1 {
+		t.Errorf("expected the note to be found (%d): %+v", code, response)
+	}
+	if code, _ := notesRequest(t, HandleNotes, http.MethodGet, "/api/notes?q=maxage&limit=0", ""); code != http.StatusBadRequest {
+		t.Errorf("expected a bad limit to be refused, got %d", code)
+	}
+	if code, response := notesRequest(t, HandleNotes, http.MethodPut, "/api/notes/missing", `{"notes": "x"}`); code != http.StatusNotFound || response.ErrorCode != database.ErrCodeExerciseNotFound {
+		t.Errorf("expected 404 for an unknown exercise, got %d: %+v", code, response)
+	}
+
+	code, response = notesRequest(t, HandleBookmarks, http.MethodPut, "/api/bookmarks/audit-logs", `{"tags": ["Exam Day"]}`)
+	if code != http.StatusOK || response.Bookmark == nil || strings.Join(response.Bookmark.Tags, ",") != "exam-day" || !response.Bookmark.HasNote {
+		t.Fatalf("unexpected bookmark (%d): %+v", code, response)
+	}
+	if code, _ := notesRequest(t, HandleBookmarks, http.MethodPut, "/api/bookmarks/audit-logs", `{"tags": ["no/slash"]}`); code != http.StatusBadRequest {
+		t.Errorf("expected a bad tag to be refused, got %d", code)
+	}
+	if _, response := notesRequest(t, HandleBookmarks, http.MethodGet, "/api/bookmarks/tags", ""); len(response.Tags) != 1 || response.Tags[0].Tag != "exam-day" {
+		t.Errorf("unexpected tags %+v", response.Tags)
+	}
+
+	// Both travel in an export and come back on import
+	w := export(t, "/api/export", "")
+	var data ExportData
+	json.NewDecoder(w.Body).Decode(&data)
+	if data.SchemaVersion != ExportSchemaVersion || len(data.Notes) != 1 || len(data.Bookmarks) != 1 {
+		t.Fatalf("expected the note and bookmark in the export, got %+v", data)
+	}
+	body, _ := json.Marshal(data)
+
+	if code, _ := notesRequest(t, HandleBookmarks, http.MethodDelete, "/api/bookmarks/audit-logs", ""); code != http.StatusOK {
+		t.Fatalf("delete returned %d", code)
+	}
+	if code, _ := notesRequest(t, HandleBookmarks, http.MethodGet, "/api/bookmarks/audit-logs", ""); code != http.StatusNotFound {
+		t.Errorf("expected the bookmark to be gone, got %d", code)
+	}
+	notesRequest(t, HandleNotes, http.MethodDelete, "/api/notes/audit-logs", "")
+
+	result, err := ImportProgress(body, ImportModeMerge, activeProfile(t))
+	if err != nil || result.NotesImported != 1 || result.BookmarksImported != 1 {
+		t.Fatalf("unexpected import %+v (%v)", result, err)
+	}
+	if _, response := notesRequest(t, HandleNotes, http.MethodGet, "/api/notes/audit-logs", ""); response.Note == nil || response.Note.Notes != data.Notes[0].Notes {
+		t.Errorf("expected the note back, got %+v", response)
+	}
+
+	// Merging again changes nothing; an older note doesn't overwrite a newer one
+	result, _ = ImportProgress(body, ImportModeMerge, activeProfile(t))
+	if result.NotesImported != 0 || result.BookmarksImported != 0 {
+		t.Errorf("expected a second merge to import nothing, got %+v", result)
+	}
+	notesRequest(t, HandleNotes, http.MethodPut, "/api/notes/audit-logs", `{"notes": "rewritten"}`)
+	database.DB.Exec("UPDATE progress SET notes_updated_at = '2099-01-01 00:00:00' WHERE exercise_id = 7")
+	if result, _ = ImportProgress(body, ImportModeMerge, activeProfile(t)); result.NotesImported != 0 {
+		t.Errorf("expected the newer note to win, got %+v", result)
+	}
+}
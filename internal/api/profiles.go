@@ -0,0 +1,169 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+// ProfileResponse is the response of the profile endpoints
+type ProfileResponse struct {
+	Success   bool               `json:"success"`
+	Profile   *database.Profile  `json:"profile,omitempty"`
+	Profiles  []database.Profile `json:"profiles,omitempty"`
+	ErrorCode string             `json:"errorCode,omitempty"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// CreateProfileRequest adds a profile
+type CreateProfileRequest struct {
+	Name     string `json:"name"`
+	Slug     string `json:"slug,omitempty"` // Derived from the name by default
+	Activate bool   `json:"activate,omitempty"`
+}
+
+// HandleProfiles handles the learner profile API:
+//
+//	GET    /api/profiles                   every profile
+//	POST   /api/profiles                   add one ({"name": ..., "slug": ..., "activate": true})
+//	GET    /api/profiles/active            the profile in use
+//	GET    /api/profiles/{slug}            one profile
+//	POST   /api/profiles/{slug}/activate   switch to a profile
+//	DELETE /api/profiles/{slug}            delete a profile and its history
+//
+// Progress, attempts, mock exams, hints, stats, export, import and reset
+// all work on the active profile; export, import and reset also take
+// ?profile= for another one.
+func HandleProfiles(w http.ResponseWriter, r *http.Request) {
+	if database.DB == nil {
+		writeProfileResponse(w, http.StatusInternalServerError, ProfileResponse{Error: "Database not initialized"})
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/profiles"), "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		profiles, err := database.ListProfiles()
+		if err != nil {
+			writeProfileError(w, err)
+			return
+		}
+		writeProfileResponse(w, http.StatusOK, ProfileResponse{Success: true, Profiles: profiles})
+
+	case path == "" && r.Method == http.MethodPost:
+		var req CreateProfileRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeProfileResponse(w, http.StatusBadRequest, ProfileResponse{Error: "Invalid request body"})
+			return
+		}
+		profile, err := database.CreateProfile(req.Name, req.Slug)
+		if err != nil {
+			writeProfileError(w, err)
+			return
+		}
+		if req.Activate {
+			if profile, err = activateProfile(profile.Slug); err != nil {
+				writeProfileError(w, err)
+				return
+			}
+		}
+		log.Printf("Added profile %s (active: %v)", profile.Slug, profile.Active)
+		writeProfileResponse(w, http.StatusCreated, ProfileResponse{Success: true, Profile: profile})
+
+	case path == "active" && r.Method == http.MethodGet:
+		profile, err := database.GetActiveProfile()
+		if err != nil {
+			writeProfileError(w, err)
+			return
+		}
+		writeProfileResponse(w, http.StatusOK, ProfileResponse{Success: true, Profile: profile})
+
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		profile, err := database.GetProfile(parts[0])
+		if err != nil {
+			writeProfileError(w, err)
+			return
+		}
+		writeProfileResponse(w, http.StatusOK, ProfileResponse{Success: true, Profile: profile})
+
+	case len(parts) == 2 && parts[1] == "activate" && r.Method == http.MethodPost:
+		profile, err := activateProfile(parts[0])
+		if err != nil {
+			writeProfileError(w, err)
+			return
+		}
+		log.Printf("Switched to profile %s", profile.Slug)
+		writeProfileResponse(w, http.StatusOK, ProfileResponse{Success: true, Profile: profile})
+
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		if err := database.DeleteProfile(parts[0]); err != nil {
+			writeProfileError(w, err)
+			return
+		}
+		log.Printf("Deleted profile %s", parts[0])
+		writeProfileResponse(w, http.StatusOK, ProfileResponse{Success: true})
+
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// errExamRunning refuses a profile switch while a mock exam runs, since the
+// exam's attempts would land in the other profile
+var errExamRunning = errors.New("finish or close the running mock exam before switching profiles")
+
+// activateProfile switches to a profile unless a mock exam is running
+func activateProfile(slug string) (*database.Profile, error) {
+	if _, running := examManager.Active(); running {
+		return nil, errExamRunning
+	}
+	if err := database.ActivateProfile(slug); err != nil {
+		return nil, err
+	}
+	return database.GetProfile(slug)
+}
+
+// requestProfile returns the profile a request names, or the active one
+func requestProfile(slug string) (*database.Profile, error) {
+	if slug == "" {
+		return database.GetActiveProfile()
+	}
+	return database.GetProfile(slug)
+}
+
+// writeProfileError maps a database error to a profile response
+func writeProfileError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	response := ProfileResponse{Error: err.Error()}
+
+	var dbErr *database.DatabaseError
+	switch {
+	case errors.Is(err, errExamRunning):
+		status = http.StatusConflict
+	case errors.As(err, &dbErr):
+		response.ErrorCode = dbErr.Code
+		response.Error = dbErr.Message
+		switch dbErr.Code {
+		case database.ErrCodeProfileNotFound:
+			status = http.StatusNotFound
+		case database.ErrCodeProfileExists:
+			status = http.StatusConflict
+		case database.ErrCodeInvalidProfile:
+			status = http.StatusBadRequest
+		}
+	}
+	writeProfileResponse(w, status, response)
+}
+
+// writeProfileResponse writes a profile response as JSON
+func writeProfileResponse(w http.ResponseWriter, status int, response ProfileResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
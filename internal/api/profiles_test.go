@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+func activeProfile(t *testing.T) *database.Profile {
+	t.Helper()
+	profile, err := database.GetActiveProfile()
+	if err != nil {
+		t.Fatalf("GetActiveProfile failed: %v", err)
+	}
+	return profile
+}
+
+func profiles(t *testing.T, method, url, body string) (int, ProfileResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	HandleProfiles(w, httptest.NewRequest(method, url, strings.NewReader(body)))
+	var response ProfileResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	return w.Code, response
+}
+
+func TestProfilesKeepHistoriesApart(t *testing.T) {
+	setupExportHistory(t)
+	defer database.Close()
+
+	code, created := profiles(t, http.MethodPost, "/api/profiles", `{"name": "Alice", "activate": true}`)
+	if code != http.StatusCreated || created.Profile == nil || created.Profile.Slug != "alice" || !created.Profile.Active {
+		t.Fatalf("unexpected create response (%d): %+v", code, created)
+	}
+
+	// The new profile starts empty
+	w := httptest.NewRecorder()
+	GetAnalytics(w, httptest.NewRequest(http.MethodGet, "/api/analytics", nil))
+	var analytics AnalyticsData
+	json.NewDecoder(w.Body).Decode(&analytics)
+	if analytics.TotalPracticeSeconds != 0 || analytics.TimeSeries.Streaks.Longest != 0 {
+		t.Errorf("expected no history for the new profile, got %d seconds", analytics.TotalPracticeSeconds)
+	}
+
+	// The default profile's history can still be exported by name, and
+	// imported into the active profile
+	var data ExportData
+	json.NewDecoder(export(t, "/api/export?profile=default", "").Body).Decode(&data)
+	if data.Profile != "default" || len(data.Attempts) != 3 {
+		t.Fatalf("expected the default profile's attempts, got %s %d", data.Profile, len(data.Attempts))
+	}
+	body, _ := json.Marshal(data)
+	result, err := ImportProgress(body, ImportModeMerge, activeProfile(t))
+	if err != nil || result.Profile != "alice" || result.AttemptsImported != 3 {
+		t.Fatalf("unexpected import %+v (%v)", result, err)
+	}
+	if countRows(t, "attempts") != 6 || countRows(t, "progress WHERE profile_id = 1") != 0 {
+		t.Error("expected the import to copy the attempts into the new profile")
+	}
+
+	// A reset only touches the chosen profile
+	if _, response := reset(t, `{"confirmation": "DELETE", "profile": "alice"}`); !response.Success || response.Counts.AttemptsCount != 3 {
+		t.Fatalf("unexpected reset %+v", response)
+	}
+	if countRows(t, "attempts WHERE profile_id = 1") != 3 || countRows(t, "attempts") != 3 {
+		t.Error("expected the default profile's attempts to survive the reset")
+	}
+
+	if code, _ := profiles(t, http.MethodDelete, "/api/profiles/alice", ""); code != http.StatusBadRequest {
+		t.Errorf("expected the active profile to be kept, got %d", code)
+	}
+	if code, response := profiles(t, http.MethodPost, "/api/profiles/default/activate", ""); code != http.StatusOK || response.Profile.Slug != "default" {
+		t.Fatalf("unexpected switch (%d): %+v", code, response)
+	}
+	if code, _ := profiles(t, http.MethodDelete, "/api/profiles/alice", ""); code != http.StatusOK {
+		t.Errorf("expected the profile to be deleted, got %d", code)
+	}
+	if code, response := profiles(t, http.MethodGet, "/api/profiles", ""); code != http.StatusOK || len(response.Profiles) != 1 {
+		t.Errorf("expected one profile left (%d): %+v", code, response)
+	}
+}
+
+func TestProfileErrors(t *testing.T) {
+	setupImportDB(t)
+	defer database.Close()
+
+	for _, tc := range []struct {
+		method, url, body string
+		want              int
+	}{
+		{http.MethodPost, "/api/profiles", `{"name": "Default"}`, http.StatusConflict},
+		{http.MethodPost, "/api/profiles", `{"name": "Bob", "slug": "Bob Smith"}`, http.StatusBadRequest},
+		{http.MethodGet, "/api/profiles/nobody", "", http.StatusNotFound},
+		{http.MethodPost, "/api/profiles/nobody/activate", "", http.StatusNotFound},
+		{http.MethodDelete, "/api/profiles/default", "", http.StatusBadRequest},
+	} {
+		if code, _ := profiles(t, tc.method, tc.url, tc.body); code != tc.want {
+			t.Errorf("%s %s: expected %d, got %d", tc.method, tc.url, tc.want, code)
+		}
+	}
+	if w := export(t, "/api/export?profile=nobody", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected an unknown profile to be refused, got %d", w.Code)
+	}
+}
@@ -17,6 +17,7 @@ type ProgressStats struct {
 	AverageScore         float64           `json:"averageScore"`
 	MockExamsTaken       int               `json:"mockExamsTaken"`
 	MockExamsPassed      int               `json:"mockExamsPassed"`
+	CurriculumVersion    string            `json:"curriculumVersion"`
 	ProgressByDomain     []DomainProgress  `json:"progressByDomain"`
 	RecentActivity       []RecentAttempt   `json:"recentActivity"`
 }
@@ -60,15 +61,29 @@ func GetProgressStats(w http.ResponseWriter, r *http.Request) {
 		RecentActivity:   []RecentAttempt{},
 	}
 
+	curriculum, err := database.GetActiveCurriculum()
+	if err != nil {
+		http.Error(w, "Failed to load curriculum", http.StatusInternalServerError)
+		return
+	}
+	stats.CurriculumVersion = curriculum.Version
+
+	// Stats cover the active profile
+	profileID, err := database.ActiveProfileID()
+	if err != nil {
+		http.Error(w, "Failed to load profile", http.StatusInternalServerError)
+		return
+	}
+
 	// Get total scenarios count
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM exercises").Scan(&stats.TotalScenarios)
+	err = database.DB.QueryRow("SELECT COUNT(*) FROM exercises").Scan(&stats.TotalScenarios)
 	if err != nil && err != sql.ErrNoRows {
 		http.Error(w, "Failed to get total scenarios", http.StatusInternalServerError)
 		return
 	}
 
 	// Get completed scenarios count (from progress table)
-	err = database.DB.QueryRow("SELECT COUNT(*) FROM progress WHERE status = 'completed'").Scan(&stats.ScenariosCompleted)
+	err = database.DB.QueryRow("SELECT COUNT(*) FROM progress WHERE profile_id = ? AND status = 'completed'", profileID).Scan(&stats.ScenariosCompleted)
 	if err != nil && err != sql.ErrNoRows {
 		stats.ScenariosCompleted = 0
 	}
@@ -80,7 +95,7 @@ func GetProgressStats(w http.ResponseWriter, r *http.Request) {
 
 	// Get total practice time (sum of all attempts)
 	var totalSeconds sql.NullInt64
-	err = database.DB.QueryRow("SELECT COALESCE(SUM(duration_seconds), 0) FROM attempts").Scan(&totalSeconds)
+	err = database.DB.QueryRow("SELECT COALESCE(SUM(duration_seconds), 0) FROM attempts WHERE profile_id = ?", profileID).Scan(&totalSeconds)
 	if err == nil && totalSeconds.Valid {
 		stats.TotalPracticeMinutes = int(totalSeconds.Int64 / 60)
 	}
@@ -90,42 +105,30 @@ func GetProgressStats(w http.ResponseWriter, r *http.Request) {
 	err = database.DB.QueryRow(`
 		SELECT AVG(CAST(score AS FLOAT) / CAST(max_score AS FLOAT) * 100)
 		FROM attempts
-		WHERE max_score > 0
-	`).Scan(&avgScore)
+		WHERE profile_id = ? AND max_score > 0
+	`, profileID).Scan(&avgScore)
 	if err == nil && avgScore.Valid {
 		stats.AverageScore = avgScore.Float64
 	}
 
 	// Get mock exams stats
-	database.DB.QueryRow("SELECT COUNT(*) FROM mock_exams").Scan(&stats.MockExamsTaken)
-	database.DB.QueryRow("SELECT COUNT(*) FROM mock_exams WHERE passed = 1").Scan(&stats.MockExamsPassed)
+	database.DB.QueryRow("SELECT COUNT(*) FROM mock_exams WHERE profile_id = ?", profileID).Scan(&stats.MockExamsTaken)
+	database.DB.QueryRow("SELECT COUNT(*) FROM mock_exams WHERE profile_id = ? AND passed = 1", profileID).Scan(&stats.MockExamsPassed)
 
 	// Get progress by domain
-	domains := map[string]struct {
-		displayName string
-		weight      int
-	}{
-		"cluster-setup":                       {"Cluster Setup", 10},
-		"cluster-hardening":                   {"Cluster Hardening", 15},
-		"system-hardening":                    {"System Hardening", 15},
-		"minimize-microservice-vulnerabilities": {"Minimize Microservice Vulnerabilities", 20},
-		"supply-chain-security":               {"Supply Chain Security", 20},
-		"monitoring-logging-runtime-security": {"Monitoring, Logging & Runtime Security", 20},
-	}
-
-	for domain, info := range domains {
+	for _, domain := range curriculum.Domains {
 		var totalCount, completedCount int
 
 		// Get total count for this domain
-		database.DB.QueryRow("SELECT COUNT(*) FROM exercises WHERE category = ?", domain).Scan(&totalCount)
+		database.DB.QueryRow("SELECT COUNT(*) FROM exercises WHERE category = ?", domain.Slug).Scan(&totalCount)
 
 		// Get completed count for this domain
 		database.DB.QueryRow(`
 			SELECT COUNT(*)
 			FROM progress p
 			JOIN exercises e ON p.exercise_id = e.id
-			WHERE e.category = ? AND p.status = 'completed'
-		`, domain).Scan(&completedCount)
+			WHERE p.profile_id = ? AND e.category = ? AND p.status = 'completed'
+		`, profileID, domain.Slug).Scan(&completedCount)
 
 		percentage := 0.0
 		if totalCount > 0 {
@@ -133,9 +136,9 @@ func GetProgressStats(w http.ResponseWriter, r *http.Request) {
 		}
 
 		stats.ProgressByDomain = append(stats.ProgressByDomain, DomainProgress{
-			Domain:              domain,
-			DisplayName:         info.displayName,
-			Weight:              info.weight,
+			Domain:              domain.Slug,
+			DisplayName:         domain.DisplayName,
+			Weight:              domain.Weight,
 			CompletedCount:      completedCount,
 			TotalCount:          totalCount,
 			CompletionPercentage: percentage,
@@ -158,11 +161,11 @@ func GetProgressStats(w http.ResponseWriter, r *http.Request) {
 			END as is_personal_best
 		FROM attempts a
 		JOIN exercises e ON a.exercise_id = e.id
-		LEFT JOIN progress p ON a.exercise_id = p.exercise_id
-		WHERE a.completed_at IS NOT NULL
+		LEFT JOIN progress p ON a.exercise_id = p.exercise_id AND p.profile_id = a.profile_id
+		WHERE a.profile_id = ? AND a.completed_at IS NOT NULL
 		ORDER BY a.completed_at DESC
 		LIMIT 5
-	`)
+	`, profileID)
 	if err == nil {
 		defer rows.Close()
 		for rows.Next() {
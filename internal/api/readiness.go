@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+	"github.com/patrickvassell/cks-weight-room/internal/readiness"
+)
+
+// ReadinessResponse is the response of the readiness endpoint
+type ReadinessResponse struct {
+	Success   bool              `json:"success"`
+	Readiness *readiness.Report `json:"readiness,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// GetReadiness handles GET /api/readiness and returns the estimated chance
+// of passing the exam, overall and per domain
+func GetReadiness(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if database.DB == nil {
+		writeReadinessResponse(w, http.StatusInternalServerError, ReadinessResponse{Error: "Database not initialized"})
+		return
+	}
+
+	report, err := readiness.Current(time.Now())
+	if err != nil {
+		log.Printf("Failed to assess readiness: %v", err)
+		writeReadinessResponse(w, http.StatusInternalServerError, ReadinessResponse{Error: err.Error()})
+		return
+	}
+	writeReadinessResponse(w, http.StatusOK, ReadinessResponse{Success: true, Readiness: &report})
+}
+
+// writeReadinessResponse writes a readiness response as JSON
+func writeReadinessResponse(w http.ResponseWriter, status int, response ReadinessResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
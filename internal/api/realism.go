@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/patrickvassell/cks-weight-room/internal/cluster"
+	"github.com/patrickvassell/cks-weight-room/internal/realism"
+)
+
+// docsMirrorPort is where the offline documentation mirror listens
+const docsMirrorPort = 3080
+
+// examRealism applies exam-style paste restrictions to one terminal connection
+type examRealism struct {
+	detector *realism.PasteDetector
+}
+
+// newExamRealism returns paste enforcement for the connection, or nil when the
+// client didn't ask for exam realism
+// Route: /api/terminal/{slug}?realism=exam
+func newExamRealism(r *http.Request, slug string) *examRealism {
+	if r.URL.Query().Get("realism") != "exam" {
+		return nil
+	}
+
+	policy, err := realism.LoadPolicy(slug)
+	if err != nil {
+		log.Printf("Failed to load exam policy for %s, using defaults: %v", slug, err)
+	}
+	return &examRealism{
+		detector: realism.NewPasteDetector(policy.Paste),
+	}
+}
+
+// admitInput decides whether an input message may reach the shell, telling
+// the user when a paste was blocked or noticed
+func (e *examRealism) admitInput(conn *terminalConn, slug, data string) bool {
+	if e == nil {
+		return true
+	}
+
+	verdict := e.detector.Inspect(data, time.Now())
+	if !verdict.IsPaste {
+		return true
+	}
+
+	if !verdict.Allowed {
+		log.Printf("Blocked paste for %s: %s", slug, verdict.Reason)
+		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\r\n\033[31m⚠  Paste blocked (exam realism): %s\033[0m\r\n", verdict.Reason)))
+		return false
+	}
+	if verdict.Warn {
+		log.Printf("Paste detected for %s: %s", slug, verdict.Reason)
+		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\r\n\033[33m⚠  Large paste detected: %s\033[0m\r\n", verdict.Reason)))
+	}
+	return true
+}
+
+// NetworkRestrictionResponse represents the API response for node egress restriction
+type NetworkRestrictionResponse struct {
+	Success        bool                   `json:"success"`
+	Nodes          []cluster.EgressStatus `json:"nodes,omitempty"`
+	AllowedDomains []string               `json:"allowedDomains,omitempty"`
+	MirrorAddr     string                 `json:"mirrorAddr,omitempty"`
+	Message        string                 `json:"message,omitempty"`
+	Error          string                 `json:"error,omitempty"`
+}
+
+// HandleNetworkRestriction handles POST and DELETE /api/realism/network/{exerciseSlug}
+// POST restricts node egress to the documentation allowlist; DELETE lifts it
+func HandleNetworkRestriction(w http.ResponseWriter, r *http.Request) {
+	slug := strings.TrimPrefix(r.URL.Path, "/api/realism/network/")
+	if !realism.ValidSlug(slug) {
+		response := NetworkRestrictionResponse{
+			Success: false,
+			Error:   "a valid exerciseSlug is required",
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		applyNetworkRestriction(w, r, slug)
+	case http.MethodDelete:
+		liftNetworkRestriction(w, r, slug)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// applyNetworkRestriction restricts egress on every node in the exercise cluster
+func applyNetworkRestriction(w http.ResponseWriter, r *http.Request, slug string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+
+	policy, err := realism.LoadPolicy(slug)
+	if err != nil {
+		log.Printf("Failed to load exam policy for %s, using defaults: %v", slug, err)
+	}
+
+	egress := cluster.EgressPolicy{
+		Domains:     policy.Domains(),
+		DocsDomains: realism.DefaultDocsDomains,
+	}
+
+	// Serve the offline docs mirror to nodes if one has been downloaded
+	response := NetworkRestrictionResponse{AllowedDomains: egress.Domains}
+	if mirrorDir := realism.GetMirrorDir(); realism.MirrorAvailable(mirrorDir) {
+		listenAddr, nodeHost, err := docsMirrorAddress(ctx)
+		if err != nil {
+			log.Printf("Docs mirror unavailable: %v", err)
+		} else if mirror, err := realism.StartMirror(listenAddr, mirrorDir); err != nil {
+			log.Printf("Failed to start docs mirror: %v", err)
+		} else {
+			egress.MirrorHost = nodeHost
+			egress.MirrorPort = docsMirrorPort
+			response.MirrorAddr = mirror.Addr
+		}
+	}
+
+	statuses, err := cluster.ApplyEgressPolicy(ctx, cluster.GetClusterName(slug), egress)
+	if err != nil {
+		response.Success = false
+		response.Error = err.Error()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response.Success = true
+	response.Nodes = statuses
+	response.Message = "Node egress restricted to the documentation allowlist"
+	for _, s := range statuses {
+		if s.Error != "" {
+			response.Success = false
+			response.Message = "Egress restriction failed on some nodes"
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// liftNetworkRestriction removes egress restrictions from the exercise cluster
+func liftNetworkRestriction(w http.ResponseWriter, r *http.Request, slug string) {
+	ctx, cancel := context.WithTimeout(r.Context(), time.Minute)
+	defer cancel()
+
+	if err := cluster.RemoveEgressPolicy(ctx, cluster.GetClusterName(slug)); err != nil {
+		response := NetworkRestrictionResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response := NetworkRestrictionResponse{
+		Success: true,
+		Message: "Node egress restriction lifted",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// docsMirrorAddress returns where the mirror should listen and how nodes
+// reach it. On Linux nodes reach the host via the kind network gateway; on
+// Docker Desktop they use host.docker.internal, which forwards to loopback.
+func docsMirrorAddress(ctx context.Context) (listenAddr, nodeHost string, err error) {
+	port := fmt.Sprintf("%d", docsMirrorPort)
+	if runtime.GOOS == "darwin" {
+		return net.JoinHostPort("127.0.0.1", port), "host.docker.internal", nil
+	}
+
+	gateway, err := cluster.GetKindGateway(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	return net.JoinHostPort(gateway, port), gateway, nil
+}
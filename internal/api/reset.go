@@ -1,24 +1,69 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/patrickvassell/cks-weight-room/internal/database"
+	"github.com/patrickvassell/cks-weight-room/internal/trends"
 )
 
+// ResetUndoWindow is how long a reset can be undone
+const ResetUndoWindow = 24 * time.Hour
+
+// errInvalidScope marks a reset scope that can't be applied
+var errInvalidScope = errors.New("invalid reset scope")
+
+// ResetScope narrows a reset; the fields combine, and an empty scope resets
+// everything
+type ResetScope struct {
+	Exercise string `json:"exercise,omitempty"` // Slug
+	Domain   string `json:"domain,omitempty"`
+	Before   string `json:"before,omitempty"` // YYYY-MM-DD; attempts from earlier days
+	TimeZone string `json:"tz,omitempty"`     // For before; the server's by default
+}
+
+// IsAll reports whether the scope covers all progress
+func (s ResetScope) IsAll() bool {
+	return s.Exercise == "" && s.Domain == "" && s.Before == ""
+}
+
 // ResetProgressRequest represents a reset request with confirmation
 type ResetProgressRequest struct {
-	Confirmation string `json:"confirmation"`
+	Confirmation string     `json:"confirmation"`
+	Scope        ResetScope `json:"scope"`
+	DryRun       bool       `json:"dryRun"`            // Report what would go without a confirmation
+	Profile      string     `json:"profile,omitempty"` // Slug; the active profile by default
+}
+
+// ResetCounts is what a reset removes
+type ResetCounts struct {
+	AttemptsCount      int `json:"attemptsCount"`
+	PersonalBestsCount int `json:"personalBestsCount"` // Cleared or recomputed
+	MockExamsCount     int `json:"mockExamsCount"`
+	HintRevealsCount   int `json:"hintRevealsCount"`
+	ExercisesCount     int `json:"exercisesCount"` // Exercises whose progress changes
 }
 
 // ResetProgressResponse represents the response from a reset operation
 type ResetProgressResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success    bool         `json:"success"`
+	Message    string       `json:"message"`
+	DryRun     bool         `json:"dryRun,omitempty"`
+	Counts     *ResetCounts `json:"counts,omitempty"`
+	SnapshotID int64        `json:"snapshotId,omitempty"` // Undo with POST /api/reset/undo/{id}
+	UndoUntil  string       `json:"undoUntil,omitempty"`
 }
 
-// GetResetStats handles GET /api/reset/stats
+// GetResetStats handles GET /api/reset/stats. The scope comes from
+// ?exercise=, ?domain=, ?before= and ?tz=, and the counts are a dry run of
+// that reset of the active profile, or the one given with ?profile=.
 func GetResetStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -30,22 +75,31 @@ func GetResetStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stats := struct {
-		AttemptsCount      int `json:"attemptsCount"`
-		PersonalBestsCount int `json:"personalBestsCount"`
-		MockExamsCount     int `json:"mockExamsCount"`
-	}{}
-
-	// Get counts for confirmation message
-	database.DB.QueryRow("SELECT COUNT(*) FROM attempts").Scan(&stats.AttemptsCount)
-	database.DB.QueryRow("SELECT COUNT(*) FROM progress WHERE personal_best_seconds IS NOT NULL").Scan(&stats.PersonalBestsCount)
-	database.DB.QueryRow("SELECT COUNT(*) FROM mock_exams").Scan(&stats.MockExamsCount)
+	query := r.URL.Query()
+	profile, err := requestProfile(query.Get("profile"))
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	scope := ResetScope{
+		Exercise: query.Get("exercise"),
+		Domain:   query.Get("domain"),
+		Before:   query.Get("before"),
+		TimeZone: query.Get("tz"),
+	}
+	result, err := resetProgress(profile, scope, true)
+	if err != nil {
+		writeResetError(w, err)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(stats)
+	json.NewEncoder(w).Encode(result.counts)
 }
 
-// ResetProgress handles POST /api/reset
+// ResetProgress handles POST /api/reset. A dry run reports the counts
+// without the confirmation; otherwise the rows are snapshotted first and the
+// reset can be undone for ResetUndoWindow.
 func ResetProgress(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -64,7 +118,7 @@ func ResetProgress(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Verify confirmation text
-	if req.Confirmation != "DELETE" {
+	if !req.DryRun && req.Confirmation != "DELETE" {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(ResetProgressResponse{
 			Success: false,
@@ -73,44 +127,305 @@ func ResetProgress(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Begin transaction for atomic operation
-	tx, err := database.DB.Begin()
+	profile, err := requestProfile(req.Profile)
 	if err != nil {
-		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		writeProfileError(w, err)
 		return
 	}
 
-	// Delete all progress data
-	_, err = tx.Exec("DELETE FROM attempts")
+	result, err := resetProgress(profile, req.Scope, req.DryRun)
 	if err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to delete attempts", http.StatusInternalServerError)
+		writeResetError(w, err)
 		return
 	}
 
-	_, err = tx.Exec("DELETE FROM progress")
+	response := ResetProgressResponse{Success: true, DryRun: req.DryRun, Counts: &result.counts}
+	switch {
+	case req.DryRun:
+		response.Message = fmt.Sprintf("%d attempts would be deleted.", result.counts.AttemptsCount)
+	case req.Scope.IsAll():
+		response.Message = "All progress data has been reset."
+	default:
+		response.Message = fmt.Sprintf("Deleted %d attempts.", result.counts.AttemptsCount)
+	}
+	if !req.DryRun {
+		response.SnapshotID = result.snapshotID
+		response.UndoUntil = result.undoUntil.Format(time.RFC3339)
+		log.Printf("Reset progress of %s (%+v): %d attempts, snapshot %d", profile.Slug, req.Scope, result.counts.AttemptsCount, result.snapshotID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// resetResult is the outcome of resetProgress
+type resetResult struct {
+	counts     ResetCounts
+	snapshotID int64
+	undoUntil  time.Time
+}
+
+// resetProgress deletes a profile's attempts in scope, with their hint
+// reveals and the pending reveals of the exercises in scope, in one
+// transaction, then rebuilds the progress of the exercises it touches from
+// the attempts left; notes are kept. Resetting everything also deletes the
+// profile's finished mock exams; an exam still provisioning or running keeps
+// its row for the manager to grade, and a narrower reset leaves mock exams
+// alone. A dry run rolls the transaction back; otherwise a snapshot of the
+// rows is saved.
+func resetProgress(profile *database.Profile, scope ResetScope, dryRun bool) (*resetResult, error) {
+	where, args, err := scope.attemptFilter()
 	if err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to delete progress", http.StatusInternalServerError)
-		return
+		return nil, err
+	}
+	where = "profile_id = ? AND " + where
+	args = append([]any{profile.ID}, args...)
+	revealWhere, revealArgs, err := scope.revealFilter(where, args, profile.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	_, err = tx.Exec("DELETE FROM mock_exams")
+	tx, err := database.DB.Begin()
 	if err != nil {
-		tx.Rollback()
-		http.Error(w, "Failed to delete mock exams", http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Exercises whose progress changes: those with attempts in scope, and
+	// for an exercise or domain every exercise in it with a progress row
+	exercises, err := queryInts(tx, "SELECT DISTINCT exercise_id FROM attempts WHERE "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	if scope.IsAll() || scope.Before == "" {
+		exerciseWhere, exerciseArgs := scope.exerciseFilter()
+		more, err := queryInts(tx, "SELECT DISTINCT exercise_id FROM progress WHERE profile_id = ? AND exercise_id IN (SELECT id FROM exercises WHERE "+exerciseWhere+")",
+			append([]any{profile.ID}, exerciseArgs...)...)
+		if err != nil {
+			return nil, err
+		}
+		exercises = mergeInts(exercises, more)
+	}
+	in, inArgs := inClause(exercises)
+
+	var snapshot resetSnapshotData
+	if snapshot.Attempts, err = snapshotRows(tx, "SELECT * FROM attempts WHERE "+where, args...); err != nil {
+		return nil, err
+	}
+	if snapshot.HintReveals, err = snapshotRows(tx, "SELECT * FROM hint_reveals WHERE "+revealWhere, revealArgs...); err != nil {
+		return nil, err
+	}
+	if snapshot.Progress, err = snapshotRows(tx, "SELECT * FROM progress WHERE profile_id = ? AND exercise_id IN "+in, append([]any{profile.ID}, inArgs...)...); err != nil {
+		return nil, err
+	}
+	if scope.IsAll() {
+		if snapshot.MockExams, err = snapshotRows(tx, "SELECT * FROM mock_exams WHERE profile_id = ? AND completed_at IS NOT NULL", profile.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	counts := ResetCounts{
+		AttemptsCount:    len(snapshot.Attempts),
+		HintRevealsCount: len(snapshot.HintReveals),
+		MockExamsCount:   len(snapshot.MockExams),
+		ExercisesCount:   len(exercises),
+	}
+	bestsBefore := map[int]int64{}
+	for _, row := range snapshot.Progress {
+		if best, ok := row["personal_best_seconds"].(int64); ok {
+			bestsBefore[int(row["exercise_id"].(int64))] = best
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM hint_reveals WHERE "+revealWhere, revealArgs...); err != nil {
+		return nil, fmt.Errorf("failed to delete hint reveals: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM attempts WHERE "+where, args...); err != nil {
+		return nil, fmt.Errorf("failed to delete attempts: %w", err)
+	}
+	if scope.IsAll() {
+		if _, err := tx.Exec("DELETE FROM mock_exams WHERE profile_id = ? AND completed_at IS NOT NULL", profile.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete mock exams: %w", err)
+		}
+	}
+	for _, exerciseID := range exercises {
+		if _, err := rebuildProgress(tx, profile.ID, exerciseID, sql.NullInt64{}, false); err != nil {
+			return nil, err
+		}
+		before, had := bestsBefore[exerciseID]
+		var after sql.NullInt64
+		tx.QueryRow("SELECT personal_best_seconds FROM progress WHERE profile_id = ? AND exercise_id = ?", profile.ID, exerciseID).Scan(&after)
+		if had && (!after.Valid || after.Int64 != before) {
+			counts.PersonalBestsCount++
+		}
 	}
 
-	// Commit transaction
+	result := &resetResult{counts: counts}
+	if dryRun {
+		return result, nil
+	}
+	if result.snapshotID, result.undoUntil, err = saveResetSnapshot(tx, profile.ID, scope, counts, snapshot); err != nil {
+		return nil, err
+	}
 	if err := tx.Commit(); err != nil {
-		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("failed to commit reset: %w", err)
 	}
+	return result, nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(ResetProgressResponse{
-		Success: true,
-		Message: "All progress data has been reset.",
-	})
+// revealFilter turns the scope into a WHERE clause on hint_reveals: the
+// reveals of the attempts in scope, and the pending reveals of the open
+// attempts (attempt_id NULL) of the exercises in scope, which would
+// otherwise be charged to the next validation
+func (s ResetScope) revealFilter(attemptWhere string, attemptArgs []any, profileID int64) (string, []any, error) {
+	exerciseWhere, exerciseArgs := s.exerciseFilter()
+	pending := "profile_id = ? AND attempt_id IS NULL AND exercise_id IN (SELECT id FROM exercises WHERE " + exerciseWhere + ")"
+	pendingArgs := append([]any{profileID}, exerciseArgs...)
+	cutoff, err := s.cutoff()
+	if err != nil {
+		return "", nil, err
+	}
+	if cutoff != "" {
+		pending += " AND datetime(revealed_at) < ?"
+		pendingArgs = append(pendingArgs, cutoff)
+	}
+	where := "attempt_id IN (SELECT id FROM attempts WHERE " + attemptWhere + ") OR (" + pending + ")"
+	return where, append(append([]any{}, attemptArgs...), pendingArgs...), nil
+}
+
+// cutoff returns the scope's before date as a UTC timestamp, or "" when it
+// has none
+func (s ResetScope) cutoff() (string, error) {
+	if s.Before == "" {
+		return "", nil
+	}
+	loc := time.Local
+	if s.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(s.TimeZone); err != nil {
+			return "", fmt.Errorf("%w: unknown time zone %s", errInvalidScope, s.TimeZone)
+		}
+	}
+	day, err := time.ParseInLocation(trends.DateLayout, s.Before, loc)
+	if err != nil {
+		return "", fmt.Errorf("%w: before must be a date (YYYY-MM-DD)", errInvalidScope)
+	}
+	return day.UTC().Format(importTime), nil
+}
+
+// attemptFilter turns the scope into a WHERE clause on attempts
+func (s ResetScope) attemptFilter() (string, []any, error) {
+	if s.Exercise != "" || s.Domain != "" {
+		if err := s.validate(); err != nil {
+			return "", nil, err
+		}
+	}
+	exerciseWhere, args := s.exerciseFilter()
+	clauses := []string{"exercise_id IN (SELECT id FROM exercises WHERE " + exerciseWhere + ")"}
+
+	cutoff, err := s.cutoff()
+	if err != nil {
+		return "", nil, err
+	}
+	if cutoff != "" {
+		clauses = append(clauses, "datetime(COALESCE(completed_at, started_at)) < ?")
+		args = append(args, cutoff)
+	}
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+// exerciseFilter turns the exercise and domain of the scope into a WHERE
+// clause on exercises
+func (s ResetScope) exerciseFilter() (string, []any) {
+	clauses := []string{"1 = 1"}
+	var args []any
+	if s.Exercise != "" {
+		clauses = append(clauses, "slug = ?")
+		args = append(args, s.Exercise)
+	}
+	if s.Domain != "" {
+		clauses = append(clauses, "category = ?")
+		args = append(args, s.Domain)
+	}
+	return strings.Join(clauses, " AND "), args
+}
+
+// validate checks that the scope's exercise and domain exist
+func (s ResetScope) validate() error {
+	if s.Exercise != "" {
+		var n int
+		database.DB.QueryRow("SELECT COUNT(*) FROM exercises WHERE slug = ?", s.Exercise).Scan(&n)
+		if n == 0 {
+			return fmt.Errorf("%w: unknown exercise %s", errInvalidScope, s.Exercise)
+		}
+	}
+	if s.Domain != "" {
+		var n int
+		database.DB.QueryRow("SELECT COUNT(*) FROM curriculum_domains WHERE slug = ?", s.Domain).Scan(&n)
+		if n == 0 {
+			return fmt.Errorf("%w: unknown domain %s", errInvalidScope, s.Domain)
+		}
+	}
+	return nil
+}
+
+// queryInts reads a column of integers
+func queryInts(tx *sql.Tx, query string, args ...any) ([]int, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query: %w", err)
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to read row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// mergeInts appends the ids of b that aren't in a
+func mergeInts(a, b []int) []int {
+	seen := map[int]bool{}
+	for _, id := range a {
+		seen[id] = true
+	}
+	for _, id := range b {
+		if !seen[id] {
+			seen[id] = true
+			a = append(a, id)
+		}
+	}
+	return a
+}
+
+// inClause builds "(?, ?, ...)" for a list of ids
+func inClause(ids []int) (string, []any) {
+	if len(ids) == 0 {
+		return "(NULL)", nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return "(" + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + ")", args
+}
+
+// writeResetError writes a reset error; a bad scope is the client's
+func writeResetError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errInvalidScope) || errors.Is(err, errSnapshotUnavailable) {
+		status := http.StatusBadRequest
+		if errors.Is(err, errSnapshotUnavailable) {
+			status = http.StatusConflict
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(ResetProgressResponse{Message: err.Error()})
+		return
+	}
+	log.Printf("Reset failed: %v", err)
+	http.Error(w, "Failed to reset progress", http.StatusInternalServerError)
 }
@@ -0,0 +1,339 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+// errSnapshotUnavailable marks a snapshot that is gone, expired or undone
+var errSnapshotUnavailable = errors.New("reset can no longer be undone")
+
+// resetSnapshotData holds the rows a reset deleted or changed, keyed by
+// column name
+type resetSnapshotData struct {
+	Attempts    []map[string]any `json:"attempts"`
+	HintReveals []map[string]any `json:"hint_reveals"`
+	Progress    []map[string]any `json:"progress"`
+	MockExams   []map[string]any `json:"mock_exams"`
+}
+
+// ResetSnapshot describes a reset that can be undone
+type ResetSnapshot struct {
+	ID        int64       `json:"id"`
+	Scope     ResetScope  `json:"scope"`
+	Counts    ResetCounts `json:"counts"`
+	CreatedAt string      `json:"createdAt"`
+	ExpiresAt string      `json:"expiresAt"`
+}
+
+// ResetSnapshotsResponse lists the resets that can still be undone
+type ResetSnapshotsResponse struct {
+	Success   bool            `json:"success"`
+	Snapshots []ResetSnapshot `json:"snapshots"`
+}
+
+// HandleResetUndo handles the undo routes:
+//
+//	GET  /api/reset/snapshots    resets that can still be undone, newest first
+//	POST /api/reset/undo         undo the latest reset
+//	POST /api/reset/undo/{id}    undo a given reset
+//
+// They cover the resets of the active profile, or the one given with
+// ?profile=.
+func HandleResetUndo(w http.ResponseWriter, r *http.Request) {
+	if database.DB == nil {
+		http.Error(w, "Database not initialized", http.StatusInternalServerError)
+		return
+	}
+
+	profile, err := requestProfile(r.URL.Query().Get("profile"))
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/reset"), "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case path == "snapshots" && r.Method == http.MethodGet:
+		snapshots, err := listResetSnapshots(profile.ID, time.Now())
+		if err != nil {
+			writeResetError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ResetSnapshotsResponse{Success: true, Snapshots: snapshots})
+
+	case parts[0] == "undo" && len(parts) <= 2 && r.Method == http.MethodPost:
+		var id int64
+		if len(parts) == 2 {
+			var err error
+			if id, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+				http.Error(w, "Invalid snapshot id", http.StatusBadRequest)
+				return
+			}
+		}
+		snapshot, err := undoReset(profile.ID, id, time.Now())
+		if err != nil {
+			writeResetError(w, err)
+			return
+		}
+		log.Printf("Undid reset %d of %s: restored %d attempts", snapshot.ID, profile.Slug, snapshot.Counts.AttemptsCount)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ResetProgressResponse{
+			Success:    true,
+			Message:    fmt.Sprintf("Restored %d attempts.", snapshot.Counts.AttemptsCount),
+			Counts:     &snapshot.Counts,
+			SnapshotID: snapshot.ID,
+		})
+
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// saveResetSnapshot stores the rows a reset is about to remove and purges
+// expired snapshots
+func saveResetSnapshot(tx *sql.Tx, profileID int64, scope ResetScope, counts ResetCounts, data resetSnapshotData) (int64, time.Time, error) {
+	now := time.Now().UTC()
+	if _, err := tx.Exec("DELETE FROM reset_snapshots WHERE datetime(expires_at) < ?", now.Format(importTime)); err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to purge snapshots: %w", err)
+	}
+
+	scopeJSON, _ := json.Marshal(scope)
+	countsJSON, _ := json.Marshal(counts)
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	expires := now.Add(ResetUndoWindow)
+	res, err := tx.Exec(`
+		INSERT INTO reset_snapshots (profile_id, scope, counts, data, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)
+	`, profileID, string(scopeJSON), string(countsJSON), string(dataJSON), now.Format(importTime), expires.Format(importTime))
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return id, expires, nil
+}
+
+// listResetSnapshots returns a profile's snapshots that can still be undone
+func listResetSnapshots(profileID int64, now time.Time) ([]ResetSnapshot, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, scope, counts, created_at, expires_at
+		FROM reset_snapshots
+		WHERE profile_id = ? AND restored_at IS NULL AND datetime(expires_at) >= ?
+		ORDER BY id DESC
+	`, profileID, now.UTC().Format(importTime))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := []ResetSnapshot{}
+	for rows.Next() {
+		var s ResetSnapshot
+		var scope, counts string
+		if err := rows.Scan(&s.ID, &scope, &counts, &s.CreatedAt, &s.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to read snapshot: %w", err)
+		}
+		json.Unmarshal([]byte(scope), &s.Scope)
+		json.Unmarshal([]byte(counts), &s.Counts)
+		s.CreatedAt = rfc3339(s.CreatedAt)
+		s.ExpiresAt = rfc3339(s.ExpiresAt)
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
+
+// undoReset restores a profile's snapshot, or its latest one when id is 0.
+// Deleted rows come back with their ids; progress rows are put back and
+// then rebuilt, so attempts made since the reset still count and the better
+// personal best wins.
+func undoReset(profileID, id int64, now time.Time) (*ResetSnapshot, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, scope, counts, data FROM reset_snapshots
+		WHERE profile_id = ? AND restored_at IS NULL AND datetime(expires_at) >= ?`
+	args := []any{profileID, now.UTC().Format(importTime)}
+	if id != 0 {
+		query += " AND id = ?"
+		args = append(args, id)
+	}
+	query += " ORDER BY id DESC LIMIT 1"
+
+	var snapshot ResetSnapshot
+	var scope, counts, data string
+	err = tx.QueryRow(query, args...).Scan(&snapshot.ID, &scope, &counts, &data)
+	if err == sql.ErrNoRows {
+		if id != 0 {
+			return nil, fmt.Errorf("%w: snapshot %d is unknown, expired or already undone", errSnapshotUnavailable, id)
+		}
+		return nil, fmt.Errorf("%w: no reset to undo", errSnapshotUnavailable)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+	json.Unmarshal([]byte(scope), &snapshot.Scope)
+	json.Unmarshal([]byte(counts), &snapshot.Counts)
+
+	var rows resetSnapshotData
+	decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&rows); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+
+	// A progress row created since the reset gives way to the restored one;
+	// its attempts are counted again below
+	for _, row := range rows.Progress {
+		if _, err := tx.Exec("DELETE FROM progress WHERE profile_id = ? AND exercise_id = ? AND id != ?", profileID, snapshotValue(row["exercise_id"]), snapshotValue(row["id"])); err != nil {
+			return nil, fmt.Errorf("failed to restore progress: %w", err)
+		}
+	}
+
+	for _, table := range []struct {
+		name    string
+		rows    []map[string]any
+		replace bool
+	}{
+		{"attempts", rows.Attempts, false},
+		{"hint_reveals", rows.HintReveals, false},
+		{"mock_exams", rows.MockExams, false},
+		{"progress", rows.Progress, true},
+	} {
+		if err := restoreRows(tx, table.name, table.rows, table.replace); err != nil {
+			return nil, err
+		}
+	}
+
+	exercises := map[int]bool{}
+	for _, group := range [][]map[string]any{rows.Attempts, rows.Progress} {
+		for _, row := range group {
+			if id, ok := snapshotValue(row["exercise_id"]).(int64); ok {
+				exercises[int(id)] = true
+			}
+		}
+	}
+	for exerciseID := range exercises {
+		if _, err := rebuildProgress(tx, profileID, exerciseID, sql.NullInt64{}, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE reset_snapshots SET restored_at = ? WHERE id = ?", now.UTC().Format(importTime), snapshot.ID); err != nil {
+		return nil, fmt.Errorf("failed to mark snapshot restored: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit undo: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// rfc3339 formats a stored timestamp as RFC 3339 in UTC
+func rfc3339(s string) string {
+	if normalised, ok := importTimestamp(s); ok {
+		t, _ := time.Parse(importTime, normalised)
+		return t.Format(time.RFC3339)
+	}
+	return s
+}
+
+// snapshotRows reads whole rows as column maps. Times are stored in
+// SQLite's format so restored rows compare like the originals.
+func snapshotRows(tx *sql.Tx, query string, args ...any) ([]map[string]any, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot rows: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot rows: %w", err)
+	}
+	var snapshot []map[string]any
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to snapshot row: %w", err)
+		}
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			switch v := values[i].(type) {
+			case time.Time:
+				row[column] = v.UTC().Format(importTime)
+			case []byte:
+				row[column] = string(v)
+			default:
+				row[column] = v
+			}
+		}
+		snapshot = append(snapshot, row)
+	}
+	return snapshot, rows.Err()
+}
+
+// restoreRows inserts snapshotted rows back into a table
+func restoreRows(tx *sql.Tx, table string, rows []map[string]any, replace bool) error {
+	verb := "INSERT"
+	if replace {
+		verb = "INSERT OR REPLACE"
+	}
+	for _, row := range rows {
+		columns := make([]string, 0, len(row))
+		for column := range row {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+
+		values := make([]any, len(columns))
+		for i, column := range columns {
+			values[i] = snapshotValue(row[column])
+		}
+		query := fmt.Sprintf("%s INTO %s (%s) VALUES (%s)", verb, table, strings.Join(columns, ", "),
+			strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+		if _, err := tx.Exec(query, values...); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// snapshotValue turns a number decoded from a snapshot back into an integer
+// or a float
+func snapshotValue(v any) any {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+func setupResetHistory(t *testing.T) {
+	t.Helper()
+	setupExportHistory(t)
+	_, err := database.DB.Exec(`
+		INSERT INTO hint_reveals (exercise_id, attempt_id, hint_index, revealed_at) VALUES (8, 2, 0, '2026-03-02 09:01:00');
+		INSERT INTO progress (exercise_id, status, completed_at, attempts, time_spent_seconds, personal_best_seconds, notes)
+		VALUES (7, 'completed', '2026-03-01 09:10:00', 1, 600, 600, 'remember the audit policy path'),
+			(8, 'completed', '2026-04-01 09:05:00', 2, 600, 300, NULL);
+		INSERT INTO mock_exams (exam_type, started_at, completed_at, total_duration_seconds, overall_score, max_score, passed, exercises_total)
+		VALUES ('quick-practice', '2026-03-03 10:00:00', '2026-03-03 11:00:00', 3600, 8, 10, 1, 1);
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert history: %v", err)
+	}
+}
+
+func reset(t *testing.T, body string) (int, ResetProgressResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	ResetProgress(w, httptest.NewRequest(http.MethodPost, "/api/reset", strings.NewReader(body)))
+	var response ResetProgressResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	return w.Code, response
+}
+
+func TestScopedResetDryRunAndUndo(t *testing.T) {
+	setupResetHistory(t)
+	defer database.Close()
+
+	// The stats endpoint is a dry run of the scoped reset
+	w := httptest.NewRecorder()
+	GetResetStats(w, httptest.NewRequest(http.MethodGet, "/api/reset/stats?domain=system-hardening", nil))
+	var counts ResetCounts
+	json.NewDecoder(w.Body).Decode(&counts)
+	if counts.AttemptsCount != 2 || counts.HintRevealsCount != 1 || counts.PersonalBestsCount != 1 || counts.MockExamsCount != 0 {
+		t.Errorf("unexpected domain preview %+v", counts)
+	}
+	if countRows(t, "attempts") != 3 {
+		t.Fatal("expected the dry run to leave the attempts")
+	}
+
+	code, response := reset(t, `{"confirmation": "DELETE", "scope": {"domain": "system-hardening"}}`)
+	if code != http.StatusOK || !response.Success || response.SnapshotID == 0 || response.UndoUntil == "" {
+		t.Fatalf("unexpected reset response (%d): %+v", code, response)
+	}
+	if countRows(t, "attempts WHERE exercise_id = 8") != 0 || countRows(t, "attempts") != 1 || countRows(t, "mock_exams") != 1 {
+		t.Error("expected only the domain's attempts to go")
+	}
+	var status string
+	var best *int
+	database.DB.QueryRow("SELECT status, personal_best_seconds FROM progress WHERE exercise_id = 8").Scan(&status, &best)
+	if status != "not-started" || best != nil {
+		t.Errorf("expected the domain's progress to be cleared, got %s %v", status, best)
+	}
+
+	// Undo restores the rows with their ids
+	w = httptest.NewRecorder()
+	HandleResetUndo(w, httptest.NewRequest(http.MethodPost, "/api/reset/undo", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("undo returned %d: %s", w.Code, w.Body.String())
+	}
+	if countRows(t, "attempts WHERE exercise_id = 8") != 2 || countRows(t, "hint_reveals WHERE attempt_id = 2") != 1 {
+		t.Error("expected the attempts and the hint reveal back")
+	}
+	database.DB.QueryRow("SELECT status, personal_best_seconds FROM progress WHERE exercise_id = 8").Scan(&status, &best)
+	if status != "completed" || best == nil || *best != 300 {
+		t.Errorf("expected the personal best back, got %s %v", status, best)
+	}
+
+	w = httptest.NewRecorder()
+	HandleResetUndo(w, httptest.NewRequest(http.MethodPost, "/api/reset/undo", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected a second undo to conflict, got %d", w.Code)
+	}
+}
+
+func TestResetBeforeDate(t *testing.T) {
+	setupResetHistory(t)
+	defer database.Close()
+
+	code, response := reset(t, `{"dryRun": true, "scope": {"before": "2026-03-02", "tz": "UTC"}}`)
+	if code != http.StatusOK || !response.DryRun || response.Counts.AttemptsCount != 1 || countRows(t, "attempts") != 3 {
+		t.Fatalf("unexpected dry run (%d): %+v", code, response)
+	}
+
+	if _, response = reset(t, `{"confirmation": "DELETE", "scope": {"before": "2026-03-02", "tz": "UTC"}}`); !response.Success {
+		t.Fatalf("reset failed: %+v", response)
+	}
+	var status, notes string
+	var best *int
+	database.DB.QueryRow("SELECT status, personal_best_seconds, notes FROM progress WHERE exercise_id = 7").Scan(&status, &best, &notes)
+	if status != "not-started" || best != nil || notes != "remember the audit policy path" {
+		t.Errorf("expected the progress cleared with its notes kept, got %s %v %q", status, best, notes)
+	}
+	if countRows(t, "attempts") != 2 {
+		t.Error("expected the later attempts to stay")
+	}
+
+	for _, body := range []string{
+		`{"confirmation": "DELETE", "scope": {"before": "March"}}`,
+		`{"confirmation": "DELETE", "scope": {"exercise": "missing"}}`,
+		`{"confirmation": "DELETE", "scope": {"domain": "missing"}}`,
+	} {
+		if code, _ := reset(t, body); code != http.StatusBadRequest {
+			t.Errorf("expected %s to be rejected, got %d", body, code)
+		}
+	}
+}
+
+func TestFullResetUndoAndExpiry(t *testing.T) {
+	setupResetHistory(t)
+	defer database.Close()
+
+	if _, response := reset(t, `{"scope": {}}`); response.Success {
+		t.Fatal("expected a reset without confirmation to be refused")
+	}
+	// A running exam keeps its row for the manager to grade
+	database.DB.Exec("INSERT INTO mock_exams (id, exam_type, started_at, max_score, exercises_total) VALUES (50, 'quick-practice', datetime('now'), 50, 5)")
+	_, response := reset(t, `{"confirmation": "DELETE"}`)
+	if !response.Success || response.Message != "All progress data has been reset." || response.Counts.MockExamsCount != 1 {
+		t.Fatalf("unexpected full reset %+v", response)
+	}
+	if countRows(t, "attempts")+countRows(t, "mock_exams WHERE id != 50")+countRows(t, "progress WHERE status != 'not-started' OR personal_best_seconds IS NOT NULL") != 0 {
+		t.Fatal("expected everything to be deleted")
+	}
+	if countRows(t, "mock_exams WHERE id = 50") != 1 {
+		t.Error("expected the running exam to be kept")
+	}
+	var kept string
+	database.DB.QueryRow("SELECT notes FROM progress WHERE exercise_id = 7").Scan(&kept)
+	if kept != "remember the audit policy path" {
+		t.Errorf("expected a full reset to keep notes like a scoped one, got %q", kept)
+	}
+
+	snapshots, err := listResetSnapshots(database.DefaultProfileID, time.Now())
+	if err != nil || len(snapshots) != 1 || !snapshots[0].Scope.IsAll() {
+		t.Fatalf("expected one snapshot, got %+v (%v)", snapshots, err)
+	}
+	if _, err := undoReset(database.DefaultProfileID, snapshots[0].ID, time.Now().Add(ResetUndoWindow+time.Minute)); err == nil {
+		t.Error("expected an expired snapshot to be refused")
+	}
+	if _, err := undoReset(database.DefaultProfileID, snapshots[0].ID, time.Now()); err != nil {
+		t.Fatalf("undoReset failed: %v", err)
+	}
+	if countRows(t, "attempts") != 3 || countRows(t, "progress") != 2 || countRows(t, "mock_exams") != 2 {
+		t.Error("expected everything back")
+	}
+	var notes string
+	database.DB.QueryRow("SELECT notes FROM progress WHERE exercise_id = 7").Scan(&notes)
+	if notes != "remember the audit policy path" {
+		t.Errorf("expected the notes back, got %q", notes)
+	}
+}
+
+func TestResetClearsPendingHintReveals(t *testing.T) {
+	setupResetHistory(t)
+	defer database.Close()
+
+	// Hints revealed for the open attempt, not yet claimed by a validation
+	_, err := database.DB.Exec(`
+		INSERT INTO hint_reveals (exercise_id, attempt_id, hint_index, revealed_at)
+		VALUES (8, NULL, 0, '2026-03-05 09:00:00'), (7, NULL, 0, '2026-03-05 09:00:00')
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert reveals: %v", err)
+	}
+
+	_, response := reset(t, `{"confirmation": "DELETE", "scope": {"exercise": "apparmor"}}`)
+	if !response.Success || response.Counts.HintRevealsCount != 2 {
+		t.Fatalf("expected the attempt's and the pending reveal to go, got %+v", response)
+	}
+	if countRows(t, "hint_reveals WHERE exercise_id = 8") != 0 || countRows(t, "hint_reveals WHERE exercise_id = 7") != 1 {
+		t.Error("expected only the reset exercise's pending reveal to go")
+	}
+
+	if _, err := undoReset(database.DefaultProfileID, response.SnapshotID, time.Now()); err != nil {
+		t.Fatalf("undoReset failed: %v", err)
+	}
+	if countRows(t, "hint_reveals WHERE exercise_id = 8 AND attempt_id IS NULL") != 1 {
+		t.Error("expected undo to bring the pending reveal back")
+	}
+}
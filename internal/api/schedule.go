@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+	"github.com/patrickvassell/cks-weight-room/internal/schedule"
+)
+
+// Defaults for the practice endpoints
+const (
+	defaultQueueLimit    = 10
+	defaultPlanMinutes   = 60
+	defaultPlanNewPerDay = 2
+)
+
+// ScheduleResponse is the response of the practice endpoints
+type ScheduleResponse struct {
+	Success bool            `json:"success"`
+	Queue   []schedule.Item `json:"queue,omitempty"`
+	DueNow  int             `json:"dueNow"` // Reviews due, not counting new exercises
+	Plan    *schedule.Plan  `json:"plan,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// GetPracticeQueue handles GET /api/practice/next?limit=10 and returns the
+// exercises to practise next, due reviews first
+func GetPracticeQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit, ok := queryInt(w, r, "limit", defaultQueueLimit)
+	if !ok {
+		return
+	}
+	queue, ok := loadPracticeQueue(w)
+	if !ok {
+		return
+	}
+
+	response := ScheduleResponse{Success: true, Queue: queue}
+	for _, item := range queue {
+		if !item.New {
+			response.DueNow++
+		}
+	}
+	if limit > 0 && len(response.Queue) > limit {
+		response.Queue = response.Queue[:limit]
+	}
+	writeScheduleResponse(w, http.StatusOK, response)
+}
+
+// GetPracticePlan handles GET /api/practice/plan?minutes=60&new=2 and
+// returns today's plan: due reviews and new exercises that fit the budget
+func GetPracticePlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	minutes, ok := queryInt(w, r, "minutes", defaultPlanMinutes)
+	if !ok {
+		return
+	}
+	maxNew, ok := queryInt(w, r, "new", defaultPlanNewPerDay)
+	if !ok {
+		return
+	}
+	queue, ok := loadPracticeQueue(w)
+	if !ok {
+		return
+	}
+
+	plan := schedule.BuildPlan(queue, minutes, maxNew, time.Now())
+	writeScheduleResponse(w, http.StatusOK, ScheduleResponse{Success: true, Plan: &plan, DueNow: plan.Reviews + plan.Deferred})
+}
+
+// loadPracticeQueue computes the queue, writing an error response on failure
+func loadPracticeQueue(w http.ResponseWriter) ([]schedule.Item, bool) {
+	if database.DB == nil {
+		writeScheduleResponse(w, http.StatusInternalServerError, ScheduleResponse{Error: "Database not initialized"})
+		return nil, false
+	}
+	curriculum, err := database.GetActiveCurriculum()
+	if err != nil {
+		log.Printf("Failed to load curriculum: %v", err)
+		writeScheduleResponse(w, http.StatusInternalServerError, ScheduleResponse{Error: err.Error()})
+		return nil, false
+	}
+	queue, err := schedule.Next(curriculum.Weights(), time.Now())
+	if err != nil {
+		log.Printf("Failed to build practice queue: %v", err)
+		writeScheduleResponse(w, http.StatusInternalServerError, ScheduleResponse{Error: err.Error()})
+		return nil, false
+	}
+	return queue, true
+}
+
+// queryInt reads a non-negative integer query parameter, writing a bad
+// request response when it is invalid
+func queryInt(w http.ResponseWriter, r *http.Request, name string, def int) (int, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		writeScheduleResponse(w, http.StatusBadRequest, ScheduleResponse{Error: name + " must be a non-negative integer"})
+		return 0, false
+	}
+	return n, true
+}
+
+// writeScheduleResponse writes a practice response as JSON
+func writeScheduleResponse(w http.ResponseWriter, status int, response ScheduleResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
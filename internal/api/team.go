@@ -0,0 +1,579 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+	"github.com/patrickvassell/cks-weight-room/internal/team"
+)
+
+// Config keys of team mode
+const (
+	teamHubURLKey    = "team_hub_url"
+	teamSecretKey    = "team_secret"
+	teamMemberKey    = "team_member"
+	teamLastSyncKey  = "team_last_sync"  // JSON teamSync of the last push
+	teamHubSecretKey = "team_hub_secret" // Secret of the hub this instance runs
+)
+
+var (
+	errTeamNotConfigured = errors.New("set the hub URL and team secret first")
+	errNotTeamHub        = errors.New("this instance isn't running the team hub; start it with -hub-addr")
+	errTeamHubRequest    = errors.New("team hub request failed")
+)
+
+// teamHubAddr is the address the hub listens on when this instance runs it
+var teamHubAddr struct {
+	sync.RWMutex
+	addr string
+}
+
+// TeamStatus is the team mode configuration of this instance
+type TeamStatus struct {
+	HubURL       string            `json:"hubUrl"`
+	Member       string            `json:"member"` // Name shown on the leaderboard
+	SecretSet    bool              `json:"secretSet"`
+	Configured   bool              `json:"configured"`
+	HubAddress   string            `json:"hubAddress,omitempty"` // Set when this instance runs the hub
+	LastSyncedAt string            `json:"lastSyncedAt,omitempty"`
+	Assignments  []team.Assignment `json:"assignments"` // From the last sync
+}
+
+// TeamConfigRequest configures team mode. An empty secret keeps the current
+// one.
+type TeamConfigRequest struct {
+	HubURL string `json:"hubUrl"`
+	Secret string `json:"secret,omitempty"`
+	Member string `json:"member"`
+}
+
+// TeamResponse is the response of the team endpoints
+type TeamResponse struct {
+	Success     bool                    `json:"success"`
+	Status      *TeamStatus             `json:"status,omitempty"`
+	Summary     *team.Summary           `json:"summary,omitempty"`
+	Accepted    *bool                   `json:"accepted,omitempty"`
+	Leaderboard []team.Standing         `json:"leaderboard,omitempty"`
+	Domains     []team.DomainComparison `json:"domains,omitempty"`
+	Assignments []team.Assignment       `json:"assignments,omitempty"`
+	Assignment  *team.Assignment        `json:"assignment,omitempty"`
+	Error       string                  `json:"error,omitempty"`
+}
+
+// teamSync is what the last push returned
+type teamSync struct {
+	SyncedAt    string            `json:"syncedAt"`
+	Assignments []team.Assignment `json:"assignments"`
+}
+
+// HandleTeam handles the team mode API:
+//
+//	GET    /api/team                      configuration and the last sync
+//	PUT    /api/team                      set the hub URL, secret and member name
+//	GET    /api/team/summary              the summary a sync would push
+//	POST   /api/team/sync                 push the active profile's summary to the hub
+//	GET    /api/team/leaderboard          the hub's leaderboard
+//	GET    /api/team/compare              the hub's per-domain comparison
+//	GET    /api/team/assignments          assignments (all of them on the hub, ?member= narrows)
+//	POST   /api/team/assignments          assign an exercise (hub only)
+//	DELETE /api/team/assignments/{id}     withdraw an assignment (hub only)
+//	DELETE /api/team/members/{slug}       remove a member (hub only)
+//
+// The hub itself serves its members on a separate listener; see
+// StartTeamHub.
+func HandleTeam(w http.ResponseWriter, r *http.Request) {
+	if database.DB == nil {
+		writeTeamResponse(w, http.StatusInternalServerError, TeamResponse{Error: "Database not initialized"})
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/team"), "/")
+	parts := strings.Split(path, "/")
+	hubAddr := runningTeamHub()
+
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		status, err := loadTeamStatus()
+		if err != nil {
+			writeTeamError(w, err)
+			return
+		}
+		writeTeamResponse(w, http.StatusOK, TeamResponse{Success: true, Status: status})
+
+	case path == "" && r.Method == http.MethodPut:
+		var req TeamConfigRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeTeamResponse(w, http.StatusBadRequest, TeamResponse{Error: "Invalid request body"})
+			return
+		}
+		if err := saveTeamConfig(req); err != nil {
+			writeTeamError(w, err)
+			return
+		}
+		status, err := loadTeamStatus()
+		if err != nil {
+			writeTeamError(w, err)
+			return
+		}
+		log.Printf("Team mode set to hub %s as %s", status.HubURL, status.Member)
+		writeTeamResponse(w, http.StatusOK, TeamResponse{Success: true, Status: status})
+
+	case path == "summary" && r.Method == http.MethodGet:
+		summary, err := currentTeamSummary()
+		if err != nil {
+			writeTeamError(w, err)
+			return
+		}
+		writeTeamResponse(w, http.StatusOK, TeamResponse{Success: true, Summary: summary})
+
+	case path == "sync" && r.Method == http.MethodPost:
+		client, err := teamClient()
+		if err != nil {
+			writeTeamError(w, err)
+			return
+		}
+		summary, err := currentTeamSummary()
+		if err != nil {
+			writeTeamError(w, err)
+			return
+		}
+		pushed, err := client.Push(r.Context(), *summary)
+		if err != nil {
+			writeTeamError(w, fmt.Errorf("%w: %v", errTeamHubRequest, err))
+			return
+		}
+		last, _ := json.Marshal(teamSync{SyncedAt: time.Now().UTC().Format(time.RFC3339), Assignments: pushed.Assignments})
+		if err := database.SetConfig(teamLastSyncKey, string(last)); err != nil {
+			writeTeamError(w, err)
+			return
+		}
+		log.Printf("Synced with team hub as %s (accepted: %v, %d assignments)", pushed.Member, pushed.Accepted, len(pushed.Assignments))
+		writeTeamResponse(w, http.StatusOK, TeamResponse{
+			Success:     true,
+			Summary:     summary,
+			Accepted:    &pushed.Accepted,
+			Assignments: pushed.Assignments,
+		})
+
+	case path == "leaderboard" && r.Method == http.MethodGet:
+		var standings []team.Standing
+		var err error
+		if hubAddr != "" {
+			var members []team.Member
+			if members, err = team.LoadMembers(); err == nil {
+				standings = team.Leaderboard(members)
+			}
+		} else {
+			standings, err = withTeamClient(func(c *team.Client) ([]team.Standing, error) { return c.Leaderboard(r.Context()) })
+		}
+		if err != nil {
+			writeTeamError(w, err)
+			return
+		}
+		writeTeamResponse(w, http.StatusOK, TeamResponse{Success: true, Leaderboard: standings})
+
+	case path == "compare" && r.Method == http.MethodGet:
+		var domains []team.DomainComparison
+		var err error
+		if hubAddr != "" {
+			domains, err = team.CurrentComparison()
+		} else {
+			domains, err = withTeamClient(func(c *team.Client) ([]team.DomainComparison, error) { return c.Compare(r.Context()) })
+		}
+		if err != nil {
+			writeTeamError(w, err)
+			return
+		}
+		writeTeamResponse(w, http.StatusOK, TeamResponse{Success: true, Domains: domains})
+
+	case path == "assignments" && r.Method == http.MethodGet:
+		var assignments []team.Assignment
+		var err error
+		if hubAddr != "" {
+			assignments, err = team.ListAssignments(r.URL.Query().Get("member"), time.Now())
+		} else {
+			var status *TeamStatus
+			if status, err = loadTeamStatus(); err == nil {
+				assignments = status.Assignments
+			}
+		}
+		if err != nil {
+			writeTeamError(w, err)
+			return
+		}
+		writeTeamResponse(w, http.StatusOK, TeamResponse{Success: true, Assignments: assignments})
+
+	case path == "assignments" && r.Method == http.MethodPost:
+		if hubAddr == "" {
+			writeTeamError(w, errNotTeamHub)
+			return
+		}
+		var req team.Assignment
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeTeamResponse(w, http.StatusBadRequest, TeamResponse{Error: "Invalid request body"})
+			return
+		}
+		assignment, err := team.Assign(req)
+		if err != nil {
+			writeTeamError(w, err)
+			return
+		}
+		log.Printf("Assigned %s to %s", assignment.Exercise, assignment.Member)
+		writeTeamResponse(w, http.StatusCreated, TeamResponse{Success: true, Assignment: assignment})
+
+	case len(parts) == 2 && parts[0] == "assignments" && r.Method == http.MethodDelete:
+		if hubAddr == "" {
+			writeTeamError(w, errNotTeamHub)
+			return
+		}
+		id, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			writeTeamResponse(w, http.StatusBadRequest, TeamResponse{Error: "Invalid assignment id"})
+			return
+		}
+		if err := team.DeleteAssignment(id); err != nil {
+			writeTeamError(w, err)
+			return
+		}
+		writeTeamResponse(w, http.StatusOK, TeamResponse{Success: true})
+
+	case len(parts) == 2 && parts[0] == "members" && r.Method == http.MethodDelete:
+		if hubAddr == "" {
+			writeTeamError(w, errNotTeamHub)
+			return
+		}
+		if err := team.DeleteMember(parts[1]); err != nil {
+			writeTeamError(w, err)
+			return
+		}
+		log.Printf("Removed team member %s", parts[1])
+		writeTeamResponse(w, http.StatusOK, TeamResponse{Success: true})
+
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// StartTeamHub starts serving the team hub on addr, which unlike the rest of
+// the app may be a LAN address. The secret defaults to the one stored by an
+// earlier run, or a new random one, stored under the team_hub_secret config
+// key. The returned server is already listening; the caller shuts it down.
+// The secret is returned only when it was generated, so a supplied one is
+// never echoed back.
+func StartTeamHub(addr, secret string) (*http.Server, string, error) {
+	if database.DB == nil {
+		return nil, "", errors.New("the team hub needs a database; start the app once to set it up")
+	}
+	if secret == "" {
+		stored, err := database.GetConfig(teamHubSecretKey)
+		if err != nil {
+			return nil, "", err
+		}
+		secret = stored
+	}
+	generated := ""
+	if secret == "" {
+		raw := make([]byte, 24)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, "", fmt.Errorf("failed to generate team secret: %w", err)
+		}
+		secret = base64.RawURLEncoding.EncodeToString(raw)
+		generated = secret
+	}
+	if len(secret) < team.MinSecretLength {
+		return nil, "", fmt.Errorf("team secret must be at least %d characters", team.MinSecretLength)
+	}
+	if err := database.SetConfig(teamHubSecretKey, secret); err != nil {
+		return nil, "", err
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	srv := &http.Server{
+		Addr:              ln.Addr().String(),
+		Handler:           team.NewHub([]byte(secret)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("Team hub stopped: %v", err)
+		}
+	}()
+
+	teamHubAddr.Lock()
+	teamHubAddr.addr = srv.Addr
+	teamHubAddr.Unlock()
+	return srv, generated, nil
+}
+
+// runningTeamHub returns the hub's address, or "" when this instance
+// doesn't run it
+func runningTeamHub() string {
+	teamHubAddr.RLock()
+	defer teamHubAddr.RUnlock()
+	return teamHubAddr.addr
+}
+
+// loadTeamStatus reads the team configuration and the last sync
+func loadTeamStatus() (*TeamStatus, error) {
+	status := &TeamStatus{HubAddress: runningTeamHub(), Assignments: []team.Assignment{}}
+	var err error
+	if status.HubURL, err = database.GetConfig(teamHubURLKey); err != nil {
+		return nil, err
+	}
+	secret, err := database.GetConfig(teamSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	status.SecretSet = secret != ""
+	status.Configured = status.HubURL != "" && status.SecretSet
+	if status.Member, err = teamMemberName(); err != nil {
+		return nil, err
+	}
+
+	last, err := database.GetConfig(teamLastSyncKey)
+	if err != nil {
+		return nil, err
+	}
+	if last != "" {
+		var synced teamSync
+		if json.Unmarshal([]byte(last), &synced) == nil {
+			status.LastSyncedAt = synced.SyncedAt
+			if synced.Assignments != nil {
+				status.Assignments = synced.Assignments
+			}
+		}
+	}
+	return status, nil
+}
+
+// saveTeamConfig validates and stores the team configuration
+func saveTeamConfig(req TeamConfigRequest) error {
+	secret := req.Secret
+	if secret == "" {
+		stored, err := database.GetConfig(teamSecretKey)
+		if err != nil {
+			return err
+		}
+		secret = stored
+	}
+	if _, err := team.NewClient(req.HubURL, []byte(secret)); err != nil {
+		return err
+	}
+	member := strings.TrimSpace(req.Member)
+	if slug := database.ProfileSlug(member); len(slug) > 40 || (member != "" && slug == "") {
+		return fmt.Errorf("%w: member name must contain letters or digits and be at most 40 characters", team.ErrInvalid)
+	}
+
+	for key, value := range map[string]string{
+		teamHubURLKey: strings.TrimRight(req.HubURL, "/"),
+		teamSecretKey: secret,
+		teamMemberKey: member,
+	} {
+		if err := database.SetConfig(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// teamMemberName is the configured member name, or the active profile's
+func teamMemberName() (string, error) {
+	member, err := database.GetConfig(teamMemberKey)
+	if err != nil || member != "" {
+		return member, err
+	}
+	profile, err := database.GetActiveProfile()
+	if err != nil {
+		return "", err
+	}
+	return profile.Name, nil
+}
+
+// teamClient returns a client for the configured hub
+func teamClient() (*team.Client, error) {
+	hubURL, err := database.GetConfig(teamHubURLKey)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := database.GetConfig(teamSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	if hubURL == "" || secret == "" {
+		return nil, errTeamNotConfigured
+	}
+	return team.NewClient(hubURL, []byte(secret))
+}
+
+// withTeamClient runs a request against the configured hub
+func withTeamClient[T any](call func(*team.Client) (T, error)) (T, error) {
+	var zero T
+	client, err := teamClient()
+	if err != nil {
+		return zero, err
+	}
+	result, err := call(client)
+	if err != nil {
+		return zero, fmt.Errorf("%w: %v", errTeamHubRequest, err)
+	}
+	return result, nil
+}
+
+// currentTeamSummary builds the active profile's summary under the member
+// name
+func currentTeamSummary() (*team.Summary, error) {
+	profile, err := database.GetActiveProfile()
+	if err != nil {
+		return nil, err
+	}
+	member, err := teamMemberName()
+	if err != nil {
+		return nil, err
+	}
+	return buildTeamSummary(member, profile, time.Now())
+}
+
+// buildTeamSummary condenses a profile's export data into the summary the
+// hub receives: totals, per-domain figures and the completed exercises
+func buildTeamSummary(member string, profile *database.Profile, now time.Time) (*team.Summary, error) {
+	data := loadExportData(profile)
+	summary := &team.Summary{
+		Member:             member,
+		GeneratedAt:        now.UTC(),
+		ScenariosCompleted: data.ScenariosCompleted,
+		PracticeMinutes:    data.TotalPracticeTimeMinutes,
+		Domains:            []team.DomainSummary{},
+		Completed:          []string{},
+	}
+	if curriculum, err := database.GetActiveCurriculum(); err == nil {
+		summary.CurriculumVersion = curriculum.Version
+	}
+
+	domains := map[string]*team.DomainSummary{}
+	var order []string
+	domain := func(slug string) *team.DomainSummary {
+		if d, ok := domains[slug]; ok {
+			return d
+		}
+		domains[slug] = &team.DomainSummary{Domain: slug}
+		order = append(order, slug)
+		return domains[slug]
+	}
+
+	rows, err := database.DB.Query("SELECT category, COUNT(*) FROM exercises GROUP BY category ORDER BY category")
+	if err != nil {
+		return nil, fmt.Errorf("failed to count exercises: %w", err)
+	}
+	for rows.Next() {
+		var slug string
+		var total int
+		if err := rows.Scan(&slug, &total); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to count exercises: %w", err)
+		}
+		domain(slug).Total = total
+		summary.TotalScenarios += total
+	}
+	rows.Close()
+
+	rows, err = database.DB.Query(`
+		SELECT e.slug, e.category FROM progress p
+		JOIN exercises e ON e.id = p.exercise_id
+		WHERE p.profile_id = ? AND p.status = 'completed'
+		ORDER BY e.slug
+	`, profile.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load completed exercises: %w", err)
+	}
+	for rows.Next() {
+		var slug, category string
+		if err := rows.Scan(&slug, &category); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to load completed exercises: %w", err)
+		}
+		summary.Completed = append(summary.Completed, slug)
+		domain(category).Completed++
+	}
+	rows.Close()
+
+	// Open attempts have no score yet
+	scores := map[string]float64{}
+	var scoreSum float64
+	for _, a := range data.Attempts {
+		if a.CompletedAt == "" {
+			continue
+		}
+		d := domain(a.Domain)
+		d.Attempts++
+		summary.Attempts++
+		if a.Status == "completed" {
+			d.Passed++
+			summary.Passed++
+		}
+		scores[a.Domain] += a.Score * 100
+		scoreSum += a.Score * 100
+	}
+	if summary.Attempts > 0 {
+		summary.AverageScore = scoreSum / float64(summary.Attempts)
+	}
+
+	for _, m := range data.MockExams {
+		if m.CompletedAt == "" {
+			continue
+		}
+		summary.MockExamsTaken++
+		if m.Result == "passed" {
+			summary.MockExamsPassed++
+		}
+	}
+
+	for _, slug := range order {
+		d := domains[slug]
+		if d.Attempts > 0 {
+			d.AverageScore = scores[slug] / float64(d.Attempts)
+		}
+		summary.Domains = append(summary.Domains, *d)
+	}
+	return summary, nil
+}
+
+// writeTeamError maps an error to a team response
+func writeTeamError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var dbErr *database.DatabaseError
+	switch {
+	case errors.Is(err, team.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, team.ErrInvalid):
+		status = http.StatusBadRequest
+	case errors.Is(err, errTeamNotConfigured), errors.Is(err, errNotTeamHub):
+		status = http.StatusConflict
+	case errors.Is(err, errTeamHubRequest):
+		status = http.StatusBadGateway
+	case errors.As(err, &dbErr):
+		writeProfileError(w, err)
+		return
+	}
+	writeTeamResponse(w, status, TeamResponse{Error: err.Error()})
+}
+
+// writeTeamResponse writes a team response as JSON
+func writeTeamResponse(w http.ResponseWriter, status int, response TeamResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+	"github.com/patrickvassell/cks-weight-room/internal/team"
+)
+
+func teamRequest(t *testing.T, method, url, body string) (int, TeamResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	HandleTeam(w, httptest.NewRequest(method, url, strings.NewReader(body)))
+	var response TeamResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	return w.Code, response
+}
+
+func TestTeamSyncWithHub(t *testing.T) {
+	setupExportHistory(t)
+	defer database.Close()
+	if _, err := database.DB.Exec("INSERT INTO progress (exercise_id, status, attempts) VALUES (7, 'completed', 1)"); err != nil {
+		t.Fatalf("failed to insert progress: %v", err)
+	}
+
+	if code, _ := teamRequest(t, http.MethodPost, "/api/team/sync", ""); code != http.StatusConflict {
+		t.Errorf("expected a sync without a hub to conflict, got %d", code)
+	}
+
+	// The hub runs as a separate server, as it would in another process
+	secret := "a shared team secret"
+	hub := httptest.NewServer(team.NewHub([]byte(secret)))
+	defer hub.Close()
+
+	if code, _ := teamRequest(t, http.MethodPut, "/api/team", `{"hubUrl": "`+hub.URL+`", "secret": "short", "member": "Ada"}`); code != http.StatusBadRequest {
+		t.Errorf("expected a short secret to be refused, got %d", code)
+	}
+	code, configured := teamRequest(t, http.MethodPut, "/api/team", `{"hubUrl": "`+hub.URL+`/", "secret": "`+secret+`", "member": "Ada"}`)
+	if code != http.StatusOK || !configured.Status.Configured || configured.Status.HubURL != hub.URL {
+		t.Fatalf("unexpected configuration (%d): %+v", code, configured)
+	}
+
+	code, synced := teamRequest(t, http.MethodPost, "/api/team/sync", "")
+	if code != http.StatusOK || synced.Accepted == nil || !*synced.Accepted {
+		t.Fatalf("unexpected sync (%d): %+v", code, synced)
+	}
+	summary := synced.Summary
+	if summary.Member != "Ada" || summary.Attempts != 3 || summary.Passed != 2 || summary.ScenariosCompleted != 1 ||
+		summary.TotalScenarios != 2 || len(summary.Completed) != 1 || summary.Completed[0] != "audit-logs" {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	for _, d := range summary.Domains {
+		if d.Domain == "system-hardening" && (d.Attempts != 2 || d.Passed != 1 || math.Abs(d.AverageScore-65) > 0.01) {
+			t.Errorf("unexpected system-hardening figures %+v", d)
+		}
+	}
+
+	// Assignments are made on the hub and reach the member on their next sync
+	if code, _ := teamRequest(t, http.MethodPost, "/api/team/assignments", `{"member": "ada", "exercise": "apparmor"}`); code != http.StatusConflict {
+		t.Errorf("expected assigning on a member instance to conflict, got %d", code)
+	}
+	if _, err := team.Assign(team.Assignment{Member: "ada", Exercise: "apparmor", Note: "pass it this time"}); err != nil {
+		t.Fatalf("Assign failed: %v", err)
+	}
+	if code, synced = teamRequest(t, http.MethodPost, "/api/team/sync", ""); code != http.StatusOK || len(synced.Assignments) != 1 {
+		t.Fatalf("expected the assignment with the sync (%d): %+v", code, synced)
+	}
+
+	w := httptest.NewRecorder()
+	HandleTeam(w, httptest.NewRequest(http.MethodGet, "/api/team", nil))
+	if strings.Contains(w.Body.String(), secret) {
+		t.Error("expected the status not to reveal the secret")
+	}
+	var status TeamResponse
+	json.NewDecoder(w.Body).Decode(&status)
+	if !status.Status.SecretSet || status.Status.LastSyncedAt == "" || len(status.Status.Assignments) != 1 ||
+		status.Status.Assignments[0].Status != team.StatusOpen {
+		t.Errorf("unexpected status %+v", status.Status)
+	}
+
+	code, board := teamRequest(t, http.MethodGet, "/api/team/leaderboard", "")
+	if code != http.StatusOK || len(board.Leaderboard) != 1 || board.Leaderboard[0].Slug != "ada" || board.Leaderboard[0].PassRate != 66.7 {
+		t.Errorf("unexpected leaderboard (%d): %+v", code, board)
+	}
+	if code, compared := teamRequest(t, http.MethodGet, "/api/team/compare", ""); code != http.StatusOK || len(compared.Domains) != 6 {
+		t.Errorf("unexpected comparison (%d): %+v", code, compared)
+	}
+
+	// A hub that's down is a gateway error, not ours
+	hub.Close()
+	if code, _ := teamRequest(t, http.MethodGet, "/api/team/leaderboard", ""); code != http.StatusBadGateway {
+		t.Errorf("expected an unreachable hub to be a bad gateway, got %d", code)
+	}
+}
+
+func TestTeamHubAdministration(t *testing.T) {
+	setupExportHistory(t)
+	defer database.Close()
+
+	srv, secret, err := StartTeamHub("127.0.0.1:0", "")
+	if err != nil {
+		t.Fatalf("StartTeamHub failed: %v", err)
+	}
+	defer func() {
+		srv.Close()
+		teamHubAddr.Lock()
+		teamHubAddr.addr = ""
+		teamHubAddr.Unlock()
+	}()
+	if stored, _ := database.GetConfig(teamHubSecretKey); stored != secret || len(secret) < team.MinSecretLength {
+		t.Fatalf("expected a generated secret to be stored, got %q", stored)
+	}
+
+	// This instance is both the hub and one of its members
+	body := `{"hubUrl": "http://` + srv.Addr + `", "secret": "` + secret + `", "member": "Instructor"}`
+	if code, _ := teamRequest(t, http.MethodPut, "/api/team", body); code != http.StatusOK {
+		t.Fatalf("configuration failed with %d", code)
+	}
+	if code, synced := teamRequest(t, http.MethodPost, "/api/team/sync", ""); code != http.StatusOK {
+		t.Fatalf("sync failed (%d): %+v", code, synced)
+	}
+
+	code, created := teamRequest(t, http.MethodPost, "/api/team/assignments", `{"member": "instructor", "exercise": "audit-logs", "dueDate": "2099-01-01"}`)
+	if code != http.StatusCreated || created.Assignment == nil || created.Assignment.Title != "Audit Logs" {
+		t.Fatalf("unexpected assignment (%d): %+v", code, created)
+	}
+	if code, _ := teamRequest(t, http.MethodPost, "/api/team/assignments", `{"member": "instructor", "exercise": "missing"}`); code != http.StatusNotFound {
+		t.Errorf("expected an unknown exercise to be refused, got %d", code)
+	}
+	if code, listed := teamRequest(t, http.MethodGet, "/api/team/assignments?member=instructor", ""); code != http.StatusOK || len(listed.Assignments) != 1 {
+		t.Errorf("unexpected assignments (%d): %+v", code, listed)
+	}
+	if code, board := teamRequest(t, http.MethodGet, "/api/team/leaderboard", ""); code != http.StatusOK || len(board.Leaderboard) != 1 {
+		t.Errorf("unexpected leaderboard (%d): %+v", code, board)
+	}
+
+	if code, _ := teamRequest(t, http.MethodDelete, "/api/team/assignments/"+strconv.FormatInt(created.Assignment.ID, 10), ""); code != http.StatusOK {
+		t.Errorf("expected the assignment to be withdrawn, got %d", code)
+	}
+	if code, _ := teamRequest(t, http.MethodDelete, "/api/team/members/ghost", ""); code != http.StatusNotFound {
+		t.Errorf("expected an unknown member to be not found, got %d", code)
+	}
+	if code, _ := teamRequest(t, http.MethodDelete, "/api/team/members/instructor", ""); code != http.StatusOK {
+		t.Errorf("expected the member to be removed, got %d", code)
+	}
+}
@@ -1,29 +1,153 @@
 package api
 
 import (
+	"compress/flate"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 	"unsafe"
 
 	"github.com/creack/pty"
 	"github.com/gorilla/websocket"
 	"github.com/patrickvassell/cks-weight-room/internal/cluster"
+	"github.com/patrickvassell/cks-weight-room/internal/terminal"
 )
 
+// upgrader negotiates permessage-deflate when the browser offers it; terminal
+// output (ANSI sequences, repeated table columns) compresses well
 var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		// Allow connections from localhost only
-		return true
-	},
+	ReadBufferSize:    4096,
+	WriteBufferSize:   32 * 1024,
+	CheckOrigin:       checkSameOrigin,
+	EnableCompression: true,
 }
 
+// terminalWriteTimeout bounds how long a frame may wait on a client that has
+// stopped reading before the connection is treated as dead
+const terminalWriteTimeout = 30 * time.Second
+
+// checkSameOrigin only accepts WebSocket handshakes whose Origin matches the
+// Host they were sent to. The auth guard has already pinned Host to a
+// loopback address, so this rejects pages from any other site.
+func checkSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// terminalSessions tracks every live terminal across both handlers so the
+// idle/duration timeouts and concurrency caps apply regardless of mode
+var terminalSessions = terminal.NewRegistry(terminalLimits())
+
+// terminalLimits returns the session limits, enforcing terminalTimeoutCLI as
+// the hard cap on how long any shell may stay open
+func terminalLimits() terminal.Limits {
+	limits := terminal.DefaultLimits()
+	limits.MaxDuration = terminalTimeoutCLI
+	return limits
+}
+
+// terminalConn serializes writes to a WebSocket, since the PTY reader, the
+// input loop and the session reaper may all write concurrently
+type terminalConn struct {
+	*websocket.Conn
+	mu sync.Mutex
+}
+
+// newTerminalConn wraps a freshly upgraded connection with the terminal
+// read limit and write compression
+func newTerminalConn(wsConn *websocket.Conn) *terminalConn {
+	wsConn.SetReadLimit(terminalReadLimit)
+	wsConn.EnableWriteCompression(true)
+	wsConn.SetCompressionLevel(flate.BestSpeed)
+	return &terminalConn{Conn: wsConn}
+}
+
+// WriteMessage writes a single message while holding the write lock
+func (c *terminalConn) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Conn.WriteMessage(messageType, data)
+}
+
+// writeOutput sends one frame of PTY output as a binary message. Binary
+// frames carry raw terminal bytes; frames are cut on UTF-8 boundaries so
+// clients that decode them as text never see a split character.
+func (c *terminalConn) writeOutput(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Conn.SetWriteDeadline(time.Now().Add(terminalWriteTimeout))
+	defer c.Conn.SetWriteDeadline(time.Time{})
+	return c.Conn.WriteMessage(websocket.BinaryMessage, data)
+}
+
+// pumpOutput streams PTY output to the client until either side closes
+func (c *terminalConn) pumpOutput(ptmx io.Reader) {
+	if err := terminal.PumpOutput(ptmx, c.writeOutput, terminal.DefaultOutputOptions()); err != nil {
+		log.Printf("Error writing to WebSocket: %v", err)
+		// Unblock the input loop so the session is torn down
+		c.Conn.Close()
+	}
+}
+
+// closeWithReason sends a close frame carrying reason and closes the socket
+func (c *terminalConn) closeWithReason(code int, reason string) {
+	c.mu.Lock()
+	c.Conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(time.Second))
+	c.mu.Unlock()
+	c.Conn.Close()
+}
+
+// openTerminalSession registers a session for conn, reporting a rejection to
+// the client when a concurrency cap is hit
+func openTerminalSession(conn *terminalConn, r *http.Request, slug, nodeName, mode string) (*terminal.Session, bool) {
+	session, err := terminalSessions.Register(slug, nodeName, mode, r.RemoteAddr)
+	if err != nil {
+		log.Printf("Terminal session rejected for %s: %v", slug, err)
+		message := err.Error()
+		if sessErr, ok := err.(*terminal.SessionError); ok {
+			message = sessErr.Message
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\033[31m✗ %s\033[0m\r\n", message)))
+		conn.closeWithReason(websocket.ClosePolicyViolation, "session limit reached")
+		return nil, false
+	}
+
+	session.SetCallbacks(terminal.Callbacks{
+		Warn: func(message string) {
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\r\n\033[33m⚠  %s\033[0m\r\n", message)))
+		},
+		Close: func(reason string) {
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\r\n\033[33m✗ Session closed: %s\033[0m\r\n", reason)))
+			code := websocket.CloseNormalClosure
+			if reason == terminal.ReasonShutdown {
+				code = websocket.CloseGoingAway
+			}
+			conn.closeWithReason(code, reason)
+		},
+	})
+	return session, true
+}
+
+// terminalReadLimit caps the size of a single client message so a huge
+// input can't be buffered in memory or flood the shell
+const terminalReadLimit = 64 * 1024
+
 // TerminalMessage represents messages sent/received over WebSocket
 type TerminalMessage struct {
 	Type string `json:"type"` // "input", "resize"
@@ -42,22 +166,66 @@ func HandleTerminal(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Upgrade to WebSocket
-	conn, err := upgrader.Upgrade(w, r, nil)
+	wsConn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade failed: %v", err)
 		return
 	}
+	conn := newTerminalConn(wsConn)
 	defer conn.Close()
 
+	// Optional exam realism (paste restrictions)
+	examMode := newExamRealism(r, slug)
+
+	// Enforce session limits before spawning a shell
+	nodeName := ""
+	if isCandidateMode(r) {
+		nodeName = candidateNode
+	}
+	session, ok := openTerminalSession(conn, r, slug, nodeName, "standard")
+	if !ok {
+		return
+	}
+	defer terminalSessions.Unregister(session.ID)
+
+	// In candidate mode the host shell is the exam's base host and nodes are
+	// reached through the generated ssh_config
+	var candidateInit string
+	if isCandidateMode(r) {
+		access, ok := prepareCandidateAccess(conn, slug)
+		if !ok {
+			return
+		}
+		candidateInit = hostCandidateInit(access)
+	}
+
 	// Get cluster context for this exercise
 	clusterName := cluster.GetClusterName(slug)
 	kubectxContext := "kind-" + clusterName
+	kubeconfig := os.Getenv("HOME") + "/.kube/config"
+	contextInit := "kubectl config use-context " + kubectxContext + " 2>/dev/null\n" +
+		"clear\n" +
+		"echo 'Connected to CKS practice environment'\n" +
+		"echo 'Cluster: " + clusterName + "'\n" +
+		"echo ''\n" +
+		"kubectl get nodes 2>/dev/null || echo 'Cluster is starting up...'\n"
+
+	// A running mock exam gets every exam context and, like the real exam,
+	// none is selected: each question names the context to switch to
+	if examKubeconfig, ok := examTerminalKubeconfig(slug); ok {
+		kubeconfig = examKubeconfig
+		contextInit = "clear\n" +
+			"echo 'Connected to CKS mock exam environment'\n" +
+			"echo 'Switch to the context each question names with: kubectl config use-context <name>'\n" +
+			"echo ''\n" +
+			"kubectl config get-contexts -o name\n"
+	}
 
 	// Start shell session with PTY
 	cmd := exec.Command("/bin/bash")
 	cmd.Env = append(os.Environ(),
 		"TERM=xterm-256color",
-		"KUBECONFIG="+os.Getenv("HOME")+"/.kube/config",
+		"KUBECONFIG="+kubeconfig,
 	)
 
 	// Start the command with a PTY
@@ -67,10 +235,7 @@ func HandleTerminal(w http.ResponseWriter, r *http.Request) {
 		conn.WriteMessage(websocket.TextMessage, []byte("Failed to start terminal session\r\n"))
 		return
 	}
-	defer func() {
-		ptmx.Close()
-		cmd.Process.Kill()
-	}()
+	defer stopShell(ptmx, cmd)
 
 	// Set initial terminal size
 	pty.Setsize(ptmx, &pty.Winsize{
@@ -80,34 +245,13 @@ func HandleTerminal(w http.ResponseWriter, r *http.Request) {
 
 	// Send initial commands to set up kubectl context
 	initCommands := "alias k=kubectl\n" +
-		"kubectl config use-context " + kubectxContext + " 2>/dev/null\n" +
-		"clear\n" +
-		"echo 'Connected to CKS practice environment'\n" +
-		"echo 'Cluster: " + clusterName + "'\n" +
+		contextInit +
 		"echo ''\n" +
-		"kubectl get nodes 2>/dev/null || echo 'Cluster is starting up...'\n" +
-		"echo ''\n"
+		candidateInit
 	ptmx.Write([]byte(initCommands))
 
 	// Copy from PTY to WebSocket
-	go func() {
-		buf := make([]byte, 1024)
-		for {
-			n, err := ptmx.Read(buf)
-			if err != nil {
-				if err != io.EOF {
-					log.Printf("Error reading from PTY: %v", err)
-				}
-				return
-			}
-			if n > 0 {
-				if err := conn.WriteMessage(websocket.TextMessage, buf[:n]); err != nil {
-					log.Printf("Error writing to WebSocket: %v", err)
-					return
-				}
-			}
-		}
-	}()
+	go conn.pumpOutput(ptmx)
 
 	// Copy from WebSocket to PTY
 	for {
@@ -127,6 +271,10 @@ func HandleTerminal(w http.ResponseWriter, r *http.Request) {
 
 		switch msg.Type {
 		case "input":
+			session.Touch()
+			if !examMode.admitInput(conn, slug, msg.Data) {
+				continue
+			}
 			if _, err := ptmx.Write([]byte(msg.Data)); err != nil {
 				log.Printf("Error writing to PTY: %v", err)
 				return
@@ -145,6 +293,35 @@ func HandleTerminal(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// shellStopGrace is how long a shell gets to exit after SIGHUP
+const shellStopGrace = 2 * time.Second
+
+// stopShell closes the PTY and ends the shell's whole process group, so
+// commands it started (kubectl watches, docker exec) don't outlive it.
+// pty.Start runs the shell in its own session, making its PID the group ID.
+func stopShell(ptmx *os.File, cmd *exec.Cmd) {
+	ptmx.Close()
+	if cmd.Process == nil {
+		return
+	}
+
+	pgid := cmd.Process.Pid
+	syscall.Kill(-pgid, syscall.SIGHUP)
+
+	done := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(shellStopGrace):
+		syscall.Kill(-pgid, syscall.SIGKILL)
+		<-done
+	}
+}
+
 // setWinsize sets the size of the given PTY
 func setWinsize(fd uintptr, w, h uint16) error {
 	ws := &struct {
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/patrickvassell/cks-weight-room/internal/terminal"
+)
+
+// TerminalSessionsResponse represents the API response for terminal session management
+type TerminalSessionsResponse struct {
+	Success   bool                   `json:"success"`
+	Sessions  []terminal.SessionInfo `json:"sessions,omitempty"`
+	Limits    *TerminalLimitsInfo    `json:"limits,omitempty"`
+	ErrorCode string                 `json:"errorCode,omitempty"`
+	Message   string                 `json:"message,omitempty"`
+}
+
+// TerminalLimitsInfo describes the limits currently enforced on terminal sessions
+type TerminalLimitsInfo struct {
+	IdleTimeoutSeconds int `json:"idleTimeoutSeconds"`
+	MaxDurationSeconds int `json:"maxDurationSeconds"`
+	WarnBeforeSeconds  int `json:"warnBeforeSeconds"`
+	MaxPerExercise     int `json:"maxPerExercise"`
+	MaxTotal           int `json:"maxTotal"`
+}
+
+// ListTerminalSessions handles GET /api/admin/terminals
+func ListTerminalSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limits := terminalSessions.Limits()
+	response := TerminalSessionsResponse{
+		Success:  true,
+		Sessions: terminalSessions.List(),
+		Limits: &TerminalLimitsInfo{
+			IdleTimeoutSeconds: int(limits.IdleTimeout.Seconds()),
+			MaxDurationSeconds: int(limits.MaxDuration.Seconds()),
+			WarnBeforeSeconds:  int(limits.WarnBefore.Seconds()),
+			MaxPerExercise:     limits.MaxPerExercise,
+			MaxTotal:           limits.MaxTotal,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// CloseTerminalSession handles DELETE /api/admin/terminals/{sessionId}
+func CloseTerminalSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/admin/terminals/")
+	if id == "" {
+		response := TerminalSessionsResponse{
+			Success:   false,
+			ErrorCode: "INVALID_SESSION_ID",
+			Message:   "Session ID is required",
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	if err := terminalSessions.Close(id, terminal.ReasonAdminClose); err != nil {
+		response := TerminalSessionsResponse{
+			Success: false,
+		}
+
+		if sessErr, ok := err.(*terminal.SessionError); ok {
+			response.ErrorCode = sessErr.Code
+			response.Message = sessErr.Message
+		} else {
+			response.ErrorCode = "UNKNOWN_ERROR"
+			response.Message = err.Error()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	response := TerminalSessionsResponse{
+		Success: true,
+		Message: "Terminal session closed",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/patrickvassell/cks-weight-room/internal/cluster"
+	"github.com/patrickvassell/cks-weight-room/internal/terminal"
+)
+
+// candidateHome is the home directory of the user in the terminal image
+const candidateHome = "/home/cksuser"
+
+// candidateNode is the node name candidate sessions are registered under
+const candidateNode = "candidate"
+
+// candidateSwitchGrace is how long a direct node terminal waits for the
+// exercise's candidate sessions to end, so a client switching exam-style mode
+// off isn't refused while its old sessions are still closing
+const candidateSwitchGrace = 2 * time.Second
+
+// isCandidateMode reports whether the client asked for an exam-style terminal
+// that starts on a base host and reaches nodes only via ssh. The terminal
+// view's "Exam-style SSH" toggle selects it.
+// Route: /api/terminal/{slug}?mode=candidate
+func isCandidateMode(r *http.Request) bool {
+	return r.URL.Query().Get("mode") == candidateNode
+}
+
+// refuseDirectNodeAccess enforces ssh-only node access while exam-style mode
+// is on: a candidate terminal can't also pick a node, and no direct node
+// terminal opens while the exercise has a candidate session. It reports
+// whether the connection was refused.
+func refuseDirectNodeAccess(conn *terminalConn, r *http.Request, slug string) bool {
+	message := ""
+	if isCandidateMode(r) {
+		if r.URL.Query().Get("node") != "" {
+			message = "Exam-style mode starts on the candidate host; reach nodes with ssh instead of ?node="
+		}
+	} else if candidateSessionOpen(r.Context(), slug) {
+		message = "Exam-style SSH is on for this exercise; reach nodes with ssh from the candidate host"
+	}
+	if message == "" {
+		return false
+	}
+
+	log.Printf("Direct node terminal refused for %s: %s", slug, message)
+	conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\033[31m✗ %s\033[0m\r\n", message)))
+	conn.closeWithReason(websocket.ClosePolicyViolation, "exam-style mode")
+	return true
+}
+
+// candidateSessionOpen reports whether the exercise still has a candidate
+// session after the switch grace period
+func candidateSessionOpen(ctx context.Context, slug string) bool {
+	ctx, cancel := context.WithTimeout(ctx, candidateSwitchGrace)
+	defer cancel()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		open := false
+		for _, s := range terminalSessions.List() {
+			if s.Slug == slug && s.Node == candidateNode {
+				open = true
+				break
+			}
+		}
+		if !open {
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return true
+		case <-ticker.C:
+		}
+	}
+}
+
+// prepareCandidateAccess sets up keys, the candidate user and ssh_config for
+// a cluster, reporting progress and failures over the WebSocket
+func prepareCandidateAccess(conn *terminalConn, slug string) (*cluster.CandidateAccess, bool) {
+	conn.WriteMessage(websocket.TextMessage, []byte("Preparing exam-style SSH access to cluster nodes...\r\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+
+	access, err := cluster.PrepareCandidateAccess(ctx, cluster.GetClusterName(slug))
+	if err != nil {
+		log.Printf("Failed to prepare candidate access for %s: %v", slug, err)
+		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\033[31m✗ Failed to prepare SSH access: %v\033[0m\r\n", err)))
+		return nil, false
+	}
+	return access, true
+}
+
+// candidateBanner returns shell lines that greet the user on the candidate host
+func candidateBanner(access *cluster.CandidateAccess) []string {
+	lines := []string{
+		"echo 'Candidate host - reach cluster nodes over ssh, as in the CKS exam:'",
+	}
+	for _, host := range access.Hosts {
+		lines = append(lines, fmt.Sprintf("echo '  ssh %s   (%s)'", host.Alias, host.Role))
+	}
+	lines = append(lines,
+		fmt.Sprintf("echo 'On a node you are %s; use sudo -i for root.'", cluster.CandidateUser),
+		"echo ''",
+	)
+	return lines
+}
+
+// hostCandidateInit returns init commands for a candidate shell on the host,
+// pointing ssh and scp at the generated ssh_config
+func hostCandidateInit(access *cluster.CandidateAccess) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "alias ssh='ssh -F %s'\n", access.ConfigPath)
+	fmt.Fprintf(&b, "alias scp='scp -F %s'\n", access.ConfigPath)
+	for _, line := range candidateBanner(access) {
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// installCandidateSSH copies the private key and an ssh_config into the
+// candidate container's ~/.ssh
+func installCandidateSSH(ctx context.Context, containerID string, access *cluster.CandidateAccess) error {
+	privateKey, err := os.ReadFile(access.PrivateKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	sshDir := candidateHome + "/.ssh"
+	files := []struct {
+		path    string
+		content string
+	}{
+		{sshDir + "/id_ed25519", string(privateKey)},
+		{sshDir + "/config", cluster.RenderSSHConfig(access.Hosts, sshDir+"/id_ed25519")},
+	}
+
+	for _, f := range files {
+		script := fmt.Sprintf("mkdir -p %s && chmod 700 %s && cat > %s && chmod 600 %s", sshDir, sshDir, f.path, f.path)
+		cmd := exec.CommandContext(ctx, "docker", "exec", "-i", containerID, "sh", "-c", script)
+		cmd.Stdin = strings.NewReader(f.content)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to install %s: %w - %s", f.path, err, string(output))
+		}
+	}
+	return nil
+}
+
+// handleCandidateTerminal starts an isolated candidate container (the exam's
+// base host) and attaches the user to it. Nodes are only reachable via ssh.
+func (h *SecureTerminalCLIHandler) handleCandidateTerminal(conn *terminalConn, session *terminal.Session, slug string, examMode *examRealism) {
+	access, ok := prepareCandidateAccess(conn, slug)
+	if !ok {
+		return
+	}
+
+	kubectxContext := "kind-" + cluster.GetClusterName(slug)
+	containerID, err := h.createAndStartContainer(slug, kubectxContext)
+	if err != nil {
+		log.Printf("Failed to start candidate container for %s: %v", slug, err)
+		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\033[31m✗ Failed to start candidate host: %v\033[0m\r\n", err)))
+		return
+	}
+	defer h.cleanupContainer(containerID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	err = installCandidateSSH(ctx, containerID, access)
+	cancel()
+	if err != nil {
+		log.Printf("Failed to install SSH material in candidate container: %v", err)
+		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\033[31m✗ Failed to configure candidate host: %v\033[0m\r\n", err)))
+		return
+	}
+
+	cmd := exec.Command("docker", "exec", "-it", "-e", "TERM=xterm-256color", containerID, "/bin/bash")
+	cmd.Env = os.Environ()
+
+	h.serveShell(conn, session, cmd, h.candidateFilter, examMode, "candidate host", slug, candidateBanner(access))
+}
@@ -0,0 +1,32 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCandidateSessionOpen(t *testing.T) {
+	if candidateSessionOpen(context.Background(), "audit-logs") {
+		t.Fatal("expected no candidate session")
+	}
+
+	session, err := terminalSessions.Register("audit-logs", candidateNode, "secure", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if !candidateSessionOpen(ctx, "audit-logs") {
+		t.Error("expected the open candidate session to block direct node access")
+	}
+	if candidateSessionOpen(context.Background(), "apparmor") {
+		t.Error("expected other exercises to be unaffected")
+	}
+
+	// A session that closes within the grace period doesn't block
+	time.AfterFunc(100*time.Millisecond, func() { terminalSessions.Unregister(session.ID) })
+	if candidateSessionOpen(context.Background(), "audit-logs") {
+		t.Error("expected the closed candidate session to be waited out")
+	}
+}
@@ -1,9 +1,9 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
@@ -15,6 +15,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/patrickvassell/cks-weight-room/internal/cluster"
 	"github.com/patrickvassell/cks-weight-room/internal/security"
+	"github.com/patrickvassell/cks-weight-room/internal/terminal"
 )
 
 const (
@@ -22,11 +23,15 @@ const (
 	maxMemoryCLI       = "512m"
 	maxCPUsCLI         = "1.0"
 	terminalTimeoutCLI = 2 * time.Hour
+
+	// terminalContainerLabel tags per-session containers with their exercise
+	terminalContainerLabel = "cks-weight-room.exercise"
 )
 
 // SecureTerminalCLIHandler manages containerized terminal sessions using Docker CLI
 type SecureTerminalCLIHandler struct {
-	commandFilter *security.CommandFilter
+	commandFilter   *security.CommandFilter
+	candidateFilter *security.CommandFilter // Permits sudo for exam-style node access
 }
 
 // NewSecureTerminalCLIHandler creates a new secure terminal handler using Docker CLI
@@ -44,7 +49,8 @@ func NewSecureTerminalCLIHandler() (*SecureTerminalCLIHandler, error) {
 	}
 
 	return &SecureTerminalCLIHandler{
-		commandFilter: security.NewCommandFilter(),
+		commandFilter:   security.NewCommandFilter(),
+		candidateFilter: security.NewCandidateCommandFilter(),
 	}, nil
 }
 
@@ -61,13 +67,34 @@ func (h *SecureTerminalCLIHandler) HandleSecureTerminalCLI(w http.ResponseWriter
 	nodeName := r.URL.Query().Get("node")
 
 	// Upgrade to WebSocket
-	conn, err := upgrader.Upgrade(w, r, nil)
+	wsConn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade failed: %v", err)
 		return
 	}
+	conn := newTerminalConn(wsConn)
 	defer conn.Close()
 
+	// Optional exam realism (paste restrictions)
+	examMode := newExamRealism(r, slug)
+
+	// Nodes are reached only over ssh while exam-style mode is on
+	if refuseDirectNodeAccess(conn, r, slug) {
+		return
+	}
+
+	// Exam-style mode: start on a candidate host and reach nodes via ssh
+	if isCandidateMode(r) {
+		session, ok := openTerminalSession(conn, r, slug, candidateNode, "secure")
+		if !ok {
+			return
+		}
+		defer terminalSessions.Unregister(session.ID)
+
+		h.handleCandidateTerminal(conn, session, slug, examMode)
+		return
+	}
+
 	// Get cluster context for this exercise
 	clusterName := cluster.GetClusterName(slug)
 
@@ -94,9 +121,16 @@ func (h *SecureTerminalCLIHandler) HandleSecureTerminalCLI(w http.ResponseWriter
 		}
 	}
 
+	// Enforce session limits before opening a shell in the node
+	session, ok := openTerminalSession(conn, r, slug, nodeName, "secure")
+	if !ok {
+		return
+	}
+	defer terminalSessions.Unregister(session.ID)
+
 	// Connect to the specified node (control-plane or worker)
 	log.Printf("Connecting to node: %s", nodeName)
-	h.handleWorkerNodeTerminal(conn, nodeName, slug)
+	h.handleWorkerNodeTerminal(conn, session, nodeName, slug, examMode)
 }
 
 // createAndStartContainer creates and starts a container with security constraints
@@ -125,6 +159,7 @@ func (h *SecureTerminalCLIHandler) createAndStartContainer(slug, kubectxContext
 		"-e", "KUBECONFIG=/tmp/.kube/config",
 		"-e", "KUBECTL_CONTEXT=" + kubectxContext,
 		"-w", "/home/cksuser",
+		"--label", terminalContainerLabel + "=" + slug, // Lets lifecycle hooks find it
 		terminalImageCLI,
 		"sleep", "infinity", // Keep container running
 	}
@@ -159,6 +194,30 @@ func (h *SecureTerminalCLIHandler) cleanupContainer(containerID string) {
 	rmCmd.Run() // Ignore errors, container might already be removed
 }
 
+// removeTerminalContainers force-removes terminal containers for an exercise
+// (or every exercise when slug is empty), returning how many were removed
+func removeTerminalContainers(ctx context.Context, slug string) (int, error) {
+	filter := terminalContainerLabel
+	if slug != "" {
+		filter += "=" + slug
+	}
+	listCmd := exec.CommandContext(ctx, "docker", "ps", "-a", "-q", "--filter", "label="+filter)
+	output, err := listCmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list terminal containers: %w", err)
+	}
+	ids := strings.Fields(string(output))
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	args := append([]string{"rm", "-f"}, ids...)
+	if output, err := exec.CommandContext(ctx, "docker", args...).CombinedOutput(); err != nil {
+		return 0, fmt.Errorf("failed to remove terminal containers: %w - %s", err, string(output))
+	}
+	return len(ids), nil
+}
+
 // checkDockerAvailable checks if Docker is installed and running
 func checkDockerAvailable() error {
 	cmd := exec.Command("docker", "version")
@@ -169,7 +228,7 @@ func checkDockerAvailable() error {
 }
 
 // handleWorkerNodeTerminal connects to a worker node's KIND container directly
-func (h *SecureTerminalCLIHandler) handleWorkerNodeTerminal(conn *websocket.Conn, nodeName, slug string) {
+func (h *SecureTerminalCLIHandler) handleWorkerNodeTerminal(conn *terminalConn, session *terminal.Session, nodeName, slug string, examMode *examRealism) {
 	log.Printf("Attempting to connect to worker node container: %s", nodeName)
 
 	// First check if the container exists
@@ -189,18 +248,22 @@ func (h *SecureTerminalCLIHandler) handleWorkerNodeTerminal(conn *websocket.Conn
 	cmd := exec.Command("docker", "exec", "-it", "-e", "TERM=xterm-256color", nodeName, "/bin/bash")
 	cmd.Env = os.Environ()
 
+	h.serveShell(conn, session, cmd, h.commandFilter, examMode, nodeName, slug, nil)
+}
+
+// serveShell runs cmd under a PTY and pumps it to and from the WebSocket,
+// validating each command line with filter before it reaches the shell.
+// extraInit lines are run after the standard prompt setup. examMode may be nil.
+func (h *SecureTerminalCLIHandler) serveShell(conn *terminalConn, session *terminal.Session, cmd *exec.Cmd, filter *security.CommandFilter, examMode *examRealism, target, slug string, extraInit []string) {
 	// Start the command with a PTY
 	ptmx, err := pty.Start(cmd)
 	if err != nil {
-		log.Printf("Failed to start PTY in node %s: %v", nodeName, err)
-		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("Failed to connect to node %s: %v\r\n", nodeName, err)))
+		log.Printf("Failed to start PTY in %s: %v", target, err)
+		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("Failed to connect to %s: %v\r\n", target, err)))
 		return
 	}
-	log.Printf("Successfully started PTY for node %s", nodeName)
-	defer func() {
-		ptmx.Close()
-		cmd.Process.Kill()
-	}()
+	log.Printf("Successfully started PTY for %s", target)
+	defer stopShell(ptmx, cmd)
 
 	// Set initial terminal size
 	pty.Setsize(ptmx, &pty.Winsize{
@@ -210,24 +273,7 @@ func (h *SecureTerminalCLIHandler) handleWorkerNodeTerminal(conn *websocket.Conn
 
 	// Start copying from PTY to WebSocket BEFORE sending init commands
 	// so we don't miss any output
-	go func() {
-		buf := make([]byte, 1024)
-		for {
-			n, err := ptmx.Read(buf)
-			if err != nil {
-				if err != io.EOF {
-					log.Printf("Error reading from PTY: %v", err)
-				}
-				return
-			}
-			if n > 0 {
-				if err := conn.WriteMessage(websocket.TextMessage, buf[:n]); err != nil {
-					log.Printf("Error writing to WebSocket: %v", err)
-					return
-				}
-			}
-		}
-	}()
+	go conn.pumpOutput(ptmx)
 
 	// Wait for bash to be fully ready
 	time.Sleep(500 * time.Millisecond)
@@ -245,6 +291,11 @@ func (h *SecureTerminalCLIHandler) handleWorkerNodeTerminal(conn *websocket.Conn
 	ptmx.Write([]byte("clear\n"))
 	time.Sleep(100 * time.Millisecond)
 
+	// Mode-specific setup (e.g. the candidate host banner) runs after the clear
+	for _, line := range extraInit {
+		ptmx.Write([]byte(line + "\n"))
+	}
+
 	// Copy from WebSocket to PTY (with command filtering)
 	cmdBuffer := ""
 	for {
@@ -264,8 +315,13 @@ func (h *SecureTerminalCLIHandler) handleWorkerNodeTerminal(conn *websocket.Conn
 
 		switch msg.Type {
 		case "input":
+			session.Touch()
+			if !examMode.admitInput(conn, slug, msg.Data) {
+				continue
+			}
+
 			// Sanitize input
-			sanitized := h.commandFilter.SanitizeInput(msg.Data)
+			sanitized := filter.SanitizeInput(msg.Data)
 
 			// Add to buffer
 			cmdBuffer += sanitized
@@ -278,13 +334,13 @@ func (h *SecureTerminalCLIHandler) handleWorkerNodeTerminal(conn *websocket.Conn
 
 				if cmd != "" {
 					// Validate command (same filtering as secure container)
-					if valid, reason := h.commandFilter.ValidateCommand(cmd); !valid {
+					if valid, reason := filter.ValidateCommand(cmd); !valid {
 						// Send newline to PTY so prompt advances
 						ptmx.Write([]byte("\r\n"))
 						// Show warning to user
 						warningMsg := fmt.Sprintf("\033[31m⚠  Command blocked: %s\033[0m\r\n", reason)
 						conn.WriteMessage(websocket.TextMessage, []byte(warningMsg))
-						log.Printf("Blocked command on node %s for %s: %s (reason: %s)", nodeName, slug, cmd, reason)
+						log.Printf("Blocked command on %s for %s: %s (reason: %s)", target, slug, cmd, reason)
 						continue
 					}
 				}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+	"github.com/patrickvassell/cks-weight-room/internal/exam"
+	"github.com/patrickvassell/cks-weight-room/internal/timer"
+)
+
+// timerTickInterval is how often running timers are pushed to clients
+const timerTickInterval = time.Second
+
+// timerService owns the exam and exercise timers
+var timerService = timer.NewService()
+
+// TimerResponse is the response of the timer endpoints
+type TimerResponse struct {
+	Success bool          `json:"success"`
+	Timer   *timer.Timer  `json:"timer,omitempty"`
+	Timers  []timer.Timer `json:"timers,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// StartTimerRequest starts an exercise timer
+type StartTimerRequest struct {
+	LimitMinutes int `json:"limitMinutes"` // 0 for a stopwatch
+}
+
+// PauseTimerRequest pauses a timer
+type PauseTimerRequest struct {
+	Reason timer.PauseReason `json:"reason"`
+}
+
+// StartTimers registers the timer callbacks and, when the database is
+// ready, restores exams and timers that were running when the app stopped
+func StartTimers() {
+	timerService.OnExpire(timer.KindExercise, exerciseTimeUp)
+
+	if database.DB == nil {
+		log.Printf("Database not initialized, not restoring timers")
+		return
+	}
+	// Exams first, so exams whose deadline passed meanwhile get graded
+	if err := examManager.Recover(); err != nil {
+		log.Printf("Failed to recover mock exams: %v", err)
+	}
+	if err := timerService.Load(); err != nil {
+		log.Printf("Failed to restore timers: %v", err)
+	}
+}
+
+// exerciseTimeUp validates an exercise when its time limit runs out and
+// records the attempt
+func exerciseTimeUp(t timer.Timer) {
+	log.Printf("Time limit reached for %s, validating", t.Subject)
+	result := validateAndRecord(t.Subject)
+	log.Printf("Auto-validated %s: passed=%v", t.Subject, result.Passed)
+}
+
+// HandleTimers routes the timer endpoints:
+//
+//	GET    /api/timers                  - list timers
+//	GET    /api/timers/ws               - WebSocket pushing timer updates
+//	POST   /api/timers/exercise/{slug}  - start an exercise timer
+//	GET    /api/timers/{id}             - get a timer
+//	POST   /api/timers/{id}/pause       - pause with {"reason": ...}
+//	POST   /api/timers/{id}/resume      - resume
+//	DELETE /api/timers/{id}             - discard an exercise timer
+func HandleTimers(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/timers"), "/")
+	parts := strings.Split(path, "/")
+
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		writeTimerResponse(w, http.StatusOK, TimerResponse{Success: true, Timers: timerService.List()})
+
+	case path == "ws":
+		streamTimers(w, r)
+
+	case len(parts) == 2 && parts[0] == "exercise" && r.Method == http.MethodPost:
+		startExerciseTimer(w, r, parts[1])
+
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		t, err := timerService.Get(parts[0])
+		writeTimerResult(w, t, err)
+
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		deleteTimer(w, parts[0])
+
+	case len(parts) == 2 && parts[1] == "pause" && r.Method == http.MethodPost:
+		var req PauseTimerRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeTimerResponse(w, http.StatusBadRequest, TimerResponse{Error: "Invalid request body"})
+			return
+		}
+		t, err := pauseTimer(parts[0], req.Reason)
+		writeTimerResult(w, t, err)
+
+	case len(parts) == 2 && parts[1] == "resume" && r.Method == http.MethodPost:
+		t, err := resumeTimer(parts[0])
+		writeTimerResult(w, t, err)
+
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// startExerciseTimer starts the timer of an exercise, or returns the one
+// already running
+func startExerciseTimer(w http.ResponseWriter, r *http.Request, slug string) {
+	var req StartTimerRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeTimerResponse(w, http.StatusBadRequest, TimerResponse{Error: "Invalid request body"})
+			return
+		}
+	}
+	if req.LimitMinutes < 0 {
+		writeTimerResponse(w, http.StatusBadRequest, TimerResponse{Error: "limitMinutes must not be negative"})
+		return
+	}
+
+	t := timerService.Start(timer.ExerciseTimerID(slug), timer.KindExercise, slug, time.Duration(req.LimitMinutes)*time.Minute)
+	writeTimerResponse(w, http.StatusOK, TimerResponse{Success: true, Timer: &t})
+}
+
+// pauseTimer pauses a timer. Exam timers go through the exam manager so
+// only running exams can be paused.
+func pauseTimer(id string, reason timer.PauseReason) (timer.Timer, error) {
+	if examID, ok := examIDFromTimer(id); ok {
+		if _, err := examManager.Pause(examID, reason); err != nil {
+			return timer.Timer{}, err
+		}
+		return timerService.Get(id)
+	}
+	return timerService.Pause(id, reason)
+}
+
+// resumeTimer resumes a timer, through the exam manager for exam timers
+func resumeTimer(id string) (timer.Timer, error) {
+	if examID, ok := examIDFromTimer(id); ok {
+		if _, err := examManager.Resume(examID); err != nil {
+			return timer.Timer{}, err
+		}
+		return timerService.Get(id)
+	}
+	return timerService.Resume(id)
+}
+
+// deleteTimer discards an exercise timer; exam timers end with their exam
+func deleteTimer(w http.ResponseWriter, id string) {
+	t, err := timerService.Get(id)
+	if err != nil {
+		writeTimerResult(w, t, err)
+		return
+	}
+	if t.Kind != timer.KindExercise {
+		writeTimerResponse(w, http.StatusConflict, TimerResponse{Error: "exam timers end with their exam"})
+		return
+	}
+	timerService.Remove(id)
+	writeTimerResponse(w, http.StatusOK, TimerResponse{Success: true})
+}
+
+// examIDFromTimer returns the exam an exam timer belongs to
+func examIDFromTimer(id string) (int64, bool) {
+	t, err := timerService.Get(id)
+	if err != nil || t.Kind != timer.KindExam {
+		return 0, false
+	}
+	examID, err := strconv.ParseInt(t.Subject, 10, 64)
+	return examID, err == nil
+}
+
+// streamTimers pushes every timer change, plus the running timers once a
+// second, so all clients count down from the same server state
+func streamTimers(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade timer connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	updates, unsubscribe := timerService.Subscribe()
+	defer unsubscribe()
+
+	// Clients only listen; reading detects when they go away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(t timer.Timer) bool {
+		conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		return conn.WriteJSON(t) == nil
+	}
+
+	for _, t := range timerService.List() {
+		if !send(t) {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(timerTickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case t, ok := <-updates:
+			if !ok || !send(t) {
+				return
+			}
+		case <-ticker.C:
+			for _, t := range timerService.List() {
+				if t.State == timer.StateRunning && !send(t) {
+					return
+				}
+			}
+		case <-closed:
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			return
+		}
+	}
+}
+
+// writeTimerResult writes a timer, or maps a timer or exam error to a status
+func writeTimerResult(w http.ResponseWriter, t timer.Timer, err error) {
+	if err == nil {
+		writeTimerResponse(w, http.StatusOK, TimerResponse{Success: true, Timer: &t})
+		return
+	}
+
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, timer.ErrNotFound), errors.Is(err, exam.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, timer.ErrPauseNotAllowed):
+		status = http.StatusBadRequest
+	case errors.Is(err, timer.ErrNotRunning), errors.Is(err, timer.ErrNotPaused), errors.Is(err, timer.ErrPauseLimit),
+		errors.Is(err, exam.ErrNotInProgress):
+		status = http.StatusConflict
+	}
+	writeTimerResponse(w, status, TimerResponse{Error: err.Error()})
+}
+
+// writeTimerResponse writes a timer response as JSON
+func writeTimerResponse(w http.ResponseWriter, status int, response TimerResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"os/exec"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/patrickvassell/cks-weight-room/internal/cluster"
 	"github.com/patrickvassell/cks-weight-room/internal/database"
+	"github.com/patrickvassell/cks-weight-room/internal/timer"
 )
 
 // ValidationResult represents the result of a solution validation
@@ -18,6 +20,14 @@ type ValidationResult struct {
 	Score    int      `json:"score"`
 	Feedback string   `json:"feedback"`
 	Details  []string `json:"details,omitempty"`
+
+	// AttemptID identifies the saved attempt, e.g. for GET /api/workspace/{slug}?attempt=ID
+	AttemptID int64 `json:"attemptId,omitempty"`
+
+	// HintsUsed counts the hints revealed during the attempt; HintPenalty is
+	// the points already taken off Score for them
+	HintsUsed   int `json:"hintsUsed"`
+	HintPenalty int `json:"hintPenalty"`
 }
 
 // ValidationRequest represents a validation request
@@ -47,6 +57,16 @@ func ValidateSolution(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	result := validateAndRecord(slug)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// validateAndRecord validates an exercise on its cluster and saves the
+// attempt. When the exercise's timer is running, the attempt takes its start
+// and elapsed time, and a pass stops the timer.
+func validateAndRecord(slug string) ValidationResult {
 	// Get cluster name
 	clusterName := cluster.GetClusterName(slug)
 	kubectxContext := "kind-" + clusterName
@@ -54,38 +74,72 @@ func ValidateSolution(w http.ResponseWriter, r *http.Request) {
 	// Run validation checks based on exercise
 	result := validateExercise(slug, kubectxContext)
 
+	// Record what changed on the nodes since exercise setup
+	workspaceDiff := captureWorkspaceDiff(clusterName)
+
+	// Without a timer, assume a 30 second attempt as before
+	completedAt := time.Now()
+	startedAt := completedAt.Add(-30 * time.Second)
+	duration := 30
+	timerID := timer.ExerciseTimerID(slug)
+	if t, err := timerService.Get(timerID); err == nil {
+		startedAt = t.StartedAt
+		duration = t.ElapsedSeconds
+		if result.Passed || t.State == timer.StateExpired {
+			timerService.Remove(timerID)
+		}
+	}
+
 	// Save attempt to database
 	if database.DB != nil {
 		// Get exercise info for max score
 		var exerciseID int
 		var maxScore int
 		err := database.DB.QueryRow("SELECT id, points FROM exercises WHERE slug = ?", slug).Scan(&exerciseID, &maxScore)
+		var profileID int64
 		if err == nil {
+			// The attempt goes to the active profile
+			profileID, err = database.ActiveProfileID()
+		}
+		if err == nil {
+			// Hints revealed since the last validation belong to this attempt
+			result.HintsUsed, _ = database.CountOpenHintReveals(exerciseID)
+			if percent, _ := database.GetHintPenaltyPercent(); percent > 0 {
+				result.HintPenalty = hintPenalty(result.Score, result.HintsUsed, percent)
+				result.Score -= result.HintPenalty
+			}
+
 			// Save attempt
-			_, err = database.DB.Exec(`
-				INSERT INTO attempts (exercise_id, started_at, completed_at, duration_seconds, score, max_score, passed, feedback, details)
-				VALUES (?, datetime('now', '-30 seconds'), datetime('now'), 30, ?, ?, ?, ?, ?)
-			`, exerciseID, result.Score, maxScore, result.Passed, result.Feedback, mustMarshalJSON(result.Details))
+			var res sql.Result
+			res, err = database.DB.Exec(`
+				INSERT INTO attempts (profile_id, exercise_id, started_at, completed_at, duration_seconds, score, max_score, passed, feedback, details, workspace_diff, hints_used, hint_penalty)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			`, profileID, exerciseID, startedAt.UTC().Format("2006-01-02 15:04:05"), completedAt.UTC().Format("2006-01-02 15:04:05"), duration,
+				result.Score, maxScore, result.Passed, result.Feedback, mustMarshalJSON(result.Details), workspaceDiff,
+				result.HintsUsed, result.HintPenalty)
+			if err == nil {
+				result.AttemptID, _ = res.LastInsertId()
+				database.ClaimHintReveals(exerciseID, result.AttemptID)
+			}
 
 			// Update progress table personal best if passed and better than previous
 			if err == nil && result.Passed {
 				database.DB.Exec(`
-					INSERT INTO progress (exercise_id, status, completed_at, attempts, time_spent_seconds, personal_best_seconds)
-					VALUES (?, 'completed', datetime('now'), 1, 30, 30)
-					ON CONFLICT(exercise_id) DO UPDATE SET
+					INSERT INTO progress (profile_id, exercise_id, status, completed_at, attempts, time_spent_seconds, personal_best_seconds)
+					VALUES (?, ?, 'completed', datetime('now'), 1, ?, ?)
+					ON CONFLICT(profile_id, exercise_id) DO UPDATE SET
 						status = 'completed',
 						completed_at = datetime('now'),
 						attempts = attempts + 1,
-						time_spent_seconds = time_spent_seconds + 30,
-						personal_best_seconds = MIN(COALESCE(personal_best_seconds, 999999), 30),
+						time_spent_seconds = time_spent_seconds + excluded.time_spent_seconds,
+						personal_best_seconds = MIN(COALESCE(personal_best_seconds, 999999), excluded.personal_best_seconds),
 						updated_at = datetime('now')
-				`, exerciseID)
+				`, profileID, exerciseID, duration, duration)
 			}
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	return result
 }
 
 // mustMarshalJSON marshals data to JSON, returning empty string on error
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/cluster"
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+	"github.com/patrickvassell/cks-weight-room/internal/workspace"
+)
+
+// workspaceDiffTimeout bounds how long reading files from all nodes may take
+const workspaceDiffTimeout = 30 * time.Second
+
+// WorkspaceDiffResponse represents per-node diffs against the exercise baseline
+type WorkspaceDiffResponse struct {
+	Success   bool                 `json:"success"`
+	AttemptID int64                `json:"attemptId,omitempty"` // Set when the diffs were stored with an attempt
+	Nodes     []workspace.NodeDiff `json:"nodes,omitempty"`
+	Error     string               `json:"error,omitempty"`
+}
+
+// HandleWorkspaceDiff handles GET /api/workspace/{exerciseSlug}
+// Without parameters it diffs the live nodes against the baseline captured
+// after exercise setup; with ?attempt=ID it returns the diffs stored with
+// that attempt.
+func HandleWorkspaceDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	slug := r.URL.Path[len("/api/workspace/"):]
+	if slug == "" {
+		writeWorkspaceResponse(w, http.StatusBadRequest, WorkspaceDiffResponse{Error: "exerciseSlug is required"})
+		return
+	}
+
+	if attemptParam := r.URL.Query().Get("attempt"); attemptParam != "" {
+		attemptID, err := strconv.ParseInt(attemptParam, 10, 64)
+		if err != nil {
+			writeWorkspaceResponse(w, http.StatusBadRequest, WorkspaceDiffResponse{Error: "attempt must be a number"})
+			return
+		}
+		nodes, status, err := storedWorkspaceDiff(slug, attemptID)
+		if err != nil {
+			writeWorkspaceResponse(w, status, WorkspaceDiffResponse{Error: err.Error()})
+			return
+		}
+		writeWorkspaceResponse(w, http.StatusOK, WorkspaceDiffResponse{Success: true, AttemptID: attemptID, Nodes: nodes})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), workspaceDiffTimeout)
+	defer cancel()
+
+	nodes, err := cluster.DiffWorkspace(ctx, cluster.GetClusterName(slug))
+	if err != nil {
+		writeWorkspaceResponse(w, http.StatusNotFound, WorkspaceDiffResponse{Error: err.Error()})
+		return
+	}
+	writeWorkspaceResponse(w, http.StatusOK, WorkspaceDiffResponse{Success: true, Nodes: nodes})
+}
+
+// storedWorkspaceDiff loads the diffs saved with one of the active
+// profile's attempts of the exercise
+func storedWorkspaceDiff(slug string, attemptID int64) ([]workspace.NodeDiff, int, error) {
+	if database.DB == nil {
+		return nil, http.StatusInternalServerError, errors.New("database not initialized")
+	}
+	profileID, err := database.ActiveProfileID()
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	var stored sql.NullString
+	err = database.DB.QueryRow(`
+		SELECT a.workspace_diff
+		FROM attempts a
+		JOIN exercises e ON a.exercise_id = e.id
+		WHERE a.id = ? AND e.slug = ? AND a.profile_id = ?
+	`, attemptID, slug, profileID).Scan(&stored)
+	if err == sql.ErrNoRows {
+		return nil, http.StatusNotFound, fmt.Errorf("attempt %d not found for %s", attemptID, slug)
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	nodes := []workspace.NodeDiff{}
+	if stored.Valid && stored.String != "" {
+		if err := json.Unmarshal([]byte(stored.String), &nodes); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+	}
+	return nodes, http.StatusOK, nil
+}
+
+// captureWorkspaceDiff diffs the exercise nodes for storage with an attempt.
+// It returns NULL when the cluster has no baseline or the diff fails, so
+// validation never depends on it.
+func captureWorkspaceDiff(clusterName string) sql.NullString {
+	ctx, cancel := context.WithTimeout(context.Background(), workspaceDiffTimeout)
+	defer cancel()
+
+	nodes, err := cluster.DiffWorkspace(ctx, clusterName)
+	if err != nil {
+		log.Printf("Skipping workspace diff for %s: %v", clusterName, err)
+		return sql.NullString{}
+	}
+	data, err := json.Marshal(nodes)
+	if err != nil {
+		log.Printf("Failed to encode workspace diff for %s: %v", clusterName, err)
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(data), Valid: true}
+}
+
+// writeWorkspaceResponse writes a workspace diff response as JSON
+func writeWorkspaceResponse(w http.ResponseWriter, status int, response WorkspaceDiffResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
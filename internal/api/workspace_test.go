@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+func TestStoredWorkspaceDiffIsProfileScoped(t *testing.T) {
+	setupImportDB(t)
+	_, err := database.DB.Exec(`
+		INSERT INTO attempts (id, exercise_id, started_at, completed_at, duration_seconds, score, max_score, passed, workspace_diff)
+		VALUES (1, 7, '2026-03-01 10:00:00', '2026-03-01 10:10:00', 600, 10, 10, 1, '[{"node":"cks-audit-logs-control-plane"}]')
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert attempt: %v", err)
+	}
+
+	if nodes, status, err := storedWorkspaceDiff("audit-logs", 1); err != nil || status != http.StatusOK || len(nodes) != 1 {
+		t.Fatalf("expected the owner to read the diff, got %d nodes, status %d (%v)", len(nodes), status, err)
+	}
+
+	other, err := database.CreateProfile("Bob", "")
+	if err != nil {
+		t.Fatalf("CreateProfile failed: %v", err)
+	}
+	if err := database.ActivateProfile(other.Slug); err != nil {
+		t.Fatalf("ActivateProfile failed: %v", err)
+	}
+	if _, status, err := storedWorkspaceDiff("audit-logs", 1); err == nil || status != http.StatusNotFound {
+		t.Errorf("expected another profile's attempt to be hidden, got status %d (%v)", status, err)
+	}
+}
@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/patrickvassell/cks-weight-room/internal/logger"
+)
+
+const (
+	// CookieName is the cookie carrying the session token for the embedded frontend
+	CookieName = "cks_session"
+
+	// HeaderName lets non-browser clients (CLI, scripts) present the token
+	HeaderName = "X-CKS-Session"
+)
+
+// Guard protects the local HTTP server with a per-launch secret and strict
+// Host/Origin checks. Browsers receive the secret as an HttpOnly SameSite
+// cookie when loading the embedded frontend; other clients read it from the
+// token file and send it in the X-CKS-Session header.
+type Guard struct {
+	token        string
+	allowedHosts map[string]bool
+}
+
+// NewGuard creates a guard for a server bound to the given port, generating a
+// fresh random token
+func NewGuard(port string) (*Guard, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate session token: %w", err)
+	}
+
+	return &Guard{
+		token:        hex.EncodeToString(buf),
+		allowedHosts: allowedHostsForPort(port),
+	}, nil
+}
+
+// allowedHostsForPort lists the Host header values a loopback server accepts.
+// Anything else (e.g. an attacker's domain rebound to 127.0.0.1) is rejected.
+func allowedHostsForPort(port string) map[string]bool {
+	return map[string]bool{
+		"127.0.0.1:" + port: true,
+		"localhost:" + port: true,
+		"[::1]:" + port:     true,
+	}
+}
+
+// Token returns the session token
+func (g *Guard) Token() string {
+	return g.token
+}
+
+// GetTokenPath returns the default location of the token file
+func GetTokenPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "./data/session-token"
+	}
+	return filepath.Join(home, ".cks-weight-room", "session-token")
+}
+
+// WriteTokenFile stores the token with owner-only permissions so local
+// tooling can authenticate against the running server
+func (g *Guard) WriteTokenFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create token directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(g.token+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	return nil
+}
+
+// ReadTokenFile reads a token written by a running server
+func ReadTokenFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Wrap returns a handler that enforces Host, Origin and token checks before
+// delegating to next
+func (g *Guard) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !g.allowedHosts[strings.ToLower(r.Host)] {
+			logger.Warn("Rejected request with unexpected Host header %q from %s", r.Host, r.RemoteAddr)
+			http.Error(w, "Invalid Host header", http.StatusMisdirectedRequest)
+			return
+		}
+
+		if !g.originAllowed(r) {
+			logger.Warn("Rejected cross-origin request from %q to %s", r.Header.Get("Origin"), r.URL.Path)
+			http.Error(w, "Cross-origin request rejected", http.StatusForbidden)
+			return
+		}
+
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			// Loading the frontend issues the cookie the frontend will use
+			g.issueCookie(w)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !g.hasValidToken(r) {
+			http.Error(w, "Missing or invalid session token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// originAllowed requires same-origin for any request that carries an Origin
+// header, and requires an Origin for WebSocket upgrades
+func (g *Guard) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return !isWebSocketUpgrade(r)
+	}
+	return g.sameOrigin(origin)
+}
+
+// sameOrigin checks an Origin header against the allowed loopback hosts
+func (g *Guard) sameOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme != "http" {
+		return false
+	}
+	return g.allowedHosts[strings.ToLower(u.Host)]
+}
+
+// hasValidToken checks the cookie, header or bearer token in constant time
+func (g *Guard) hasValidToken(r *http.Request) bool {
+	candidates := []string{r.Header.Get(HeaderName)}
+	if cookie, err := r.Cookie(CookieName); err == nil {
+		candidates = append(candidates, cookie.Value)
+	}
+	if bearer := r.Header.Get("Authorization"); strings.HasPrefix(bearer, "Bearer ") {
+		candidates = append(candidates, strings.TrimPrefix(bearer, "Bearer "))
+	}
+
+	for _, candidate := range candidates {
+		if candidate != "" && subtle.ConstantTimeCompare([]byte(candidate), []byte(g.token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// issueCookie sets the session cookie on a frontend response
+func (g *Guard) issueCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    g.token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// isWebSocketUpgrade reports whether r is a WebSocket handshake
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
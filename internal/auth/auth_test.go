@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func newTestGuard(t *testing.T) (*Guard, http.Handler) {
+	t.Helper()
+	guard, err := NewGuard("3000")
+	if err != nil {
+		t.Fatalf("NewGuard failed: %v", err)
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return guard, guard.Wrap(ok)
+}
+
+func TestGuardRequests(t *testing.T) {
+	guard, handler := newTestGuard(t)
+
+	tests := []struct {
+		name           string
+		path           string
+		host           string
+		origin         string
+		token          string
+		cookie         bool
+		websocket      bool
+		expectedStatus int
+	}{
+		{
+			name:           "frontend loads without token",
+			path:           "/",
+			host:           "127.0.0.1:3000",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "API without token is rejected",
+			path:           "/api/exercises",
+			host:           "127.0.0.1:3000",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "API with header token succeeds",
+			path:           "/api/exercises",
+			host:           "localhost:3000",
+			token:          guard.Token(),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "API with cookie succeeds",
+			path:           "/api/exercises",
+			host:           "127.0.0.1:3000",
+			origin:         "http://127.0.0.1:3000",
+			cookie:         true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "API with wrong token is rejected",
+			path:           "/api/exercises",
+			host:           "127.0.0.1:3000",
+			token:          "not-the-token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "rebound hostname is rejected",
+			path:           "/",
+			host:           "attacker.example:3000",
+			expectedStatus: http.StatusMisdirectedRequest,
+		},
+		{
+			name:           "cross-origin request is rejected",
+			path:           "/api/exercises",
+			host:           "127.0.0.1:3000",
+			origin:         "http://attacker.example",
+			cookie:         true,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "WebSocket without Origin is rejected",
+			path:           "/api/terminal/demo",
+			host:           "127.0.0.1:3000",
+			cookie:         true,
+			websocket:      true,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "same-origin WebSocket with cookie is allowed",
+			path:           "/api/terminal/demo",
+			host:           "127.0.0.1:3000",
+			origin:         "http://127.0.0.1:3000",
+			cookie:         true,
+			websocket:      true,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.token != "" {
+				req.Header.Set(HeaderName, tt.token)
+			}
+			if tt.cookie {
+				req.AddCookie(&http.Cookie{Name: CookieName, Value: guard.Token()})
+			}
+			if tt.websocket {
+				req.Header.Set("Upgrade", "websocket")
+				req.Header.Set("Connection", "Upgrade")
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestFrontendIssuesCookie(t *testing.T) {
+	guard, handler := newTestGuard(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "127.0.0.1:3000"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CookieName || cookies[0].Value != guard.Token() {
+		t.Fatalf("Expected session cookie to be issued, got %v", cookies)
+	}
+	if !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteStrictMode {
+		t.Error("Session cookie must be HttpOnly and SameSite=Strict")
+	}
+}
+
+func TestTokenFileRoundTrip(t *testing.T) {
+	guard, _ := newTestGuard(t)
+	path := filepath.Join(t.TempDir(), "session-token")
+
+	if err := guard.WriteTokenFile(path); err != nil {
+		t.Fatalf("WriteTokenFile failed: %v", err)
+	}
+	token, err := ReadTokenFile(path)
+	if err != nil {
+		t.Fatalf("ReadTokenFile failed: %v", err)
+	}
+	if token != guard.Token() {
+		t.Error("Token read from file does not match")
+	}
+}
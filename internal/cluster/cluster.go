@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/patrickvassell/cks-weight-room/internal/logger"
+	"github.com/patrickvassell/cks-weight-room/internal/workspace"
 )
 
 // ClusterStatus represents the current state of a cluster
@@ -112,9 +113,19 @@ func ClusterExists(ctx context.Context, clusterName string) (bool, error) {
 	return false, nil
 }
 
+// DefaultSSHBasePort is the host port mapped to the control plane's sshd;
+// workers get the following ports
+const DefaultSSHBasePort = 2200
+
 // ProvisionCluster creates a new KIND cluster for an exercise
 // This is a simplified version - in production would use KIND's Go API
 func ProvisionCluster(ctx context.Context, exerciseSlug string, progressChan chan<- string) (*Cluster, error) {
+	return ProvisionClusterWithSSHPorts(ctx, exerciseSlug, DefaultSSHBasePort, progressChan)
+}
+
+// ProvisionClusterWithSSHPorts is ProvisionCluster with the node sshd ports
+// mapped from sshBasePort upwards, so several clusters can run side by side
+func ProvisionClusterWithSSHPorts(ctx context.Context, exerciseSlug string, sshBasePort int, progressChan chan<- string) (*Cluster, error) {
 	clusterName := GetClusterName(exerciseSlug)
 	logger.Info("Starting cluster provisioning for exercise: %s (cluster: %s)", exerciseSlug, clusterName)
 
@@ -177,26 +188,26 @@ func ProvisionCluster(ctx context.Context, exerciseSlug string, progressChan cha
 	)
 
 	// KIND cluster config matching CKS exam environment with custom node names
-	kindConfig := `kind: Cluster
+	kindConfig := fmt.Sprintf(`kind: Cluster
 apiVersion: kind.x-k8s.io/v1alpha4
 nodes:
 - role: control-plane
   # Custom node name for easier identification (exam-realistic)
   extraPortMappings:
   - containerPort: 22
-    hostPort: 2200
+    hostPort: %d
     protocol: TCP
 - role: worker
   extraPortMappings:
   - containerPort: 22
-    hostPort: 2201
+    hostPort: %d
     protocol: TCP
 - role: worker
   extraPortMappings:
   - containerPort: 22
-    hostPort: 2202
+    hostPort: %d
     protocol: TCP
-`
+`, sshBasePort, sshBasePort+1, sshBasePort+2)
 	cmd.Stdin = strings.NewReader(kindConfig)
 
 	output, err := cmd.CombinedOutput()
@@ -254,6 +265,12 @@ nodes:
 		progressChan <- "Code-server installation complete!"
 	}
 
+	// Set up exam-style candidate SSH access (keys, sudo user, ssh_config)
+	if _, err := PrepareCandidateAccess(ctx, clusterName); err != nil {
+		logger.Warn("Failed to prepare candidate SSH access: %v", err)
+		// Candidate terminals retry this lazily, so don't fail provisioning
+	}
+
 	// Run exercise-specific setup
 	if progressChan != nil {
 		progressChan <- "Setting up exercise environment..."
@@ -265,6 +282,12 @@ nodes:
 		logger.Info("Exercise environment setup complete")
 	}
 
+	// Snapshot the watched paths so edits can be diffed at validation time
+	if err := CaptureWorkspaceBaseline(ctx, clusterName); err != nil {
+		logger.Warn("Failed to capture workspace baseline: %v", err)
+		// Diffs are informational, so don't fail provisioning
+	}
+
 	if progressChan != nil {
 		progressChan <- "Exercise setup complete!"
 	}
@@ -289,6 +312,13 @@ func DeleteCluster(ctx context.Context, clusterName string) error {
 		}
 	}
 	logger.Info("Successfully deleted cluster: %s", clusterName)
+
+	if err := RemoveSSHMaterial(clusterName); err != nil {
+		logger.Warn("Failed to remove SSH material for %s: %v", clusterName, err)
+	}
+	if err := workspace.RemoveBaseline(clusterName); err != nil {
+		logger.Warn("Failed to remove workspace baseline for %s: %v", clusterName, err)
+	}
 	return nil
 }
 
@@ -0,0 +1,112 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/patrickvassell/cks-weight-room/internal/logger"
+)
+
+// CreateContext adds a kubectl context named name that points at a KIND
+// cluster, like the per-task contexts of the real exam. An existing context
+// with the same name is overwritten.
+func CreateContext(ctx context.Context, name, clusterName, namespace string) error {
+	kindName := fmt.Sprintf("kind-%s", clusterName)
+	args := []string{"config", "set-context", name,
+		"--cluster", kindName,
+		"--user", kindName,
+	}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to create context %s: %w - %s", name, err, strings.TrimSpace(string(output)))
+	}
+	logger.Debug("Created kubectl context %s -> %s", name, kindName)
+	return nil
+}
+
+// DeleteContext removes a kubectl context. A missing context is not an error.
+func DeleteContext(ctx context.Context, name string) error {
+	cmd := exec.CommandContext(ctx, "kubectl", "config", "delete-context", name)
+	output, err := cmd.CombinedOutput()
+	if err != nil && !strings.Contains(string(output), "not in") {
+		return fmt.Errorf("failed to delete context %s: %w - %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// WriteMergedKubeconfig writes a standalone kubeconfig at path holding one
+// context per entry of contexts (context name -> KIND cluster name). No
+// context is selected, so like the exam every task starts with
+// kubectl config use-context.
+func WriteMergedKubeconfig(ctx context.Context, path string, contexts map[string]string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create kubeconfig directory: %w", err)
+	}
+	partsDir, err := os.MkdirTemp(dir, "parts-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(partsDir)
+
+	names := make([]string, 0, len(contexts))
+	for name := range contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// One file per context, renamed from kind-<cluster> to the exam name
+	var parts []string
+	for i, name := range names {
+		clusterName := contexts[name]
+		part := filepath.Join(partsDir, fmt.Sprintf("%d.yaml", i))
+
+		output, err := exec.CommandContext(ctx, "kind", "get", "kubeconfig", "--name", clusterName).Output()
+		if err != nil {
+			return fmt.Errorf("failed to get kubeconfig for %s: %w", clusterName, err)
+		}
+		if err := os.WriteFile(part, output, 0600); err != nil {
+			return fmt.Errorf("failed to write kubeconfig for %s: %w", clusterName, err)
+		}
+		if err := kubectlConfig(ctx, part, "rename-context", "kind-"+clusterName, name); err != nil {
+			return err
+		}
+		parts = append(parts, part)
+	}
+
+	cmd := exec.CommandContext(ctx, "kubectl", "config", "view", "--flatten")
+	cmd.Env = append(os.Environ(), "KUBECONFIG="+strings.Join(parts, string(os.PathListSeparator)))
+	merged, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to merge kubeconfigs: %w", err)
+	}
+	if err := os.WriteFile(path, merged, 0600); err != nil {
+		return fmt.Errorf("failed to write merged kubeconfig: %w", err)
+	}
+	if err := kubectlConfig(ctx, path, "unset", "current-context"); err != nil {
+		return err
+	}
+
+	logger.Info("Wrote kubeconfig with %d contexts to %s", len(names), path)
+	return nil
+}
+
+// kubectlConfig runs a kubectl config subcommand against one kubeconfig file
+func kubectlConfig(ctx context.Context, kubeconfig string, args ...string) error {
+	args = append([]string{"config", "--kubeconfig", kubeconfig}, args...)
+	output, err := exec.CommandContext(ctx, "kubectl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("kubectl %s failed: %w - %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
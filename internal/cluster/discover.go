@@ -0,0 +1,126 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// kindClusterLabel is set by KIND on every node container
+const kindClusterLabel = "io.x-k8s.kind.cluster"
+
+// RunningNode is a KIND node container found on the Docker host
+type RunningNode struct {
+	Name    string
+	Cluster string
+}
+
+// ExerciseSlug returns the exercise a node belongs to, or "" for clusters
+// this app didn't create
+func (n RunningNode) ExerciseSlug() string {
+	if !strings.HasPrefix(n.Cluster, "cks-") {
+		return ""
+	}
+	return strings.TrimPrefix(n.Cluster, "cks-")
+}
+
+// ListRunningNodes returns every running KIND node container
+func ListRunningNodes(ctx context.Context) ([]RunningNode, error) {
+	cmd := exec.CommandContext(ctx, "docker", "ps",
+		"--filter", "label="+kindClusterLabel,
+		"--format", fmt.Sprintf(`{{.Names}} {{.Label %q}}`, kindClusterLabel))
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list KIND nodes: %w", err)
+	}
+
+	var nodes []RunningNode
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		nodes = append(nodes, RunningNode{Name: fields[0], Cluster: fields[1]})
+	}
+	return nodes, nil
+}
+
+// codeServerBindPattern extracts the port from a code-server command line
+var codeServerBindPattern = regexp.MustCompile(`--bind-addr[ =][^\s:]*:(\d+)`)
+
+// FindCodeServerPorts returns the ports of code-server instances running in a node
+func FindCodeServerPorts(ctx context.Context, nodeName string) ([]int, error) {
+	cmd := exec.CommandContext(ctx, "docker", "exec", nodeName, "pgrep", "-af", "code-server")
+	output, err := cmd.Output()
+	if err != nil {
+		// pgrep exits 1 when nothing matches
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list code-server processes in %s: %w", nodeName, err)
+	}
+	return parseCodeServerPorts(string(output)), nil
+}
+
+// parseCodeServerPorts reads `pgrep -af` output and returns each distinct
+// bind port. code-server forks a worker with the same arguments, so a
+// single instance can appear more than once.
+func parseCodeServerPorts(output string) []int {
+	seen := make(map[int]bool)
+	var ports []int
+	for _, line := range strings.Split(output, "\n") {
+		m := codeServerBindPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		port, err := strconv.Atoi(m[1])
+		if err != nil || seen[port] {
+			continue
+		}
+		seen[port] = true
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// PortInUse reports whether something is listening on port inside a node
+func PortInUse(ctx context.Context, nodeName string, port int) bool {
+	probe := fmt.Sprintf("exec 3<>/dev/tcp/127.0.0.1/%d", port)
+	cmd := exec.CommandContext(ctx, "docker", "exec", nodeName, "bash", "-c", probe)
+	return cmd.Run() == nil
+}
+
+// StopCodeServer kills the code-server instance bound to port inside a node
+func StopCodeServer(ctx context.Context, nodeName string, port int) error {
+	killCmd := fmt.Sprintf("pkill -f 'code-server.*:%d'", port)
+	return exec.CommandContext(ctx, "docker", "exec", nodeName, "sh", "-c", killCmd).Run()
+}
+
+// StopSocatProxies kills socat port forwarders left in a node by older
+// versions of the IDE proxy
+func StopSocatProxies(ctx context.Context, nodeName string) error {
+	return exec.CommandContext(ctx, "docker", "exec", nodeName, "sh", "-c", "pkill -f 'socat TCP-LISTEN' || true").Run()
+}
+
+// RemoveLegacyIDEProxies removes the ide-proxy-* socat containers older
+// versions started on the host, returning how many were removed
+func RemoveLegacyIDEProxies(ctx context.Context) (int, error) {
+	listCmd := exec.CommandContext(ctx, "docker", "ps", "-a", "-q", "--filter", "name=ide-proxy-")
+	output, err := listCmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list IDE proxy containers: %w", err)
+	}
+	ids := strings.Fields(string(output))
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	args := append([]string{"rm", "-f"}, ids...)
+	if output, err := exec.CommandContext(ctx, "docker", args...).CombinedOutput(); err != nil {
+		return 0, fmt.Errorf("failed to remove IDE proxy containers: %w - %s", err, string(output))
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,35 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCodeServerPorts(t *testing.T) {
+	output := `412 /usr/lib/code-server/lib/node /usr/lib/code-server --bind-addr 0.0.0.0:8081 --auth none --disable-telemetry /root
+430 /usr/lib/code-server/lib/node /usr/lib/code-server/out/node/entry --bind-addr 0.0.0.0:8081 --auth none --disable-telemetry /root
+501 /usr/lib/code-server/lib/node /usr/lib/code-server --bind-addr=127.0.0.1:8083 /root
+612 pgrep -af code-server
+`
+	got := parseCodeServerPorts(output)
+	if !reflect.DeepEqual(got, []int{8081, 8083}) {
+		t.Errorf("parseCodeServerPorts = %v, expected [8081 8083]", got)
+	}
+}
+
+func TestRunningNodeExerciseSlug(t *testing.T) {
+	tests := []struct {
+		cluster  string
+		expected string
+	}{
+		{"cks-falco-basics", "falco-basics"},
+		{"kind", ""},
+	}
+
+	for _, tt := range tests {
+		node := RunningNode{Name: tt.cluster + "-control-plane", Cluster: tt.cluster}
+		if got := node.ExerciseSlug(); got != tt.expected {
+			t.Errorf("ExerciseSlug(%s) = %q, expected %q", tt.cluster, got, tt.expected)
+		}
+	}
+}
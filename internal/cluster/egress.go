@@ -0,0 +1,223 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/patrickvassell/cks-weight-room/internal/logger"
+)
+
+// EgressPolicy restricts outbound traffic from cluster nodes to an allowlist
+type EgressPolicy struct {
+	Domains     []string // Domains nodes may reach (docs sites, image registries)
+	Subnets     []string // Cluster ranges nodes may reach; defaults to the kind network, pod and service subnets
+	DocsDomains []string // Subset of Domains redirected to the mirror when offline
+	MirrorHost  string   // Docs mirror address as seen from nodes (IP or hostname)
+	MirrorPort  int      // Docs mirror port; 0 disables the offline fallback
+}
+
+// EgressStatus reports what was applied to each node
+type EgressStatus struct {
+	Node        string `json:"node"`
+	Restricted  bool   `json:"restricted"`
+	UsingMirror bool   `json:"usingMirror"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Marker used to find and remove the mirror's /etc/hosts entries
+const mirrorHostsMarker = "# cks-docs-mirror"
+
+// Pod and service ranges of KIND clusters, which keep kind's defaults
+const (
+	kindPodSubnet     = "10.244.0.0/16"
+	kindServiceSubnet = "10.96.0.0/12"
+)
+
+// kindNetworkIPv4 returns the IPv4 values of an IPAM field (Gateway, Subnet)
+// of the kind Docker network
+func kindNetworkIPv4(ctx context.Context, field string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "docker", "network", "inspect", "kind",
+		"-f", "{{range .IPAM.Config}}{{."+field+"}} {{end}}")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect kind network: %w", err)
+	}
+
+	// Skip the IPv6 entries of a dual-stack network
+	var values []string
+	for _, v := range strings.Fields(string(output)) {
+		if !strings.Contains(v, ":") {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("kind network has no IPv4 %s", strings.ToLower(field))
+	}
+	return values, nil
+}
+
+// GetKindGateway returns the gateway IP of the kind Docker network, which is
+// the host as seen from KIND nodes on Linux
+func GetKindGateway(ctx context.Context) (string, error) {
+	gateways, err := kindNetworkIPv4(ctx, "Gateway")
+	if err != nil {
+		return "", err
+	}
+	return gateways[0], nil
+}
+
+// GetClusterSubnets returns the ranges cluster traffic uses: the kind Docker
+// network that nodes share, and the pod and service subnets
+func GetClusterSubnets(ctx context.Context) ([]string, error) {
+	subnets, err := kindNetworkIPv4(ctx, "Subnet")
+	if err != nil {
+		return nil, err
+	}
+	return append(subnets, kindPodSubnet, kindServiceSubnet), nil
+}
+
+// nodeIsOnline checks whether a node can reach a public site over HTTPS
+func nodeIsOnline(ctx context.Context, nodeName, domain string) bool {
+	cmd := exec.CommandContext(ctx, "docker", "exec", nodeName,
+		"curl", "-sS", "-o", "/dev/null", "--max-time", "5", "https://"+domain)
+	return cmd.Run() == nil
+}
+
+// ApplyEgressPolicy restricts outbound connections from every node in the
+// cluster. When a node is offline and a mirror is configured, the docs
+// domains are pointed at the mirror instead. Domains are resolved once, so
+// the allowlist goes stale when a site moves to new addresses; applying the
+// policy again resolves them afresh.
+func ApplyEgressPolicy(ctx context.Context, clusterName string, policy EgressPolicy) ([]EgressStatus, error) {
+	nodes, err := GetClusterNodes(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(policy.Subnets) == 0 {
+		if policy.Subnets, err = GetClusterSubnets(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var statuses []EgressStatus
+	for _, node := range nodes {
+		status := EgressStatus{Node: node.Name}
+
+		useMirror := false
+		if policy.MirrorPort > 0 && len(policy.DocsDomains) > 0 {
+			useMirror = !nodeIsOnline(ctx, node.Name, policy.DocsDomains[0])
+		}
+
+		script := renderEgressScript(policy, useMirror)
+		cmd := exec.CommandContext(ctx, "docker", "exec", "-i", node.Name, "bash", "-s")
+		cmd.Stdin = strings.NewReader(script)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			logger.Warn("Failed to restrict egress on %s: %v - %s", node.Name, err, string(output))
+			status.Error = fmt.Sprintf("%v: %s", err, strings.TrimSpace(string(output)))
+		} else {
+			status.Restricted = true
+			status.UsingMirror = useMirror
+			logger.Info("Restricted egress on %s (mirror: %v)", node.Name, useMirror)
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// RemoveEgressPolicy lifts the restrictions applied by ApplyEgressPolicy
+func RemoveEgressPolicy(ctx context.Context, clusterName string) error {
+	nodes, err := GetClusterNodes(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+
+	script := renderRemoveEgressScript()
+
+	var failed []string
+	for _, node := range nodes {
+		cmd := exec.CommandContext(ctx, "docker", "exec", "-i", node.Name, "bash", "-s")
+		cmd.Stdin = strings.NewReader(script)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			logger.Warn("Failed to lift egress restriction on %s: %v - %s", node.Name, err, string(output))
+			failed = append(failed, node.Name)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to lift egress restriction on: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// renderRemoveEgressScript builds the script that undoes renderEgressScript.
+// Every step tolerates a missing chain or entry, so it can run on nodes that
+// were never restricted or were already restored.
+func renderRemoveEgressScript() string {
+	return fmt.Sprintf(`
+iptables -D OUTPUT -j CKS-EGRESS 2>/dev/null
+iptables -F CKS-EGRESS 2>/dev/null
+iptables -X CKS-EGRESS 2>/dev/null
+iptables -t nat -D OUTPUT -j CKS-MIRROR 2>/dev/null
+iptables -t nat -F CKS-MIRROR 2>/dev/null
+iptables -t nat -X CKS-MIRROR 2>/dev/null
+grep -v '%[1]s' /etc/hosts > /tmp/hosts.cks && cat /tmp/hosts.cks > /etc/hosts
+rm -f /tmp/hosts.cks
+true
+`, mirrorHostsMarker)
+}
+
+// renderEgressScript builds the iptables script run on each node. Cluster
+// traffic (policy.Subnets), DNS and established connections are always
+// allowed; other destinations, including the host's LAN, must resolve from an
+// allowed domain. Domains resolve to their addresses at the time the script
+// runs.
+func renderEgressScript(policy EgressPolicy, useMirror bool) string {
+	var b strings.Builder
+	b.WriteString("set -e\n")
+	b.WriteString("iptables -N CKS-EGRESS 2>/dev/null || iptables -F CKS-EGRESS\n")
+	b.WriteString("iptables -C OUTPUT -j CKS-EGRESS 2>/dev/null || iptables -I OUTPUT 1 -j CKS-EGRESS\n")
+	b.WriteString("iptables -A CKS-EGRESS -o lo -j RETURN\n")
+	b.WriteString("iptables -A CKS-EGRESS -m conntrack --ctstate ESTABLISHED,RELATED -j RETURN\n")
+	for _, cidr := range policy.Subnets {
+		fmt.Fprintf(&b, "iptables -A CKS-EGRESS -d %s -j RETURN\n", cidr)
+	}
+	b.WriteString("iptables -A CKS-EGRESS -p udp --dport 53 -j RETURN\n")
+	b.WriteString("iptables -A CKS-EGRESS -p tcp --dport 53 -j RETURN\n")
+
+	// Clear mirror entries from a previous run before resolving domains
+	fmt.Fprintf(&b, "grep -v '%s' /etc/hosts > /tmp/hosts.cks && cat /tmp/hosts.cks > /etc/hosts; rm -f /tmp/hosts.cks\n", mirrorHostsMarker)
+
+	b.WriteString("for domain in")
+	for _, d := range policy.Domains {
+		b.WriteString(" " + d)
+	}
+	b.WriteString("; do\n")
+	b.WriteString("  for ip in $(getent ahostsv4 \"$domain\" 2>/dev/null | awk '{print $1}' | sort -u); do\n")
+	b.WriteString("    iptables -A CKS-EGRESS -d \"$ip\" -j RETURN\n")
+	b.WriteString("  done\n")
+	b.WriteString("done\n")
+
+	if useMirror {
+		// The mirror may sit outside the cluster subnets (Docker Desktop)
+		fmt.Fprintf(&b, "MIRROR_IP=$(getent ahostsv4 %s | awk 'NR==1{print $1}')\n", policy.MirrorHost)
+		b.WriteString("[ -n \"$MIRROR_IP\" ] || MIRROR_IP=" + policy.MirrorHost + "\n")
+		fmt.Fprintf(&b, "iptables -A CKS-EGRESS -d \"$MIRROR_IP\" -p tcp --dport %d -j RETURN\n", policy.MirrorPort)
+	}
+	b.WriteString("iptables -A CKS-EGRESS -j REJECT\n")
+
+	if useMirror {
+		// Point the docs domains at the mirror and send port 80 to its port
+		for _, d := range policy.DocsDomains {
+			fmt.Fprintf(&b, "echo \"$MIRROR_IP %s %s\" >> /etc/hosts\n", d, mirrorHostsMarker)
+		}
+		b.WriteString("iptables -t nat -N CKS-MIRROR 2>/dev/null || iptables -t nat -F CKS-MIRROR\n")
+		b.WriteString("iptables -t nat -C OUTPUT -j CKS-MIRROR 2>/dev/null || iptables -t nat -I OUTPUT 1 -j CKS-MIRROR\n")
+		fmt.Fprintf(&b, "iptables -t nat -A CKS-MIRROR -d \"$MIRROR_IP\" -p tcp --dport 80 -j DNAT --to-destination \"$MIRROR_IP:%d\"\n", policy.MirrorPort)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package cluster
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderEgressScript(t *testing.T) {
+	policy := EgressPolicy{
+		Domains:     []string{"kubernetes.io", "github.com"},
+		Subnets:     []string{"172.18.0.0/16", kindPodSubnet, kindServiceSubnet},
+		DocsDomains: []string{"kubernetes.io"},
+		MirrorHost:  "172.18.0.1",
+		MirrorPort:  8473,
+	}
+
+	tests := []struct {
+		name      string
+		useMirror bool
+		expected  []string
+		absent    []string
+	}{
+		{
+			name:      "online",
+			useMirror: false,
+			expected: []string{
+				"for domain in kubernetes.io github.com; do\n",
+				"iptables -A CKS-EGRESS -p udp --dport 53 -j RETURN\n",
+				"iptables -A CKS-EGRESS -p tcp --dport 53 -j RETURN\n",
+				"iptables -A CKS-EGRESS -d 172.18.0.0/16 -j RETURN\n",
+				"iptables -A CKS-EGRESS -d 10.244.0.0/16 -j RETURN\n",
+				"iptables -A CKS-EGRESS -d 10.96.0.0/12 -j RETURN\n",
+				"grep -v '" + mirrorHostsMarker + "' /etc/hosts",
+			},
+			absent: []string{"CKS-MIRROR", "172.18.0.1", "10.0.0.0/8", "192.168.0.0/16"},
+		},
+		{
+			name:      "offline with mirror",
+			useMirror: true,
+			expected: []string{
+				"for domain in kubernetes.io github.com; do\n",
+				"iptables -A CKS-EGRESS -p udp --dport 53 -j RETURN\n",
+				"echo \"$MIRROR_IP kubernetes.io " + mirrorHostsMarker + "\" >> /etc/hosts\n",
+				"[ -n \"$MIRROR_IP\" ] || MIRROR_IP=172.18.0.1\n",
+				"iptables -A CKS-EGRESS -d \"$MIRROR_IP\" -p tcp --dport 8473 -j RETURN\n",
+				"--dport 80 -j DNAT --to-destination \"$MIRROR_IP:8473\"\n",
+			},
+			absent: []string{"github.com " + mirrorHostsMarker},
+		},
+	}
+
+	for _, tt := range tests {
+		script := renderEgressScript(policy, tt.useMirror)
+
+		for _, expected := range tt.expected {
+			if !strings.Contains(script, expected) {
+				t.Errorf("%s: script missing %q:\n%s", tt.name, expected, script)
+			}
+		}
+		for _, absent := range tt.absent {
+			if strings.Contains(script, absent) {
+				t.Errorf("%s: script should not contain %q:\n%s", tt.name, absent, script)
+			}
+		}
+
+		// Chains are reused rather than duplicated when the script runs again
+		for _, expected := range []string{
+			"iptables -N CKS-EGRESS 2>/dev/null || iptables -F CKS-EGRESS\n",
+			"iptables -C OUTPUT -j CKS-EGRESS 2>/dev/null || iptables -I OUTPUT 1 -j CKS-EGRESS\n",
+		} {
+			if !strings.Contains(script, expected) {
+				t.Errorf("%s: script missing %q", tt.name, expected)
+			}
+		}
+
+		// Everything not allowed above is rejected, as the last filter rule
+		last := ""
+		for _, line := range strings.Split(script, "\n") {
+			if line = strings.TrimSpace(line); strings.HasPrefix(line, "iptables -A CKS-EGRESS") {
+				last = line
+			}
+		}
+		if last != "iptables -A CKS-EGRESS -j REJECT" {
+			t.Errorf("%s: last egress rule is %q, expected the default reject", tt.name, last)
+		}
+	}
+}
+
+func TestRenderRemoveEgressScript(t *testing.T) {
+	script := renderRemoveEgressScript()
+
+	if strings.Contains(script, "set -e") {
+		t.Error("removal script must not stop at the first missing chain")
+	}
+	if !strings.HasSuffix(strings.TrimSpace(script), "true") {
+		t.Error("removal script should always succeed so it can run twice")
+	}
+
+	// Each iptables step must tolerate the chain already being gone
+	for _, line := range strings.Split(strings.TrimSpace(script), "\n") {
+		if strings.HasPrefix(line, "iptables") && !strings.HasSuffix(line, "2>/dev/null") {
+			t.Errorf("removal step %q fails when the chain is missing", line)
+		}
+	}
+	for _, chain := range []string{"iptables -X CKS-EGRESS", "iptables -t nat -X CKS-MIRROR", "grep -v '" + mirrorHostsMarker + "'"} {
+		if !strings.Contains(script, chain) {
+			t.Errorf("removal script missing %q", chain)
+		}
+	}
+}
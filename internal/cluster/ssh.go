@@ -0,0 +1,212 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/patrickvassell/cks-weight-room/internal/logger"
+)
+
+// CandidateUser is the unprivileged account used for exam-style SSH access.
+// Like the real CKS exam, candidates land on nodes as this user and escalate
+// with sudo -i when they need root.
+const CandidateUser = "candidate"
+
+// SSHHost describes how to reach one node over SSH from the candidate host
+type SSHHost struct {
+	Alias    string `json:"alias"`    // Name used with ssh, e.g. cks-node1
+	NodeName string `json:"nodeName"` // KIND node container name
+	Role     string `json:"role"`
+	Port     int    `json:"port"` // Host port mapped to the node's sshd
+}
+
+// CandidateAccess holds everything a candidate terminal needs to ssh into nodes
+type CandidateAccess struct {
+	Dir            string    `json:"dir"` // Directory holding the key pair and ssh_config
+	PrivateKeyPath string    `json:"privateKeyPath"`
+	PublicKeyPath  string    `json:"publicKeyPath"`
+	ConfigPath     string    `json:"configPath"`
+	Hosts          []SSHHost `json:"hosts"`
+}
+
+// GetSSHDir returns the directory holding SSH material for a cluster
+func GetSSHDir(clusterName string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", "data", "ssh", clusterName)
+	}
+	return filepath.Join(home, ".cks-weight-room", "ssh", clusterName)
+}
+
+// SSHAlias returns the exam-style host alias for a node
+func SSHAlias(node Node, workerIndex int) string {
+	if node.Role == "control-plane" {
+		return "cks-controlplane"
+	}
+	return fmt.Sprintf("cks-node%d", workerIndex)
+}
+
+// EnsureSSHKeys generates an ed25519 key pair for the cluster if one doesn't exist
+func EnsureSSHKeys(ctx context.Context, clusterName string) (privateKey, publicKey string, err error) {
+	dir := GetSSHDir(clusterName)
+	privateKey = filepath.Join(dir, "id_ed25519")
+	publicKey = privateKey + ".pub"
+
+	if _, err := os.Stat(privateKey); err == nil {
+		if _, err := os.Stat(publicKey); err == nil {
+			return privateKey, publicKey, nil
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", fmt.Errorf("failed to create SSH directory: %w", err)
+	}
+	os.Remove(privateKey)
+	os.Remove(publicKey)
+
+	logger.Info("Generating SSH key pair for cluster %s", clusterName)
+	cmd := exec.CommandContext(ctx, "ssh-keygen",
+		"-t", "ed25519",
+		"-N", "",
+		"-C", fmt.Sprintf("%s@%s", CandidateUser, clusterName),
+		"-f", privateKey,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", "", fmt.Errorf("failed to generate SSH key: %w - %s", err, string(output))
+	}
+
+	return privateKey, publicKey, nil
+}
+
+// InstallCandidateUserInNode creates the candidate account on a node with
+// passwordless sudo and authorizes the cluster's public key. It is idempotent.
+func InstallCandidateUserInNode(ctx context.Context, nodeName, publicKey string) error {
+	logger.Info("Configuring candidate SSH access in node: %s", nodeName)
+
+	script := fmt.Sprintf(`
+set -e
+command -v sudo >/dev/null 2>&1 || (apt-get update -qq && apt-get install -y -qq sudo)
+id %[1]s >/dev/null 2>&1 || useradd -m -s /bin/bash %[1]s
+echo '%[1]s ALL=(ALL) NOPASSWD:ALL' > /etc/sudoers.d/%[1]s
+chmod 440 /etc/sudoers.d/%[1]s
+install -d -m 700 -o %[1]s -g %[1]s /home/%[1]s/.ssh
+cat > /home/%[1]s/.ssh/authorized_keys
+chown %[1]s:%[1]s /home/%[1]s/.ssh/authorized_keys
+chmod 600 /home/%[1]s/.ssh/authorized_keys
+pgrep -x sshd >/dev/null 2>&1 || service ssh start || /usr/sbin/sshd
+`, CandidateUser)
+
+	cmd := exec.CommandContext(ctx, "docker", "exec", "-i", nodeName, "bash", "-c", script)
+	cmd.Stdin = strings.NewReader(publicKey)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to configure candidate user: %w - %s", err, string(output))
+	}
+	return nil
+}
+
+// GetNodeSSHPort returns the host port mapped to a node's sshd
+func GetNodeSSHPort(ctx context.Context, nodeName string) (int, error) {
+	cmd := exec.CommandContext(ctx, "docker", "port", nodeName, "22/tcp")
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get SSH port for %s: %w", nodeName, err)
+	}
+
+	// Output looks like "0.0.0.0:2200" and may list an IPv6 binding too
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		idx := strings.LastIndex(line, ":")
+		if idx == -1 {
+			continue
+		}
+		if port, err := strconv.Atoi(strings.TrimSpace(line[idx+1:])); err == nil {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no SSH port mapping found for %s", nodeName)
+}
+
+// RenderSSHConfig builds an ssh_config that maps exam-style aliases to nodes
+func RenderSSHConfig(hosts []SSHHost, identityFile string) string {
+	var b strings.Builder
+	b.WriteString("# Generated by CKS Weight Room - exam-style node access\n")
+	for _, host := range hosts {
+		fmt.Fprintf(&b, "\nHost %s\n", host.Alias)
+		b.WriteString("    HostName 127.0.0.1\n")
+		fmt.Fprintf(&b, "    Port %d\n", host.Port)
+		fmt.Fprintf(&b, "    User %s\n", CandidateUser)
+		fmt.Fprintf(&b, "    IdentityFile %s\n", identityFile)
+		b.WriteString("    IdentitiesOnly yes\n")
+		b.WriteString("    StrictHostKeyChecking no\n")
+		b.WriteString("    UserKnownHostsFile /dev/null\n")
+		b.WriteString("    LogLevel ERROR\n")
+	}
+	return b.String()
+}
+
+// PrepareCandidateAccess makes sure every node in the cluster accepts the
+// candidate key and writes an ssh_config for reaching them by alias
+func PrepareCandidateAccess(ctx context.Context, clusterName string) (*CandidateAccess, error) {
+	privateKey, publicKeyPath, err := EnsureSSHKeys(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := os.ReadFile(publicKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key: %w", err)
+	}
+
+	nodes, err := GetClusterNodes(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	var hosts []SSHHost
+	workerIndex := 0
+	for _, node := range nodes {
+		if node.Role != "control-plane" {
+			workerIndex++
+		}
+
+		if err := InstallCandidateUserInNode(ctx, node.Name, string(publicKey)); err != nil {
+			return nil, err
+		}
+
+		port, err := GetNodeSSHPort(ctx, node.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		hosts = append(hosts, SSHHost{
+			Alias:    SSHAlias(node, workerIndex),
+			NodeName: node.Name,
+			Role:     node.Role,
+			Port:     port,
+		})
+	}
+
+	dir := GetSSHDir(clusterName)
+	configPath := filepath.Join(dir, "config")
+	if err := os.WriteFile(configPath, []byte(RenderSSHConfig(hosts, privateKey)), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write ssh_config: %w", err)
+	}
+
+	logger.Info("Candidate SSH access ready for cluster %s (%d nodes)", clusterName, len(hosts))
+	return &CandidateAccess{
+		Dir:            dir,
+		PrivateKeyPath: privateKey,
+		PublicKeyPath:  publicKeyPath,
+		ConfigPath:     configPath,
+		Hosts:          hosts,
+	}, nil
+}
+
+// RemoveSSHMaterial deletes the generated keys and config for a cluster
+func RemoveSSHMaterial(clusterName string) error {
+	return os.RemoveAll(GetSSHDir(clusterName))
+}
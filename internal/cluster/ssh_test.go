@@ -0,0 +1,46 @@
+package cluster
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSSHAlias(t *testing.T) {
+	tests := []struct {
+		node        Node
+		workerIndex int
+		expected    string
+	}{
+		{Node{Name: "cks-demo-control-plane", Role: "control-plane"}, 0, "cks-controlplane"},
+		{Node{Name: "cks-demo-worker", Role: "worker"}, 1, "cks-node1"},
+		{Node{Name: "cks-demo-worker2", Role: "worker"}, 2, "cks-node2"},
+	}
+
+	for _, tt := range tests {
+		if got := SSHAlias(tt.node, tt.workerIndex); got != tt.expected {
+			t.Errorf("SSHAlias(%s) = %s, expected %s", tt.node.Name, got, tt.expected)
+		}
+	}
+}
+
+func TestRenderSSHConfig(t *testing.T) {
+	hosts := []SSHHost{
+		{Alias: "cks-controlplane", NodeName: "cks-demo-control-plane", Role: "control-plane", Port: 2200},
+		{Alias: "cks-node1", NodeName: "cks-demo-worker", Role: "worker", Port: 2201},
+	}
+
+	config := RenderSSHConfig(hosts, "/home/cksuser/.ssh/id_ed25519")
+
+	for _, expected := range []string{
+		"Host cks-controlplane\n",
+		"Port 2200\n",
+		"Host cks-node1\n",
+		"Port 2201\n",
+		"User " + CandidateUser + "\n",
+		"IdentityFile /home/cksuser/.ssh/id_ed25519\n",
+	} {
+		if !strings.Contains(config, expected) {
+			t.Errorf("ssh_config missing %q:\n%s", expected, config)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/logger"
+)
+
+// NodeTunnel opens TCP connections to ports inside a KIND node without
+// publishing them on the host. On Linux the node's address on the kind
+// network is routable from the host and is dialed directly; elsewhere (Docker
+// Desktop) each connection is carried over a `docker exec` stream.
+type NodeTunnel struct {
+	NodeName string
+	NodeIP   string
+	direct   bool
+}
+
+// directProbeTimeout bounds the reachability check for the node's IP
+const directProbeTimeout = time.Second
+
+// tunnelCommand builds the command that carries one exec-backed connection;
+// tests replace it to run the relay script locally
+var tunnelCommand = func(nodeName, script string) *exec.Cmd {
+	return exec.Command("docker", "exec", "-i", nodeName, "bash", "-c", script)
+}
+
+// GetNodeIP returns a node container's address on the kind network
+func GetNodeIP(ctx context.Context, nodeName string) (string, error) {
+	cmd := exec.CommandContext(ctx, "docker", "inspect", "-f",
+		"{{range .NetworkSettings.Networks}}{{.IPAddress}} {{end}}", nodeName)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect node %s: %w", nodeName, err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("node %s has no IP address", nodeName)
+	}
+	return fields[0], nil
+}
+
+// NewNodeTunnel prepares a tunnel to nodeName, probing port to decide whether
+// the node can be dialed directly
+func NewNodeTunnel(ctx context.Context, nodeName string, port int) (*NodeTunnel, error) {
+	ip, err := GetNodeIP(ctx, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &NodeTunnel{NodeName: nodeName, NodeIP: ip}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, fmt.Sprint(port)), directProbeTimeout)
+	if err == nil {
+		conn.Close()
+		t.direct = true
+	}
+	logger.Debug("Tunnel to %s (%s): direct=%v", nodeName, ip, t.direct)
+	return t, nil
+}
+
+// Direct reports whether connections go straight to the node's IP
+func (t *NodeTunnel) Direct() bool {
+	return t.direct
+}
+
+// DialContext opens a connection to port inside the node
+func (t *NodeTunnel) DialContext(ctx context.Context, port int) (net.Conn, error) {
+	if t.direct {
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", net.JoinHostPort(t.NodeIP, fmt.Sprint(port)))
+	}
+	return dialExec(ctx, t.NodeName, port)
+}
+
+// relayScript connects to a port inside the node with bash's /dev/tcp and
+// relays it over stdin/stdout. Whichever direction ends first tears down
+// the other, so both sides see the connection close. (A polling loop is
+// used because `wait -n` misses jobs that exit before it is called.)
+func relayScript(port int) string {
+	return fmt.Sprintf(`exec 3<>/dev/tcp/127.0.0.1/%d || exit 1
+exec 4<&0
+cat <&3 & reader=$!
+cat <&4 >&3 & writer=$!
+while kill -0 $reader 2>/dev/null && kill -0 $writer 2>/dev/null; do sleep 0.2; done
+kill $reader $writer 2>/dev/null
+`, port)
+}
+
+// dialExec starts a relay inside the node and wraps its stdio as a net.Conn
+func dialExec(ctx context.Context, nodeName string, port int) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cmd := tunnelCommand(nodeName, relayScript(port))
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start tunnel to %s:%d: %w", nodeName, port, err)
+	}
+
+	return &execConn{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: stdout,
+		local:  tunnelAddr("local"),
+		remote: tunnelAddr(fmt.Sprintf("%s:%d", nodeName, port)),
+	}, nil
+}
+
+// tunnelAddr names the ends of an exec-backed connection
+type tunnelAddr string
+
+func (a tunnelAddr) Network() string { return "docker-exec" }
+func (a tunnelAddr) String() string  { return string(a) }
+
+// execConn is a net.Conn over the stdio of a relay process. Deadlines are
+// not supported; callers rely on Close to unblock reads and writes.
+type execConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	local  net.Addr
+	remote net.Addr
+
+	closeOnce sync.Once
+}
+
+func (c *execConn) Read(p []byte) (int, error)  { return c.stdout.Read(p) }
+func (c *execConn) Write(p []byte) (int, error) { return c.stdin.Write(p) }
+func (c *execConn) LocalAddr() net.Addr         { return c.local }
+func (c *execConn) RemoteAddr() net.Addr        { return c.remote }
+
+func (c *execConn) SetDeadline(t time.Time) error      { return nil }
+func (c *execConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *execConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// Close ends the relay and reaps the process
+func (c *execConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		if c.cmd.Process != nil {
+			c.cmd.Process.Kill()
+		}
+		go c.cmd.Wait()
+	})
+	return nil
+}
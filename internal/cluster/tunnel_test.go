@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"testing"
+)
+
+// useLocalRelay runs the relay script on the host instead of inside a node
+func useLocalRelay(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	orig := tunnelCommand
+	tunnelCommand = func(nodeName, script string) *exec.Cmd {
+		return exec.Command("bash", "-c", script)
+	}
+	t.Cleanup(func() { tunnelCommand = orig })
+}
+
+func serverPort(t *testing.T, srv *httptest.Server) int {
+	t.Helper()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return port
+}
+
+func TestExecTunnelCarriesHTTP(t *testing.T) {
+	useLocalRelay(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello from "+r.URL.Path)
+	}))
+	defer srv.Close()
+	port := serverPort(t, srv)
+
+	tunnel := &NodeTunnel{NodeName: "cks-test-control-plane"}
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return tunnel.DialContext(ctx, port)
+		},
+	}}
+
+	// Several requests exercise both fresh and reused connections
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get("http://code-server/workspace")
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "hello from /workspace" {
+			t.Errorf("request %d: unexpected body %q", i, body)
+		}
+	}
+}
+
+func TestExecTunnelSeesRemoteClose(t *testing.T) {
+	useLocalRelay(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("bye"))
+		conn.Close()
+	}()
+
+	conn, err := dialExec(context.Background(), "node", listener.Addr().(*net.TCPAddr).Port)
+	if err != nil {
+		t.Fatalf("dialExec failed: %v", err)
+	}
+	defer conn.Close()
+
+	data, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if string(data) != "bye" {
+		t.Errorf("expected %q, got %q", "bye", data)
+	}
+}
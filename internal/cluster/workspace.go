@@ -0,0 +1,44 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/patrickvassell/cks-weight-room/internal/workspace"
+)
+
+// CaptureWorkspaceBaseline snapshots the watched paths on every node of a
+// freshly set up exercise cluster
+func CaptureWorkspaceBaseline(ctx context.Context, clusterName string) error {
+	names, err := nodeNames(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	return workspace.CaptureBaseline(ctx, clusterName, names)
+}
+
+// DiffWorkspace returns per-node diffs of the watched paths against the
+// baseline captured after exercise setup
+func DiffWorkspace(ctx context.Context, clusterName string) ([]workspace.NodeDiff, error) {
+	if !workspace.HasBaseline(clusterName) {
+		return nil, fmt.Errorf("no workspace baseline for cluster %s", clusterName)
+	}
+	names, err := nodeNames(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return workspace.DiffCluster(ctx, clusterName, names), nil
+}
+
+// nodeNames lists the node container names of a cluster
+func nodeNames(ctx context.Context, clusterName string) ([]string, error) {
+	nodes, err := GetClusterNodes(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	return names, nil
+}
@@ -0,0 +1,313 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MaxBookmarkTags is how many tags a bookmark may have
+const MaxBookmarkTags = 20
+
+// Error codes of the bookmark functions
+const (
+	ErrCodeBookmarkNotFound = "BOOKMARK_NOT_FOUND"
+	ErrCodeInvalidBookmark  = "INVALID_BOOKMARK"
+)
+
+// bookmarkTagPattern is what a tag may look like once normalised
+var bookmarkTagPattern = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N}_.-]{0,31}$`)
+
+// Bookmark is an exercise the active profile saved for later
+type Bookmark struct {
+	ID            int64    `json:"id"`
+	ExerciseID    int      `json:"exerciseId"`
+	ExerciseSlug  string   `json:"exerciseSlug"`
+	ExerciseTitle string   `json:"exerciseTitle"`
+	Domain        string   `json:"domain"`
+	Difficulty    string   `json:"difficulty"`
+	Tags          []string `json:"tags"`
+	HasNote       bool     `json:"hasNote"`
+	CreatedAt     string   `json:"createdAt"`
+}
+
+// BookmarkTag is a tag with the number of bookmarks carrying it
+type BookmarkTag struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// NormalizeBookmarkTags lower-cases and trims tags, turns spaces into
+// dashes, drops duplicates and sorts them
+func NormalizeBookmarkTags(tags []string) ([]string, error) {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if !bookmarkTagPattern.MatchString(tag) {
+			return nil, &DatabaseError{
+				Code:    ErrCodeInvalidBookmark,
+				Message: fmt.Sprintf("Invalid tag %q: use up to 32 letters, digits, dots, dashes and underscores", tag),
+			}
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > MaxBookmarkTags {
+		return nil, &DatabaseError{
+			Code:    ErrCodeInvalidBookmark,
+			Message: fmt.Sprintf("A bookmark can have at most %d tags", MaxBookmarkTags),
+		}
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// ListBookmarks returns the active profile's bookmarks, newest first, only
+// those tagged tag when it isn't empty
+func ListBookmarks(tag string) ([]Bookmark, error) {
+	if DB == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	profileID, err := ActiveProfileID()
+	if err != nil {
+		return nil, err
+	}
+	where := "b.profile_id = ?"
+	args := []any{profileID}
+	if tag != "" {
+		where += " AND b.id IN (SELECT bookmark_id FROM bookmark_tags WHERE tag = ?)"
+		args = append(args, strings.ToLower(strings.TrimSpace(tag)))
+	}
+	return queryBookmarks(where, args...)
+}
+
+// GetBookmark returns the active profile's bookmark of an exercise
+func GetBookmark(slug string) (*Bookmark, error) {
+	if DB == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	if _, err := lookupExercise(DB, slug); err != nil {
+		return nil, err
+	}
+	profileID, err := ActiveProfileID()
+	if err != nil {
+		return nil, err
+	}
+	bookmarks, err := queryBookmarks("b.profile_id = ? AND e.slug = ?", profileID, slug)
+	if err != nil {
+		return nil, err
+	}
+	if len(bookmarks) == 0 {
+		return nil, &DatabaseError{
+			Code:    ErrCodeBookmarkNotFound,
+			Message: fmt.Sprintf("%s isn't bookmarked", slug),
+		}
+	}
+	return &bookmarks[0], nil
+}
+
+func queryBookmarks(where string, args ...any) ([]Bookmark, error) {
+	rows, err := DB.Query(`
+		SELECT b.id, e.id, e.slug, e.title, e.category, e.difficulty, b.created_at,
+			COALESCE((SELECT group_concat(tag, char(31)) FROM (SELECT tag FROM bookmark_tags WHERE bookmark_id = b.id ORDER BY tag)), ''),
+			EXISTS (SELECT 1 FROM progress p WHERE p.profile_id = b.profile_id AND p.exercise_id = e.id AND p.notes IS NOT NULL AND p.notes != '')
+		FROM bookmarks b
+		JOIN exercises e ON e.id = b.exercise_id
+		WHERE `+where+`
+		ORDER BY b.created_at DESC, b.id DESC
+	`, args...)
+	if err != nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to list bookmarks",
+			Err:     err,
+		}
+	}
+	defer rows.Close()
+
+	bookmarks := []Bookmark{}
+	for rows.Next() {
+		var b Bookmark
+		var createdAt sql.NullString
+		var tags string
+		if err := rows.Scan(&b.ID, &b.ExerciseID, &b.ExerciseSlug, &b.ExerciseTitle, &b.Domain, &b.Difficulty, &createdAt, &tags, &b.HasNote); err != nil {
+			return nil, &DatabaseError{
+				Code:    ErrCodeQueryFailed,
+				Message: "Failed to read bookmark",
+				Err:     err,
+			}
+		}
+		b.CreatedAt = createdAt.String
+		b.Tags = []string{}
+		if tags != "" {
+			b.Tags = strings.Split(tags, "\x1f")
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks, rows.Err()
+}
+
+// SaveBookmark bookmarks an exercise for the active profile, or replaces the
+// tags of an existing bookmark
+func SaveBookmark(slug string, tags []string) (*Bookmark, error) {
+	if DB == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	tags, err := NormalizeBookmarkTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	exerciseID, err := lookupExercise(DB, slug)
+	if err != nil {
+		return nil, err
+	}
+	profileID, err := ActiveProfileID()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to start transaction",
+			Err:     err,
+		}
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(`
+		INSERT INTO bookmarks (profile_id, exercise_id) VALUES (?, ?)
+		ON CONFLICT(profile_id, exercise_id) DO UPDATE SET exercise_id = excluded.exercise_id
+		RETURNING id
+	`, profileID, exerciseID).Scan(&id)
+	if err != nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to save bookmark",
+			Err:     err,
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM bookmark_tags WHERE bookmark_id = ?", id); err != nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to replace bookmark tags",
+			Err:     err,
+		}
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT INTO bookmark_tags (bookmark_id, tag) VALUES (?, ?)", id, tag); err != nil {
+			return nil, &DatabaseError{
+				Code:    ErrCodeQueryFailed,
+				Message: "Failed to save bookmark tag",
+				Err:     err,
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to commit bookmark",
+			Err:     err,
+		}
+	}
+	return GetBookmark(slug)
+}
+
+// DeleteBookmark removes the active profile's bookmark of an exercise
+func DeleteBookmark(slug string) error {
+	if DB == nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	exerciseID, err := lookupExercise(DB, slug)
+	if err != nil {
+		return err
+	}
+	profileID, err := ActiveProfileID()
+	if err != nil {
+		return err
+	}
+
+	res, err := DB.Exec("DELETE FROM bookmarks WHERE profile_id = ? AND exercise_id = ?", profileID, exerciseID)
+	if err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to delete bookmark",
+			Err:     err,
+		}
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return &DatabaseError{
+			Code:    ErrCodeBookmarkNotFound,
+			Message: fmt.Sprintf("%s isn't bookmarked", slug),
+		}
+	}
+	return nil
+}
+
+// ListBookmarkTags returns the tags the active profile uses, most used first
+func ListBookmarkTags() ([]BookmarkTag, error) {
+	if DB == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	profileID, err := ActiveProfileID()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := DB.Query(`
+		SELECT t.tag, COUNT(*) FROM bookmark_tags t
+		JOIN bookmarks b ON b.id = t.bookmark_id
+		WHERE b.profile_id = ?
+		GROUP BY t.tag
+		ORDER BY COUNT(*) DESC, t.tag
+	`, profileID)
+	if err != nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to list tags",
+			Err:     err,
+		}
+	}
+	defer rows.Close()
+
+	tags := []BookmarkTag{}
+	for rows.Next() {
+		var t BookmarkTag
+		if err := rows.Scan(&t.Tag, &t.Count); err != nil {
+			return nil, &DatabaseError{
+				Code:    ErrCodeQueryFailed,
+				Message: "Failed to read tag",
+				Err:     err,
+			}
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,520 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Error codes of the curriculum functions
+const (
+	ErrCodeCurriculumNotFound = "CURRICULUM_NOT_FOUND"
+	ErrCodeCurriculumExists   = "CURRICULUM_EXISTS"
+	ErrCodeInvalidCurriculum  = "INVALID_CURRICULUM"
+)
+
+// Curriculum is one version of the CKS curriculum
+type Curriculum struct {
+	Version   string             `json:"version"`
+	Source    string             `json:"source,omitempty"`
+	Active    bool               `json:"active"`
+	CreatedAt string             `json:"createdAt,omitempty"`
+	Domains   []CurriculumDomain `json:"domains"`
+}
+
+// CurriculumDomain is a domain and its weight in a curriculum version
+type CurriculumDomain struct {
+	Slug         string       `json:"domain"` // Matches exercises.category
+	DisplayName  string       `json:"displayName"`
+	Weight       int          `json:"weight"` // Percent of the exam score
+	Competencies []Competency `json:"competencies"`
+}
+
+// Competency is a sub-competency of a domain
+type Competency struct {
+	Slug   string `json:"slug"`
+	Name   string `json:"name"`
+	Domain string `json:"domain,omitempty"`
+}
+
+// Weights maps each domain to its weight
+func (c *Curriculum) Weights() map[string]int {
+	weights := make(map[string]int, len(c.Domains))
+	for _, d := range c.Domains {
+		weights[d.Slug] = d.Weight
+	}
+	return weights
+}
+
+// Domain returns the domain with the given slug
+func (c *Curriculum) Domain(slug string) (CurriculumDomain, bool) {
+	for _, d := range c.Domains {
+		if d.Slug == slug {
+			return d, true
+		}
+	}
+	return CurriculumDomain{}, false
+}
+
+// GetActiveCurriculum returns the curriculum version in use
+func GetActiveCurriculum() (*Curriculum, error) {
+	return getCurriculum("active = 1")
+}
+
+// GetCurriculum returns a curriculum version
+func GetCurriculum(version string) (*Curriculum, error) {
+	return getCurriculum("version = ?", version)
+}
+
+func getCurriculum(where string, args ...any) (*Curriculum, error) {
+	if DB == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	var c Curriculum
+	var source, createdAt sql.NullString
+	err := DB.QueryRow("SELECT version, source, active, created_at FROM curriculum_versions WHERE "+where, args...).
+		Scan(&c.Version, &source, &c.Active, &createdAt)
+	if err != nil {
+		code := ErrCodeQueryFailed
+		if err == sql.ErrNoRows {
+			code = ErrCodeCurriculumNotFound
+		}
+		return nil, &DatabaseError{
+			Code:    code,
+			Message: "Curriculum not found",
+			Err:     err,
+		}
+	}
+	c.Source, c.CreatedAt = source.String, createdAt.String
+
+	if err := loadCurriculumDomains(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListCurricula returns every curriculum version, oldest first
+func ListCurricula() ([]Curriculum, error) {
+	if DB == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	rows, err := DB.Query("SELECT version, source, active, created_at FROM curriculum_versions ORDER BY created_at, version")
+	if err != nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to list curriculum versions",
+			Err:     err,
+		}
+	}
+	curricula := []Curriculum{}
+	for rows.Next() {
+		var c Curriculum
+		var source, createdAt sql.NullString
+		if err := rows.Scan(&c.Version, &source, &c.Active, &createdAt); err != nil {
+			rows.Close()
+			return nil, &DatabaseError{
+				Code:    ErrCodeQueryFailed,
+				Message: "Failed to read curriculum version",
+				Err:     err,
+			}
+		}
+		c.Source, c.CreatedAt = source.String, createdAt.String
+		curricula = append(curricula, c)
+	}
+	rows.Close()
+
+	for i := range curricula {
+		if err := loadCurriculumDomains(&curricula[i]); err != nil {
+			return nil, err
+		}
+	}
+	return curricula, nil
+}
+
+// loadCurriculumDomains fills in a version's domains and competencies
+func loadCurriculumDomains(c *Curriculum) error {
+	rows, err := DB.Query(`
+		SELECT w.domain, d.display_name, w.weight
+		FROM curriculum_domain_weights w
+		JOIN curriculum_domains d ON d.slug = w.domain
+		WHERE w.version = ?
+		ORDER BY w.position
+	`, c.Version)
+	if err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to load curriculum domains",
+			Err:     err,
+		}
+	}
+	defer rows.Close()
+
+	c.Domains = []CurriculumDomain{}
+	index := make(map[string]int)
+	for rows.Next() {
+		d := CurriculumDomain{Competencies: []Competency{}}
+		if err := rows.Scan(&d.Slug, &d.DisplayName, &d.Weight); err != nil {
+			return &DatabaseError{
+				Code:    ErrCodeQueryFailed,
+				Message: "Failed to read curriculum domain",
+				Err:     err,
+			}
+		}
+		index[d.Slug] = len(c.Domains)
+		c.Domains = append(c.Domains, d)
+	}
+	rows.Close()
+
+	rows, err = DB.Query(`
+		SELECT c.slug, c.name, c.domain
+		FROM curriculum_version_competencies vc
+		JOIN curriculum_competencies c ON c.slug = vc.competency
+		WHERE vc.version = ?
+		ORDER BY vc.position
+	`, c.Version)
+	if err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to load curriculum competencies",
+			Err:     err,
+		}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var comp Competency
+		if err := rows.Scan(&comp.Slug, &comp.Name, &comp.Domain); err != nil {
+			return &DatabaseError{
+				Code:    ErrCodeQueryFailed,
+				Message: "Failed to read curriculum competency",
+				Err:     err,
+			}
+		}
+		if i, ok := index[comp.Domain]; ok {
+			c.Domains[i].Competencies = append(c.Domains[i].Competencies, comp)
+		}
+	}
+	return rows.Err()
+}
+
+// validateCurriculum checks a new version before it is stored
+func validateCurriculum(c *Curriculum) error {
+	invalid := func(format string, args ...any) error {
+		return &DatabaseError{
+			Code:    ErrCodeInvalidCurriculum,
+			Message: fmt.Sprintf(format, args...),
+		}
+	}
+
+	if strings.TrimSpace(c.Version) == "" {
+		return invalid("version is required")
+	}
+	if len(c.Domains) == 0 {
+		return invalid("a curriculum needs at least one domain")
+	}
+
+	total := 0
+	domains := make(map[string]bool)
+	competencies := make(map[string]bool)
+	for _, d := range c.Domains {
+		if d.Slug == "" || d.DisplayName == "" {
+			return invalid("every domain needs a slug and a display name")
+		}
+		if domains[d.Slug] {
+			return invalid("domain %s is listed twice", d.Slug)
+		}
+		domains[d.Slug] = true
+		if d.Weight < 0 || d.Weight > 100 {
+			return invalid("weight of %s must be between 0 and 100", d.Slug)
+		}
+		total += d.Weight
+
+		for _, comp := range d.Competencies {
+			if comp.Slug == "" || comp.Name == "" {
+				return invalid("every competency of %s needs a slug and a name", d.Slug)
+			}
+			if competencies[comp.Slug] {
+				return invalid("competency %s is listed twice", comp.Slug)
+			}
+			competencies[comp.Slug] = true
+		}
+	}
+	if total != 100 {
+		return invalid("domain weights add up to %d, not 100", total)
+	}
+	return nil
+}
+
+// SaveCurriculum stores a new curriculum version, activating it if
+// c.Active is set. Domains and competencies are matched to earlier versions
+// by slug; a competency can't move to another domain.
+func SaveCurriculum(c *Curriculum) error {
+	if DB == nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+	if err := validateCurriculum(c); err != nil {
+		return err
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to start transaction",
+			Err:     err,
+		}
+	}
+	defer tx.Rollback()
+
+	var exists int
+	tx.QueryRow("SELECT COUNT(*) FROM curriculum_versions WHERE version = ?", c.Version).Scan(&exists)
+	if exists > 0 {
+		return &DatabaseError{
+			Code:    ErrCodeCurriculumExists,
+			Message: fmt.Sprintf("Curriculum %s already exists", c.Version),
+		}
+	}
+
+	failed := func(message string, err error) error {
+		return &DatabaseError{Code: ErrCodeQueryFailed, Message: message, Err: err}
+	}
+
+	if _, err := tx.Exec("INSERT INTO curriculum_versions (version, source) VALUES (?, ?)", c.Version, c.Source); err != nil {
+		return failed("Failed to record curriculum version", err)
+	}
+
+	position := 0
+	for i, d := range c.Domains {
+		_, err := tx.Exec(`
+			INSERT INTO curriculum_domains (slug, display_name) VALUES (?, ?)
+			ON CONFLICT(slug) DO UPDATE SET display_name = excluded.display_name
+		`, d.Slug, d.DisplayName)
+		if err != nil {
+			return failed("Failed to record curriculum domain", err)
+		}
+		_, err = tx.Exec("INSERT INTO curriculum_domain_weights (version, domain, weight, position) VALUES (?, ?, ?, ?)",
+			c.Version, d.Slug, d.Weight, i+1)
+		if err != nil {
+			return failed("Failed to record domain weight", err)
+		}
+
+		for _, comp := range d.Competencies {
+			var domain string
+			err := tx.QueryRow("SELECT domain FROM curriculum_competencies WHERE slug = ?", comp.Slug).Scan(&domain)
+			switch {
+			case err == sql.ErrNoRows:
+				_, err = tx.Exec("INSERT INTO curriculum_competencies (slug, domain, name) VALUES (?, ?, ?)", comp.Slug, d.Slug, comp.Name)
+			case err == nil && domain != d.Slug:
+				return &DatabaseError{
+					Code:    ErrCodeInvalidCurriculum,
+					Message: fmt.Sprintf("Competency %s belongs to %s, not %s", comp.Slug, domain, d.Slug),
+				}
+			case err == nil:
+				_, err = tx.Exec("UPDATE curriculum_competencies SET name = ? WHERE slug = ?", comp.Name, comp.Slug)
+			}
+			if err != nil {
+				return failed("Failed to record competency", err)
+			}
+
+			position++
+			_, err = tx.Exec("INSERT INTO curriculum_version_competencies (version, competency, position) VALUES (?, ?, ?)",
+				c.Version, comp.Slug, position)
+			if err != nil {
+				return failed("Failed to record competency", err)
+			}
+		}
+	}
+
+	if c.Active {
+		if err := activateCurriculum(tx, c.Version); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return failed("Failed to commit curriculum", err)
+	}
+	return nil
+}
+
+// ActivateCurriculum makes a curriculum version the one in use
+func ActivateCurriculum(version string) error {
+	if DB == nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to start transaction",
+			Err:     err,
+		}
+	}
+	defer tx.Rollback()
+
+	if err := activateCurriculum(tx, version); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to commit curriculum activation",
+			Err:     err,
+		}
+	}
+	return nil
+}
+
+func activateCurriculum(tx *sql.Tx, version string) error {
+	if _, err := tx.Exec("UPDATE curriculum_versions SET active = 0 WHERE active = 1"); err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to deactivate curriculum",
+			Err:     err,
+		}
+	}
+	res, err := tx.Exec("UPDATE curriculum_versions SET active = 1 WHERE version = ?", version)
+	if err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to activate curriculum",
+			Err:     err,
+		}
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return &DatabaseError{
+			Code:    ErrCodeCurriculumNotFound,
+			Message: fmt.Sprintf("Curriculum %s not found", version),
+		}
+	}
+	return nil
+}
+
+// GetExerciseCompetencies returns the competencies an exercise is tagged
+// with
+func GetExerciseCompetencies(slug string) ([]Competency, error) {
+	if DB == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	id, _, err := exerciseHints(DB, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := DB.Query(`
+		SELECT c.slug, c.name, c.domain
+		FROM exercise_competencies ec
+		JOIN curriculum_competencies c ON c.slug = ec.competency
+		WHERE ec.exercise_id = ?
+		ORDER BY c.slug
+	`, id)
+	if err != nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to load exercise competencies",
+			Err:     err,
+		}
+	}
+	defer rows.Close()
+
+	competencies := []Competency{}
+	for rows.Next() {
+		var c Competency
+		if err := rows.Scan(&c.Slug, &c.Name, &c.Domain); err != nil {
+			return nil, &DatabaseError{
+				Code:    ErrCodeQueryFailed,
+				Message: "Failed to read exercise competency",
+				Err:     err,
+			}
+		}
+		competencies = append(competencies, c)
+	}
+	return competencies, rows.Err()
+}
+
+// SetExerciseCompetencies replaces the competencies an exercise is tagged
+// with
+func SetExerciseCompetencies(slug string, competencies []string) error {
+	if DB == nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to start transaction",
+			Err:     err,
+		}
+	}
+	defer tx.Rollback()
+
+	id, _, err := exerciseHints(tx, slug)
+	if err != nil {
+		return err
+	}
+	if err := tagExercise(tx, id, competencies, true); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to commit exercise competencies",
+			Err:     err,
+		}
+	}
+	return nil
+}
+
+// tagExercise tags an exercise with competencies, first dropping its
+// existing tags if replace is set
+func tagExercise(tx *sql.Tx, exerciseID int, competencies []string, replace bool) error {
+	if replace {
+		if _, err := tx.Exec("DELETE FROM exercise_competencies WHERE exercise_id = ?", exerciseID); err != nil {
+			return &DatabaseError{
+				Code:    ErrCodeQueryFailed,
+				Message: "Failed to clear exercise competencies",
+				Err:     err,
+			}
+		}
+	}
+	for _, slug := range competencies {
+		var exists int
+		tx.QueryRow("SELECT COUNT(*) FROM curriculum_competencies WHERE slug = ?", slug).Scan(&exists)
+		if exists == 0 {
+			return &DatabaseError{
+				Code:    ErrCodeInvalidCurriculum,
+				Message: fmt.Sprintf("Unknown competency: %s", slug),
+			}
+		}
+		if _, err := tx.Exec("INSERT OR IGNORE INTO exercise_competencies (exercise_id, competency) VALUES (?, ?)", exerciseID, slug); err != nil {
+			return &DatabaseError{
+				Code:    ErrCodeQueryFailed,
+				Message: "Failed to tag exercise",
+				Err:     err,
+			}
+		}
+	}
+	return nil
+}
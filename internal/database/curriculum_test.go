@@ -0,0 +1,143 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func setupCurriculumDB(t *testing.T) {
+	t.Helper()
+	if err := Initialize(Config{Path: filepath.Join(t.TempDir(), "test.db")}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { Close() })
+	if err := ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+}
+
+func errorCode(err error) string {
+	var dbErr *DatabaseError
+	if errors.As(err, &dbErr) {
+		return dbErr.Code
+	}
+	return ""
+}
+
+func TestActiveCurriculumAndSeedTags(t *testing.T) {
+	setupCurriculumDB(t)
+	if err := SeedExercises(); err != nil {
+		t.Fatalf("SeedExercises failed: %v", err)
+	}
+
+	c, err := GetActiveCurriculum()
+	if err != nil {
+		t.Fatalf("GetActiveCurriculum failed: %v", err)
+	}
+	if c.Version != "v1.30" || len(c.Domains) != 6 {
+		t.Fatalf("unexpected active curriculum %+v", c)
+	}
+	total := 0
+	for _, d := range c.Domains {
+		total += d.Weight
+		if len(d.Competencies) == 0 {
+			t.Errorf("expected competencies for %s", d.Slug)
+		}
+	}
+	if total != 100 || c.Weights()["cluster-setup"] != 10 {
+		t.Errorf("unexpected weights %v", c.Weights())
+	}
+
+	ex, err := GetExerciseBySlug("cilium-network-policy-mtls")
+	if err != nil {
+		t.Fatalf("GetExerciseBySlug failed: %v", err)
+	}
+	if len(ex.Competencies) != 2 {
+		t.Errorf("expected the seeded exercise to be tagged twice, got %v", ex.Competencies)
+	}
+}
+
+func TestExerciseCategoryMustBeCurriculumDomain(t *testing.T) {
+	setupCurriculumDB(t)
+
+	_, err := DB.Exec(`
+		INSERT INTO exercises (slug, title, description, category, difficulty)
+		VALUES ('bad', 'Bad', 'test', 'no-such-domain', 'easy')
+	`)
+	if err == nil {
+		t.Fatal("expected an exercise outside the curriculum to be rejected")
+	}
+
+	_, err = DB.Exec(`
+		INSERT INTO exercises (slug, title, description, category, difficulty)
+		VALUES ('good', 'Good', 'test', 'cluster-setup', 'easy')
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert exercise: %v", err)
+	}
+	if _, err := DB.Exec("DELETE FROM curriculum_domains WHERE slug = 'cluster-setup'"); err == nil {
+		t.Error("expected a domain in use to be kept")
+	}
+	if err := SetExerciseCompetencies("good", []string{"no-such-competency"}); errorCode(err) != ErrCodeInvalidCurriculum {
+		t.Errorf("expected an unknown competency to be rejected, got %v", err)
+	}
+	if err := SetExerciseCompetencies("good", []string{"rbac", "cis-benchmark"}); err != nil {
+		t.Fatalf("SetExerciseCompetencies failed: %v", err)
+	}
+	competencies, err := GetExerciseCompetencies("good")
+	if err != nil || len(competencies) != 2 || competencies[0].Slug != "cis-benchmark" {
+		t.Errorf("unexpected competencies %v (%v)", competencies, err)
+	}
+}
+
+func TestSaveAndActivateCurriculum(t *testing.T) {
+	setupCurriculumDB(t)
+
+	next := Curriculum{
+		Version: "v1.31",
+		Domains: []CurriculumDomain{
+			{Slug: "cluster-setup", DisplayName: "Cluster Setup", Weight: 60, Competencies: []Competency{
+				{Slug: "network-policies", Name: "Use network policies"},
+			}},
+			{Slug: "new-domain", DisplayName: "New Domain", Weight: 30},
+		},
+	}
+	if err := SaveCurriculum(&next); errorCode(err) != ErrCodeInvalidCurriculum {
+		t.Fatalf("expected weights adding up to 90 to be rejected, got %v", err)
+	}
+
+	next.Domains[1].Weight = 40
+	next.Domains[1].Competencies = []Competency{{Slug: "rbac", Name: "RBAC"}}
+	if err := SaveCurriculum(&next); errorCode(err) != ErrCodeInvalidCurriculum {
+		t.Fatalf("expected a competency moving domains to be rejected, got %v", err)
+	}
+
+	next.Domains[1].Competencies = []Competency{{Slug: "new-skill", Name: "New Skill"}}
+	next.Active = true
+	if err := SaveCurriculum(&next); err != nil {
+		t.Fatalf("SaveCurriculum failed: %v", err)
+	}
+	if err := SaveCurriculum(&next); errorCode(err) != ErrCodeCurriculumExists {
+		t.Errorf("expected a duplicate version to be rejected, got %v", err)
+	}
+
+	active, err := GetActiveCurriculum()
+	if err != nil || active.Version != "v1.31" || active.Weights()["new-domain"] != 40 {
+		t.Fatalf("expected v1.31 to be active, got %+v (%v)", active, err)
+	}
+	if active.Domains[0].Competencies[0].Name != "Use network policies" {
+		t.Errorf("expected the competency to be renamed, got %+v", active.Domains[0].Competencies)
+	}
+
+	if err := ActivateCurriculum("v1.30"); err != nil {
+		t.Fatalf("ActivateCurriculum failed: %v", err)
+	}
+	versions, err := ListCurricula()
+	if err != nil || len(versions) != 2 || !versions[0].Active || versions[1].Active {
+		t.Errorf("expected v1.30 active again, got %+v (%v)", versions, err)
+	}
+	if err := ActivateCurriculum("v9"); errorCode(err) != ErrCodeCurriculumNotFound {
+		t.Errorf("expected an unknown version to be rejected, got %v", err)
+	}
+}
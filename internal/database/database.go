@@ -154,10 +154,28 @@ func Connect(cfg Config) error {
 	return nil
 }
 
+// Checkpoint folds the WAL back into the main database file so the data
+// survives if the -wal file is lost or copied without it
+func Checkpoint() error {
+	if DB == nil {
+		return nil
+	}
+	if _, err := DB.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to checkpoint WAL",
+			Err:     err,
+		}
+	}
+	return nil
+}
+
 // Close closes the database connection
 func Close() error {
 	if DB != nil {
-		return DB.Close()
+		err := DB.Close()
+		DB = nil
+		return err
 	}
 	return nil
 }
@@ -0,0 +1,244 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// HintPenaltyConfigKey is the config key holding the score penalty per
+// hint, as a percentage of the validation score
+const HintPenaltyConfigKey = "hint_penalty_percent"
+
+// Error codes of the hint functions
+const (
+	ErrCodeNoMoreHints      = "NO_MORE_HINTS"
+	ErrCodeExerciseNotFound = "EXERCISE_NOT_FOUND"
+)
+
+// HintReveal is one hint revealed for the open attempt
+type HintReveal struct {
+	Index      int    `json:"index"` // 0-based
+	Hint       string `json:"hint"`
+	RevealedAt string `json:"revealedAt"`
+}
+
+// exerciseHints returns an exercise's id and hints
+func exerciseHints(q interface {
+	QueryRow(query string, args ...any) *sql.Row
+}, slug string) (int, []string, error) {
+	var id int
+	var hintsJSON sql.NullString
+	err := q.QueryRow("SELECT id, hints FROM exercises WHERE slug = ?", slug).Scan(&id, &hintsJSON)
+	if err != nil {
+		code := ErrCodeQueryFailed
+		if err == sql.ErrNoRows {
+			code = ErrCodeExerciseNotFound
+		}
+		return 0, nil, &DatabaseError{
+			Code:    code,
+			Message: fmt.Sprintf("Exercise not found: %s", slug),
+			Err:     err,
+		}
+	}
+
+	var hints []string
+	if hintsJSON.Valid && hintsJSON.String != "" {
+		json.Unmarshal([]byte(hintsJSON.String), &hints)
+	}
+	return id, hints, nil
+}
+
+// RevealedHints returns the hints revealed for an exercise's open attempt
+// and how many hints it has in total
+func RevealedHints(slug string) ([]HintReveal, int, error) {
+	if DB == nil {
+		return nil, 0, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	id, hints, err := exerciseHints(DB, slug)
+	if err != nil {
+		return nil, 0, err
+	}
+	profileID, err := ActiveProfileID()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := DB.Query(`
+		SELECT hint_index, revealed_at FROM hint_reveals
+		WHERE profile_id = ? AND exercise_id = ? AND attempt_id IS NULL
+		ORDER BY hint_index
+	`, profileID, id)
+	if err != nil {
+		return nil, 0, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to load hint reveals",
+			Err:     err,
+		}
+	}
+	defer rows.Close()
+
+	reveals := []HintReveal{}
+	for rows.Next() {
+		var r HintReveal
+		if err := rows.Scan(&r.Index, &r.RevealedAt); err != nil {
+			return nil, 0, &DatabaseError{
+				Code:    ErrCodeQueryFailed,
+				Message: "Failed to read hint reveal",
+				Err:     err,
+			}
+		}
+		if r.Index < len(hints) {
+			r.Hint = hints[r.Index]
+		}
+		reveals = append(reveals, r)
+	}
+	return reveals, len(hints), rows.Err()
+}
+
+// RevealNextHint logs the next hint of an exercise as revealed for its open
+// attempt and returns it with the number of hints in total
+func RevealNextHint(slug string) (*HintReveal, int, error) {
+	if DB == nil {
+		return nil, 0, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	profileID, err := ActiveProfileID()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, 0, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to start transaction",
+			Err:     err,
+		}
+	}
+	defer tx.Rollback()
+
+	id, hints, err := exerciseHints(tx, slug)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var revealed int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM hint_reveals WHERE profile_id = ? AND exercise_id = ? AND attempt_id IS NULL", profileID, id).Scan(&revealed); err != nil {
+		return nil, 0, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to count hint reveals",
+			Err:     err,
+		}
+	}
+	if revealed >= len(hints) {
+		return nil, len(hints), &DatabaseError{
+			Code:    ErrCodeNoMoreHints,
+			Message: "All hints have been revealed",
+		}
+	}
+
+	reveal := HintReveal{Index: revealed, Hint: hints[revealed]}
+	err = tx.QueryRow(`
+		INSERT INTO hint_reveals (profile_id, exercise_id, hint_index) VALUES (?, ?, ?)
+		RETURNING revealed_at
+	`, profileID, id, revealed).Scan(&reveal.RevealedAt)
+	if err != nil {
+		return nil, 0, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to record hint reveal",
+			Err:     err,
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, 0, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to commit hint reveal",
+			Err:     err,
+		}
+	}
+	return &reveal, len(hints), nil
+}
+
+// CountOpenHintReveals returns how many hints the open attempt at an
+// exercise has revealed
+func CountOpenHintReveals(exerciseID int) (int, error) {
+	if DB == nil {
+		return 0, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	profileID, err := ActiveProfileID()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = DB.QueryRow("SELECT COUNT(*) FROM hint_reveals WHERE profile_id = ? AND exercise_id = ? AND attempt_id IS NULL", profileID, exerciseID).Scan(&count)
+	if err != nil {
+		return 0, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to count hint reveals",
+			Err:     err,
+		}
+	}
+	return count, nil
+}
+
+// ClaimHintReveals assigns the open attempt's hint reveals to a saved
+// attempt, so the next attempt starts without hints
+func ClaimHintReveals(exerciseID int, attemptID int64) error {
+	if DB == nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	profileID, err := ActiveProfileID()
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Exec("UPDATE hint_reveals SET attempt_id = ? WHERE profile_id = ? AND exercise_id = ? AND attempt_id IS NULL", attemptID, profileID, exerciseID)
+	if err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to assign hint reveals",
+			Err:     err,
+		}
+	}
+	return nil
+}
+
+// GetHintPenaltyPercent returns the score penalty per hint, 0 when off
+func GetHintPenaltyPercent() (int, error) {
+	value, err := GetConfig(HintPenaltyConfigKey)
+	if err != nil || value == "" {
+		return 0, err
+	}
+	percent, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Invalid hint penalty setting",
+			Err:     err,
+		}
+	}
+	return percent, nil
+}
+
+// SetHintPenaltyPercent sets the score penalty per hint
+func SetHintPenaltyPercent(percent int) error {
+	return SetConfig(HintPenaltyConfigKey, strconv.Itoa(percent))
+}
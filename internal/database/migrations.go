@@ -12,6 +12,36 @@ var migration002 string
 //go:embed migrations/003_add_activation_table.sql
 var migration003 string
 
+//go:embed migrations/004_add_attempt_workspace_diff.sql
+var migration004 string
+
+//go:embed migrations/005_add_timers.sql
+var migration005 string
+
+//go:embed migrations/006_add_attempt_hints_used.sql
+var migration006 string
+
+//go:embed migrations/007_add_hint_reveals.sql
+var migration007 string
+
+//go:embed migrations/008_add_curriculum.sql
+var migration008 string
+
+//go:embed migrations/009_add_reset_snapshots.sql
+var migration009 string
+
+//go:embed migrations/010_add_profiles.sql
+var migration010 string
+
+//go:embed migrations/011_add_team_hub.sql
+var migration011 string
+
+//go:embed migrations/012_add_notes_and_bookmarks.sql
+var migration012 string
+
+//go:embed migrations/013_add_exercise_search.sql
+var migration013 string
+
 // ApplyMigrations applies any pending database migrations
 func ApplyMigrations() error {
 	if DB == nil {
@@ -39,6 +69,16 @@ func ApplyMigrations() error {
 	}{
 		{2, migration002},
 		{3, migration003},
+		{4, migration004},
+		{5, migration005},
+		{6, migration006},
+		{7, migration007},
+		{8, migration008},
+		{9, migration009},
+		{10, migration010},
+		{11, migration011},
+		{12, migration012},
+		{13, migration013},
 	}
 
 	for _, migration := range migrations {
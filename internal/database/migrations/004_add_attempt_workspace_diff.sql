@@ -0,0 +1,8 @@
+-- Migration 004: Store workspace diffs with attempts
+-- JSON array of per-node diffs of watched paths (/etc/kubernetes, /root,
+-- /etc/falco) against the baseline captured after exercise setup
+
+ALTER TABLE attempts ADD COLUMN workspace_diff TEXT;
+
+-- Insert schema version
+INSERT INTO schema_version (version) VALUES (4);
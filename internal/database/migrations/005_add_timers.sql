@@ -0,0 +1,23 @@
+-- Migration 005: Server-side timers
+-- Exam and exercise timers are persisted so a restart resumes them with the
+-- right remaining time. Times are RFC 3339 strings; pauses is a JSON array.
+
+CREATE TABLE IF NOT EXISTS timers (
+    id TEXT PRIMARY KEY,
+    kind TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    state TEXT NOT NULL,
+    started_at TEXT NOT NULL,
+    limit_ms INTEGER NOT NULL DEFAULT 0,
+    elapsed_ms INTEGER NOT NULL DEFAULT 0,
+    running_since TEXT,
+    pauses TEXT,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- In-progress exam state (questions, flags, clusters) for recovery after a
+-- restart. Cleared once the exam is graded.
+ALTER TABLE mock_exams ADD COLUMN state TEXT;
+
+-- Insert schema version
+INSERT INTO schema_version (version) VALUES (5);
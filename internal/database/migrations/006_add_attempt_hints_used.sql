@@ -0,0 +1,8 @@
+-- Migration 006: Track hint usage per attempt
+-- The practice scheduler treats a pass that needed hints as harder to
+-- remember than one without
+
+ALTER TABLE attempts ADD COLUMN hints_used INTEGER NOT NULL DEFAULT 0;
+
+-- Insert schema version
+INSERT INTO schema_version (version) VALUES (6);
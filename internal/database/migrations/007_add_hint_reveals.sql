@@ -0,0 +1,22 @@
+-- Migration 007: Log hint reveals
+-- Hints are handed out one at a time. A reveal belongs to the open attempt
+-- (attempt_id NULL) until the next validation claims it.
+
+CREATE TABLE IF NOT EXISTS hint_reveals (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    exercise_id INTEGER NOT NULL,
+    attempt_id INTEGER, -- NULL until the attempt is validated
+    hint_index INTEGER NOT NULL, -- 0-based position in exercises.hints
+    revealed_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (exercise_id) REFERENCES exercises(id) ON DELETE CASCADE,
+    FOREIGN KEY (attempt_id) REFERENCES attempts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_hint_reveals_exercise_id ON hint_reveals(exercise_id);
+CREATE INDEX IF NOT EXISTS idx_hint_reveals_attempt_id ON hint_reveals(attempt_id);
+
+-- Points taken off an attempt's score for the hints it used
+ALTER TABLE attempts ADD COLUMN hint_penalty INTEGER NOT NULL DEFAULT 0;
+
+-- Insert schema version
+INSERT INTO schema_version (version) VALUES (7);
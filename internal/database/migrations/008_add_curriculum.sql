@@ -0,0 +1,171 @@
+-- Migration 008: Versioned CKS curriculum registry
+-- Domains and sub-competencies are identified by slug across versions; each
+-- version sets the weights and the competencies it covers. Exactly one
+-- version is active, and every stats endpoint reads it.
+
+CREATE TABLE IF NOT EXISTS curriculum_versions (
+    version TEXT PRIMARY KEY, -- e.g. 'v1.30'
+    source TEXT, -- Where the curriculum was taken from
+    active BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_curriculum_versions_active ON curriculum_versions(active) WHERE active = 1;
+
+CREATE TABLE IF NOT EXISTS curriculum_domains (
+    slug TEXT PRIMARY KEY, -- Referenced by exercises.category
+    display_name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS curriculum_domain_weights (
+    version TEXT NOT NULL,
+    domain TEXT NOT NULL,
+    weight INTEGER NOT NULL CHECK(weight BETWEEN 0 AND 100), -- Percent of the exam score
+    position INTEGER NOT NULL,
+    PRIMARY KEY (version, domain),
+    FOREIGN KEY (version) REFERENCES curriculum_versions(version) ON DELETE CASCADE,
+    FOREIGN KEY (domain) REFERENCES curriculum_domains(slug)
+);
+
+CREATE TABLE IF NOT EXISTS curriculum_competencies (
+    slug TEXT PRIMARY KEY,
+    domain TEXT NOT NULL,
+    name TEXT NOT NULL,
+    FOREIGN KEY (domain) REFERENCES curriculum_domains(slug)
+);
+
+CREATE TABLE IF NOT EXISTS curriculum_version_competencies (
+    version TEXT NOT NULL,
+    competency TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (version, competency),
+    FOREIGN KEY (version) REFERENCES curriculum_versions(version) ON DELETE CASCADE,
+    FOREIGN KEY (competency) REFERENCES curriculum_competencies(slug)
+);
+
+CREATE TABLE IF NOT EXISTS exercise_competencies (
+    exercise_id INTEGER NOT NULL,
+    competency TEXT NOT NULL,
+    PRIMARY KEY (exercise_id, competency),
+    FOREIGN KEY (exercise_id) REFERENCES exercises(id) ON DELETE CASCADE,
+    FOREIGN KEY (competency) REFERENCES curriculum_competencies(slug)
+);
+
+CREATE INDEX IF NOT EXISTS idx_exercise_competencies_competency ON exercise_competencies(competency);
+
+-- The CKS curriculum the app has used so far
+INSERT INTO curriculum_versions (version, source, active)
+VALUES ('v1.30', 'https://github.com/cncf/curriculum', 1);
+
+INSERT INTO curriculum_domains (slug, display_name) VALUES
+    ('cluster-setup', 'Cluster Setup'),
+    ('cluster-hardening', 'Cluster Hardening'),
+    ('system-hardening', 'System Hardening'),
+    ('minimize-microservice-vulnerabilities', 'Minimize Microservice Vulnerabilities'),
+    ('supply-chain-security', 'Supply Chain Security'),
+    ('monitoring-logging-runtime-security', 'Monitoring, Logging & Runtime Security');
+
+INSERT INTO curriculum_domain_weights (version, domain, weight, position) VALUES
+    ('v1.30', 'cluster-setup', 10, 1),
+    ('v1.30', 'cluster-hardening', 15, 2),
+    ('v1.30', 'system-hardening', 15, 3),
+    ('v1.30', 'minimize-microservice-vulnerabilities', 20, 4),
+    ('v1.30', 'supply-chain-security', 20, 5),
+    ('v1.30', 'monitoring-logging-runtime-security', 20, 6);
+
+INSERT INTO curriculum_competencies (slug, domain, name) VALUES
+    ('network-policies', 'cluster-setup', 'Use network security policies to restrict cluster level access'),
+    ('cis-benchmark', 'cluster-setup', 'Use CIS benchmark to review the security configuration of Kubernetes components'),
+    ('ingress-tls', 'cluster-setup', 'Properly set up Ingress objects with security control'),
+    ('node-metadata', 'cluster-setup', 'Protect node metadata and endpoints'),
+    ('verify-binaries', 'cluster-setup', 'Verify platform binaries before deploying'),
+    ('rbac', 'cluster-hardening', 'Use Role Based Access Controls to minimize exposure'),
+    ('service-accounts', 'cluster-hardening', 'Exercise caution in using service accounts'),
+    ('api-access', 'cluster-hardening', 'Restrict access to the Kubernetes API'),
+    ('upgrades', 'cluster-hardening', 'Upgrade Kubernetes to avoid vulnerabilities'),
+    ('host-footprint', 'system-hardening', 'Minimize host OS footprint'),
+    ('least-privilege-iam', 'system-hardening', 'Use least-privilege identity and access management'),
+    ('external-network-access', 'system-hardening', 'Minimize external access to the network'),
+    ('kernel-hardening', 'system-hardening', 'Use kernel hardening tools such as AppArmor and seccomp'),
+    ('pod-security-standards', 'minimize-microservice-vulnerabilities', 'Use appropriate pod security standards'),
+    ('secrets', 'minimize-microservice-vulnerabilities', 'Manage Kubernetes secrets'),
+    ('isolation', 'minimize-microservice-vulnerabilities', 'Understand and implement isolation techniques'),
+    ('pod-to-pod-encryption', 'minimize-microservice-vulnerabilities', 'Implement pod-to-pod encryption'),
+    ('base-image-footprint', 'supply-chain-security', 'Minimize base image footprint'),
+    ('supply-chain-sbom', 'supply-chain-security', 'Understand your supply chain (SBOM, CI/CD, artifact repositories)'),
+    ('secure-supply-chain', 'supply-chain-security', 'Secure your supply chain (permitted registries, signed artifacts)'),
+    ('static-analysis', 'supply-chain-security', 'Perform static analysis of user workloads and container images'),
+    ('behavioral-analytics', 'monitoring-logging-runtime-security', 'Perform behavioral analytics to detect malicious activities'),
+    ('threat-detection', 'monitoring-logging-runtime-security', 'Detect threats within infrastructure, apps, networks, data, users and workloads'),
+    ('attack-investigation', 'monitoring-logging-runtime-security', 'Investigate and identify phases of attack and bad actors'),
+    ('immutable-containers', 'monitoring-logging-runtime-security', 'Ensure immutability of containers at runtime'),
+    ('audit-logs', 'monitoring-logging-runtime-security', 'Use Kubernetes audit logs to monitor access');
+
+INSERT INTO curriculum_version_competencies (version, competency, position)
+SELECT 'v1.30', slug, rowid FROM curriculum_competencies;
+
+-- Categories outside the curriculum become domains without a weight, so
+-- existing rows satisfy the triggers below
+INSERT OR IGNORE INTO curriculum_domains (slug, display_name)
+SELECT DISTINCT category, category FROM exercises;
+
+-- Tag the seeded exercises
+INSERT OR IGNORE INTO exercise_competencies (exercise_id, competency)
+SELECT e.id, t.competency FROM exercises e JOIN (
+    SELECT 'falco-dev-mem-detection' AS slug, 'behavioral-analytics' AS competency
+    UNION ALL SELECT 'falco-dev-mem-detection', 'threat-detection'
+    UNION ALL SELECT 'bom-libcrypto-version', 'supply-chain-sbom'
+    UNION ALL SELECT 'docker-group-tcp-hardening', 'least-privilege-iam'
+    UNION ALL SELECT 'docker-group-tcp-hardening', 'external-network-access'
+    UNION ALL SELECT 'projected-volume-sa-token', 'service-accounts'
+    UNION ALL SELECT 'imagepolicywebhook-admission', 'secure-supply-chain'
+    UNION ALL SELECT 'audit-policy-configuration', 'audit-logs'
+    UNION ALL SELECT 'networkpolicy-default-deny', 'network-policies'
+    UNION ALL SELECT 'ingress-tls-redirect', 'ingress-tls'
+    UNION ALL SELECT 'kube-bench-cis-fixes', 'cis-benchmark'
+    UNION ALL SELECT 'cilium-network-policy-mtls', 'network-policies'
+    UNION ALL SELECT 'cilium-network-policy-mtls', 'pod-to-pod-encryption'
+    UNION ALL SELECT 'trivy-image-scan', 'static-analysis'
+    UNION ALL SELECT 'kubeadm-node-upgrade', 'upgrades'
+    UNION ALL SELECT 'pod-security-standards', 'pod-security-standards'
+    UNION ALL SELECT 'istio-sidecar-mtls', 'pod-to-pod-encryption'
+    UNION ALL SELECT 'gvisor-runtime-class', 'isolation'
+    UNION ALL SELECT 'static-analysis-security', 'static-analysis'
+    UNION ALL SELECT 'etcd-encryption-at-rest', 'secrets'
+    UNION ALL SELECT 'disable-anonymous-access', 'api-access'
+    UNION ALL SELECT 'container-immutability', 'immutable-containers'
+    UNION ALL SELECT 'verify-platform-binaries', 'verify-binaries'
+) t ON t.slug = e.slug;
+
+-- exercises.category can't gain a foreign key without rebuilding the
+-- table, so triggers keep it pointing at a curriculum domain
+CREATE TRIGGER IF NOT EXISTS exercises_category_insert
+    BEFORE INSERT ON exercises
+    WHEN NOT EXISTS (SELECT 1 FROM curriculum_domains WHERE slug = NEW.category)
+    BEGIN
+        SELECT RAISE(ABORT, 'FOREIGN KEY constraint failed: exercises.category');
+    END;
+
+CREATE TRIGGER IF NOT EXISTS exercises_category_update
+    BEFORE UPDATE OF category ON exercises
+    WHEN NOT EXISTS (SELECT 1 FROM curriculum_domains WHERE slug = NEW.category)
+    BEGIN
+        SELECT RAISE(ABORT, 'FOREIGN KEY constraint failed: exercises.category');
+    END;
+
+CREATE TRIGGER IF NOT EXISTS curriculum_domains_delete
+    BEFORE DELETE ON curriculum_domains
+    WHEN EXISTS (SELECT 1 FROM exercises WHERE category = OLD.slug)
+    BEGIN
+        SELECT RAISE(ABORT, 'FOREIGN KEY constraint failed: exercises.category');
+    END;
+
+CREATE TRIGGER IF NOT EXISTS curriculum_domains_update
+    BEFORE UPDATE OF slug ON curriculum_domains
+    WHEN EXISTS (SELECT 1 FROM exercises WHERE category = OLD.slug)
+    BEGIN
+        SELECT RAISE(ABORT, 'FOREIGN KEY constraint failed: exercises.category');
+    END;
+
+-- Insert schema version
+INSERT INTO schema_version (version) VALUES (8);
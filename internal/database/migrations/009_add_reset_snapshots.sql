@@ -0,0 +1,18 @@
+-- Migration 009: Snapshots taken before a reset
+-- The rows a reset deletes or changes are kept as JSON so the reset can be
+-- undone until expires_at. Expired snapshots are purged by the next reset.
+
+CREATE TABLE IF NOT EXISTS reset_snapshots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    scope TEXT NOT NULL, -- JSON scope of the reset; {} for everything
+    counts TEXT NOT NULL, -- JSON counts of what the reset removed
+    data TEXT NOT NULL, -- JSON object of table name to rows
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME NOT NULL,
+    restored_at DATETIME -- Set once undone
+);
+
+CREATE INDEX IF NOT EXISTS idx_reset_snapshots_expires_at ON reset_snapshots(expires_at);
+
+-- Insert schema version
+INSERT INTO schema_version (version) VALUES (9);
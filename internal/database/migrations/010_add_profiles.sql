@@ -0,0 +1,58 @@
+-- Migration 010: Learner profiles
+-- Several learners can share one installation. Progress, attempts, mock
+-- exams, hint reveals and reset snapshots belong to a profile; exactly one
+-- profile is active, and the history written before profiles existed goes
+-- to the default profile.
+
+CREATE TABLE IF NOT EXISTS profiles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slug TEXT NOT NULL UNIQUE, -- e.g. 'alice'
+    name TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_profiles_active ON profiles(active) WHERE active = 1;
+
+INSERT INTO profiles (id, slug, name, active) VALUES (1, 'default', 'Default', 1);
+
+-- Existing rows take the default profile's id
+ALTER TABLE progress ADD COLUMN profile_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE attempts ADD COLUMN profile_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE mock_exams ADD COLUMN profile_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE hint_reveals ADD COLUMN profile_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE reset_snapshots ADD COLUMN profile_id INTEGER NOT NULL DEFAULT 1;
+
+-- One progress row per exercise and profile, so the upsert on validation
+-- has a conflict target. Duplicates are merged into the latest row first:
+-- attempts and time add up, the best time and earliest dates win, the most
+-- advanced status wins and notes are joined in order.
+UPDATE progress SET
+    attempts = (SELECT SUM(COALESCE(d.attempts, 0)) FROM progress d WHERE d.exercise_id = progress.exercise_id),
+    time_spent_seconds = (SELECT SUM(COALESCE(d.time_spent_seconds, 0)) FROM progress d WHERE d.exercise_id = progress.exercise_id),
+    personal_best_seconds = (SELECT MIN(d.personal_best_seconds) FROM progress d WHERE d.exercise_id = progress.exercise_id),
+    started_at = (SELECT MIN(d.started_at) FROM progress d WHERE d.exercise_id = progress.exercise_id),
+    completed_at = (SELECT MIN(d.completed_at) FROM progress d WHERE d.exercise_id = progress.exercise_id),
+    status = (
+        SELECT d.status FROM progress d WHERE d.exercise_id = progress.exercise_id
+        ORDER BY CASE d.status WHEN 'completed' THEN 0 WHEN 'in-progress' THEN 1 WHEN 'skipped' THEN 2 ELSE 3 END, d.id DESC
+        LIMIT 1
+    ),
+    notes = (
+        SELECT group_concat(n.notes, char(10) || char(10)) FROM (
+            SELECT d.notes FROM progress d
+            WHERE d.exercise_id = progress.exercise_id AND TRIM(COALESCE(d.notes, '')) != ''
+            ORDER BY d.id
+        ) n
+    )
+WHERE id IN (SELECT MAX(id) FROM progress GROUP BY exercise_id HAVING COUNT(*) > 1);
+DELETE FROM progress WHERE id NOT IN (SELECT MAX(id) FROM progress GROUP BY exercise_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_progress_profile_exercise ON progress(profile_id, exercise_id);
+
+CREATE INDEX IF NOT EXISTS idx_attempts_profile_id ON attempts(profile_id);
+CREATE INDEX IF NOT EXISTS idx_mock_exams_profile_id ON mock_exams(profile_id);
+CREATE INDEX IF NOT EXISTS idx_hint_reveals_profile_id ON hint_reveals(profile_id);
+CREATE INDEX IF NOT EXISTS idx_reset_snapshots_profile_id ON reset_snapshots(profile_id);
+
+-- Insert schema version
+INSERT INTO schema_version (version) VALUES (10);
@@ -0,0 +1,27 @@
+-- Migration 011: Team hub
+-- An instance running as the team hub keeps the latest progress summary
+-- each member pushed, and the exercises assigned to them. Members are
+-- identified by the slug of their display name.
+
+CREATE TABLE IF NOT EXISTS team_members (
+    slug TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    summary TEXT NOT NULL, -- JSON summary as pushed
+    generated_at DATETIME NOT NULL, -- When the member built the summary
+    received_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS team_assignments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    member TEXT NOT NULL REFERENCES team_members(slug) ON DELETE CASCADE,
+    exercise TEXT NOT NULL, -- Exercise slug
+    note TEXT,
+    due_date TEXT, -- YYYY-MM-DD
+    assigned_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (member, exercise)
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_assignments_member ON team_assignments(member);
+
+-- Insert schema version
+INSERT INTO schema_version (version) VALUES (11);
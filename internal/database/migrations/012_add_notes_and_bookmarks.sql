@@ -0,0 +1,49 @@
+-- Migration 012: Notes search and bookmarks
+-- Notes stay in progress.notes (Markdown, one per exercise and profile).
+-- notes_fts indexes them for full-text search, keyed by progress id and kept
+-- in step by triggers. The insert trigger clears the rowid first because a
+-- progress row restored with INSERT OR REPLACE doesn't fire the delete one.
+
+ALTER TABLE progress ADD COLUMN notes_updated_at DATETIME;
+UPDATE progress SET notes_updated_at = COALESCE(updated_at, CURRENT_TIMESTAMP) WHERE notes IS NOT NULL AND notes != '';
+
+CREATE VIRTUAL TABLE IF NOT EXISTS notes_fts USING fts5(notes, tokenize = 'porter unicode61');
+
+INSERT INTO notes_fts (rowid, notes)
+SELECT id, notes FROM progress WHERE notes IS NOT NULL AND notes != '';
+
+CREATE TRIGGER IF NOT EXISTS progress_notes_insert AFTER INSERT ON progress BEGIN
+    DELETE FROM notes_fts WHERE rowid = new.id;
+    INSERT INTO notes_fts (rowid, notes) SELECT new.id, new.notes WHERE new.notes IS NOT NULL AND new.notes != '';
+END;
+
+CREATE TRIGGER IF NOT EXISTS progress_notes_update AFTER UPDATE OF notes ON progress BEGIN
+    DELETE FROM notes_fts WHERE rowid = old.id;
+    INSERT INTO notes_fts (rowid, notes) SELECT new.id, new.notes WHERE new.notes IS NOT NULL AND new.notes != '';
+END;
+
+CREATE TRIGGER IF NOT EXISTS progress_notes_delete AFTER DELETE ON progress BEGIN
+    DELETE FROM notes_fts WHERE rowid = old.id;
+END;
+
+-- Bookmarked exercises, per profile, with free-form tags
+CREATE TABLE IF NOT EXISTS bookmarks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    profile_id INTEGER NOT NULL DEFAULT 1,
+    exercise_id INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (profile_id, exercise_id),
+    FOREIGN KEY (exercise_id) REFERENCES exercises(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS bookmark_tags (
+    bookmark_id INTEGER NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (bookmark_id, tag),
+    FOREIGN KEY (bookmark_id) REFERENCES bookmarks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_bookmark_tags_tag ON bookmark_tags(tag);
+
+-- Insert schema version
+INSERT INTO schema_version (version) VALUES (12);
@@ -0,0 +1,32 @@
+-- Migration 013: Exercise search
+-- exercises_fts indexes each exercise's title, description and hints (the
+-- JSON array flattened to text), keyed by exercise id and kept in step by
+-- triggers, like notes_fts.
+
+CREATE VIRTUAL TABLE IF NOT EXISTS exercises_fts USING fts5(title, description, hints, tokenize = 'porter unicode61');
+
+INSERT INTO exercises_fts (rowid, title, description, hints)
+SELECT id, title, description,
+    CASE WHEN json_valid(hints) THEN (SELECT COALESCE(group_concat(value, ' '), '') FROM json_each(hints)) ELSE COALESCE(hints, '') END
+FROM exercises;
+
+CREATE TRIGGER IF NOT EXISTS exercises_fts_insert AFTER INSERT ON exercises BEGIN
+    DELETE FROM exercises_fts WHERE rowid = new.id;
+    INSERT INTO exercises_fts (rowid, title, description, hints)
+    SELECT new.id, new.title, new.description,
+        CASE WHEN json_valid(new.hints) THEN (SELECT COALESCE(group_concat(value, ' '), '') FROM json_each(new.hints)) ELSE COALESCE(new.hints, '') END;
+END;
+
+CREATE TRIGGER IF NOT EXISTS exercises_fts_update AFTER UPDATE OF title, description, hints ON exercises BEGIN
+    DELETE FROM exercises_fts WHERE rowid = old.id;
+    INSERT INTO exercises_fts (rowid, title, description, hints)
+    SELECT new.id, new.title, new.description,
+        CASE WHEN json_valid(new.hints) THEN (SELECT COALESCE(group_concat(value, ' '), '') FROM json_each(new.hints)) ELSE COALESCE(new.hints, '') END;
+END;
+
+CREATE TRIGGER IF NOT EXISTS exercises_fts_delete AFTER DELETE ON exercises BEGIN
+    DELETE FROM exercises_fts WHERE rowid = old.id;
+END;
+
+-- Insert schema version
+INSERT INTO schema_version (version) VALUES (13);
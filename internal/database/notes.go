@@ -0,0 +1,301 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxNoteLength is the longest note accepted, in characters
+const MaxNoteLength = 100000
+
+// Error codes of the note functions
+const (
+	ErrCodeNoteNotFound = "NOTE_NOT_FOUND"
+	ErrCodeInvalidNote  = "INVALID_NOTE"
+)
+
+// Note is the Markdown note kept on an exercise, in progress.notes
+type Note struct {
+	ExerciseID    int    `json:"exerciseId"`
+	ExerciseSlug  string `json:"exerciseSlug"`
+	ExerciseTitle string `json:"exerciseTitle"`
+	Domain        string `json:"domain"`
+	Notes         string `json:"notes"` // Markdown
+	UpdatedAt     string `json:"updatedAt,omitempty"`
+}
+
+// NoteMatch is a note found by a search
+type NoteMatch struct {
+	Note
+	Snippet string  `json:"snippet"` // Matching text, with matches wrapped in ** as in Markdown
+	Rank    float64 `json:"rank"`    // bm25 score; lower is better
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// lookupExercise returns an exercise's id by slug
+func lookupExercise(q queryRower, slug string) (int, error) {
+	var id int
+	if err := q.QueryRow("SELECT id FROM exercises WHERE slug = ?", slug).Scan(&id); err != nil {
+		code := ErrCodeQueryFailed
+		if err == sql.ErrNoRows {
+			code = ErrCodeExerciseNotFound
+		}
+		return 0, &DatabaseError{
+			Code:    code,
+			Message: fmt.Sprintf("Exercise not found: %s", slug),
+			Err:     err,
+		}
+	}
+	return id, nil
+}
+
+const noteColumns = `e.id, e.slug, e.title, e.category, p.notes, COALESCE(p.notes_updated_at, '')`
+
+// GetNote returns the active profile's note on an exercise
+func GetNote(slug string) (*Note, error) {
+	if DB == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	if _, err := lookupExercise(DB, slug); err != nil {
+		return nil, err
+	}
+	profileID, err := ActiveProfileID()
+	if err != nil {
+		return nil, err
+	}
+
+	var n Note
+	err = DB.QueryRow(`
+		SELECT `+noteColumns+`
+		FROM progress p JOIN exercises e ON e.id = p.exercise_id
+		WHERE p.profile_id = ? AND e.slug = ? AND p.notes IS NOT NULL AND p.notes != ''
+	`, profileID, slug).Scan(&n.ExerciseID, &n.ExerciseSlug, &n.ExerciseTitle, &n.Domain, &n.Notes, &n.UpdatedAt)
+	if err != nil {
+		code := ErrCodeQueryFailed
+		if err == sql.ErrNoRows {
+			code = ErrCodeNoteNotFound
+		}
+		return nil, &DatabaseError{
+			Code:    code,
+			Message: fmt.Sprintf("No note on %s", slug),
+			Err:     err,
+		}
+	}
+	return &n, nil
+}
+
+// SaveNote sets the active profile's note on an exercise, starting its
+// progress row if there is none yet. An empty note deletes it.
+func SaveNote(slug, notes string) (*Note, error) {
+	if DB == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	if strings.TrimSpace(notes) == "" {
+		if err := DeleteNote(slug); err != nil && !isNoteNotFound(err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+	if utf8.RuneCountInString(notes) > MaxNoteLength {
+		return nil, &DatabaseError{
+			Code:    ErrCodeInvalidNote,
+			Message: fmt.Sprintf("Notes are limited to %d characters", MaxNoteLength),
+		}
+	}
+
+	exerciseID, err := lookupExercise(DB, slug)
+	if err != nil {
+		return nil, err
+	}
+	profileID, err := ActiveProfileID()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = DB.Exec(`
+		INSERT INTO progress (profile_id, exercise_id, status, notes, notes_updated_at)
+		VALUES (?, ?, 'not-started', ?, datetime('now'))
+		ON CONFLICT(profile_id, exercise_id) DO UPDATE SET
+			notes = excluded.notes,
+			notes_updated_at = excluded.notes_updated_at,
+			updated_at = CURRENT_TIMESTAMP
+	`, profileID, exerciseID, notes)
+	if err != nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to save note",
+			Err:     err,
+		}
+	}
+	return GetNote(slug)
+}
+
+// DeleteNote removes the active profile's note on an exercise
+func DeleteNote(slug string) error {
+	if DB == nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	exerciseID, err := lookupExercise(DB, slug)
+	if err != nil {
+		return err
+	}
+	profileID, err := ActiveProfileID()
+	if err != nil {
+		return err
+	}
+
+	res, err := DB.Exec(`
+		UPDATE progress SET notes = NULL, notes_updated_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE profile_id = ? AND exercise_id = ? AND notes IS NOT NULL AND notes != ''
+	`, profileID, exerciseID)
+	if err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to delete note",
+			Err:     err,
+		}
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return &DatabaseError{
+			Code:    ErrCodeNoteNotFound,
+			Message: fmt.Sprintf("No note on %s", slug),
+		}
+	}
+	return nil
+}
+
+// ListNotes returns the active profile's notes, most recently edited first
+func ListNotes() ([]Note, error) {
+	if DB == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	profileID, err := ActiveProfileID()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := DB.Query(`
+		SELECT `+noteColumns+`
+		FROM progress p JOIN exercises e ON e.id = p.exercise_id
+		WHERE p.profile_id = ? AND p.notes IS NOT NULL AND p.notes != ''
+		ORDER BY p.notes_updated_at DESC, e.slug
+	`, profileID)
+	if err != nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to list notes",
+			Err:     err,
+		}
+	}
+	defer rows.Close()
+
+	notes := []Note{}
+	for rows.Next() {
+		var n Note
+		if err := rows.Scan(&n.ExerciseID, &n.ExerciseSlug, &n.ExerciseTitle, &n.Domain, &n.Notes, &n.UpdatedAt); err != nil {
+			return nil, &DatabaseError{
+				Code:    ErrCodeQueryFailed,
+				Message: "Failed to read note",
+				Err:     err,
+			}
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+// SearchNotes runs a full-text search over the active profile's notes.
+// Every word must appear, as a prefix, so "apparm prof" finds "AppArmor
+// profiles"; words are stemmed, and FTS5 operators are treated as text.
+func SearchNotes(query string, limit int) ([]NoteMatch, error) {
+	if DB == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	match := FullTextQuery(query)
+	if match == "" {
+		return nil, &DatabaseError{
+			Code:    ErrCodeInvalidNote,
+			Message: "Search query is empty",
+		}
+	}
+	profileID, err := ActiveProfileID()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := DB.Query(`
+		SELECT `+noteColumns+`, snippet(notes_fts, 0, '**', '**', '…', 16), bm25(notes_fts)
+		FROM notes_fts
+		JOIN progress p ON p.id = notes_fts.rowid
+		JOIN exercises e ON e.id = p.exercise_id
+		WHERE notes_fts MATCH ? AND p.profile_id = ?
+		ORDER BY bm25(notes_fts), e.slug
+		LIMIT ?
+	`, match, profileID, limit)
+	if err != nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to search notes",
+			Err:     err,
+		}
+	}
+	defer rows.Close()
+
+	matches := []NoteMatch{}
+	for rows.Next() {
+		var m NoteMatch
+		if err := rows.Scan(&m.ExerciseID, &m.ExerciseSlug, &m.ExerciseTitle, &m.Domain, &m.Notes, &m.UpdatedAt, &m.Snippet, &m.Rank); err != nil {
+			return nil, &DatabaseError{
+				Code:    ErrCodeQueryFailed,
+				Message: "Failed to read note",
+				Err:     err,
+			}
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// FullTextQuery turns free text into an FTS5 query: every word quoted, so
+// operators are literal, and matched as a prefix. Words without a letter or
+// digit are dropped since they match nothing.
+func FullTextQuery(text string) string {
+	var terms []string
+	for _, word := range strings.Fields(text) {
+		if strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+func isNoteNotFound(err error) bool {
+	dbErr, ok := err.(*DatabaseError)
+	return ok && dbErr.Code == ErrCodeNoteNotFound
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func setupNotesDB(t *testing.T) {
+	t.Helper()
+	setupCurriculumDB(t)
+	_, err := DB.Exec(`
+		INSERT INTO exercises (id, slug, title, description, category, difficulty, points)
+		VALUES (7, 'audit-logs', 'Audit Logs', 'test', 'cluster-setup', 'easy', 10),
+			(8, 'apparmor', 'AppArmor', 'test', 'system-hardening', 'medium', 10)
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert exercises: %v", err)
+	}
+}
+
+func TestNotesAndSearch(t *testing.T) {
+	setupNotesDB(t)
+
+	if _, err := GetNote("apparmor"); errorCode(err) != ErrCodeNoteNotFound {
+		t.Errorf("expected no note yet, got %v", err)
+	}
+	note, err := SaveNote("apparmor", "Load **profiles** with `apparmor_parser -r`.\n\nCheck with aa-status.")
+	if err != nil || note.ExerciseSlug != "apparmor" || note.Domain != "system-hardening" || note.UpdatedAt == "" {
+		t.Fatalf("unexpected note %+v (%v)", note, err)
+	}
+	var status string
+	DB.QueryRow("SELECT status FROM progress WHERE exercise_id = 8").Scan(&status)
+	if status != "not-started" {
+		t.Errorf("expected a note to start a not-started progress row, got %q", status)
+	}
+	if _, err := SaveNote("audit-logs", "Audit policy lives in /etc/kubernetes/audit-policy.yaml"); err != nil {
+		t.Fatalf("SaveNote failed: %v", err)
+	}
+	if _, err := SaveNote("missing", "x"); errorCode(err) != ErrCodeExerciseNotFound {
+		t.Errorf("expected an unknown exercise, got %v", err)
+	}
+
+	// Prefixes and stems match; operators are plain text
+	for query, want := range map[string]string{
+		"apparm prof":      "apparmor",
+		"profile":          "apparmor",
+		"audit-policy":     "audit-logs",
+		`NOT "audit" OR (`: "",
+	} {
+		matches, err := SearchNotes(query, 10)
+		if err != nil {
+			t.Errorf("%q: SearchNotes failed: %v", query, err)
+			continue
+		}
+		got := ""
+		if len(matches) > 0 {
+			got = matches[0].ExerciseSlug
+		}
+		if got != want || len(matches) > 1 {
+			t.Errorf("%q: expected %q, got %+v", query, want, matches)
+		}
+	}
+	matches, _ := SearchNotes("status", 10)
+	if len(matches) != 1 || !strings.Contains(matches[0].Snippet, "aa-**status**") {
+		t.Errorf("expected a highlighted snippet, got %+v", matches)
+	}
+	if _, err := SearchNotes(" - ", 10); errorCode(err) != ErrCodeInvalidNote {
+		t.Errorf("expected an empty query to be refused, got %v", err)
+	}
+
+	// Editing, deleting and replacing progress rows keep the index in step
+	SaveNote("apparmor", "Use seccomp instead")
+	if matches, _ := SearchNotes("profiles", 10); len(matches) != 0 {
+		t.Errorf("expected the old text to be gone from the index, got %+v", matches)
+	}
+	if _, err := DB.Exec("INSERT OR REPLACE INTO progress (id, profile_id, exercise_id, status, notes) SELECT id, profile_id, exercise_id, status, 'restored seccomp' FROM progress WHERE exercise_id = 8"); err != nil {
+		t.Fatalf("failed to replace progress: %v", err)
+	}
+	if matches, _ := SearchNotes("seccomp", 10); len(matches) != 1 || matches[0].Notes != "restored seccomp" {
+		t.Errorf("expected one match for the replaced row, got %+v", matches)
+	}
+	if _, err := SaveNote("apparmor", "  "); err != nil {
+		t.Fatalf("expected an empty note to delete it, got %v", err)
+	}
+	if err := DeleteNote("apparmor"); errorCode(err) != ErrCodeNoteNotFound {
+		t.Errorf("expected the note to be gone, got %v", err)
+	}
+	if notes, _ := ListNotes(); len(notes) != 1 || notes[0].ExerciseSlug != "audit-logs" {
+		t.Errorf("unexpected notes %+v", notes)
+	}
+
+	// Notes belong to the active profile
+	if _, err := CreateProfile("Bob", ""); err != nil {
+		t.Fatal(err)
+	}
+	ActivateProfile("bob")
+	if notes, _ := ListNotes(); len(notes) != 0 {
+		t.Errorf("expected another profile to have no notes, got %+v", notes)
+	}
+	if matches, _ := SearchNotes("audit", 10); len(matches) != 0 {
+		t.Errorf("expected another profile's search to find nothing, got %+v", matches)
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	setupNotesDB(t)
+
+	bookmark, err := SaveBookmark("apparmor", []string{"Weak Spot", "exam", "exam", " "})
+	if err != nil || strings.Join(bookmark.Tags, ",") != "exam,weak-spot" || bookmark.Difficulty != "medium" || bookmark.HasNote {
+		t.Fatalf("unexpected bookmark %+v (%v)", bookmark, err)
+	}
+	if _, err := SaveBookmark("audit-logs", []string{"exam"}); err != nil {
+		t.Fatalf("SaveBookmark failed: %v", err)
+	}
+	if _, err := SaveBookmark("audit-logs", []string{"bad/tag"}); errorCode(err) != ErrCodeInvalidBookmark {
+		t.Errorf("expected a bad tag to be refused, got %v", err)
+	}
+
+	// Saving again replaces the tags and keeps the bookmark
+	SaveNote("apparmor", "remember the profile path")
+	again, err := SaveBookmark("apparmor", []string{"review"})
+	if err != nil || again.ID != bookmark.ID || strings.Join(again.Tags, ",") != "review" || !again.HasNote {
+		t.Fatalf("unexpected retagged bookmark %+v (%v)", again, err)
+	}
+
+	if tagged, _ := ListBookmarks("exam"); len(tagged) != 1 || tagged[0].ExerciseSlug != "audit-logs" {
+		t.Errorf("unexpected bookmarks tagged exam %+v", tagged)
+	}
+	if all, _ := ListBookmarks(""); len(all) != 2 {
+		t.Errorf("expected two bookmarks, got %+v", all)
+	}
+	tags, err := ListBookmarkTags()
+	if err != nil || len(tags) != 2 || tags[0] != (BookmarkTag{Tag: "exam", Count: 1}) {
+		t.Errorf("unexpected tags %+v (%v)", tags, err)
+	}
+
+	if err := DeleteBookmark("apparmor"); err != nil {
+		t.Fatalf("DeleteBookmark failed: %v", err)
+	}
+	if _, err := GetBookmark("apparmor"); errorCode(err) != ErrCodeBookmarkNotFound {
+		t.Errorf("expected the bookmark to be gone, got %v", err)
+	}
+	var orphans int
+	DB.QueryRow("SELECT COUNT(*) FROM bookmark_tags WHERE tag = 'review'").Scan(&orphans)
+	if orphans != 0 {
+		t.Error("expected the bookmark's tags to go with it")
+	}
+}
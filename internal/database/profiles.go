@@ -0,0 +1,279 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultProfileID is the profile that owns the history recorded before
+// profiles existed
+const DefaultProfileID = 1
+
+// Error codes of the profile functions
+const (
+	ErrCodeProfileNotFound = "PROFILE_NOT_FOUND"
+	ErrCodeProfileExists   = "PROFILE_EXISTS"
+	ErrCodeInvalidProfile  = "INVALID_PROFILE"
+)
+
+// profileSlugPattern is what a profile slug may look like
+var profileSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,39}$`)
+
+// profileTables are the tables holding a profile's history
+var profileTables = []string{"hint_reveals", "attempts", "progress", "mock_exams", "reset_snapshots", "bookmarks"}
+
+// Profile is one learner sharing the installation
+type Profile struct {
+	ID        int64  `json:"id"`
+	Slug      string `json:"slug"`
+	Name      string `json:"name"`
+	Active    bool   `json:"active"`
+	CreatedAt string `json:"createdAt,omitempty"`
+}
+
+// ProfileSlug derives a slug from a profile name: lower case, with runs of
+// anything but letters and digits turned into a dash
+func ProfileSlug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// ListProfiles returns every profile, oldest first
+func ListProfiles() ([]Profile, error) {
+	if DB == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	rows, err := DB.Query("SELECT id, slug, name, active, created_at FROM profiles ORDER BY id")
+	if err != nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to list profiles",
+			Err:     err,
+		}
+	}
+	defer rows.Close()
+
+	profiles := []Profile{}
+	for rows.Next() {
+		var p Profile
+		var createdAt sql.NullString
+		if err := rows.Scan(&p.ID, &p.Slug, &p.Name, &p.Active, &createdAt); err != nil {
+			return nil, &DatabaseError{
+				Code:    ErrCodeQueryFailed,
+				Message: "Failed to read profile",
+				Err:     err,
+			}
+		}
+		p.CreatedAt = createdAt.String
+		profiles = append(profiles, p)
+	}
+	return profiles, rows.Err()
+}
+
+// GetActiveProfile returns the profile in use
+func GetActiveProfile() (*Profile, error) {
+	return getProfile("active = 1")
+}
+
+// GetProfile returns a profile by slug
+func GetProfile(slug string) (*Profile, error) {
+	return getProfile("slug = ?", slug)
+}
+
+// ActiveProfileID returns the id of the profile in use, which every read and
+// write of practice history is scoped to
+func ActiveProfileID() (int64, error) {
+	p, err := GetActiveProfile()
+	if err != nil {
+		return 0, err
+	}
+	return p.ID, nil
+}
+
+func getProfile(where string, args ...any) (*Profile, error) {
+	if DB == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	var p Profile
+	var createdAt sql.NullString
+	err := DB.QueryRow("SELECT id, slug, name, active, created_at FROM profiles WHERE "+where, args...).
+		Scan(&p.ID, &p.Slug, &p.Name, &p.Active, &createdAt)
+	if err != nil {
+		code := ErrCodeQueryFailed
+		if err == sql.ErrNoRows {
+			code = ErrCodeProfileNotFound
+		}
+		return nil, &DatabaseError{
+			Code:    code,
+			Message: "Profile not found",
+			Err:     err,
+		}
+	}
+	p.CreatedAt = createdAt.String
+	return &p, nil
+}
+
+// CreateProfile adds a profile. The slug is derived from the name when
+// empty.
+func CreateProfile(name, slug string) (*Profile, error) {
+	if DB == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	name = strings.TrimSpace(name)
+	if slug == "" {
+		slug = ProfileSlug(name)
+	}
+	if name == "" {
+		return nil, &DatabaseError{
+			Code:    ErrCodeInvalidProfile,
+			Message: "Profile name is required",
+		}
+	}
+	if !profileSlugPattern.MatchString(slug) {
+		return nil, &DatabaseError{
+			Code:    ErrCodeInvalidProfile,
+			Message: fmt.Sprintf("Invalid profile slug %q: use up to 40 lower-case letters, digits and dashes", slug),
+		}
+	}
+
+	if _, err := GetProfile(slug); err == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeProfileExists,
+			Message: fmt.Sprintf("Profile %s already exists", slug),
+		}
+	}
+	if _, err := DB.Exec("INSERT INTO profiles (slug, name) VALUES (?, ?)", slug, name); err != nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to create profile",
+			Err:     err,
+		}
+	}
+	return GetProfile(slug)
+}
+
+// ActivateProfile makes a profile the one in use
+func ActivateProfile(slug string) error {
+	if DB == nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to start transaction",
+			Err:     err,
+		}
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE profiles SET active = 0 WHERE active = 1"); err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to deactivate profile",
+			Err:     err,
+		}
+	}
+	res, err := tx.Exec("UPDATE profiles SET active = 1 WHERE slug = ?", slug)
+	if err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to activate profile",
+			Err:     err,
+		}
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return &DatabaseError{
+			Code:    ErrCodeProfileNotFound,
+			Message: fmt.Sprintf("Profile %s not found", slug),
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to commit profile activation",
+			Err:     err,
+		}
+	}
+	return nil
+}
+
+// DeleteProfile removes a profile and its whole history. The default
+// profile and the active one can't be deleted.
+func DeleteProfile(slug string) error {
+	p, err := GetProfile(slug)
+	if err != nil {
+		return err
+	}
+	if p.ID == DefaultProfileID || p.Active {
+		return &DatabaseError{
+			Code:    ErrCodeInvalidProfile,
+			Message: fmt.Sprintf("Profile %s is the default or active profile and can't be deleted", slug),
+		}
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to start transaction",
+			Err:     err,
+		}
+	}
+	defer tx.Rollback()
+
+	for _, table := range profileTables {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE profile_id = ?", p.ID); err != nil {
+			return &DatabaseError{
+				Code:    ErrCodeQueryFailed,
+				Message: fmt.Sprintf("Failed to delete the profile's %s", table),
+				Err:     err,
+			}
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM profiles WHERE id = ?", p.ID); err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to delete profile",
+			Err:     err,
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to commit profile deletion",
+			Err:     err,
+		}
+	}
+	return nil
+}
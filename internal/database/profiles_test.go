@@ -0,0 +1,116 @@
+package database
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	setupCurriculumDB(t)
+
+	// Existing history belongs to the default profile
+	if _, err := DB.Exec("INSERT INTO mock_exams (exam_type, started_at, max_score, exercises_total) VALUES ('quick-practice', '2026-03-01 10:00:00', 10, 1)"); err != nil {
+		t.Fatalf("failed to insert mock exam: %v", err)
+	}
+	var owner int64
+	DB.QueryRow("SELECT profile_id FROM mock_exams").Scan(&owner)
+	active, err := GetActiveProfile()
+	if err != nil || active.ID != DefaultProfileID || owner != DefaultProfileID {
+		t.Fatalf("expected the default profile to be active and own the history, got %+v (%v), owner %d", active, err, owner)
+	}
+
+	alice, err := CreateProfile("  Alice O'Neil ", "")
+	if err != nil || alice.Slug != "alice-o-neil" || alice.Name != "Alice O'Neil" || alice.Active {
+		t.Fatalf("unexpected profile %+v (%v)", alice, err)
+	}
+	if _, err := CreateProfile("Alice", "alice-o-neil"); errorCode(err) != ErrCodeProfileExists {
+		t.Errorf("expected a duplicate slug to be refused, got %v", err)
+	}
+	for _, name := range []string{"", "!!!"} {
+		if _, err := CreateProfile(name, ""); errorCode(err) != ErrCodeInvalidProfile {
+			t.Errorf("expected %q to be refused, got %v", name, err)
+		}
+	}
+
+	if err := ActivateProfile(alice.Slug); err != nil {
+		t.Fatalf("ActivateProfile failed: %v", err)
+	}
+	if id, err := ActiveProfileID(); err != nil || id != alice.ID {
+		t.Errorf("expected %d to be active, got %d (%v)", alice.ID, id, err)
+	}
+	if err := ActivateProfile("nobody"); errorCode(err) != ErrCodeProfileNotFound {
+		t.Errorf("expected an unknown profile to be refused, got %v", err)
+	}
+	if err := DeleteProfile(alice.Slug); errorCode(err) != ErrCodeInvalidProfile {
+		t.Errorf("expected the active profile to be kept, got %v", err)
+	}
+
+	if err := ActivateProfile("default"); err != nil {
+		t.Fatalf("ActivateProfile failed: %v", err)
+	}
+	if err := DeleteProfile(alice.Slug); err != nil {
+		t.Fatalf("DeleteProfile failed: %v", err)
+	}
+	profiles, _ := ListProfiles()
+	if len(profiles) != 1 || !profiles[0].Active {
+		t.Errorf("expected only the default profile left, got %+v", profiles)
+	}
+}
+
+func TestProfilesMigrationMergesDuplicateProgress(t *testing.T) {
+	if err := Initialize(Config{Path: filepath.Join(t.TempDir(), "test.db")}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { Close() })
+
+	// A database from before profiles, where progress could hold duplicates
+	for _, migration := range []string{migration002, migration003, migration004, migration005, migration006, migration007, migration008, migration009} {
+		if _, err := DB.Exec(migration); err != nil {
+			t.Fatalf("failed to apply migration: %v", err)
+		}
+	}
+	_, err := DB.Exec(`
+		INSERT INTO exercises (id, slug, title, description, category, difficulty, points)
+		VALUES (1, 'audit-logs', 'Audit Logs', 'test', 'cluster-setup', 'easy', 10),
+		       (2, 'apparmor', 'AppArmor', 'test', 'system-hardening', 'easy', 10);
+		INSERT INTO progress (exercise_id, status, started_at, completed_at, attempts, time_spent_seconds, notes, personal_best_seconds)
+		VALUES (1, 'completed', '2026-03-01 10:00:00', '2026-03-01 10:30:00', 2, 600, 'first note', 300),
+		       (1, 'in-progress', '2026-03-05 10:00:00', NULL, 1, 120, NULL, NULL),
+		       (1, 'in-progress', '2026-03-06 10:00:00', NULL, 3, 900, 'second note', 450),
+		       (2, 'in-progress', '2026-03-02 10:00:00', NULL, 1, 60, 'only note', NULL);
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert progress: %v", err)
+	}
+
+	if err := ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+
+	var count int
+	DB.QueryRow("SELECT COUNT(*) FROM progress").Scan(&count)
+	if count != 2 {
+		t.Fatalf("expected one progress row per exercise, got %d", count)
+	}
+	var status, started, notes string
+	var attempts, spent, best int
+	err = DB.QueryRow(`
+		SELECT status, started_at, attempts, time_spent_seconds, personal_best_seconds, notes
+		FROM progress WHERE exercise_id = 1
+	`).Scan(&status, &started, &attempts, &spent, &best, &notes)
+	if err != nil {
+		t.Fatalf("failed to read merged progress: %v", err)
+	}
+	if status != "completed" || attempts != 6 || spent != 1620 || best != 300 || notes != "first note\n\nsecond note" {
+		t.Errorf("unexpected merged progress: %s, %d attempts, %ds, best %d, notes %q", status, attempts, spent, best, notes)
+	}
+	if !strings.HasPrefix(started, "2026-03-01") {
+		t.Errorf("expected the earliest start to be kept, got %s", started)
+	}
+
+	DB.QueryRow("SELECT notes FROM progress WHERE exercise_id = 2").Scan(&notes)
+	if notes != "only note" {
+		t.Errorf("expected a single row to be left alone, got %q", notes)
+	}
+}
@@ -0,0 +1,503 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrCodeInvalidSearch is returned for a search with an unknown sort, facet
+// value or page size
+const ErrCodeInvalidSearch = "INVALID_SEARCH"
+
+// Page sizes of an exercise search
+const (
+	DefaultSearchPageSize = 20
+	MaxSearchPageSize     = 100
+)
+
+// Estimated time buckets of an exercise search
+const (
+	DurationShort  = "up-to-15" // 15 minutes or less
+	DurationMedium = "16-30"
+	DurationLong   = "over-30"
+)
+
+// Sort orders of an exercise search; a leading "-" reverses them
+const (
+	SortRelevance  = "relevance" // Best match first; without a query, the catalogue order
+	SortTitle      = "title"
+	SortDifficulty = "difficulty"
+	SortDuration   = "duration"
+	SortPoints     = "points"
+	SortDomain     = "domain"
+)
+
+var (
+	difficultyOrder = []string{"easy", "medium", "hard"}
+	durationOrder   = []string{DurationShort, DurationMedium, DurationLong}
+	statusOrder     = []string{"not-started", "in-progress", "completed"}
+)
+
+// exerciseTools are the tools a search can facet on, with the words that
+// show an exercise uses them in its title, description, hints or solution
+var exerciseTools = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"apparmor", regexp.MustCompile(`\b(apparmor|aa-status|apparmor_parser)\b`)},
+	{"seccomp", regexp.MustCompile(`\bseccomp\b`)},
+	{"falco", regexp.MustCompile(`\bfalco\b`)},
+	{"trivy", regexp.MustCompile(`\btrivy\b`)},
+	{"kube-bench", regexp.MustCompile(`\bkube-bench\b`)},
+	{"opa-gatekeeper", regexp.MustCompile(`\b(gatekeeper|opa|rego)\b`)},
+	{"kyverno", regexp.MustCompile(`\bkyverno\b`)},
+	{"gvisor", regexp.MustCompile(`\b(gvisor|runsc)\b`)},
+	{"cilium", regexp.MustCompile(`\bcilium\b`)},
+	{"istio", regexp.MustCompile(`\bistio\b`)},
+	{"cosign", regexp.MustCompile(`\bcosign\b`)},
+	{"kubesec", regexp.MustCompile(`\bkubesec\b`)},
+	{"etcdctl", regexp.MustCompile(`\betcdctl\b`)},
+	{"openssl", regexp.MustCompile(`\bopenssl\b`)},
+	{"crictl", regexp.MustCompile(`\bcrictl\b`)},
+	{"docker", regexp.MustCompile(`\bdocker\b`)},
+	{"strace", regexp.MustCompile(`\bstrace\b`)},
+	{"syft", regexp.MustCompile(`\bsyft\b`)},
+}
+
+// ExerciseSearch is a search over the exercise catalogue. Within a facet
+// any of the values match; across facets all of them must.
+type ExerciseSearch struct {
+	Text         string   // Free text over title, description and hints
+	Domains      []string // Curriculum domain slugs
+	Difficulties []string // easy, medium, hard
+	Durations    []string // DurationShort, DurationMedium, DurationLong
+	Tools        []string // Names from exerciseTools
+	Statuses     []string // Completion status of the active profile
+	Sort         string   // One of the Sort constants, optionally prefixed with "-"
+	Page         int      // From 1
+	PageSize     int
+}
+
+// ExerciseHit is an exercise found by a search
+type ExerciseHit struct {
+	Exercise
+	Tools   []string `json:"tools"`
+	Status  string   `json:"status"`            // The active profile's completion status
+	Snippet string   `json:"snippet,omitempty"` // Matching description text, matches wrapped in **
+	Rank    float64  `json:"rank,omitempty"`    // bm25 score; lower is better
+
+	duration string
+}
+
+// FacetCount is how many results a facet value would give, with the other
+// facets' filters applied
+type FacetCount struct {
+	Value    string `json:"value"`
+	Count    int    `json:"count"`
+	Selected bool   `json:"selected,omitempty"`
+}
+
+// ExerciseFacets are the facet counts of a search
+type ExerciseFacets struct {
+	Domain     []FacetCount `json:"domain"`
+	Difficulty []FacetCount `json:"difficulty"`
+	Duration   []FacetCount `json:"duration"`
+	Tool       []FacetCount `json:"tool"`
+	Status     []FacetCount `json:"status"`
+}
+
+// ExerciseSearchResult is one page of search results
+type ExerciseSearchResult struct {
+	Exercises  []ExerciseHit  `json:"exercises"`
+	Total      int            `json:"total"`
+	Page       int            `json:"page"`
+	PageSize   int            `json:"pageSize"`
+	TotalPages int            `json:"totalPages"`
+	Sort       string         `json:"sort"`
+	Facets     ExerciseFacets `json:"facets"`
+}
+
+// DurationBucket returns the estimated time bucket of an exercise, or ""
+// when it has no estimate
+func DurationBucket(minutes int) string {
+	switch {
+	case minutes <= 0:
+		return ""
+	case minutes <= 15:
+		return DurationShort
+	case minutes <= 30:
+		return DurationMedium
+	default:
+		return DurationLong
+	}
+}
+
+// ExerciseTools returns the tools an exercise uses, by name
+func ExerciseTools(ex *Exercise) []string {
+	text := strings.ToLower(strings.Join(append([]string{ex.Title, ex.Description, ex.Solution}, ex.Hints...), "\n"))
+	tools := []string{}
+	for _, tool := range exerciseTools {
+		if tool.pattern.MatchString(text) {
+			tools = append(tools, tool.name)
+		}
+	}
+	return tools
+}
+
+// SearchExercises runs a full-text and faceted search over the exercises.
+// Words are matched as prefixes and stemmed, as in SearchNotes; a title
+// match ranks above a description match, which ranks above a hint match.
+func SearchExercises(search ExerciseSearch) (*ExerciseSearchResult, error) {
+	if DB == nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Database not initialized",
+		}
+	}
+
+	if err := search.normalize(); err != nil {
+		return nil, err
+	}
+	match := FullTextQuery(search.Text)
+	if strings.TrimSpace(search.Text) != "" && match == "" {
+		return nil, &DatabaseError{
+			Code:    ErrCodeInvalidSearch,
+			Message: "Search query has no words",
+		}
+	}
+	profileID, err := ActiveProfileID()
+	if err != nil {
+		return nil, err
+	}
+
+	hits, err := searchCandidates(match, profileID)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := map[string]map[string]bool{
+		"domain":     valueSet(search.Domains),
+		"difficulty": valueSet(search.Difficulties),
+		"duration":   valueSet(search.Durations),
+		"tool":       valueSet(search.Tools),
+		"status":     valueSet(search.Statuses),
+	}
+	// matches reports whether a hit passes every filter but skip's
+	matches := func(h *ExerciseHit, skip string) bool {
+		for facet, values := range filters {
+			if facet == skip || len(values) == 0 {
+				continue
+			}
+			found := false
+			for _, v := range hitValues(h, facet) {
+				if values[v] {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+
+	counts := map[string]map[string]int{}
+	for facet := range filters {
+		counts[facet] = map[string]int{}
+	}
+	results := []ExerciseHit{}
+	for i := range hits {
+		h := &hits[i]
+		for facet := range filters {
+			if matches(h, facet) {
+				for _, v := range hitValues(h, facet) {
+					counts[facet][v]++
+				}
+			}
+		}
+		if matches(h, "") {
+			results = append(results, *h)
+		}
+	}
+
+	sortHits(results, search.Sort, match != "")
+
+	result := &ExerciseSearchResult{
+		Total:    len(results),
+		Page:     search.Page,
+		PageSize: search.PageSize,
+		Sort:     search.Sort,
+		Facets: ExerciseFacets{
+			Domain:     facetCounts(counts["domain"], filters["domain"], nil),
+			Difficulty: facetCounts(counts["difficulty"], filters["difficulty"], difficultyOrder),
+			Duration:   facetCounts(counts["duration"], filters["duration"], durationOrder),
+			Tool:       facetCounts(counts["tool"], filters["tool"], nil),
+			Status:     facetCounts(counts["status"], filters["status"], statusOrder),
+		},
+	}
+	result.TotalPages = (result.Total + search.PageSize - 1) / search.PageSize
+	start := min((search.Page-1)*search.PageSize, len(results))
+	end := min(start+search.PageSize, len(results))
+	result.Exercises = results[start:end]
+	return result, nil
+}
+
+// normalize fills in defaults and checks the sort, page and facet values
+func (s *ExerciseSearch) normalize() error {
+	invalid := func(format string, args ...any) error {
+		return &DatabaseError{
+			Code:    ErrCodeInvalidSearch,
+			Message: fmt.Sprintf(format, args...),
+		}
+	}
+
+	if s.Sort == "" {
+		s.Sort = SortRelevance
+	}
+	switch strings.TrimPrefix(s.Sort, "-") {
+	case SortRelevance, SortTitle, SortDifficulty, SortDuration, SortPoints, SortDomain:
+	default:
+		return invalid("Unknown sort: %s", s.Sort)
+	}
+	if s.Page == 0 {
+		s.Page = 1
+	}
+	if s.PageSize == 0 {
+		s.PageSize = DefaultSearchPageSize
+	}
+	if s.Page < 1 {
+		return invalid("Page must be 1 or more")
+	}
+	if s.PageSize < 1 || s.PageSize > MaxSearchPageSize {
+		return invalid("Page size must be between 1 and %d", MaxSearchPageSize)
+	}
+
+	toolNames := make([]string, len(exerciseTools))
+	for i, tool := range exerciseTools {
+		toolNames[i] = tool.name
+	}
+	for _, facet := range []struct {
+		name   string
+		values []string
+		known  []string
+	}{
+		{"difficulty", s.Difficulties, difficultyOrder},
+		{"duration", s.Durations, durationOrder},
+		{"tool", s.Tools, toolNames},
+		{"status", s.Statuses, statusOrder},
+	} {
+		for _, v := range facet.values {
+			if !contains(facet.known, v) {
+				return invalid("Unknown %s: %s (expected one of %s)", facet.name, v, strings.Join(facet.known, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// searchCandidates loads the exercises matching the full-text query, or all
+// of them without one, with the active profile's completion status
+func searchCandidates(match string, profileID int64) ([]ExerciseHit, error) {
+	columns := `e.slug, e.title, e.description, e.category, e.difficulty,
+		e.points, COALESCE(e.estimated_minutes, 0), COALESCE(e.prerequisites, '[]'), COALESCE(e.hints, '[]'), COALESCE(e.solution, ''),
+		(SELECT json_group_array(competency) FROM exercise_competencies WHERE exercise_id = e.id),
+		COALESCE(p.status, 'not-started')`
+	progress := `LEFT JOIN progress p ON p.exercise_id = e.id AND p.profile_id = ?`
+	args := []any{profileID}
+	query := `SELECT ` + columns + `, '', 0.0 FROM exercises e ` + progress
+	if match != "" {
+		query = `SELECT ` + columns + `, snippet(exercises_fts, 1, '**', '**', '…', 16), bm25(exercises_fts, 10.0, 2.0, 1.0)
+			FROM exercises_fts
+			JOIN exercises e ON e.id = exercises_fts.rowid
+			` + progress + `
+			WHERE exercises_fts MATCH ?`
+		args = append(args, match)
+	}
+	query += ` ORDER BY e.category, e.difficulty, e.points, e.slug`
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Failed to search exercises",
+			Err:     err,
+		}
+	}
+	defer rows.Close()
+
+	hits := []ExerciseHit{}
+	for rows.Next() {
+		var h ExerciseHit
+		var prerequisitesJSON, hintsJSON, competenciesJSON string
+		err := rows.Scan(
+			&h.Slug,
+			&h.Title,
+			&h.Description,
+			&h.Category,
+			&h.Difficulty,
+			&h.Points,
+			&h.EstimatedMinutes,
+			&prerequisitesJSON,
+			&hintsJSON,
+			&h.Solution,
+			&competenciesJSON,
+			&h.Status,
+			&h.Snippet,
+			&h.Rank,
+		)
+		if err != nil {
+			return nil, &DatabaseError{
+				Code:    ErrCodeQueryFailed,
+				Message: "Failed to scan exercise row",
+				Err:     err,
+			}
+		}
+
+		// Parse JSON fields
+		json.Unmarshal([]byte(prerequisitesJSON), &h.Prerequisites)
+		json.Unmarshal([]byte(hintsJSON), &h.Hints)
+		json.Unmarshal([]byte(competenciesJSON), &h.Competencies)
+
+		h.Tools = ExerciseTools(&h.Exercise)
+		h.duration = DurationBucket(h.EstimatedMinutes)
+		hits = append(hits, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, &DatabaseError{
+			Code:    ErrCodeQueryFailed,
+			Message: "Error iterating exercise rows",
+			Err:     err,
+		}
+	}
+	return hits, nil
+}
+
+// hitValues returns a hit's values for a facet
+func hitValues(h *ExerciseHit, facet string) []string {
+	switch facet {
+	case "domain":
+		return []string{h.Category}
+	case "difficulty":
+		return []string{h.Difficulty}
+	case "duration":
+		if h.duration == "" {
+			return nil
+		}
+		return []string{h.duration}
+	case "tool":
+		return h.Tools
+	case "status":
+		return []string{h.Status}
+	}
+	return nil
+}
+
+// facetCounts lists a facet's values: in order when it has one, otherwise
+// most results first. Selected values are listed even without results.
+func facetCounts(counts map[string]int, selected map[string]bool, order []string) []FacetCount {
+	values := []string{}
+	for v := range counts {
+		values = append(values, v)
+	}
+	for v := range selected {
+		if _, ok := counts[v]; !ok {
+			values = append(values, v)
+		}
+	}
+	if order != nil {
+		for _, v := range order {
+			if !contains(values, v) {
+				values = append(values, v)
+			}
+		}
+		sort.SliceStable(values, func(i, j int) bool { return indexOf(order, values[i]) < indexOf(order, values[j]) })
+	} else {
+		sort.Slice(values, func(i, j int) bool {
+			if counts[values[i]] != counts[values[j]] {
+				return counts[values[i]] > counts[values[j]]
+			}
+			return values[i] < values[j]
+		})
+	}
+
+	facet := make([]FacetCount, len(values))
+	for i, v := range values {
+		facet[i] = FacetCount{Value: v, Count: counts[v], Selected: selected[v]}
+	}
+	return facet
+}
+
+// sortHits orders hits by a sort key, breaking ties by title
+func sortHits(hits []ExerciseHit, key string, ranked bool) {
+	desc := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+	if key == SortRelevance && !ranked {
+		if desc {
+			for i, j := 0, len(hits)-1; i < j; i, j = i+1, j-1 {
+				hits[i], hits[j] = hits[j], hits[i]
+			}
+		}
+		return // Already in catalogue order
+	}
+
+	compare := func(a, b *ExerciseHit) int {
+		switch key {
+		case SortRelevance:
+			return cmpFloat(a.Rank, b.Rank)
+		case SortDifficulty:
+			return indexOf(difficultyOrder, a.Difficulty) - indexOf(difficultyOrder, b.Difficulty)
+		case SortDuration:
+			return a.EstimatedMinutes - b.EstimatedMinutes
+		case SortPoints:
+			return a.Points - b.Points
+		case SortDomain:
+			return strings.Compare(a.Category, b.Category)
+		}
+		return 0
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		c := compare(&hits[i], &hits[j])
+		if desc {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+		return strings.ToLower(hits[i].Title) < strings.ToLower(hits[j].Title)
+	})
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func valueSet(values []string) map[string]bool {
+	set := map[string]bool{}
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func contains(values []string, v string) bool {
+	return indexOf(values, v) >= 0
+}
+
+func indexOf(values []string, v string) int {
+	for i, value := range values {
+		if value == v {
+			return i
+		}
+	}
+	return -1
+}
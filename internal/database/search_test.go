@@ -0,0 +1,90 @@
+package database
+
+import (
+	"testing"
+)
+
+func setupSearchDB(t *testing.T) {
+	t.Helper()
+	setupCurriculumDB(t)
+	_, err := DB.Exec(`
+		INSERT INTO exercises (id, slug, title, description, category, difficulty, points, estimated_minutes, hints, solution)
+		VALUES
+			(1, 'falco-rules', 'Falco Rules', 'Write a rule that alerts on shells in containers', 'monitoring-logging-runtime-security', 'hard', 30, 40, '["Edit /etc/falco/falco_rules.local.yaml"]', ''),
+			(2, 'image-scan', 'Image Scanning', 'Find the images with critical vulnerabilities', 'supply-chain-security', 'medium', 20, 20, '["Run trivy image --severity CRITICAL"]', 'trivy image nginx'),
+			(3, 'apparmor', 'AppArmor Profiles', 'Confine a pod with a profile', 'system-hardening', 'medium', 20, 15, '["Load it with apparmor_parser"]', ''),
+			(4, 'audit-logs', 'Audit Logs', 'Enable auditing of secrets', 'cluster-setup', 'easy', 10, 10, NULL, NULL)
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert exercises: %v", err)
+	}
+}
+
+func slugs(result *ExerciseSearchResult) []string {
+	list := []string{}
+	for _, h := range result.Exercises {
+		list = append(list, h.Slug)
+	}
+	return list
+}
+
+func facet(counts []FacetCount, value string) int {
+	for _, c := range counts {
+		if c.Value == value {
+			return c.Count
+		}
+	}
+	return -1
+}
+
+func TestSearchExercises(t *testing.T) {
+	setupSearchDB(t)
+
+	// Titles rank above descriptions and hints; hints are searched too
+	result, err := SearchExercises(ExerciseSearch{Text: "profile"})
+	if err != nil || len(result.Exercises) != 1 || result.Exercises[0].Slug != "apparmor" || result.Exercises[0].Snippet == "" {
+		t.Fatalf("unexpected search %+v (%v)", result, err)
+	}
+	if result, _ := SearchExercises(ExerciseSearch{Text: "trivy"}); len(result.Exercises) != 1 || result.Exercises[0].Slug != "image-scan" {
+		t.Errorf("expected a hint to match, got %v", slugs(result))
+	}
+	if _, err := SearchExercises(ExerciseSearch{Text: "--"}); errorCode(err) != ErrCodeInvalidSearch {
+		t.Errorf("expected a query without words to be refused, got %v", err)
+	}
+
+	// Tools come from the text, durations from the estimate
+	result, _ = SearchExercises(ExerciseSearch{Tools: []string{"trivy", "falco"}, Sort: SortTitle})
+	if got := slugs(result); len(got) != 2 || got[0] != "falco-rules" || got[1] != "image-scan" {
+		t.Errorf("unexpected tool results %v", got)
+	}
+	if facet(result.Facets.Tool, "apparmor") != 1 || facet(result.Facets.Duration, DurationLong) != 1 || facet(result.Facets.Duration, DurationMedium) != 1 {
+		t.Errorf("unexpected facets %+v", result.Facets)
+	}
+
+	// A facet's counts ignore its own filter but not the others
+	DB.Exec("INSERT INTO progress (profile_id, exercise_id, status) VALUES (1, 3, 'completed')")
+	result, _ = SearchExercises(ExerciseSearch{Difficulties: []string{"medium"}, Statuses: []string{"completed"}})
+	if got := slugs(result); len(got) != 1 || got[0] != "apparmor" || result.Exercises[0].Status != "completed" {
+		t.Errorf("unexpected filtered results %v", got)
+	}
+	if facet(result.Facets.Difficulty, "medium") != 1 || facet(result.Facets.Difficulty, "easy") != 0 || facet(result.Facets.Status, "not-started") != 1 {
+		t.Errorf("unexpected facet counts %+v", result.Facets)
+	}
+
+	// Sorting and pages
+	result, _ = SearchExercises(ExerciseSearch{Sort: "-points", PageSize: 3, Page: 2})
+	if got := slugs(result); result.Total != 4 || result.TotalPages != 2 || len(got) != 1 || got[0] != "audit-logs" {
+		t.Errorf("unexpected second page %v of %+v", got, result)
+	}
+	for _, bad := range []ExerciseSearch{{Sort: "newest"}, {PageSize: 1000}, {Statuses: []string{"done"}}, {Tools: []string{"hammer"}}} {
+		if _, err := SearchExercises(bad); errorCode(err) != ErrCodeInvalidSearch {
+			t.Errorf("%+v: expected an invalid search, got %v", bad, err)
+		}
+	}
+
+	// The index follows edits
+	DB.Exec("UPDATE exercises SET title = 'Seccomp Profiles' WHERE slug = 'audit-logs'")
+	if result, _ := SearchExercises(ExerciseSearch{Text: "seccomp"}); len(result.Exercises) != 1 || result.Exercises[0].Slug != "audit-logs" {
+		t.Errorf("expected the renamed exercise to be found, got %v", slugs(result))
+	}
+}
@@ -20,6 +20,8 @@ type Exercise struct {
 	EstimatedMinutes int      `json:"estimatedMinutes"`
 	Prerequisites    []string `json:"prerequisites"`
 	Hints            []string `json:"hints"`
+	HintCount        int      `json:"hintCount"`    // Set by the API, which only returns revealed hints
+	Competencies     []string `json:"competencies"` // Curriculum competency slugs
 	Solution         string   `json:"solution"`
 }
 
@@ -89,7 +91,7 @@ func SeedExercises() error {
 		prerequisitesJSON, _ := json.Marshal(ex.Prerequisites)
 		hintsJSON, _ := json.Marshal(ex.Hints)
 
-		_, err := stmt.Exec(
+		res, err := stmt.Exec(
 			ex.Slug,
 			ex.Title,
 			ex.Description,
@@ -108,6 +110,18 @@ func SeedExercises() error {
 				Err:     err,
 			}
 		}
+
+		id, err := res.LastInsertId()
+		if err == nil {
+			err = tagExercise(tx, int(id), ex.Competencies, false)
+		}
+		if err != nil {
+			return &DatabaseError{
+				Code:    "SEED_INSERT_FAILED",
+				Message: fmt.Sprintf("Failed to tag exercise: %s", ex.Slug),
+				Err:     err,
+			}
+		}
 	}
 
 	// Commit transaction
@@ -134,7 +148,8 @@ func GetExercises() ([]Exercise, error) {
 
 	rows, err := DB.Query(`
 		SELECT slug, title, description, category, difficulty,
-		       points, estimated_minutes, prerequisites, hints, solution
+		       points, estimated_minutes, prerequisites, hints, solution,
+		       (SELECT json_group_array(competency) FROM exercise_competencies WHERE exercise_id = exercises.id)
 		FROM exercises
 		ORDER BY category, difficulty, points
 	`)
@@ -150,7 +165,7 @@ func GetExercises() ([]Exercise, error) {
 	var exercises []Exercise
 	for rows.Next() {
 		var ex Exercise
-		var prerequisitesJSON, hintsJSON string
+		var prerequisitesJSON, hintsJSON, competenciesJSON string
 
 		err := rows.Scan(
 			&ex.Slug,
@@ -163,6 +178,7 @@ func GetExercises() ([]Exercise, error) {
 			&prerequisitesJSON,
 			&hintsJSON,
 			&ex.Solution,
+			&competenciesJSON,
 		)
 		if err != nil {
 			return nil, &DatabaseError{
@@ -175,6 +191,7 @@ func GetExercises() ([]Exercise, error) {
 		// Parse JSON fields
 		json.Unmarshal([]byte(prerequisitesJSON), &ex.Prerequisites)
 		json.Unmarshal([]byte(hintsJSON), &ex.Hints)
+		json.Unmarshal([]byte(competenciesJSON), &ex.Competencies)
 
 		exercises = append(exercises, ex)
 	}
@@ -200,11 +217,12 @@ func GetExerciseBySlug(slug string) (*Exercise, error) {
 	}
 
 	var ex Exercise
-	var prerequisitesJSON, hintsJSON string
+	var prerequisitesJSON, hintsJSON, competenciesJSON string
 
 	err := DB.QueryRow(`
 		SELECT slug, title, description, category, difficulty,
-		       points, estimated_minutes, prerequisites, hints, solution
+		       points, estimated_minutes, prerequisites, hints, solution,
+		       (SELECT json_group_array(competency) FROM exercise_competencies WHERE exercise_id = exercises.id)
 		FROM exercises
 		WHERE slug = ?
 	`, slug).Scan(
@@ -218,6 +236,7 @@ func GetExerciseBySlug(slug string) (*Exercise, error) {
 		&prerequisitesJSON,
 		&hintsJSON,
 		&ex.Solution,
+		&competenciesJSON,
 	)
 
 	if err != nil {
@@ -231,6 +250,7 @@ func GetExerciseBySlug(slug string) (*Exercise, error) {
 	// Parse JSON fields
 	json.Unmarshal([]byte(prerequisitesJSON), &ex.Prerequisites)
 	json.Unmarshal([]byte(hintsJSON), &ex.Hints)
+	json.Unmarshal([]byte(competenciesJSON), &ex.Competencies)
 
 	return &ex, nil
 }
@@ -246,7 +266,8 @@ func GetExercisesByCategory(category string) ([]Exercise, error) {
 
 	rows, err := DB.Query(`
 		SELECT slug, title, description, category, difficulty,
-		       points, estimated_minutes, prerequisites, hints, solution
+		       points, estimated_minutes, prerequisites, hints, solution,
+		       (SELECT json_group_array(competency) FROM exercise_competencies WHERE exercise_id = exercises.id)
 		FROM exercises
 		WHERE category = ?
 		ORDER BY difficulty, points
@@ -263,7 +284,7 @@ func GetExercisesByCategory(category string) ([]Exercise, error) {
 	var exercises []Exercise
 	for rows.Next() {
 		var ex Exercise
-		var prerequisitesJSON, hintsJSON string
+		var prerequisitesJSON, hintsJSON, competenciesJSON string
 
 		err := rows.Scan(
 			&ex.Slug,
@@ -276,6 +297,7 @@ func GetExercisesByCategory(category string) ([]Exercise, error) {
 			&prerequisitesJSON,
 			&hintsJSON,
 			&ex.Solution,
+			&competenciesJSON,
 		)
 		if err != nil {
 			return nil, &DatabaseError{
@@ -288,6 +310,7 @@ func GetExercisesByCategory(category string) ([]Exercise, error) {
 		// Parse JSON fields
 		json.Unmarshal([]byte(prerequisitesJSON), &ex.Prerequisites)
 		json.Unmarshal([]byte(hintsJSON), &ex.Hints)
+		json.Unmarshal([]byte(competenciesJSON), &ex.Competencies)
 
 		exercises = append(exercises, ex)
 	}
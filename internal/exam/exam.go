@@ -0,0 +1,182 @@
+// Package exam runs mock CKS exams: it picks exercises weighted by CKS
+// domain, sets them all up in one shared environment, tracks the countdown
+// and flagged/skipped questions, and grades every task when the exam ends.
+package exam
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/timer"
+)
+
+// Type identifies a kind of mock exam, matching mock_exams.exam_type
+type Type string
+
+const (
+	QuickPractice Type = "quick-practice"
+	FullMockExam  Type = "full-mock-exam"
+)
+
+// Definition describes how an exam type is built
+type Definition struct {
+	Type            Type   `json:"type"`
+	Name            string `json:"name"`
+	DurationMinutes int    `json:"durationMinutes"`
+	QuestionCount   int    `json:"questionCount"`
+}
+
+// Duration returns the exam's time limit
+func (d Definition) Duration() time.Duration {
+	return time.Duration(d.DurationMinutes) * time.Minute
+}
+
+// Definitions lists the available exam types
+var Definitions = []Definition{
+	{Type: QuickPractice, Name: "Quick Practice", DurationMinutes: 30, QuestionCount: 5},
+	{Type: FullMockExam, Name: "Full Mock CKS Exam", DurationMinutes: 120, QuestionCount: 15},
+}
+
+// LookupDefinition returns the definition for an exam type
+func LookupDefinition(t Type) (Definition, bool) {
+	for _, def := range Definitions {
+		if def.Type == t {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
+
+// PassingPercentage is the CKS passing score
+const PassingPercentage = 67
+
+// EnvironmentSlug is the pseudo exercise slug the exam environment's
+// terminal runs under. The shared cluster uses it too, so node terminals and
+// the IDE reach it like any exercise cluster.
+const EnvironmentSlug = "mock-exam"
+
+// Mode selects how an exam's environment is laid out
+type Mode string
+
+const (
+	// ModeShared runs every question in one cluster, each with its own context
+	ModeShared Mode = "shared"
+	// ModeMultiCluster spreads questions over a fixed set of clusters, like
+	// the real exam where each task names the context to switch to
+	ModeMultiCluster Mode = "multi-cluster"
+)
+
+// ClusterSpec is one cluster of an exam environment
+type ClusterSpec struct {
+	Slug        string `json:"slug"`    // Pseudo exercise slug; the KIND cluster is cks-<slug>
+	Context     string `json:"context"` // Context name questions on this cluster use
+	SSHBasePort int    `json:"-"`       // Host port of the control plane's sshd; 0 for the default
+}
+
+// MultiClusterSet is the fixed set of clusters a multi-cluster exam runs on.
+// Their sshd ports sit above the 2200-2202 range practice clusters use.
+var MultiClusterSet = []ClusterSpec{
+	{Slug: "mock-exam-1", Context: "cks-cluster1", SSHBasePort: 2210},
+	{Slug: "mock-exam-2", Context: "cks-cluster2", SSHBasePort: 2220},
+	{Slug: "mock-exam-3", Context: "cks-cluster3", SSHBasePort: 2230},
+}
+
+// Status is the state of an exam
+type Status string
+
+const (
+	StatusProvisioning Status = "provisioning" // Shared environment is being set up
+	StatusInProgress   Status = "in-progress"  // Countdown running
+	StatusGrading      Status = "grading"
+	StatusCompleted    Status = "completed"
+	StatusFailed       Status = "failed" // Environment could not be set up
+	StatusAbandoned    Status = "abandoned"
+)
+
+// Question is one task in an exam
+type Question struct {
+	Number      int    `json:"number"` // 1-based position in the exam
+	ExerciseID  int    `json:"exerciseId"`
+	Slug        string `json:"slug"`
+	Title       string `json:"title"`
+	Domain      string `json:"domain"`
+	Points      int    `json:"points"`
+	Context     string `json:"context"`     // kubectl context the task must be solved in
+	ClusterSlug string `json:"clusterSlug"` // Slug of the ClusterSpec the context points at
+	Flagged     bool   `json:"flagged"`
+	Skipped     bool   `json:"skipped"`
+}
+
+// QuestionResult is the graded outcome of one question. The first four
+// fields are the shape documented for mock_exams.results.
+type QuestionResult struct {
+	ExerciseID int    `json:"exercise_id"`
+	Score      int    `json:"score"`
+	MaxScore   int    `json:"max_score"`
+	Passed     bool   `json:"passed"`
+	Number     int    `json:"number"`
+	Slug       string `json:"slug"`
+	Domain     string `json:"domain"`
+	Flagged    bool   `json:"flagged,omitempty"`
+	Skipped    bool   `json:"skipped,omitempty"`
+	Feedback   string `json:"feedback,omitempty"`
+	// WrongContext names the context the task was solved in when it wasn't
+	// the one the question asked for
+	WrongContext string   `json:"wrong_context,omitempty"`
+	Details      []string `json:"details,omitempty"`
+}
+
+// Exam is the state of one mock exam
+type Exam struct {
+	ID               int64            `json:"id"` // mock_exams.id
+	Type             Type             `json:"type"`
+	Mode             Mode             `json:"mode"`
+	Status           Status           `json:"status"`
+	Message          string           `json:"message,omitempty"` // Latest provisioning progress or error
+	EnvironmentSlug  string           `json:"environmentSlug"`   // Slug for the exam terminal at /api/terminal/
+	Clusters         []ClusterSpec    `json:"clusters"`
+	CreatedAt        time.Time        `json:"createdAt"`
+	StartedAt        *time.Time       `json:"startedAt,omitempty"` // Countdown start, once the environment is ready
+	Deadline         *time.Time       `json:"deadline,omitempty"`  // Moves when the countdown is paused; unset while paused
+	CompletedAt      *time.Time       `json:"completedAt,omitempty"`
+	RemainingSeconds int              `json:"remainingSeconds"`
+	Timer            *timer.Timer     `json:"timer,omitempty"` // Countdown details while in progress
+	Questions        []Question       `json:"questions"`
+	Results          []QuestionResult `json:"results,omitempty"`
+	Score            int              `json:"score"`
+	MaxScore         int              `json:"maxScore"`
+	Percentage       float64          `json:"percentage"`
+	Passed           bool             `json:"passed"`
+}
+
+// Grade is a validator's verdict on one task
+type Grade struct {
+	Passed   bool
+	Feedback string
+	Details  []string
+}
+
+// Grader runs an exercise's validator against a kubectl context
+type Grader func(ctx context.Context, slug, kubeContext string) Grade
+
+// Environment sets up and removes the clusters an exam runs in
+type Environment interface {
+	// Provision creates the clusters, runs every question's exercise setup
+	// in its cluster and creates the question contexts
+	Provision(ctx context.Context, clusters []ClusterSpec, questions []Question, progress func(string)) error
+	// Teardown deletes the clusters and the question contexts
+	Teardown(ctx context.Context, clusters []ClusterSpec, questions []Question) error
+}
+
+// Errors returned by the Manager
+var (
+	ErrNotFound        = errors.New("exam not found")
+	ErrExamActive      = errors.New("another exam is already running")
+	ErrNotInProgress   = errors.New("exam is not in progress")
+	ErrTimeUp          = errors.New("exam time is up")
+	ErrUnknownQuestion = errors.New("question not found")
+	ErrUnknownType     = errors.New("unknown exam type")
+	ErrUnknownMode     = errors.New("unknown exam mode")
+	ErrNoExercises     = errors.New("no exercises available to build an exam")
+)
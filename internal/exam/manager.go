@@ -0,0 +1,509 @@
+package exam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/logger"
+	"github.com/patrickvassell/cks-weight-room/internal/timer"
+)
+
+// provisionTimeout bounds setting up the shared environment: cluster
+// creation plus every question's setup
+const provisionTimeout = 20 * time.Minute
+
+// autoGradeTimeout bounds grading an exam whose time ran out
+const autoGradeTimeout = 10 * time.Minute
+
+// ContextName returns the kubectl context question number n is solved in
+func ContextName(n int) string {
+	return fmt.Sprintf("mock-q%d", n)
+}
+
+// Manager builds, runs and grades mock exams. Only one exam can be active
+// at a time because they all share the same environment.
+type Manager struct {
+	mu      sync.Mutex
+	exams   map[int64]*Exam
+	cancels map[int64]context.CancelFunc // Cancels an exam's provisioning
+	env     Environment
+	grade   Grader
+	timers  *timer.Service
+	now     func() time.Time
+	rng     *rand.Rand
+}
+
+// NewManager creates a manager that provisions exams with env, grades them
+// with grade and runs their countdowns on timers. An exam whose countdown
+// reaches its deadline is graded automatically.
+func NewManager(env Environment, grade Grader, timers *timer.Service) *Manager {
+	m := &Manager{
+		exams:   make(map[int64]*Exam),
+		cancels: make(map[int64]context.CancelFunc),
+		env:     env,
+		grade:   grade,
+		timers:  timers,
+		now:     time.Now,
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	timers.OnExpire(timer.KindExam, m.deadlineReached)
+	return m
+}
+
+// Start builds a new exam of the given type and starts provisioning its
+// environment in the background. The countdown begins once the environment
+// is ready.
+func (m *Manager) Start(examType Type, mode Mode) (Exam, error) {
+	def, ok := LookupDefinition(examType)
+	if !ok {
+		return Exam{}, ErrUnknownType
+	}
+	if mode == "" {
+		mode = ModeShared
+	}
+	if mode != ModeShared && mode != ModeMultiCluster {
+		return Exam{}, ErrUnknownMode
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.activeLocked() != nil {
+		return Exam{}, ErrExamActive
+	}
+
+	candidates, err := loadCandidates()
+	if err != nil {
+		return Exam{}, err
+	}
+	weights, err := loadDomainWeights()
+	if err != nil {
+		return Exam{}, err
+	}
+	picked := Select(candidates, def.QuestionCount, weights, m.rng)
+	if len(picked) == 0 {
+		return Exam{}, ErrNoExercises
+	}
+
+	e := &Exam{
+		Type:            examType,
+		Mode:            mode,
+		Status:          StatusProvisioning,
+		Message:         "Preparing exam environment...",
+		EnvironmentSlug: EnvironmentSlug,
+		CreatedAt:       m.now(),
+	}
+	for i, c := range picked {
+		e.Questions = append(e.Questions, Question{
+			Number:     i + 1,
+			ExerciseID: c.ExerciseID,
+			Slug:       c.Slug,
+			Title:      c.Title,
+			Domain:     c.Domain,
+			Points:     c.Points,
+		})
+		e.MaxScore += c.Points
+	}
+	e.Clusters = assignClusters(mode, e.Questions)
+
+	id, err := insertExam(e)
+	if err != nil {
+		return Exam{}, err
+	}
+	e.ID = id
+	m.exams[id] = e
+
+	ctx, cancel := context.WithTimeout(context.Background(), provisionTimeout)
+	m.cancels[id] = cancel
+	go m.provision(ctx, e, def)
+
+	logger.Info("Started %s exam %d with %d questions on %d clusters", examType, id, len(e.Questions), len(e.Clusters))
+	return m.snapshotLocked(e), nil
+}
+
+// assignClusters maps each question to a cluster and context. In shared
+// mode every question gets its own context on one cluster; in multi-cluster
+// mode questions are dealt round-robin over MultiClusterSet and use that
+// cluster's context.
+func assignClusters(mode Mode, questions []Question) []ClusterSpec {
+	if mode == ModeShared {
+		shared := ClusterSpec{Slug: EnvironmentSlug}
+		for i := range questions {
+			questions[i].ClusterSlug = shared.Slug
+			questions[i].Context = ContextName(questions[i].Number)
+		}
+		return []ClusterSpec{shared}
+	}
+
+	used := len(MultiClusterSet)
+	if len(questions) < used {
+		used = len(questions)
+	}
+	for i := range questions {
+		spec := MultiClusterSet[i%used]
+		questions[i].ClusterSlug = spec.Slug
+		questions[i].Context = spec.Context
+	}
+	return append([]ClusterSpec(nil), MultiClusterSet[:used]...)
+}
+
+// provision sets up the exam clusters and starts the countdown
+func (m *Manager) provision(ctx context.Context, e *Exam, def Definition) {
+	m.mu.Lock()
+	clusters := append([]ClusterSpec(nil), e.Clusters...)
+	questions := append([]Question(nil), e.Questions...)
+	m.mu.Unlock()
+
+	err := m.env.Provision(ctx, clusters, questions, func(msg string) {
+		m.mu.Lock()
+		e.Message = msg
+		m.mu.Unlock()
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cancel, ok := m.cancels[e.ID]; ok {
+		cancel()
+		delete(m.cancels, e.ID)
+	}
+	if e.Status != StatusProvisioning {
+		return // Abandoned while provisioning
+	}
+
+	if err != nil {
+		logger.Error("Failed to provision exam %d: %v", e.ID, err)
+		e.Status = StatusFailed
+		e.Message = fmt.Sprintf("Failed to set up the exam environment: %v", err)
+		if err := deleteExam(e.ID); err != nil {
+			logger.Warn("Failed to remove failed exam %d: %v", e.ID, err)
+		}
+		return
+	}
+
+	countdown := m.timers.Start(timer.ExamTimerID(e.ID), timer.KindExam, strconv.FormatInt(e.ID, 10), def.Duration())
+	e.StartedAt = &countdown.StartedAt
+	e.Status = StatusInProgress
+	e.Message = ""
+	if err := markStarted(e.ID, countdown.StartedAt); err != nil {
+		logger.Warn("Failed to record start of exam %d: %v", e.ID, err)
+	}
+	if err := saveState(e); err != nil {
+		logger.Warn("Failed to save state of exam %d: %v", e.ID, err)
+	}
+	logger.Info("Exam %d environment ready, countdown started (%s)", e.ID, def.Duration())
+}
+
+// deadlineReached grades an exam when its countdown runs out
+func (m *Manager) deadlineReached(t timer.Timer) {
+	id, err := strconv.ParseInt(t.Subject, 10, 64)
+	if err != nil {
+		logger.Warn("Ignoring exam timer %s with invalid subject", t.ID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), autoGradeTimeout)
+	defer cancel()
+
+	logger.Info("Time is up for exam %d, grading", id)
+	if _, err := m.Finish(ctx, id); err != nil && !errors.Is(err, ErrNotInProgress) {
+		logger.Error("Failed to grade exam %d at its deadline: %v", id, err)
+	}
+}
+
+// Recover restores exams that were in progress when the app last stopped,
+// so they continue after a restart. Call it before the timers are loaded so
+// an exam whose deadline passed meanwhile is graded. Exams that were still
+// being provisioned are dropped; their environment has to be rebuilt.
+func (m *Manager) Recover() error {
+	exams, err := loadUnfinished()
+	if err != nil {
+		return err
+	}
+	dropped, err := deleteUnstarted()
+	if err != nil {
+		return err
+	}
+	if dropped > 0 {
+		logger.Warn("Dropped %d mock exams that were interrupted while provisioning", dropped)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range exams {
+		e.Status = StatusInProgress
+		e.Message = ""
+		m.exams[e.ID] = e
+		logger.Info("Recovered exam %d", e.ID)
+	}
+	return nil
+}
+
+// Get returns the current state of an exam
+func (m *Manager) Get(id int64) (Exam, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.exams[id]
+	if !ok {
+		return Exam{}, ErrNotFound
+	}
+	return m.snapshotLocked(e), nil
+}
+
+// Active returns the exam that is being provisioned, running or graded
+func (m *Manager) Active() (Exam, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e := m.activeLocked(); e != nil {
+		return m.snapshotLocked(e), true
+	}
+	return Exam{}, false
+}
+
+// Pause stops a running exam's countdown for reason. Exams only pause for
+// environment problems, a limited number of times.
+func (m *Manager) Pause(id int64, reason timer.PauseReason) (Exam, error) {
+	return m.updateTimer(id, func(timerID string) (timer.Timer, error) {
+		return m.timers.Pause(timerID, reason)
+	})
+}
+
+// Resume restarts a paused exam's countdown
+func (m *Manager) Resume(id int64) (Exam, error) {
+	return m.updateTimer(id, m.timers.Resume)
+}
+
+// updateTimer applies fn to the countdown of a running exam
+func (m *Manager) updateTimer(id int64, fn func(timerID string) (timer.Timer, error)) (Exam, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.exams[id]
+	if !ok {
+		return Exam{}, ErrNotFound
+	}
+	if e.Status != StatusInProgress {
+		return Exam{}, ErrNotInProgress
+	}
+	if _, err := fn(timer.ExamTimerID(id)); err != nil {
+		return Exam{}, err
+	}
+	return m.snapshotLocked(e), nil
+}
+
+// SetFlagged marks a question for review, or clears the mark
+func (m *Manager) SetFlagged(id int64, number int, flagged bool) (Exam, error) {
+	return m.updateQuestion(id, number, func(q *Question) { q.Flagged = flagged })
+}
+
+// SetSkipped marks a question as skipped, or clears the mark. Skipped
+// questions are still graded at the end.
+func (m *Manager) SetSkipped(id int64, number int, skipped bool) (Exam, error) {
+	return m.updateQuestion(id, number, func(q *Question) { q.Skipped = skipped })
+}
+
+// updateQuestion applies fn to a question of a running exam
+func (m *Manager) updateQuestion(id int64, number int, fn func(q *Question)) (Exam, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.exams[id]
+	if !ok {
+		return Exam{}, ErrNotFound
+	}
+	if e.Status != StatusInProgress {
+		return Exam{}, ErrNotInProgress
+	}
+	if countdown, err := m.timers.Get(timer.ExamTimerID(id)); err == nil && countdown.RemainingSeconds == 0 {
+		return Exam{}, ErrTimeUp
+	}
+	if number < 1 || number > len(e.Questions) {
+		return Exam{}, ErrUnknownQuestion
+	}
+	fn(&e.Questions[number-1])
+	if err := saveState(e); err != nil {
+		logger.Warn("Failed to save state of exam %d: %v", id, err)
+	}
+	return m.snapshotLocked(e), nil
+}
+
+// Finish ends a running exam, grades every question with the validators
+// and stores the results in mock_exams
+func (m *Manager) Finish(ctx context.Context, id int64) (Exam, error) {
+	m.mu.Lock()
+	e, ok := m.exams[id]
+	if !ok {
+		m.mu.Unlock()
+		return Exam{}, ErrNotFound
+	}
+	if e.Status != StatusInProgress {
+		m.mu.Unlock()
+		return Exam{}, ErrNotInProgress
+	}
+	e.Status = StatusGrading
+
+	// The countdown keeps running until the results are saved, so an exam
+	// interrupted while grading still ends at its deadline after a restart
+	completedAt := m.now()
+	duration := 0
+	if countdown, err := m.timers.Get(timer.ExamTimerID(id)); err == nil {
+		duration = countdown.ElapsedSeconds
+		if e.StartedAt != nil {
+			completedAt = e.StartedAt.Add(time.Duration(countdown.ElapsedSeconds+countdown.PausedSeconds) * time.Second)
+		}
+	}
+	questions := append([]Question(nil), e.Questions...)
+	var contexts []string
+	if e.Mode == ModeMultiCluster {
+		for _, c := range e.Clusters {
+			contexts = append(contexts, c.Context)
+		}
+	}
+	m.mu.Unlock()
+
+	logger.Info("Grading exam %d (%d questions)", id, len(questions))
+	results := make([]QuestionResult, 0, len(questions))
+	score := 0
+	for _, q := range questions {
+		result := m.gradeQuestion(ctx, q, contexts)
+		score += result.Score
+		results = append(results, result)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Grade a copy so the exam only reads as completed once its results are
+	// stored; if saving fails it stays in progress, with its timer, and
+	// Finish can be retried
+	graded := *e
+	graded.Results = results
+	graded.Score = score
+	graded.CompletedAt = &completedAt
+	if graded.MaxScore > 0 {
+		graded.Percentage = float64(score) / float64(graded.MaxScore) * 100
+	}
+	graded.Passed = graded.Percentage >= PassingPercentage
+
+	if err := saveResults(&graded, duration); err != nil {
+		e.Status = StatusInProgress
+		return m.snapshotLocked(e), err
+	}
+	graded.Status = StatusCompleted
+	*e = graded
+	m.timers.Remove(timer.ExamTimerID(id))
+	logger.Info("Exam %d graded: %d/%d (%.0f%%)", id, e.Score, e.MaxScore, e.Percentage)
+	return m.snapshotLocked(e), nil
+}
+
+// gradeQuestion runs the validator for one question in its context. When a
+// question fails and contexts lists other exam contexts, they are checked
+// too so a task solved on the wrong cluster is reported as such; it still
+// scores nothing.
+func (m *Manager) gradeQuestion(ctx context.Context, q Question, contexts []string) QuestionResult {
+	grade := m.grade(ctx, q.Slug, q.Context)
+
+	wrongContext := ""
+	if !grade.Passed {
+		for _, other := range contexts {
+			if other == q.Context {
+				continue
+			}
+			if m.grade(ctx, q.Slug, other).Passed {
+				wrongContext = other
+				grade.Feedback = fmt.Sprintf("The task was solved in context %s, but the question asked for %s.", other, q.Context)
+				break
+			}
+		}
+	}
+
+	// Validators report their own scale and no partial credit, so a passed
+	// question earns the exercise's points
+	score := 0
+	if grade.Passed {
+		score = q.Points
+	}
+
+	return QuestionResult{
+		ExerciseID: q.ExerciseID,
+		Score:      score,
+		MaxScore:   q.Points,
+		Passed:     grade.Passed,
+		Number:     q.Number,
+		Slug:       q.Slug,
+		Domain:     q.Domain,
+		Flagged:    q.Flagged,
+		Skipped:    q.Skipped,
+		Feedback:   grade.Feedback,
+		Details:    grade.Details,
+
+		WrongContext: wrongContext,
+	}
+}
+
+// Close tears down an exam's environment and forgets the exam. An exam
+// that hasn't been graded is abandoned and its mock_exams row removed.
+func (m *Manager) Close(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	e, ok := m.exams[id]
+	if !ok {
+		m.mu.Unlock()
+		return ErrNotFound
+	}
+	if e.Status == StatusGrading {
+		m.mu.Unlock()
+		return errors.New("exam is being graded")
+	}
+	if cancel, ok := m.cancels[id]; ok {
+		cancel()
+		delete(m.cancels, id)
+	}
+	if e.Status != StatusCompleted {
+		e.Status = StatusAbandoned
+		if err := deleteExam(id); err != nil {
+			logger.Warn("Failed to remove abandoned exam %d: %v", id, err)
+		}
+	}
+	clusters := append([]ClusterSpec(nil), e.Clusters...)
+	questions := append([]Question(nil), e.Questions...)
+	delete(m.exams, id)
+	m.timers.Remove(timer.ExamTimerID(id))
+	m.mu.Unlock()
+
+	return m.env.Teardown(ctx, clusters, questions)
+}
+
+// activeLocked returns the exam holding the shared environment, if any
+func (m *Manager) activeLocked() *Exam {
+	for _, e := range m.exams {
+		switch e.Status {
+		case StatusProvisioning, StatusInProgress, StatusGrading:
+			return e
+		}
+	}
+	return nil
+}
+
+// snapshotLocked copies an exam for callers and fills in the time left
+func (m *Manager) snapshotLocked(e *Exam) Exam {
+	s := *e
+	s.Clusters = append([]ClusterSpec(nil), e.Clusters...)
+	s.Questions = append([]Question(nil), e.Questions...)
+	s.Results = append([]QuestionResult(nil), e.Results...)
+	if e.Status == StatusInProgress {
+		if countdown, err := m.timers.Get(timer.ExamTimerID(e.ID)); err == nil {
+			s.Timer = &countdown
+			s.Deadline = countdown.Deadline
+			s.RemainingSeconds = countdown.RemainingSeconds
+		}
+	}
+	return s
+}
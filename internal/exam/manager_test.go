@@ -0,0 +1,401 @@
+package exam
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+	"github.com/patrickvassell/cks-weight-room/internal/timer"
+)
+
+type fakeEnvironment struct {
+	mu           sync.Mutex
+	provisionErr error
+	provisioned  []Question
+	tornDown     bool
+}
+
+func (f *fakeEnvironment) Provision(ctx context.Context, clusters []ClusterSpec, questions []Question, progress func(string)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	progress("Creating cluster...")
+	f.provisioned = questions
+	return f.provisionErr
+}
+
+func (f *fakeEnvironment) Teardown(ctx context.Context, clusters []ClusterSpec, questions []Question) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tornDown = true
+	return nil
+}
+
+// setupExamDB creates a database with exercises spread over the domains
+func setupExamDB(t *testing.T) {
+	t.Helper()
+	if err := database.Initialize(database.Config{Path: filepath.Join(t.TempDir(), "test.db")}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := database.ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+
+	curriculum, err := database.GetActiveCurriculum()
+	if err != nil {
+		t.Fatalf("GetActiveCurriculum failed: %v", err)
+	}
+	for _, d := range curriculum.Domains {
+		domain := d.Slug
+		for i := 0; i < 4; i++ {
+			_, err := database.DB.Exec(`
+				INSERT INTO exercises (slug, title, description, category, difficulty, points)
+				VALUES (?, ?, 'test', ?, 'easy', 10)
+			`, fmt.Sprintf("%s-%d", domain, i), fmt.Sprintf("%s %d", domain, i), domain)
+			if err != nil {
+				t.Fatalf("failed to insert exercise: %v", err)
+			}
+		}
+	}
+}
+
+// waitForStatus polls until provisioning has finished
+func waitForStatus(t *testing.T, m *Manager, id int64, status Status) Exam {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		e, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if e.Status == status {
+			return e
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("exam %d never reached status %s", id, status)
+	return Exam{}
+}
+
+func TestExamLifecycle(t *testing.T) {
+	setupExamDB(t)
+
+	env := &fakeEnvironment{}
+	var graded []string
+	grader := func(ctx context.Context, slug, kubeContext string) Grade {
+		graded = append(graded, kubeContext)
+		// Pass every question except the first
+		if kubeContext == ContextName(1) {
+			return Grade{Passed: false, Feedback: "not done"}
+		}
+		return Grade{Passed: true}
+	}
+
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	m := NewManager(env, grader, timer.NewServiceWithClock(clock))
+	m.now = clock
+
+	started, err := m.Start(FullMockExam, ModeShared)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if len(started.Questions) != 15 || started.MaxScore != 150 {
+		t.Fatalf("expected 15 questions worth 150 points, got %d worth %d", len(started.Questions), started.MaxScore)
+	}
+	if _, err := m.Start(QuickPractice, ""); !errors.Is(err, ErrExamActive) {
+		t.Errorf("expected ErrExamActive for a second exam, got %v", err)
+	}
+
+	e := waitForStatus(t, m, started.ID, StatusInProgress)
+	if e.RemainingSeconds != 7200 {
+		t.Errorf("expected a 2 hour countdown, got %d seconds", e.RemainingSeconds)
+	}
+
+	if _, err := m.SetFlagged(e.ID, 2, true); err != nil {
+		t.Fatalf("SetFlagged failed: %v", err)
+	}
+	if _, err := m.SetSkipped(e.ID, 3, true); err != nil {
+		t.Fatalf("SetSkipped failed: %v", err)
+	}
+	if _, err := m.SetFlagged(e.ID, 16, true); !errors.Is(err, ErrUnknownQuestion) {
+		t.Errorf("expected ErrUnknownQuestion, got %v", err)
+	}
+
+	now = now.Add(2*time.Hour + time.Minute)
+	if _, err := m.SetFlagged(e.ID, 4, true); !errors.Is(err, ErrTimeUp) {
+		t.Errorf("expected ErrTimeUp after the deadline, got %v", err)
+	}
+
+	finished, err := m.Finish(context.Background(), e.ID)
+	if err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if len(graded) != 15 {
+		t.Errorf("expected every question to be graded, got %d", len(graded))
+	}
+	if finished.Score != 140 || !finished.Passed {
+		t.Errorf("expected 140/150 and a pass, got %d/%d passed=%v", finished.Score, finished.MaxScore, finished.Passed)
+	}
+	if !finished.Results[1].Flagged || !finished.Results[2].Skipped {
+		t.Error("expected flag and skip marks to be kept in results")
+	}
+
+	var score, completed, duration int
+	var passed bool
+	var resultsJSON string
+	err = database.DB.QueryRow(`
+		SELECT overall_score, exercises_completed, total_duration_seconds, passed, results
+		FROM mock_exams WHERE id = ?
+	`, e.ID).Scan(&score, &completed, &duration, &passed, &resultsJSON)
+	if err != nil {
+		t.Fatalf("failed to read mock_exams row: %v", err)
+	}
+	if score != 140 || completed != 14 || duration != 7200 || !passed {
+		t.Errorf("unexpected stored result: score=%d completed=%d duration=%d passed=%v", score, completed, duration, passed)
+	}
+	var stored []QuestionResult
+	if err := json.Unmarshal([]byte(resultsJSON), &stored); err != nil || len(stored) != 15 {
+		t.Errorf("unexpected stored results (%v): %s", err, resultsJSON)
+	}
+
+	if err := m.Close(context.Background(), e.ID); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !env.tornDown {
+		t.Error("expected environment to be torn down")
+	}
+}
+
+func TestExamProvisionFailure(t *testing.T) {
+	setupExamDB(t)
+
+	m := NewManager(&fakeEnvironment{provisionErr: errors.New("docker not running")}, nil, timer.NewService())
+	started, err := m.Start(QuickPractice, "")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	waitForStatus(t, m, started.ID, StatusFailed)
+
+	var count int
+	database.DB.QueryRow("SELECT COUNT(*) FROM mock_exams").Scan(&count)
+	if count != 0 {
+		t.Errorf("expected failed exam not to be recorded, found %d rows", count)
+	}
+
+	// A failed exam doesn't block the next one
+	next, err := m.Start(QuickPractice, "")
+	if err != nil {
+		t.Fatalf("expected a new exam to start after a failure, got %v", err)
+	}
+	waitForStatus(t, m, next.ID, StatusFailed)
+}
+
+func TestMultiClusterExam(t *testing.T) {
+	setupExamDB(t)
+
+	// Every task is done, but question 2's work landed on the wrong cluster
+	var misplaced string
+	grader := func(ctx context.Context, slug, kubeContext string) Grade {
+		if slug == misplaced {
+			return Grade{Passed: kubeContext == "cks-cluster1"}
+		}
+		return Grade{Passed: true}
+	}
+
+	m := NewManager(&fakeEnvironment{}, grader, timer.NewService())
+	started, err := m.Start(QuickPractice, ModeMultiCluster)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if len(started.Clusters) != 3 {
+		t.Fatalf("expected 3 clusters, got %d", len(started.Clusters))
+	}
+	for i, q := range started.Questions {
+		want := MultiClusterSet[i%3]
+		if q.Context != want.Context || q.ClusterSlug != want.Slug {
+			t.Errorf("question %d: expected %s on %s, got %s on %s", q.Number, want.Context, want.Slug, q.Context, q.ClusterSlug)
+		}
+	}
+	misplaced = started.Questions[1].Slug
+
+	waitForStatus(t, m, started.ID, StatusInProgress)
+	finished, err := m.Finish(context.Background(), started.ID)
+	if err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+
+	wrong := finished.Results[1]
+	if wrong.Passed || wrong.Score != 0 || wrong.WrongContext != "cks-cluster1" {
+		t.Errorf("expected question 2 to fail as solved in cks-cluster1, got %+v", wrong)
+	}
+	if finished.Score != 40 {
+		t.Errorf("expected 40 points for the other four questions, got %d", finished.Score)
+	}
+}
+
+func TestStartRejectsUnknownMode(t *testing.T) {
+	m := NewManager(&fakeEnvironment{}, nil, timer.NewService())
+	if _, err := m.Start(QuickPractice, "single-node"); !errors.Is(err, ErrUnknownMode) {
+		t.Errorf("expected ErrUnknownMode, got %v", err)
+	}
+}
+
+func TestExamPauseAndDeadline(t *testing.T) {
+	setupExamDB(t)
+
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	m := NewManager(&fakeEnvironment{}, func(ctx context.Context, slug, kubeContext string) Grade {
+		return Grade{Passed: true}
+	}, timer.NewServiceWithClock(clock))
+	m.now = clock
+
+	started, err := m.Start(QuickPractice, "")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	waitForStatus(t, m, started.ID, StatusInProgress)
+
+	if _, err := m.Pause(started.ID, timer.ReasonBreak); !errors.Is(err, timer.ErrPauseNotAllowed) {
+		t.Errorf("expected breaks to be refused during an exam, got %v", err)
+	}
+	now = now.Add(10 * time.Minute)
+	paused, err := m.Pause(started.ID, timer.ReasonEnvironment)
+	if err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	if paused.Timer == nil || paused.Timer.State != timer.StatePaused || paused.Deadline != nil {
+		t.Errorf("expected a paused countdown without deadline, got %+v", paused.Timer)
+	}
+
+	// Time spent paused doesn't count
+	now = now.Add(5 * time.Minute)
+	resumed, err := m.Resume(started.ID)
+	if err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if resumed.RemainingSeconds != 20*60 {
+		t.Errorf("expected 20 minutes left, got %d seconds", resumed.RemainingSeconds)
+	}
+
+	// The deadline grades the exam without anyone pressing finish
+	now = now.Add(20 * time.Minute)
+	m.deadlineReached(timer.Timer{ID: timer.ExamTimerID(started.ID), Kind: timer.KindExam, Subject: fmt.Sprint(started.ID)})
+	finished, err := m.Get(started.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if finished.Status != StatusCompleted || !finished.Passed {
+		t.Errorf("expected a graded pass at the deadline, got %s passed=%v", finished.Status, finished.Passed)
+	}
+
+	var duration int
+	database.DB.QueryRow("SELECT total_duration_seconds FROM mock_exams WHERE id = ?", started.ID).Scan(&duration)
+	if duration != 30*60 {
+		t.Errorf("expected 30 minutes of exam time stored, got %d seconds", duration)
+	}
+	if finished.CompletedAt == nil || !finished.CompletedAt.Equal(finished.StartedAt.Add(35*time.Minute)) {
+		t.Errorf("expected completion 35 minutes after the start, got %v", finished.CompletedAt)
+	}
+}
+
+func TestExamRecoverAfterRestart(t *testing.T) {
+	setupExamDB(t)
+
+	now := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	m := NewManager(&fakeEnvironment{}, nil, timer.NewServiceWithClock(clock))
+	m.now = clock
+
+	started, err := m.Start(QuickPractice, "")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	waitForStatus(t, m, started.ID, StatusInProgress)
+	if _, err := m.SetFlagged(started.ID, 2, true); err != nil {
+		t.Fatalf("SetFlagged failed: %v", err)
+	}
+
+	// An exam left provisioning by the crash is dropped
+	if _, err := database.DB.Exec("INSERT INTO mock_exams (exam_type, started_at, max_score, exercises_total) VALUES ('quick-practice', datetime('now'), 50, 5)"); err != nil {
+		t.Fatalf("failed to insert interrupted exam: %v", err)
+	}
+
+	// The app restarts 10 minutes later
+	now = now.Add(10 * time.Minute)
+	timers := timer.NewServiceWithClock(clock)
+	restarted := NewManager(&fakeEnvironment{}, nil, timers)
+	restarted.now = clock
+	if err := restarted.Recover(); err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	if err := timers.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	e, err := restarted.Get(started.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if e.Status != StatusInProgress || e.RemainingSeconds != 20*60 {
+		t.Errorf("expected the exam to resume with 20 minutes left, got %s with %d seconds", e.Status, e.RemainingSeconds)
+	}
+	if !e.Questions[1].Flagged {
+		t.Error("expected the flag on question 2 to survive the restart")
+	}
+
+	var count int
+	database.DB.QueryRow("SELECT COUNT(*) FROM mock_exams").Scan(&count)
+	if count != 1 {
+		t.Errorf("expected only the recovered exam to remain, found %d rows", count)
+	}
+}
+
+func TestFinishRetriesAfterSaveFailure(t *testing.T) {
+	setupExamDB(t)
+
+	grader := func(ctx context.Context, slug, kubeContext string) Grade {
+		return Grade{Passed: true}
+	}
+	m := NewManager(&fakeEnvironment{}, grader, timer.NewService())
+
+	started, err := m.Start(QuickPractice, "")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	waitForStatus(t, m, started.ID, StatusInProgress)
+
+	// Results can't be stored
+	_, err = database.DB.Exec(`
+		CREATE TRIGGER fail_results BEFORE UPDATE OF results ON mock_exams
+		BEGIN SELECT RAISE(ABORT, 'disk full'); END
+	`)
+	if err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+	if _, err := m.Finish(context.Background(), started.ID); err == nil {
+		t.Fatal("expected Finish to fail")
+	}
+	e, _ := m.Get(started.ID)
+	if e.Status != StatusInProgress || e.Timer == nil || e.CompletedAt != nil || len(e.Results) != 0 {
+		t.Fatalf("expected the exam to stay in progress with its timer, got %+v", e)
+	}
+
+	database.DB.Exec("DROP TRIGGER fail_results")
+	e, err = m.Finish(context.Background(), started.ID)
+	if err != nil || e.Status != StatusCompleted || !e.Passed {
+		t.Fatalf("expected the retry to complete the exam, got %+v (%v)", e, err)
+	}
+	var results string
+	database.DB.QueryRow("SELECT COALESCE(results, '') FROM mock_exams WHERE id = ?", started.ID).Scan(&results)
+	if results == "" {
+		t.Error("expected the results to be stored")
+	}
+}
@@ -0,0 +1,107 @@
+package exam
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Candidate is an exercise that can be picked for an exam
+type Candidate struct {
+	ExerciseID int
+	Slug       string
+	Title      string
+	Domain     string
+	Points     int
+}
+
+// Select picks count candidates, spreading them across domains in
+// proportion to weights (largest remainder method). Domains without
+// candidates are ignored, and a domain that runs out passes its share on to
+// the others. The result is shuffled so domains are interleaved.
+func Select(candidates []Candidate, count int, weights map[string]int, rng *rand.Rand) []Candidate {
+	byDomain := make(map[string][]Candidate)
+	for _, c := range candidates {
+		if weights[c.Domain] > 0 {
+			byDomain[c.Domain] = append(byDomain[c.Domain], c)
+		}
+	}
+
+	// Sorted so the allocation is deterministic for a given rng
+	domains := make([]string, 0, len(byDomain))
+	for d := range byDomain {
+		domains = append(domains, d)
+	}
+	sort.Strings(domains)
+
+	quotas := allocate(domains, byDomain, count, weights)
+
+	var picked []Candidate
+	for _, d := range domains {
+		pool := byDomain[d]
+		rng.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+		picked = append(picked, pool[:quotas[d]]...)
+	}
+	rng.Shuffle(len(picked), func(i, j int) { picked[i], picked[j] = picked[j], picked[i] })
+	return picked
+}
+
+// allocate splits count between domains by weight, capped at the number of
+// candidates each domain has
+func allocate(domains []string, byDomain map[string][]Candidate, count int, weights map[string]int) map[string]int {
+	quotas := make(map[string]int, len(domains))
+	remaining := count
+
+	// Each pass distributes what's left among domains that still have spare
+	// candidates, so capped domains hand their share to the rest
+	for remaining > 0 {
+		var open []string
+		totalWeight := 0
+		for _, d := range domains {
+			if quotas[d] < len(byDomain[d]) {
+				open = append(open, d)
+				totalWeight += weights[d]
+			}
+		}
+		if len(open) == 0 {
+			break
+		}
+
+		type share struct {
+			domain    string
+			remainder int
+		}
+		shares := make([]share, 0, len(open))
+		given := 0
+		for _, d := range open {
+			exact := remaining * weights[d]
+			n := exact / totalWeight
+			if spare := len(byDomain[d]) - quotas[d]; n > spare {
+				n = spare
+			}
+			quotas[d] += n
+			given += n
+			shares = append(shares, share{d, exact % totalWeight})
+		}
+
+		// Hand out the rounding leftovers to the largest remainders, one each
+		if given == 0 {
+			sort.SliceStable(shares, func(i, j int) bool {
+				if shares[i].remainder != shares[j].remainder {
+					return shares[i].remainder > shares[j].remainder
+				}
+				return weights[shares[i].domain] > weights[shares[j].domain]
+			})
+			for _, s := range shares {
+				if given == remaining {
+					break
+				}
+				if quotas[s.domain] < len(byDomain[s.domain]) {
+					quotas[s.domain]++
+					given++
+				}
+			}
+		}
+		remaining -= given
+	}
+	return quotas
+}
@@ -0,0 +1,106 @@
+package exam
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func candidatesFor(perDomain map[string]int) []Candidate {
+	var candidates []Candidate
+	id := 1
+	for domain, n := range perDomain {
+		for i := 0; i < n; i++ {
+			candidates = append(candidates, Candidate{
+				ExerciseID: id,
+				Slug:       fmt.Sprintf("%s-%d", domain, i),
+				Domain:     domain,
+				Points:     10,
+			})
+			id++
+		}
+	}
+	return candidates
+}
+
+// cksWeights are the curriculum weights the app ships with
+var cksWeights = map[string]int{
+	"cluster-setup":                         10,
+	"cluster-hardening":                     15,
+	"system-hardening":                      15,
+	"minimize-microservice-vulnerabilities": 20,
+	"supply-chain-security":                 20,
+	"monitoring-logging-runtime-security":   20,
+}
+
+func countByDomain(picked []Candidate) map[string]int {
+	counts := make(map[string]int)
+	for _, c := range picked {
+		counts[c.Domain]++
+	}
+	return counts
+}
+
+func TestSelectFollowsDomainWeights(t *testing.T) {
+	candidates := candidatesFor(map[string]int{
+		"cluster-setup":                         10,
+		"cluster-hardening":                     10,
+		"system-hardening":                      10,
+		"minimize-microservice-vulnerabilities": 10,
+		"supply-chain-security":                 10,
+		"monitoring-logging-runtime-security":   10,
+	})
+
+	picked := Select(candidates, 20, cksWeights, rand.New(rand.NewSource(1)))
+	if len(picked) != 20 {
+		t.Fatalf("expected 20 questions, got %d", len(picked))
+	}
+
+	expected := map[string]int{
+		"cluster-setup":                         2,
+		"cluster-hardening":                     3,
+		"system-hardening":                      3,
+		"minimize-microservice-vulnerabilities": 4,
+		"supply-chain-security":                 4,
+		"monitoring-logging-runtime-security":   4,
+	}
+	for domain, want := range expected {
+		if got := countByDomain(picked)[domain]; got != want {
+			t.Errorf("%s: expected %d questions, got %d", domain, want, got)
+		}
+	}
+
+	seen := make(map[int]bool)
+	for _, c := range picked {
+		if seen[c.ExerciseID] {
+			t.Fatalf("exercise %d picked twice", c.ExerciseID)
+		}
+		seen[c.ExerciseID] = true
+	}
+}
+
+func TestSelectRedistributesWhenDomainRunsOut(t *testing.T) {
+	candidates := candidatesFor(map[string]int{
+		"cluster-setup":         1,
+		"supply-chain-security": 3,
+		"system-hardening":      10,
+	})
+
+	picked := Select(candidates, 10, cksWeights, rand.New(rand.NewSource(2)))
+	if len(picked) != 10 {
+		t.Fatalf("expected 10 questions, got %d", len(picked))
+	}
+	counts := countByDomain(picked)
+	if counts["cluster-setup"] != 1 || counts["supply-chain-security"] != 3 || counts["system-hardening"] != 6 {
+		t.Errorf("unexpected distribution: %v", counts)
+	}
+}
+
+func TestSelectFewerCandidatesThanRequested(t *testing.T) {
+	candidates := candidatesFor(map[string]int{"cluster-setup": 2, "unknown-domain": 5})
+
+	picked := Select(candidates, 15, cksWeights, rand.New(rand.NewSource(3)))
+	if len(picked) != 2 {
+		t.Errorf("expected only the 2 weighted candidates, got %d", len(picked))
+	}
+}
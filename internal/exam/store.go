@@ -0,0 +1,185 @@
+package exam
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+// sqliteTime matches the format SQLite's datetime() produces
+const sqliteTime = "2006-01-02 15:04:05"
+
+var errNoDatabase = errors.New("database not initialized")
+
+// loadCandidates reads every exercise that can be used as an exam question
+func loadCandidates() ([]Candidate, error) {
+	if database.DB == nil {
+		return nil, errNoDatabase
+	}
+
+	rows, err := database.DB.Query("SELECT id, slug, title, category, points FROM exercises ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exercises: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []Candidate
+	for rows.Next() {
+		var c Candidate
+		if err := rows.Scan(&c.ExerciseID, &c.Slug, &c.Title, &c.Domain, &c.Points); err != nil {
+			return nil, fmt.Errorf("failed to read exercise: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// loadDomainWeights reads the weights of the active curriculum
+func loadDomainWeights() (map[string]int, error) {
+	curriculum, err := database.GetActiveCurriculum()
+	if err != nil {
+		return nil, err
+	}
+	return curriculum.Weights(), nil
+}
+
+// insertExam creates the mock_exams row for a new exam, owned by the active
+// profile, and returns its id
+func insertExam(e *Exam) (int64, error) {
+	if database.DB == nil {
+		return 0, errNoDatabase
+	}
+	profileID, err := database.ActiveProfileID()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := database.DB.Exec(`
+		INSERT INTO mock_exams (profile_id, exam_type, started_at, max_score, exercises_total)
+		VALUES (?, ?, ?, ?, ?)
+	`, profileID, string(e.Type), e.CreatedAt.UTC().Format(sqliteTime), e.MaxScore, len(e.Questions))
+	if err != nil {
+		return 0, fmt.Errorf("failed to record exam: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// markStarted records when the countdown began
+func markStarted(id int64, startedAt time.Time) error {
+	if database.DB == nil {
+		return errNoDatabase
+	}
+	_, err := database.DB.Exec("UPDATE mock_exams SET started_at = ? WHERE id = ?",
+		startedAt.UTC().Format(sqliteTime), id)
+	return err
+}
+
+// saveState stores an in-progress exam so it can be recovered after a
+// restart
+func saveState(e *Exam) error {
+	if database.DB == nil {
+		return errNoDatabase
+	}
+
+	state, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode exam state: %w", err)
+	}
+	_, err = database.DB.Exec("UPDATE mock_exams SET state = ? WHERE id = ?", string(state), e.ID)
+	return err
+}
+
+// loadUnfinished reads the saved state of every started exam that has no
+// result yet
+func loadUnfinished() ([]*Exam, error) {
+	if database.DB == nil {
+		return nil, errNoDatabase
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT state FROM mock_exams
+		WHERE completed_at IS NULL AND state IS NOT NULL
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load unfinished exams: %w", err)
+	}
+	defer rows.Close()
+
+	var exams []*Exam
+	for rows.Next() {
+		var state string
+		if err := rows.Scan(&state); err != nil {
+			return nil, fmt.Errorf("failed to read exam state: %w", err)
+		}
+		var e Exam
+		if err := json.Unmarshal([]byte(state), &e); err != nil {
+			return nil, fmt.Errorf("invalid exam state: %w", err)
+		}
+		exams = append(exams, &e)
+	}
+	return exams, rows.Err()
+}
+
+// deleteUnstarted removes exams whose environment was never ready, and
+// returns how many there were
+func deleteUnstarted() (int64, error) {
+	if database.DB == nil {
+		return 0, errNoDatabase
+	}
+	res, err := database.DB.Exec("DELETE FROM mock_exams WHERE completed_at IS NULL AND state IS NULL")
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove interrupted exams: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// saveResults writes the graded outcome of a finished exam that ran for
+// duration seconds, not counting pauses
+func saveResults(e *Exam, duration int) error {
+	if database.DB == nil {
+		return errNoDatabase
+	}
+
+	results, err := json.Marshal(e.Results)
+	if err != nil {
+		return fmt.Errorf("failed to encode exam results: %w", err)
+	}
+
+	completed := 0
+	for _, r := range e.Results {
+		if r.Passed {
+			completed++
+		}
+	}
+
+	_, err = database.DB.Exec(`
+		UPDATE mock_exams SET
+			completed_at = ?,
+			total_duration_seconds = ?,
+			overall_score = ?,
+			max_score = ?,
+			passed = ?,
+			exercises_completed = ?,
+			results = ?,
+			state = NULL
+		WHERE id = ?
+	`, e.CompletedAt.UTC().Format(sqliteTime), duration, e.Score, e.MaxScore, e.Passed, completed, string(results), e.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save exam results: %w", err)
+	}
+	return nil
+}
+
+// deleteExam removes the row of an exam that never produced a result, so
+// abandoned or failed exams don't count as taken
+func deleteExam(id int64) error {
+	if database.DB == nil {
+		return errNoDatabase
+	}
+	_, err := database.DB.Exec("DELETE FROM mock_exams WHERE id = ? AND completed_at IS NULL", id)
+	return err
+}
@@ -0,0 +1,138 @@
+// Package lifecycle lets components that own per-exercise resources (IDE
+// sessions, terminals, helper containers) tear them down when an exercise
+// environment goes away or the process exits.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/patrickvassell/cks-weight-room/internal/logger"
+)
+
+// EventType identifies a lifecycle event
+type EventType string
+
+const (
+	// ClusterDeleted is emitted before an exercise cluster is deleted
+	ClusterDeleted EventType = "cluster-deleted"
+	// ExerciseReset is emitted before an exercise cluster is torn down to be recreated
+	ExerciseReset EventType = "exercise-reset"
+	// Shutdown is emitted once when the process is exiting
+	Shutdown EventType = "shutdown"
+)
+
+// Event describes something that happened to an exercise or the process
+type Event struct {
+	Type EventType
+	Slug string // Exercise the event applies to; empty for Shutdown
+}
+
+// Handler reacts to an event. It should return once its resources are
+// released, or when ctx is done.
+type Handler func(ctx context.Context, event Event) error
+
+type subscription struct {
+	id    int
+	name  string
+	types map[EventType]bool
+	fn    Handler
+}
+
+// Bus delivers events to subscribers synchronously so teardown is
+// deterministic: exercise events run subscribers in registration order,
+// Shutdown runs them in reverse, like deferred calls.
+type Bus struct {
+	mu     sync.Mutex
+	subs   []*subscription
+	nextID int
+}
+
+// NewBus creates an empty bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers fn for the given event types (all types if none are
+// given) and returns a function that removes the subscription
+func (b *Bus) Subscribe(name string, fn Handler, types ...EventType) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &subscription{id: b.nextID, name: name, fn: fn}
+	b.nextID++
+	if len(types) > 0 {
+		sub.types = make(map[EventType]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+	b.subs = append(b.subs, sub)
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.subs {
+			if s.id == sub.id {
+				b.subs = append(b.subs[:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Emit delivers event to every matching subscriber and waits for them to
+// finish. A failing or panicking subscriber doesn't stop the others; their
+// errors are joined in the result.
+func (b *Bus) Emit(ctx context.Context, event Event) error {
+	b.mu.Lock()
+	var targets []*subscription
+	for _, s := range b.subs {
+		if s.types == nil || s.types[event.Type] {
+			targets = append(targets, s)
+		}
+	}
+	b.mu.Unlock()
+
+	if event.Type == Shutdown {
+		for i, j := 0, len(targets)-1; i < j; i, j = i+1, j-1 {
+			targets[i], targets[j] = targets[j], targets[i]
+		}
+	}
+
+	logger.Info("Lifecycle event %s (exercise: %q): notifying %d subscribers", event.Type, event.Slug, len(targets))
+
+	var errs []error
+	for _, s := range targets {
+		if err := b.deliver(ctx, s, event); err != nil {
+			logger.Warn("Lifecycle subscriber %s failed on %s: %v", s.name, event.Type, err)
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver runs one subscriber, converting a panic into an error
+func (b *Bus) deliver(ctx context.Context, s *subscription, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return s.fn(ctx, event)
+}
+
+// defaultBus is the process-wide bus used by the package-level functions
+var defaultBus = NewBus()
+
+// Subscribe registers fn on the process-wide bus
+func Subscribe(name string, fn Handler, types ...EventType) func() {
+	return defaultBus.Subscribe(name, fn, types...)
+}
+
+// Emit delivers event on the process-wide bus
+func Emit(ctx context.Context, event Event) error {
+	return defaultBus.Emit(ctx, event)
+}
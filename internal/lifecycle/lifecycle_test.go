@@ -0,0 +1,78 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func recorder(calls *[]string, name string) Handler {
+	return func(ctx context.Context, event Event) error {
+		*calls = append(*calls, name+":"+string(event.Type)+":"+event.Slug)
+		return nil
+	}
+}
+
+func TestEmitOrder(t *testing.T) {
+	bus := NewBus()
+	var calls []string
+	bus.Subscribe("terminals", recorder(&calls, "terminals"))
+	bus.Subscribe("ide", recorder(&calls, "ide"))
+
+	bus.Emit(context.Background(), Event{Type: ClusterDeleted, Slug: "demo"})
+	bus.Emit(context.Background(), Event{Type: Shutdown})
+
+	expected := []string{
+		"terminals:cluster-deleted:demo",
+		"ide:cluster-deleted:demo",
+		"ide:shutdown:",
+		"terminals:shutdown:",
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("unexpected call order:\n got %v\nwant %v", calls, expected)
+	}
+}
+
+func TestEmitFiltersByType(t *testing.T) {
+	bus := NewBus()
+	var calls []string
+	bus.Subscribe("mirror", recorder(&calls, "mirror"), Shutdown)
+	bus.Subscribe("ide", recorder(&calls, "ide"), ClusterDeleted, ExerciseReset)
+
+	bus.Emit(context.Background(), Event{Type: ExerciseReset, Slug: "demo"})
+
+	if !reflect.DeepEqual(calls, []string{"ide:exercise-reset:demo"}) {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	bus := NewBus()
+	var calls []string
+	unsubscribe := bus.Subscribe("ide", recorder(&calls, "ide"))
+	unsubscribe()
+
+	bus.Emit(context.Background(), Event{Type: Shutdown})
+	if len(calls) != 0 {
+		t.Errorf("expected no calls after unsubscribe, got %v", calls)
+	}
+}
+
+func TestEmitContinuesAfterFailures(t *testing.T) {
+	bus := NewBus()
+	var calls []string
+	failure := errors.New("docker unavailable")
+
+	bus.Subscribe("failing", func(ctx context.Context, event Event) error { return failure })
+	bus.Subscribe("panicking", func(ctx context.Context, event Event) error { panic("boom") })
+	bus.Subscribe("ide", recorder(&calls, "ide"))
+
+	err := bus.Emit(context.Background(), Event{Type: ClusterDeleted, Slug: "demo"})
+	if !errors.Is(err, failure) {
+		t.Errorf("expected joined error to include subscriber failure, got %v", err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("expected later subscribers to still run, got %v", calls)
+	}
+}
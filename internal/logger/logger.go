@@ -231,11 +231,22 @@ func (l *Logger) Error(format string, args ...interface{}) {
 	l.log(LevelError, format, args...)
 }
 
-// Close closes the logger
+// Sync flushes written log lines to disk
+func (l *Logger) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		return l.file.Sync()
+	}
+	return nil
+}
+
+// Close flushes and closes the logger
 func (l *Logger) Close() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	if l.file != nil {
+		l.file.Sync()
 		return l.file.Close()
 	}
 	return nil
@@ -271,6 +282,14 @@ func Error(format string, args ...interface{}) {
 	}
 }
 
+// Sync flushes the global logger to disk
+func Sync() error {
+	if globalLogger != nil {
+		return globalLogger.Sync()
+	}
+	return nil
+}
+
 // Close closes the global logger
 func Close() error {
 	if globalLogger != nil {
@@ -0,0 +1,395 @@
+// Package readiness estimates how likely a pass of the CKS exam is. Every
+// validated attempt and every graded mock exam question is evidence about
+// its domain; evidence fades with a half-life so recent practice counts
+// most, solving slower than the exam allows drags the estimate down, and
+// the estimates are combined with the curriculum weights into an expected
+// exam score and a probability of clearing the passing mark.
+package readiness
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+const (
+	// HalfLifeDays is how long it takes for evidence to count half as much
+	HalfLifeDays = 14.0
+	// MockWeight is how much more a mock exam question counts than a
+	// practice attempt, since it was solved under exam conditions
+	MockWeight = 2.0
+	// PriorScore is the score assumed for a domain without evidence
+	PriorScore = 0.4
+	// PriorWeight is how many fresh attempts the prior is worth
+	PriorWeight = 1.0
+	// MinTimeFactor caps how much slow solving can take off a score
+	MinTimeFactor = 0.5
+	// ConfidenceScale is the evidence at which confidence reaches one half
+	ConfidenceScale = 3.0
+	// MaxWeakSkills is how many weak exercises a report lists
+	MaxWeakSkills = 5
+)
+
+// Exercise is the part of an exercise the model needs
+type Exercise struct {
+	ID     int
+	Slug   string
+	Title  string
+	Domain string
+	Points int
+}
+
+// Attempt is one validated practice attempt
+type Attempt struct {
+	ExerciseID      int
+	At              time.Time
+	Score           int
+	MaxScore        int
+	DurationSeconds int
+	Passed          bool
+}
+
+// MockQuestion is one graded question of a finished mock exam
+type MockQuestion struct {
+	ExerciseID int
+	Domain     string
+	At         time.Time
+	Score      int
+	MaxScore   int
+}
+
+// MockExam is a finished mock exam
+type MockExam struct {
+	At       time.Time
+	Score    int
+	MaxScore int
+	Passed   bool
+}
+
+// Input is everything the model is computed from
+type Input struct {
+	Exercises []Exercise
+	Attempts  []Attempt
+	Mocks     []MockExam
+	Questions []MockQuestion
+	// Domains are the active curriculum's domains and their weights
+	Domains []database.CurriculumDomain
+	// PassingPercentage is the score needed to pass
+	PassingPercentage int
+	// SecondsPerPoint is the exam clock available per point of score
+	SecondsPerPoint float64
+}
+
+// Report is the readiness estimate at a point in time
+type Report struct {
+	GeneratedAt       time.Time         `json:"generatedAt"`
+	PassingPercentage int               `json:"passingPercentage"`
+	ExpectedScore     float64           `json:"expectedScore"`   // Percent
+	PassProbability   float64           `json:"passProbability"` // 0 to 1
+	Confidence        float64           `json:"confidence"`      // 0 to 1
+	ConfidenceLevel   string            `json:"confidenceLevel"` // low, medium or high
+	Domains           []DomainReadiness `json:"domains"`
+	WeakestSkills     []Skill           `json:"weakestSkills"`
+	MockExams         MockSummary       `json:"mockExams"`
+}
+
+// DomainReadiness is the estimate for one curriculum domain
+type DomainReadiness struct {
+	Domain          string     `json:"domain"`
+	DisplayName     string     `json:"displayName"`
+	Weight          int        `json:"weight"`
+	ExpectedScore   float64    `json:"expectedScore"` // Percent, after the time factor
+	RawScore        float64    `json:"rawScore"`      // Percent, before it
+	PassProbability float64    `json:"passProbability"`
+	Confidence      float64    `json:"confidence"`
+	ConfidenceLevel string     `json:"confidenceLevel"`
+	Evidence        float64    `json:"evidence"` // Decayed weight of the attempts and mock questions
+	Attempts        int        `json:"attempts"`
+	MockQuestions   int        `json:"mockQuestions"`
+	TimeRatio       float64    `json:"timeRatio"` // Solve time over exam budget; 0 without timed passes
+	TimeFactor      float64    `json:"timeFactor"`
+	LastPracticed   *time.Time `json:"lastPracticed,omitempty"`
+}
+
+// Skill is an exercise and how well it is mastered
+type Skill struct {
+	ExerciseID    int       `json:"exerciseId"`
+	Slug          string    `json:"slug"`
+	Title         string    `json:"title"`
+	Domain        string    `json:"domain"`
+	Score         float64   `json:"score"` // Percent, decayed towards the prior
+	Attempts      int       `json:"attempts"`
+	LastPracticed time.Time `json:"lastPracticed"`
+}
+
+// MockSummary sums up the mock exams taken
+type MockSummary struct {
+	Taken       int        `json:"taken"`
+	Passed      int        `json:"passed"`
+	LatestScore *float64   `json:"latestScore,omitempty"` // Percent
+	LatestAt    *time.Time `json:"latestAt,omitempty"`
+}
+
+// evidence accumulates decayed observations of a score between 0 and 1
+type evidence struct {
+	weight, sum, sumSq float64
+	count              int
+	last               time.Time
+}
+
+func (e *evidence) add(score, weight float64, at time.Time) {
+	e.weight += weight
+	e.sum += weight * score
+	e.sumSq += weight * score * score
+	e.count++
+	if at.After(e.last) {
+		e.last = at
+	}
+}
+
+// mean is the evidence's weighted mean, pulled towards the prior
+func (e *evidence) mean() float64 {
+	return (e.sum + PriorWeight*PriorScore) / (e.weight + PriorWeight)
+}
+
+// variance is the uncertainty of mean: the spread of the observations
+// shrunk by how much evidence there is
+func (e *evidence) variance() float64 {
+	m := e.mean()
+	spread := m * (1 - m)
+	if e.weight > 0 {
+		observed := e.sumSq/e.weight - (e.sum/e.weight)*(e.sum/e.weight)
+		spread = math.Max(spread, observed)
+	}
+	return spread / (e.weight + PriorWeight)
+}
+
+// decay returns how much evidence from at still counts at now
+func decay(at, now time.Time) float64 {
+	days := now.Sub(at).Hours() / 24
+	if days <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, days/HalfLifeDays)
+}
+
+// confidence maps an amount of evidence to 0..1
+func confidence(weight float64) float64 {
+	return weight / (weight + ConfidenceScale)
+}
+
+// ConfidenceLevel names a confidence value
+func ConfidenceLevel(c float64) string {
+	switch {
+	case c >= 0.75:
+		return "high"
+	case c >= 0.4:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// normalCDF is the standard normal distribution function
+func normalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// passProbability is the chance a score estimated at mean with the given
+// variance clears threshold
+func passProbability(mean, variance, threshold float64) float64 {
+	if variance <= 0 {
+		if mean >= threshold {
+			return 1
+		}
+		return 0
+	}
+	return normalCDF((mean - threshold) / math.Sqrt(variance))
+}
+
+// ratio returns score over maxScore, clamped to 0..1
+func ratio(score, maxScore int) (float64, bool) {
+	if maxScore <= 0 {
+		return 0, false
+	}
+	return math.Min(1, math.Max(0, float64(score)/float64(maxScore))), true
+}
+
+// Assess computes the readiness report at now. Domains without a weight are
+// left out; exercises never attempted only count through their domain prior.
+func Assess(in Input, now time.Time) Report {
+	exercises := make(map[int]Exercise, len(in.Exercises))
+	for _, ex := range in.Exercises {
+		exercises[ex.ID] = ex
+	}
+
+	domainScores := make(map[string]*evidence)
+	skillScores := make(map[int]*evidence)
+	timeWeight := make(map[string]float64)
+	timeSum := make(map[string]float64)
+	attempts := make(map[string]int)
+	questions := make(map[string]int)
+	domainEvidence := func(domain string) *evidence {
+		if domainScores[domain] == nil {
+			domainScores[domain] = &evidence{}
+		}
+		return domainScores[domain]
+	}
+
+	for _, a := range in.Attempts {
+		ex, ok := exercises[a.ExerciseID]
+		if !ok {
+			continue
+		}
+		score, ok := ratio(a.Score, a.MaxScore)
+		if !ok {
+			continue
+		}
+		w := decay(a.At, now)
+		domainEvidence(ex.Domain).add(score, w, a.At)
+		attempts[ex.Domain]++
+		if skillScores[ex.ID] == nil {
+			skillScores[ex.ID] = &evidence{}
+		}
+		skillScores[ex.ID].add(score, w, a.At)
+
+		// Only passes say how long a task takes to get right
+		if a.Passed && a.DurationSeconds > 0 && ex.Points > 0 && in.SecondsPerPoint > 0 {
+			budget := float64(ex.Points) * in.SecondsPerPoint
+			timeWeight[ex.Domain] += w
+			timeSum[ex.Domain] += w * float64(a.DurationSeconds) / budget
+		}
+	}
+
+	for _, q := range in.Questions {
+		domain := q.Domain
+		if ex, ok := exercises[q.ExerciseID]; ok && domain == "" {
+			domain = ex.Domain
+		}
+		score, ok := ratio(q.Score, q.MaxScore)
+		if !ok || domain == "" {
+			continue
+		}
+		domainEvidence(domain).add(score, MockWeight*decay(q.At, now), q.At)
+		questions[domain]++
+	}
+
+	threshold := float64(in.PassingPercentage) / 100
+	report := Report{
+		GeneratedAt:       now,
+		PassingPercentage: in.PassingPercentage,
+		Domains:           []DomainReadiness{},
+		WeakestSkills:     []Skill{},
+	}
+
+	totalWeight := 0
+	for _, domain := range in.Domains {
+		if domain.Weight > 0 {
+			totalWeight += domain.Weight
+		}
+	}
+
+	var expected, variance, conf float64
+	for _, domain := range in.Domains {
+		if domain.Weight <= 0 {
+			continue
+		}
+		ev := domainEvidence(domain.Slug)
+		d := DomainReadiness{
+			Domain:        domain.Slug,
+			DisplayName:   domain.DisplayName,
+			Weight:        domain.Weight,
+			Evidence:      ev.weight,
+			Attempts:      attempts[domain.Slug],
+			MockQuestions: questions[domain.Slug],
+			TimeFactor:    1,
+		}
+		if ev.count > 0 {
+			last := ev.last
+			d.LastPracticed = &last
+		}
+		if timeWeight[domain.Slug] > 0 {
+			d.TimeRatio = timeSum[domain.Slug] / timeWeight[domain.Slug]
+			if d.TimeRatio > 1 {
+				d.TimeFactor = math.Max(MinTimeFactor, 1/d.TimeRatio)
+			}
+		}
+
+		raw := ev.mean()
+		score := raw * d.TimeFactor
+		v := ev.variance() * d.TimeFactor * d.TimeFactor
+		d.RawScore = raw * 100
+		d.ExpectedScore = score * 100
+		d.PassProbability = passProbability(score, v, threshold)
+		d.Confidence = confidence(ev.weight)
+		d.ConfidenceLevel = ConfidenceLevel(d.Confidence)
+		report.Domains = append(report.Domains, d)
+
+		share := float64(d.Weight) / float64(totalWeight)
+		expected += share * score
+		variance += share * share * v
+		conf += share * d.Confidence
+	}
+
+	if totalWeight > 0 {
+		report.ExpectedScore = expected * 100
+		report.PassProbability = passProbability(expected, variance, threshold)
+		report.Confidence = conf
+	}
+	report.ConfidenceLevel = ConfidenceLevel(report.Confidence)
+	report.WeakestSkills = weakest(skillScores, exercises, threshold)
+	report.MockExams = summarizeMocks(in.Mocks)
+	return report
+}
+
+// weakest lists the attempted exercises scoring below threshold, lowest
+// first
+func weakest(scores map[int]*evidence, exercises map[int]Exercise, threshold float64) []Skill {
+	skills := []Skill{}
+	for id, ev := range scores {
+		score := ev.mean()
+		if score >= threshold {
+			continue
+		}
+		ex := exercises[id]
+		skills = append(skills, Skill{
+			ExerciseID:    id,
+			Slug:          ex.Slug,
+			Title:         ex.Title,
+			Domain:        ex.Domain,
+			Score:         score * 100,
+			Attempts:      ev.count,
+			LastPracticed: ev.last,
+		})
+	}
+	sort.Slice(skills, func(i, j int) bool {
+		if skills[i].Score != skills[j].Score {
+			return skills[i].Score < skills[j].Score
+		}
+		return skills[i].ExerciseID < skills[j].ExerciseID
+	})
+	if len(skills) > MaxWeakSkills {
+		skills = skills[:MaxWeakSkills]
+	}
+	return skills
+}
+
+// summarizeMocks counts the mock exams and finds the latest score
+func summarizeMocks(mocks []MockExam) MockSummary {
+	var summary MockSummary
+	for _, m := range mocks {
+		summary.Taken++
+		if m.Passed {
+			summary.Passed++
+		}
+		score, ok := ratio(m.Score, m.MaxScore)
+		if !ok || (summary.LatestAt != nil && !m.At.After(*summary.LatestAt)) {
+			continue
+		}
+		at, percent := m.At, score*100
+		summary.LatestAt, summary.LatestScore = &at, &percent
+	}
+	return summary
+}
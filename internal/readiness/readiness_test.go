@@ -0,0 +1,181 @@
+package readiness
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+	"github.com/patrickvassell/cks-weight-room/internal/exam"
+)
+
+var testDomains = []database.CurriculumDomain{
+	{Slug: "a", DisplayName: "A", Weight: 50},
+	{Slug: "b", DisplayName: "B", Weight: 50},
+}
+
+func testInput(attempts []Attempt) Input {
+	return Input{
+		Exercises: []Exercise{
+			{ID: 1, Slug: "a1", Domain: "a", Points: 10},
+			{ID: 2, Slug: "b1", Domain: "b", Points: 10},
+			{ID: 3, Slug: "b2", Domain: "b", Points: 10},
+		},
+		Attempts:          attempts,
+		Domains:           testDomains,
+		PassingPercentage: 67,
+		SecondsPerPoint:   60,
+	}
+}
+
+func passes(exerciseID, n int, at time.Time, duration int) []Attempt {
+	var attempts []Attempt
+	for i := 0; i < n; i++ {
+		attempts = append(attempts, Attempt{ExerciseID: exerciseID, At: at, Score: 10, MaxScore: 10, DurationSeconds: duration, Passed: true})
+	}
+	return attempts
+}
+
+func TestAssessWithoutEvidence(t *testing.T) {
+	report := Assess(testInput(nil), time.Now())
+
+	if math.Abs(report.ExpectedScore-PriorScore*100) > 1e-9 {
+		t.Errorf("expected the prior score, got %.2f", report.ExpectedScore)
+	}
+	if report.PassProbability >= 0.5 || report.ConfidenceLevel != "low" || report.Confidence != 0 {
+		t.Errorf("expected a low-confidence fail, got %+v", report)
+	}
+	if len(report.Domains) != 2 || report.Domains[0].DisplayName != "A" {
+		t.Errorf("expected both domains in order, got %+v", report.Domains)
+	}
+}
+
+func TestAssessRecentPassesAreReady(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	attempts := append(passes(1, 10, now, 300), passes(2, 10, now, 300)...)
+	report := Assess(testInput(attempts), now)
+
+	if report.ExpectedScore < 90 || report.PassProbability < 0.95 {
+		t.Errorf("expected a likely pass, got score %.1f probability %.3f", report.ExpectedScore, report.PassProbability)
+	}
+	if report.ConfidenceLevel != "high" {
+		t.Errorf("expected high confidence, got %s (%.2f)", report.ConfidenceLevel, report.Confidence)
+	}
+	if report.Domains[0].TimeRatio != 0.5 || report.Domains[0].TimeFactor != 1 {
+		t.Errorf("expected fast solves to cost nothing, got %+v", report.Domains[0])
+	}
+}
+
+func TestAssessDecaysOldEvidence(t *testing.T) {
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	attempts := append(passes(1, 10, at, 300), passes(2, 10, at, 300)...)
+
+	fresh := Assess(testInput(attempts), at)
+	stale := Assess(testInput(attempts), at.AddDate(0, 0, 90))
+
+	if stale.ExpectedScore >= fresh.ExpectedScore || stale.Confidence >= fresh.Confidence {
+		t.Errorf("expected old evidence to count less: fresh %+v, stale %+v", fresh, stale)
+	}
+	got := stale.Domains[0].Evidence
+	want := 10 * math.Pow(0.5, 90/HalfLifeDays)
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("expected evidence %.4f after 90 days, got %.4f", want, got)
+	}
+}
+
+func TestAssessPenalisesSlowSolving(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	// A 10 point exercise gets 600 seconds of exam clock
+	attempts := append(passes(1, 10, now, 1200), passes(2, 10, now, 300)...)
+	report := Assess(testInput(attempts), now)
+
+	slow := report.Domains[0]
+	if slow.TimeRatio != 2 || slow.TimeFactor != 0.5 {
+		t.Fatalf("expected double the budget to halve the score, got %+v", slow)
+	}
+	if math.Abs(slow.ExpectedScore-slow.RawScore/2) > 1e-9 {
+		t.Errorf("expected the time factor applied, got raw %.1f expected %.1f", slow.RawScore, slow.ExpectedScore)
+	}
+	if slow.PassProbability >= report.Domains[1].PassProbability {
+		t.Errorf("expected the slow domain to be less ready than the fast one")
+	}
+}
+
+func TestAssessMockQuestionsAndWeakestSkills(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	in := testInput([]Attempt{
+		{ExerciseID: 2, At: now, Score: 2, MaxScore: 10},
+		{ExerciseID: 3, At: now, Score: 5, MaxScore: 10},
+		{ExerciseID: 1, At: now, Score: 10, MaxScore: 10, Passed: true},
+	})
+	in.Mocks = []MockExam{
+		{At: now.AddDate(0, 0, -7), Score: 50, MaxScore: 100},
+		{At: now, Score: 80, MaxScore: 100, Passed: true},
+	}
+	in.Questions = []MockQuestion{{ExerciseID: 1, At: now, Score: 0, MaxScore: 10}}
+	report := Assess(in, now)
+
+	a := report.Domains[0]
+	if a.MockQuestions != 1 || a.Evidence != 1+MockWeight {
+		t.Errorf("expected the mock question to count double in its exercise's domain, got %+v", a)
+	}
+	if len(report.WeakestSkills) != 2 || report.WeakestSkills[0].Slug != "b1" || report.WeakestSkills[1].Slug != "b2" {
+		t.Errorf("expected b1 then b2 as weakest skills, got %+v", report.WeakestSkills)
+	}
+	if report.MockExams.Taken != 2 || report.MockExams.Passed != 1 || *report.MockExams.LatestScore != 80 {
+		t.Errorf("unexpected mock summary %+v", report.MockExams)
+	}
+}
+
+func TestCurrentFromDatabase(t *testing.T) {
+	if err := database.Initialize(database.Config{Path: filepath.Join(t.TempDir(), "test.db")}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer database.Close()
+	if err := database.ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+
+	_, err := database.DB.Exec(`
+		INSERT INTO exercises (slug, title, description, category, difficulty, points, estimated_minutes)
+		VALUES ('setup', 'Setup', 'test', 'cluster-setup', 'easy', 20, 10)
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert exercise: %v", err)
+	}
+	_, err = database.DB.Exec(`
+		INSERT INTO attempts (exercise_id, started_at, completed_at, duration_seconds, score, max_score, passed)
+		VALUES (1, '2026-03-01 08:50:00', '2026-03-01 09:00:00', 600, 20, 20, 1)
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert attempt: %v", err)
+	}
+	_, err = database.DB.Exec(`
+		INSERT INTO mock_exams (exam_type, started_at, completed_at, overall_score, max_score, passed, exercises_total, results)
+		VALUES ('quick-practice', '2026-03-01 10:00:00', '2026-03-01 10:30:00', 10, 20, 0, 1,
+			'[{"exercise_id": 1, "score": 10, "max_score": 20, "passed": false, "domain": "cluster-setup"}]')
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert mock exam: %v", err)
+	}
+
+	report, err := Current(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Current failed: %v", err)
+	}
+	if len(report.Domains) != 6 || report.PassingPercentage != exam.PassingPercentage {
+		t.Fatalf("expected every curriculum domain, got %+v", report)
+	}
+	setup := report.Domains[0]
+	if setup.Domain != "cluster-setup" || setup.Attempts != 1 || setup.MockQuestions != 1 {
+		t.Errorf("unexpected cluster-setup readiness %+v", setup)
+	}
+	// 120 minutes over 15 questions of 20 points is 24 seconds a point
+	if math.Abs(setup.TimeRatio-600.0/480) > 1e-9 {
+		t.Errorf("expected the solve to be measured against 480 seconds, got ratio %.3f", setup.TimeRatio)
+	}
+	if report.MockExams.Taken != 1 {
+		t.Errorf("expected the mock exam to be counted, got %+v", report.MockExams)
+	}
+}
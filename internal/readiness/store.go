@@ -0,0 +1,160 @@
+package readiness
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+	"github.com/patrickvassell/cks-weight-room/internal/exam"
+)
+
+var errNoDatabase = errors.New("database not initialized")
+
+// Load reads the exercises and the active profile's attempts and mock exams
+// the model needs
+func Load() (Input, error) {
+	in := Input{PassingPercentage: exam.PassingPercentage}
+	if database.DB == nil {
+		return in, errNoDatabase
+	}
+
+	curriculum, err := database.GetActiveCurriculum()
+	if err != nil {
+		return in, err
+	}
+	in.Domains = curriculum.Domains
+	profileID, err := database.ActiveProfileID()
+	if err != nil {
+		return in, err
+	}
+	if in.Exercises, err = loadExercises(); err != nil {
+		return in, err
+	}
+	if in.Attempts, err = loadAttempts(profileID); err != nil {
+		return in, err
+	}
+	if in.Mocks, in.Questions, err = loadMocks(profileID); err != nil {
+		return in, err
+	}
+	in.SecondsPerPoint = secondsPerPoint(in.Exercises)
+	return in, nil
+}
+
+// Current loads the data and assesses it at now
+func Current(now time.Time) (Report, error) {
+	in, err := Load()
+	if err != nil {
+		return Report{}, err
+	}
+	return Assess(in, now), nil
+}
+
+// secondsPerPoint is how much of the full mock exam's clock a point of
+// score gets, for a draw of average exercises
+func secondsPerPoint(exercises []Exercise) float64 {
+	def, ok := exam.LookupDefinition(exam.FullMockExam)
+	if !ok || def.QuestionCount == 0 || len(exercises) == 0 {
+		return 0
+	}
+	total := 0
+	for _, ex := range exercises {
+		total += ex.Points
+	}
+	if total == 0 {
+		return 0
+	}
+	average := float64(total) / float64(len(exercises))
+	return def.Duration().Seconds() / (average * float64(def.QuestionCount))
+}
+
+func loadExercises() ([]Exercise, error) {
+	rows, err := database.DB.Query("SELECT id, slug, title, category, points FROM exercises ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exercises: %w", err)
+	}
+	defer rows.Close()
+
+	var exercises []Exercise
+	for rows.Next() {
+		var e Exercise
+		if err := rows.Scan(&e.ID, &e.Slug, &e.Title, &e.Domain, &e.Points); err != nil {
+			return nil, fmt.Errorf("failed to read exercise: %w", err)
+		}
+		exercises = append(exercises, e)
+	}
+	return exercises, rows.Err()
+}
+
+func loadAttempts(profileID int64) ([]Attempt, error) {
+	rows, err := database.DB.Query(`
+		SELECT exercise_id, CAST(strftime('%s', completed_at) AS INTEGER), score, max_score,
+			COALESCE(duration_seconds, 0), passed
+		FROM attempts
+		WHERE profile_id = ? AND completed_at IS NOT NULL
+		ORDER BY completed_at, id
+	`, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []Attempt
+	for rows.Next() {
+		var a Attempt
+		var at int64
+		if err := rows.Scan(&a.ExerciseID, &at, &a.Score, &a.MaxScore, &a.DurationSeconds, &a.Passed); err != nil {
+			return nil, fmt.Errorf("failed to read attempt: %w", err)
+		}
+		a.At = time.Unix(at, 0)
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+// loadMocks reads every finished mock exam and its graded questions
+func loadMocks(profileID int64) ([]MockExam, []MockQuestion, error) {
+	rows, err := database.DB.Query(`
+		SELECT CAST(strftime('%s', completed_at) AS INTEGER), overall_score, max_score, passed, results
+		FROM mock_exams
+		WHERE profile_id = ? AND completed_at IS NOT NULL
+		ORDER BY completed_at, id
+	`, profileID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load mock exams: %w", err)
+	}
+	defer rows.Close()
+
+	var mocks []MockExam
+	var questions []MockQuestion
+	for rows.Next() {
+		var m MockExam
+		var at int64
+		var results sql.NullString
+		if err := rows.Scan(&at, &m.Score, &m.MaxScore, &m.Passed, &results); err != nil {
+			return nil, nil, fmt.Errorf("failed to read mock exam: %w", err)
+		}
+		m.At = time.Unix(at, 0)
+		mocks = append(mocks, m)
+
+		if !results.Valid || results.String == "" {
+			continue
+		}
+		var graded []exam.QuestionResult
+		if err := json.Unmarshal([]byte(results.String), &graded); err != nil {
+			continue // Results predating the exam engine carry no usable detail
+		}
+		for _, r := range graded {
+			questions = append(questions, MockQuestion{
+				ExerciseID: r.ExerciseID,
+				Domain:     r.Domain,
+				At:         m.At,
+				Score:      r.Score,
+				MaxScore:   r.MaxScore,
+			})
+		}
+	}
+	return mocks, questions, rows.Err()
+}
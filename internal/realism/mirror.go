@@ -0,0 +1,117 @@
+package realism
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/logger"
+)
+
+// GetMirrorDir returns the directory holding offline copies of the allowed
+// documentation sites, one subdirectory per domain
+// (e.g. ~/.cks-weight-room/docs-mirror/kubernetes.io/docs/...)
+func GetMirrorDir() string {
+	if dir := os.Getenv("CKS_DOCS_MIRROR_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", "data", "docs-mirror")
+	}
+	return filepath.Join(home, ".cks-weight-room", "docs-mirror")
+}
+
+// MirrorAvailable reports whether a docs mirror has been downloaded
+func MirrorAvailable(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err == nil && len(entries) > 0
+}
+
+// NewMirrorHandler serves the mirror using name-based virtual hosting: a
+// request for http://kubernetes.io/docs/ is served from <dir>/kubernetes.io/docs/
+func NewMirrorHandler(dir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(host)
+
+		// Reject anything that could escape the mirror directory
+		if host == "" || strings.ContainsAny(host, `/\`) || strings.HasPrefix(host, ".") {
+			http.NotFound(w, r)
+			return
+		}
+
+		siteDir := filepath.Join(dir, host)
+		if _, err := os.Stat(siteDir); err != nil {
+			http.Error(w, fmt.Sprintf("%s is not available in the offline documentation mirror", host), http.StatusNotFound)
+			return
+		}
+
+		http.FileServer(http.Dir(siteDir)).ServeHTTP(w, r)
+	})
+}
+
+// MirrorServer serves the docs mirror to cluster nodes
+type MirrorServer struct {
+	Addr string
+	srv  *http.Server
+}
+
+var (
+	mirrorMu     sync.Mutex
+	activeMirror *MirrorServer
+)
+
+// StartMirror starts (or returns the already running) docs mirror listening on addr.
+// On Linux addr is the kind network gateway, the host's own address on that
+// bridge, so besides the cluster nodes any other container on the kind
+// network can reach the mirror. It only serves the public documentation.
+func StartMirror(addr, dir string) (*MirrorServer, error) {
+	mirrorMu.Lock()
+	defer mirrorMu.Unlock()
+
+	if activeMirror != nil {
+		return activeMirror, nil
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start docs mirror on %s: %w", addr, err)
+	}
+
+	srv := &http.Server{
+		Handler:           NewMirrorHandler(dir),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Error("Docs mirror stopped: %v", err)
+		}
+	}()
+
+	activeMirror = &MirrorServer{Addr: listener.Addr().String(), srv: srv}
+	logger.Info("Docs mirror serving %s on %s", dir, activeMirror.Addr)
+	return activeMirror, nil
+}
+
+// StopMirror shuts down the docs mirror if it is running
+func StopMirror(ctx context.Context) error {
+	mirrorMu.Lock()
+	defer mirrorMu.Unlock()
+
+	if activeMirror == nil {
+		return nil
+	}
+	err := activeMirror.srv.Shutdown(ctx)
+	activeMirror = nil
+	return err
+}
@@ -0,0 +1,134 @@
+package realism
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Bracketed paste markers that xterm.js adds around pasted text when the
+// shell has enabled bracketed paste mode. They are stripped before measuring,
+// so small pastes (a resource name, a flag) pass like typing does.
+const (
+	bracketedPasteStart = "\x1b[200~"
+	bracketedPasteEnd   = "\x1b[201~"
+)
+
+// Verdict describes how a single input message was classified
+type Verdict struct {
+	IsPaste bool   // Input looks like a bulk paste rather than typing
+	Allowed bool   // Input may be written to the terminal
+	Warn    bool   // The user should be told their paste was noticed
+	Bytes   int    // Size of the input, excluding paste markers
+	Lines   int    // Number of lines in the input
+	Reason  string // Why the input was classified as a paste
+}
+
+// PasteDetector classifies terminal input as typing or bulk paste according
+// to a PastePolicy. It is not safe for concurrent use; each terminal
+// connection owns one.
+type PasteDetector struct {
+	policy  PastePolicy
+	snippet map[string]bool
+	recent  []burstEntry
+}
+
+type burstEntry struct {
+	at    time.Time
+	bytes int
+}
+
+// NewPasteDetector creates a detector for the given policy
+func NewPasteDetector(policy PastePolicy) *PasteDetector {
+	snippets := make(map[string]bool, len(policy.AllowedSnippets))
+	for _, s := range policy.AllowedSnippets {
+		snippets[normalizeSnippet(s)] = true
+	}
+	return &PasteDetector{
+		policy:  policy,
+		snippet: snippets,
+	}
+}
+
+// Inspect classifies one input message received at time now
+func (d *PasteDetector) Inspect(data string, now time.Time) Verdict {
+	content := strings.ReplaceAll(strings.ReplaceAll(data, bracketedPasteStart, ""), bracketedPasteEnd, "")
+
+	v := Verdict{
+		Allowed: true,
+		Bytes:   len(content),
+		Lines:   countLines(content),
+	}
+
+	burst := d.recordBurst(now, v.Bytes)
+
+	switch {
+	case d.policy.MaxBytes > 0 && v.Bytes > d.policy.MaxBytes:
+		v.IsPaste = true
+		v.Reason = fmt.Sprintf("%d bytes in a single input (limit %d)", v.Bytes, d.policy.MaxBytes)
+	case d.policy.MaxLines > 0 && v.Lines > d.policy.MaxLines:
+		v.IsPaste = true
+		v.Reason = fmt.Sprintf("%d lines in a single input (limit %d)", v.Lines, d.policy.MaxLines)
+	case d.policy.BurstBytes > 0 && burst > d.policy.BurstBytes:
+		v.IsPaste = true
+		v.Reason = fmt.Sprintf("%d bytes within %dms", burst, d.policy.BurstWindowMs)
+	}
+
+	if !v.IsPaste {
+		return v
+	}
+
+	if d.snippet[normalizeSnippet(content)] {
+		return v
+	}
+
+	switch d.policy.Mode {
+	case PasteModeAllow:
+	case PasteModeWarn:
+		v.Warn = true
+	default:
+		v.Allowed = false
+	}
+	return v
+}
+
+// recordBurst adds n bytes at now to the sliding window and returns the
+// total within the window
+func (d *PasteDetector) recordBurst(now time.Time, n int) int {
+	window := time.Duration(d.policy.BurstWindowMs) * time.Millisecond
+	if window <= 0 {
+		return n
+	}
+
+	cutoff := now.Add(-window)
+	kept := d.recent[:0]
+	total := 0
+	for _, e := range d.recent {
+		if e.at.After(cutoff) {
+			kept = append(kept, e)
+			total += e.bytes
+		}
+	}
+	d.recent = append(kept, burstEntry{at: now, bytes: n})
+	return total + n
+}
+
+// countLines counts lines the way a shell would see them, treating \r
+// (what xterm.js sends for Enter) as a line break
+func countLines(s string) int {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	s = strings.TrimRight(s, "\n")
+	if s == "" {
+		return 0
+	}
+	return strings.Count(s, "\n") + 1
+}
+
+// normalizeSnippet makes snippet matching insensitive to line endings and
+// surrounding whitespace
+func normalizeSnippet(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.TrimSpace(s)
+}
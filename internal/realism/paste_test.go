@@ -0,0 +1,144 @@
+package realism
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPasteDetectorTypingPasses(t *testing.T) {
+	d := NewPasteDetector(DefaultPolicy().Paste)
+	now := time.Now()
+
+	for i, ch := range "kubectl get pods -A\r" {
+		v := d.Inspect(string(ch), now.Add(time.Duration(i)*50*time.Millisecond))
+		if v.IsPaste || !v.Allowed {
+			t.Fatalf("keystroke %q classified as paste: %+v", ch, v)
+		}
+	}
+}
+
+func TestPasteDetectorBlocksLargePaste(t *testing.T) {
+	d := NewPasteDetector(DefaultPolicy().Paste)
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"too many bytes", strings.Repeat("a", 300)},
+		{"too many lines", "line1\rline2\rline3\rline4\r"},
+		{"bracketed multiline", bracketedPasteStart + "a\nb\nc\nd" + bracketedPasteEnd},
+	}
+
+	now := time.Now()
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Space inputs out so burst detection doesn't carry between cases
+			v := d.Inspect(tt.input, now.Add(time.Duration(i)*time.Second))
+			if !v.IsPaste || v.Allowed {
+				t.Errorf("expected blocked paste, got %+v", v)
+			}
+			if v.Reason == "" {
+				t.Error("expected a reason for the blocked paste")
+			}
+		})
+	}
+}
+
+func TestPasteDetectorSmallBracketedPasteAllowed(t *testing.T) {
+	d := NewPasteDetector(DefaultPolicy().Paste)
+
+	v := d.Inspect(bracketedPasteStart+"nginx-deployment"+bracketedPasteEnd, time.Now())
+	if v.IsPaste || !v.Allowed {
+		t.Errorf("small paste should pass like typing, got %+v", v)
+	}
+	if v.Bytes != len("nginx-deployment") {
+		t.Errorf("expected paste markers to be excluded from size, got %d bytes", v.Bytes)
+	}
+}
+
+func TestPasteDetectorBurst(t *testing.T) {
+	policy := DefaultPolicy().Paste
+	d := NewPasteDetector(policy)
+	now := time.Now()
+
+	chunk := strings.Repeat("x", 200)
+	for i := 0; i < 2; i++ {
+		if v := d.Inspect(chunk, now.Add(time.Duration(i)*10*time.Millisecond)); v.IsPaste {
+			t.Fatalf("chunk %d should not trip burst detection yet: %+v", i, v)
+		}
+	}
+	v := d.Inspect(chunk, now.Add(20*time.Millisecond))
+	if !v.IsPaste || v.Allowed {
+		t.Errorf("expected burst to be blocked, got %+v", v)
+	}
+
+	// Once the window has passed the counter starts over
+	v = d.Inspect(chunk, now.Add(time.Second))
+	if v.IsPaste {
+		t.Errorf("expected burst window to expire, got %+v", v)
+	}
+}
+
+func TestPasteDetectorModes(t *testing.T) {
+	paste := strings.Repeat("a", 1000)
+
+	tests := []struct {
+		mode    string
+		allowed bool
+		warn    bool
+	}{
+		{PasteModeAllow, true, false},
+		{PasteModeWarn, true, true},
+		{PasteModeBlock, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			policy := DefaultPolicy().Paste
+			policy.Mode = tt.mode
+			v := NewPasteDetector(policy).Inspect(paste, time.Now())
+			if !v.IsPaste {
+				t.Fatalf("expected paste to be detected")
+			}
+			if v.Allowed != tt.allowed || v.Warn != tt.warn {
+				t.Errorf("mode %s: got allowed=%v warn=%v, expected allowed=%v warn=%v",
+					tt.mode, v.Allowed, v.Warn, tt.allowed, tt.warn)
+			}
+		})
+	}
+}
+
+func TestPasteDetectorAllowedSnippet(t *testing.T) {
+	snippet := "apiVersion: v1\nkind: Pod\nmetadata:\n  name: test\n"
+	policy := DefaultPolicy().Paste
+	policy.AllowedSnippets = []string{snippet}
+	d := NewPasteDetector(policy)
+
+	// xterm.js sends \r line endings; the snippet should still match
+	input := bracketedPasteStart + strings.ReplaceAll(snippet, "\n", "\r") + bracketedPasteEnd
+	v := d.Inspect(input, time.Now())
+	if !v.IsPaste || !v.Allowed {
+		t.Errorf("expected allowed snippet to pass, got %+v", v)
+	}
+}
+
+func TestExamPolicyDomains(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.AllowedDomains = []string{"kubernetes.io", "example.com"}
+
+	domains := policy.Domains()
+	seen := make(map[string]int)
+	for _, d := range domains {
+		seen[d]++
+	}
+	if seen["kubernetes.io"] != 1 {
+		t.Errorf("expected kubernetes.io exactly once, got %d", seen["kubernetes.io"])
+	}
+	if seen["example.com"] != 1 {
+		t.Error("expected extra domain to be included")
+	}
+	if seen["registry.k8s.io"] != 1 {
+		t.Error("expected registry domains to be included")
+	}
+}
@@ -0,0 +1,116 @@
+package realism
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Paste handling modes
+const (
+	PasteModeAllow = "allow" // Pass pastes through untouched
+	PasteModeWarn  = "warn"  // Pass pastes through but warn the user
+	PasteModeBlock = "block" // Drop pastes that aren't explicitly allowed
+)
+
+// PastePolicy controls how bulk pastes into an exam-realism terminal are treated
+type PastePolicy struct {
+	Mode            string   `json:"mode"`
+	MaxBytes        int      `json:"maxBytes"`        // Larger single inputs count as a paste
+	MaxLines        int      `json:"maxLines"`        // Inputs spanning more lines count as a paste
+	BurstBytes      int      `json:"burstBytes"`      // Bytes within BurstWindowMs that count as a paste
+	BurstWindowMs   int      `json:"burstWindowMs"`   // Window for burst detection
+	AllowedSnippets []string `json:"allowedSnippets"` // Text that may always be pasted (e.g. from the task)
+}
+
+// ExamPolicy is the per-exercise exam realism configuration
+type ExamPolicy struct {
+	Paste          PastePolicy `json:"paste"`
+	AllowedDomains []string    `json:"allowedDomains"` // Extra domains beyond the default docs allowlist
+}
+
+// DefaultDocsDomains lists the documentation sites allowed during the CKS exam
+var DefaultDocsDomains = []string{
+	"kubernetes.io",
+	"github.com",
+	"raw.githubusercontent.com",
+	"falco.org",
+	"etcd.io",
+	"aquasecurity.github.io",
+	"gitlab.com",
+	"helm.sh",
+}
+
+// DefaultRegistryDomains keeps image pulls working while node egress is restricted
+var DefaultRegistryDomains = []string{
+	"registry.k8s.io",
+	"registry-1.docker.io",
+	"auth.docker.io",
+	"production.cloudflare.docker.com",
+	"quay.io",
+	"ghcr.io",
+	"gcr.io",
+}
+
+// DefaultPolicy returns the policy used when an exercise doesn't define one
+func DefaultPolicy() ExamPolicy {
+	return ExamPolicy{
+		Paste: PastePolicy{
+			Mode:          PasteModeBlock,
+			MaxBytes:      256,
+			MaxLines:      3,
+			BurstBytes:    512,
+			BurstWindowMs: 100,
+		},
+	}
+}
+
+// policyFile is the per-exercise override, stored alongside the setup scripts
+const policyFile = "exam-policy.json"
+
+// ValidSlug reports whether an exercise slug from a URL is safe to use as a
+// path element
+func ValidSlug(slug string) bool {
+	return slug != "" && !strings.ContainsAny(slug, `/\`) && !strings.Contains(slug, "..")
+}
+
+// LoadPolicy returns the exam policy for an exercise, merging any
+// exam-policy.json in its setup directory over the defaults
+func LoadPolicy(exerciseSlug string) (ExamPolicy, error) {
+	policy := DefaultPolicy()
+	if !ValidSlug(exerciseSlug) {
+		return policy, fmt.Errorf("invalid exercise slug %q", exerciseSlug)
+	}
+
+	path := filepath.Join("internal", "exercises", "setups", exerciseSlug, policyFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return policy, nil
+	}
+	if err != nil {
+		return policy, fmt.Errorf("failed to read exam policy: %w", err)
+	}
+
+	// Unmarshal over the defaults so partial files only override what they set
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return DefaultPolicy(), fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// Domains returns the full egress allowlist for a policy
+func (p ExamPolicy) Domains() []string {
+	seen := make(map[string]bool)
+	var domains []string
+	for _, list := range [][]string{DefaultDocsDomains, DefaultRegistryDomains, p.AllowedDomains} {
+		for _, d := range list {
+			if d != "" && !seen[d] {
+				seen[d] = true
+				domains = append(domains, d)
+			}
+		}
+	}
+	return domains
+}
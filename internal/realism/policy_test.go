@@ -0,0 +1,16 @@
+package realism
+
+import "testing"
+
+func TestLoadPolicyRejectsUnsafeSlugs(t *testing.T) {
+	for _, slug := range []string{"", "../../etc", "a/b", `a\b`, ".."} {
+		if _, err := LoadPolicy(slug); err == nil {
+			t.Errorf("LoadPolicy(%q) should fail", slug)
+		}
+	}
+
+	policy, err := LoadPolicy("no-such-exercise")
+	if err != nil || policy.Paste.Mode != DefaultPolicy().Paste.Mode {
+		t.Errorf("expected the defaults for an exercise without a policy, got %+v (%v)", policy, err)
+	}
+}
@@ -0,0 +1,149 @@
+package schedule
+
+import (
+	"sort"
+	"time"
+)
+
+// defaultMinutes is the time planned for an exercise without an estimate
+const defaultMinutes = 15
+
+// Exercise is the part of an exercise the scheduler needs
+type Exercise struct {
+	ID               int    `json:"exerciseId"`
+	Slug             string `json:"slug"`
+	Title            string `json:"title"`
+	Domain           string `json:"domain"`
+	Difficulty       string `json:"difficulty"`
+	EstimatedMinutes int    `json:"estimatedMinutes"`
+}
+
+// minutes returns the time to plan for the exercise
+func (e Exercise) minutes() int {
+	if e.EstimatedMinutes > 0 {
+		return e.EstimatedMinutes
+	}
+	return defaultMinutes
+}
+
+// Item is an exercise in the practice queue
+type Item struct {
+	Exercise
+	State       State   `json:"state"`
+	New         bool    `json:"new"` // Never attempted
+	OverdueDays float64 `json:"overdueDays"`
+	Priority    float64 `json:"priority"`
+}
+
+// difficultyFactor orders new exercises easy first
+var difficultyFactor = map[string]float64{
+	"easy":   1.0,
+	"medium": 0.85,
+	"hard":   0.7,
+}
+
+// Queue returns what to practise at now: exercises due for review first,
+// most urgent first, then exercises never attempted. Urgency grows with
+// the domain's weight, with how overdue the exercise is relative to its
+// interval, and as its ease drops. Exercises not yet due are left out.
+func Queue(exercises []Exercise, history map[int][]Attempt, weights map[string]int, now time.Time) []Item {
+	var due, fresh []Item
+	for _, ex := range exercises {
+		weight := float64(weights[ex.Domain])
+		if weight == 0 {
+			weight = 1 // Unknown domains still get scheduled, last
+		}
+
+		attempts := history[ex.ID]
+		if len(attempts) == 0 {
+			factor, ok := difficultyFactor[ex.Difficulty]
+			if !ok {
+				factor = difficultyFactor["medium"]
+			}
+			fresh = append(fresh, Item{
+				Exercise: ex,
+				State:    NewState(),
+				New:      true,
+				Priority: weight / 100 * factor,
+			})
+			continue
+		}
+
+		state := Replay(attempts, ex.EstimatedMinutes)
+		if state.Due == nil || state.Due.After(now) {
+			continue
+		}
+		overdue := now.Sub(*state.Due).Hours() / 24
+		interval := float64(state.IntervalDays)
+		if interval < 1 {
+			interval = 1
+		}
+		due = append(due, Item{
+			Exercise:    ex,
+			State:       state,
+			OverdueDays: overdue,
+			Priority:    weight / 100 * (1 + overdue/interval) * (DefaultEase / state.Ease),
+		})
+	}
+
+	byPriority := func(items []Item) {
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].Priority != items[j].Priority {
+				return items[i].Priority > items[j].Priority
+			}
+			return items[i].ID < items[j].ID
+		})
+	}
+	byPriority(due)
+	byPriority(fresh)
+	return append(due, fresh...)
+}
+
+// Plan is a day's practice: due reviews and a few new exercises that fit
+// the time budget
+type Plan struct {
+	Date           string         `json:"date"` // YYYY-MM-DD, local time
+	BudgetMinutes  int            `json:"budgetMinutes"`
+	PlannedMinutes int            `json:"plannedMinutes"`
+	Items          []Item         `json:"items"`
+	DomainMinutes  map[string]int `json:"domainMinutes"`
+	Reviews        int            `json:"reviews"`
+	New            int            `json:"new"`
+	Deferred       int            `json:"deferred"` // Due reviews that didn't fit
+}
+
+// BuildPlan fills budgetMinutes from the queue in order, taking at most
+// maxNew never-attempted exercises. Exercises that don't fit are skipped in
+// favour of shorter ones further down, but the plan always holds at least
+// one exercise when the queue isn't empty.
+func BuildPlan(queue []Item, budgetMinutes, maxNew int, now time.Time) Plan {
+	plan := Plan{
+		Date:          now.Format("2006-01-02"),
+		BudgetMinutes: budgetMinutes,
+		Items:         []Item{},
+		DomainMinutes: make(map[string]int),
+	}
+
+	for _, item := range queue {
+		if item.New && plan.New >= maxNew {
+			continue
+		}
+		minutes := item.minutes()
+		if plan.PlannedMinutes+minutes > budgetMinutes && len(plan.Items) > 0 {
+			if !item.New {
+				plan.Deferred++
+			}
+			continue
+		}
+
+		plan.Items = append(plan.Items, item)
+		plan.PlannedMinutes += minutes
+		plan.DomainMinutes[item.Domain] += minutes
+		if item.New {
+			plan.New++
+		} else {
+			plan.Reviews++
+		}
+	}
+	return plan
+}
@@ -0,0 +1,128 @@
+// Package schedule decides what to practise next. Each exercise's attempt
+// history is replayed through SM-2 to get an ease factor and a due date;
+// due exercises are then ranked by how overdue they are and by the weight
+// of their CKS domain.
+package schedule
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// DefaultEase is the SM-2 starting ease factor
+	DefaultEase = 2.5
+	// MinEase keeps hard exercises from being scheduled every day forever
+	MinEase = 1.3
+	// PassQuality is the lowest quality that counts as remembered
+	PassQuality = 3
+)
+
+// Attempt is one validated attempt at an exercise
+type Attempt struct {
+	At              time.Time
+	Passed          bool
+	Score           int
+	MaxScore        int
+	DurationSeconds int
+	HintsUsed       int
+}
+
+// State is the SM-2 state of one exercise after replaying its attempts
+type State struct {
+	Ease         float64    `json:"ease"`
+	IntervalDays int        `json:"intervalDays"`
+	Repetitions  int        `json:"repetitions"` // Successful reviews in a row
+	Lapses       int        `json:"lapses"`      // Failed reviews
+	Reviews      int        `json:"reviews"`     // Attempts that counted as reviews
+	LastQuality  int        `json:"lastQuality"`
+	LastReviewed *time.Time `json:"lastReviewed,omitempty"`
+	Due          *time.Time `json:"due,omitempty"` // Unset until the first attempt
+}
+
+// NewState returns the state of an exercise that was never attempted
+func NewState() State {
+	return State{Ease: DefaultEase}
+}
+
+// Quality grades an attempt on the SM-2 scale of 0 to 5. A pass starts at 5
+// and loses a point for running over the estimated time (two for more than
+// double) and a point per hint, down to 3. A fail scores 2 with at least
+// half the points, 1 with some and 0 with none.
+func Quality(a Attempt, estimatedMinutes int) int {
+	if !a.Passed {
+		switch {
+		case a.MaxScore > 0 && a.Score*2 >= a.MaxScore:
+			return 2
+		case a.Score > 0:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	q := 5
+	if estimatedMinutes > 0 && a.DurationSeconds > 0 {
+		estimated := estimatedMinutes * 60
+		if a.DurationSeconds > 2*estimated {
+			q -= 2
+		} else if a.DurationSeconds > estimated {
+			q--
+		}
+	}
+	q -= a.HintsUsed
+	if q < PassQuality {
+		q = PassQuality
+	}
+	return q
+}
+
+// Review applies one review of the given quality at 'at' and returns the
+// new state
+func (s State) Review(quality int, at time.Time) State {
+	if quality < PassQuality {
+		s.Repetitions = 0
+		s.IntervalDays = 1
+		s.Lapses++
+	} else {
+		switch s.Repetitions {
+		case 0:
+			s.IntervalDays = 1
+		case 1:
+			s.IntervalDays = 6
+		default:
+			s.IntervalDays = int(math.Round(float64(s.IntervalDays) * s.Ease))
+		}
+		s.Repetitions++
+	}
+
+	d := float64(5 - quality)
+	s.Ease += 0.1 - d*(0.08+d*0.02)
+	if s.Ease < MinEase {
+		s.Ease = MinEase
+	}
+
+	reviewed := at
+	due := at.AddDate(0, 0, s.IntervalDays)
+	s.LastReviewed = &reviewed
+	s.Due = &due
+	s.LastQuality = quality
+	s.Reviews++
+	return s
+}
+
+// Replay computes an exercise's state from its attempts, oldest first. A
+// pass before the exercise is due doesn't count as a review, so validating
+// the same solution again doesn't push the exercise further out; a fail
+// always does.
+func Replay(attempts []Attempt, estimatedMinutes int) State {
+	s := NewState()
+	for _, a := range attempts {
+		q := Quality(a, estimatedMinutes)
+		if s.Due != nil && q >= PassQuality && a.At.Before(*s.Due) {
+			continue
+		}
+		s = s.Review(q, a.At)
+	}
+	return s
+}
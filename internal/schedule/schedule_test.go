@@ -0,0 +1,117 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+var day0 = time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+func days(n int) time.Time { return day0.AddDate(0, 0, n) }
+
+func TestQuality(t *testing.T) {
+	tests := []struct {
+		name    string
+		attempt Attempt
+		want    int
+	}{
+		{"clean pass", Attempt{Passed: true, DurationSeconds: 600}, 5},
+		{"slow pass", Attempt{Passed: true, DurationSeconds: 1000}, 4},
+		{"very slow pass", Attempt{Passed: true, DurationSeconds: 2000}, 3},
+		{"pass with a hint", Attempt{Passed: true, DurationSeconds: 600, HintsUsed: 1}, 4},
+		{"slow pass with hints", Attempt{Passed: true, DurationSeconds: 2000, HintsUsed: 3}, 3},
+		{"near miss", Attempt{Score: 6, MaxScore: 10}, 2},
+		{"partial", Attempt{Score: 2, MaxScore: 10}, 1},
+		{"blank", Attempt{MaxScore: 10}, 0},
+	}
+	for _, tt := range tests {
+		if got := Quality(tt.attempt, 15); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReplayIntervals(t *testing.T) {
+	pass := func(d int) Attempt { return Attempt{At: days(d), Passed: true, DurationSeconds: 300} }
+
+	s := Replay([]Attempt{pass(0), pass(1), pass(7)}, 15)
+	if s.Repetitions != 3 || s.IntervalDays != 16 {
+		t.Errorf("expected 3 repetitions and a 16 day interval, got %d and %d", s.Repetitions, s.IntervalDays)
+	}
+	if !s.Due.Equal(days(23)) {
+		t.Errorf("expected due on day 23, got %v", s.Due)
+	}
+	if s.Ease <= DefaultEase {
+		t.Errorf("expected perfect reviews to raise the ease, got %.2f", s.Ease)
+	}
+
+	// Revalidating before the due date doesn't count
+	early := Replay([]Attempt{pass(0), pass(0), pass(0)}, 15)
+	if early.Reviews != 1 || early.IntervalDays != 1 {
+		t.Errorf("expected early passes to be ignored, got %d reviews", early.Reviews)
+	}
+
+	// A failure resets the interval and lowers the ease, even when early
+	failed := Replay([]Attempt{pass(0), pass(1), {At: days(2), MaxScore: 10}}, 15)
+	if failed.Repetitions != 0 || failed.IntervalDays != 1 || failed.Lapses != 1 {
+		t.Errorf("expected a lapse to reset the schedule, got %+v", failed)
+	}
+	if failed.Ease >= DefaultEase {
+		t.Errorf("expected a lapse to lower the ease, got %.2f", failed.Ease)
+	}
+
+	floor := NewState()
+	for i := 0; i < 20; i++ {
+		floor = floor.Review(0, days(i))
+	}
+	if floor.Ease != MinEase {
+		t.Errorf("expected ease to bottom out at %.1f, got %.2f", MinEase, floor.Ease)
+	}
+}
+
+func TestQueueOrdering(t *testing.T) {
+	exercises := []Exercise{
+		{ID: 1, Slug: "setup", Domain: "cluster-setup", Difficulty: "easy", EstimatedMinutes: 10},
+		{ID: 2, Slug: "supply", Domain: "supply-chain-security", Difficulty: "easy", EstimatedMinutes: 10},
+		{ID: 3, Slug: "later", Domain: "supply-chain-security", Difficulty: "easy", EstimatedMinutes: 10},
+		{ID: 4, Slug: "new-hard", Domain: "supply-chain-security", Difficulty: "hard", EstimatedMinutes: 30},
+		{ID: 5, Slug: "new-easy", Domain: "supply-chain-security", Difficulty: "easy", EstimatedMinutes: 10},
+	}
+	weights := map[string]int{"cluster-setup": 10, "supply-chain-security": 20}
+	history := map[int][]Attempt{
+		1: {{At: days(0), Passed: true}},
+		2: {{At: days(0), Passed: true}},
+		3: {{At: days(0), Passed: true}, {At: days(1), Passed: true}},
+	}
+
+	queue := Queue(exercises, history, weights, days(2))
+	var slugs []string
+	for _, item := range queue {
+		slugs = append(slugs, item.Slug)
+	}
+	want := []string{"supply", "setup", "new-easy", "new-hard"}
+	if len(slugs) != len(want) {
+		t.Fatalf("got queue %v, want %v", slugs, want)
+	}
+	for i := range want {
+		if slugs[i] != want[i] {
+			t.Fatalf("got queue %v, want %v", slugs, want)
+		}
+	}
+	if queue[0].OverdueDays != 1 || queue[2].New != true {
+		t.Errorf("unexpected queue items: %+v", queue)
+	}
+
+	plan := BuildPlan(queue, 25, 1, days(2))
+	if plan.Reviews != 2 || plan.New != 0 || plan.PlannedMinutes != 20 {
+		t.Errorf("expected both reviews and no new exercise in 25 minutes, got %+v", plan)
+	}
+	plan = BuildPlan(queue, 60, 1, days(2))
+	if plan.Reviews != 2 || plan.New != 1 || plan.PlannedMinutes != 30 || plan.DomainMinutes["supply-chain-security"] != 20 {
+		t.Errorf("expected two reviews and one new exercise, got %+v", plan)
+	}
+	plan = BuildPlan(queue, 5, 0, days(2))
+	if len(plan.Items) != 1 || plan.Deferred != 1 {
+		t.Errorf("expected one exercise even over budget and one deferred, got %+v", plan)
+	}
+}
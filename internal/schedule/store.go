@@ -0,0 +1,87 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+var errNoDatabase = errors.New("database not initialized")
+
+// LoadExercises reads every exercise
+func LoadExercises() ([]Exercise, error) {
+	if database.DB == nil {
+		return nil, errNoDatabase
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id, slug, title, category, difficulty, COALESCE(estimated_minutes, 0)
+		FROM exercises ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exercises: %w", err)
+	}
+	defer rows.Close()
+
+	var exercises []Exercise
+	for rows.Next() {
+		var e Exercise
+		if err := rows.Scan(&e.ID, &e.Slug, &e.Title, &e.Domain, &e.Difficulty, &e.EstimatedMinutes); err != nil {
+			return nil, fmt.Errorf("failed to read exercise: %w", err)
+		}
+		exercises = append(exercises, e)
+	}
+	return exercises, rows.Err()
+}
+
+// LoadHistory reads the active profile's completed attempts, oldest first,
+// keyed by exercise id
+func LoadHistory() (map[int][]Attempt, error) {
+	if database.DB == nil {
+		return nil, errNoDatabase
+	}
+	profileID, err := database.ActiveProfileID()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT exercise_id, CAST(strftime('%s', completed_at) AS INTEGER), passed, score, max_score,
+			COALESCE(duration_seconds, 0), hints_used
+		FROM attempts
+		WHERE profile_id = ? AND completed_at IS NOT NULL
+		ORDER BY completed_at, id
+	`, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attempts: %w", err)
+	}
+	defer rows.Close()
+
+	history := make(map[int][]Attempt)
+	for rows.Next() {
+		var exerciseID int
+		var at int64
+		var a Attempt
+		if err := rows.Scan(&exerciseID, &at, &a.Passed, &a.Score, &a.MaxScore, &a.DurationSeconds, &a.HintsUsed); err != nil {
+			return nil, fmt.Errorf("failed to read attempt: %w", err)
+		}
+		a.At = time.Unix(at, 0)
+		history[exerciseID] = append(history[exerciseID], a)
+	}
+	return history, rows.Err()
+}
+
+// Next loads the attempt history and returns the practice queue at now
+func Next(weights map[string]int, now time.Time) ([]Item, error) {
+	exercises, err := LoadExercises()
+	if err != nil {
+		return nil, err
+	}
+	history, err := LoadHistory()
+	if err != nil {
+		return nil, err
+	}
+	return Queue(exercises, history, weights, now), nil
+}
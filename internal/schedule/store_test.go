@@ -0,0 +1,47 @@
+package schedule
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+func TestNextFromDatabase(t *testing.T) {
+	if err := database.Initialize(database.Config{Path: filepath.Join(t.TempDir(), "test.db")}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer database.Close()
+	if err := database.ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+
+	for _, slug := range []string{"reviewed", "untouched"} {
+		_, err := database.DB.Exec(`
+			INSERT INTO exercises (slug, title, description, category, difficulty, points, estimated_minutes)
+			VALUES (?, ?, 'test', 'cluster-setup', 'easy', 10, 10)
+		`, slug, slug)
+		if err != nil {
+			t.Fatalf("failed to insert exercise: %v", err)
+		}
+	}
+	_, err := database.DB.Exec(`
+		INSERT INTO attempts (exercise_id, started_at, completed_at, duration_seconds, score, max_score, passed, hints_used)
+		VALUES (1, '2026-03-01 08:50:00', '2026-03-01 09:00:00', 600, 10, 10, 1, 2)
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert attempt: %v", err)
+	}
+
+	queue, err := Next(map[string]int{"cluster-setup": 10}, time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Next failed: %v", err)
+	}
+	if len(queue) != 2 || queue[0].Slug != "reviewed" || !queue[1].New {
+		t.Fatalf("expected the due review before the new exercise, got %+v", queue)
+	}
+	if queue[0].State.LastQuality != 3 || queue[0].OverdueDays != 1 {
+		t.Errorf("expected a hinted pass due a day ago, got quality %d overdue %.1f", queue[0].State.LastQuality, queue[0].OverdueDays)
+	}
+}
@@ -81,7 +81,7 @@ func NewCommandFilter() *CommandFilter {
 			// CKS-specific tools
 			"falco", "trivy", "kube-bench", "kubesec", "opa", "conftest",
 			"crictl", "ctr", "nerdctl",
-			"openssl", "ssh-keygen", "gpg",
+			"openssl", "ssh", "scp", "ssh-keygen", "gpg",
 			"apparmor_parser", "aa-status", "seccomp",
 		},
 	}
@@ -89,6 +89,31 @@ func NewCommandFilter() *CommandFilter {
 	return cf
 }
 
+// NewCandidateCommandFilter creates a command filter for exam-style candidate
+// terminals. The filter sees everything typed in the terminal, including the
+// commands of an ssh session on a node, where the candidate user escalates
+// with sudo -i as in the real exam; sudo and su are therefore permitted. The
+// candidate host itself runs with no-new-privileges and no capabilities, so
+// they grant nothing there.
+func NewCandidateCommandFilter() *CommandFilter {
+	cf := NewCommandFilter()
+
+	allowed := map[string]bool{
+		"Sudo execution": true,
+		"Switch user":    true,
+	}
+	var blocked []DangerousCommand
+	for _, cmd := range cf.blockedCommands {
+		if !allowed[cmd.Description] {
+			blocked = append(blocked, cmd)
+		}
+	}
+	cf.blockedCommands = blocked
+	cf.allowedCommands = append(cf.allowedCommands, "sudo", "su")
+
+	return cf
+}
+
 // ValidateCommand checks if a command is safe to execute
 func (cf *CommandFilter) ValidateCommand(cmd string) (bool, string) {
 	// Trim whitespace
@@ -0,0 +1,100 @@
+package team
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client talks to a team hub on behalf of a member
+type Client struct {
+	HubURL string // e.g. http://192.168.1.20:3100
+	Secret []byte
+	HTTP   *http.Client
+}
+
+// NewClient creates a client for a hub. The URL must be http or https; the
+// signature, not the transport, is what authenticates the member.
+func NewClient(hubURL string, secret []byte) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(hubURL, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: hub URL %q must look like http://host:port", ErrInvalid, hubURL)
+	}
+	if len(secret) < MinSecretLength {
+		return nil, fmt.Errorf("%w: team secret must be at least %d characters", ErrInvalid, MinSecretLength)
+	}
+	return &Client{
+		HubURL: u.String(),
+		Secret: secret,
+		HTTP:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Push sends a member's summary and returns their assignments
+func (c *Client) Push(ctx context.Context, s Summary) (*PushResponse, error) {
+	body, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode summary: %w", err)
+	}
+	var response PushResponse
+	if err := c.do(ctx, http.MethodPost, SummariesPath, body, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Leaderboard fetches the hub's leaderboard
+func (c *Client) Leaderboard(ctx context.Context) ([]Standing, error) {
+	var response HubResponse
+	if err := c.do(ctx, http.MethodGet, LeaderboardPath, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Leaderboard, nil
+}
+
+// Compare fetches the hub's per-domain comparison
+func (c *Client) Compare(ctx context.Context) ([]DomainComparison, error) {
+	var response HubResponse
+	if err := c.do(ctx, http.MethodGet, ComparePath, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Domains, nil
+}
+
+// do sends a signed request and decodes the JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.HubURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create hub request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	SignRequest(req, c.Secret, body, time.Now())
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("hub unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxBodyBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read hub response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var failure HubResponse
+		if json.Unmarshal(data, &failure) == nil && failure.Error != "" {
+			return fmt.Errorf("hub returned %d: %s", resp.StatusCode, failure.Error)
+		}
+		return fmt.Errorf("hub returned %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode hub response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package team
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+// Hub routes, served on the hub's own listener rather than the local API
+const (
+	SummariesPath   = "/team/summaries"
+	LeaderboardPath = "/team/leaderboard"
+	ComparePath     = "/team/compare"
+)
+
+// MaxBodyBytes caps the size of a summary and of a hub response
+const MaxBodyBytes = 1 << 20
+
+// HubResponse is the response of the hub's read routes
+type HubResponse struct {
+	Success     bool               `json:"success"`
+	Leaderboard []Standing         `json:"leaderboard,omitempty"`
+	Domains     []DomainComparison `json:"domains,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// Hub serves the team routes to the members on the network:
+//
+//	POST /team/summaries    store a member's summary; answers with their assignments
+//	GET  /team/leaderboard  members ranked by progress
+//	GET  /team/compare      members side by side in each domain
+//
+// Every request must be signed with the team secret.
+type Hub struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// NewHub creates a hub that accepts requests signed with secret
+func NewHub(secret []byte) *Hub {
+	return &Hub{secret: secret, now: time.Now}
+}
+
+// ServeHTTP verifies the signature and routes the request
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	if err != nil {
+		writeHubResponse(w, http.StatusRequestEntityTooLarge, HubResponse{Error: "Request too large"})
+		return
+	}
+	if err := VerifyRequest(r, h.secret, body, h.now()); err != nil {
+		log.Printf("Team hub: refused %s %s from %s: %v", r.Method, r.URL.Path, r.RemoteAddr, err)
+		writeHubResponse(w, http.StatusUnauthorized, HubResponse{Error: err.Error()})
+		return
+	}
+
+	switch {
+	case r.URL.Path == SummariesPath && r.Method == http.MethodPost:
+		h.receiveSummary(w, body)
+
+	case r.URL.Path == LeaderboardPath && r.Method == http.MethodGet:
+		members, err := LoadMembers()
+		if err != nil {
+			writeHubResponse(w, http.StatusInternalServerError, HubResponse{Error: err.Error()})
+			return
+		}
+		writeHubResponse(w, http.StatusOK, HubResponse{Success: true, Leaderboard: Leaderboard(members)})
+
+	case r.URL.Path == ComparePath && r.Method == http.MethodGet:
+		comparison, err := CurrentComparison()
+		if err != nil {
+			writeHubResponse(w, http.StatusInternalServerError, HubResponse{Error: err.Error()})
+			return
+		}
+		writeHubResponse(w, http.StatusOK, HubResponse{Success: true, Domains: comparison})
+
+	default:
+		writeHubResponse(w, http.StatusNotFound, HubResponse{Error: "Not found"})
+	}
+}
+
+// receiveSummary stores a pushed summary and answers with the member's
+// assignments
+func (h *Hub) receiveSummary(w http.ResponseWriter, body []byte) {
+	var summary Summary
+	if err := json.Unmarshal(body, &summary); err != nil {
+		writeHubResponse(w, http.StatusBadRequest, HubResponse{Error: "Invalid summary"})
+		return
+	}
+
+	now := h.now()
+	accepted, err := SaveSummary(summary, now)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalid) {
+			status = http.StatusBadRequest
+		}
+		writeHubResponse(w, status, HubResponse{Error: err.Error()})
+		return
+	}
+	slug := summary.MemberSlug()
+	assignments, err := ListAssignments(slug, now)
+	if err != nil {
+		writeHubResponse(w, http.StatusInternalServerError, HubResponse{Error: err.Error()})
+		return
+	}
+	log.Printf("Team hub: summary from %s (accepted: %v)", slug, accepted)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PushResponse{Success: true, Accepted: accepted, Member: slug, Assignments: assignments})
+}
+
+// CurrentComparison compares the members in the domains of the hub's
+// active curriculum
+func CurrentComparison() ([]DomainComparison, error) {
+	members, err := LoadMembers()
+	if err != nil {
+		return nil, err
+	}
+	curriculum, err := database.GetActiveCurriculum()
+	if err != nil {
+		return nil, err
+	}
+	return Compare(members, curriculum.Domains), nil
+}
+
+func writeHubResponse(w http.ResponseWriter, status int, response HubResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
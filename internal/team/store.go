@@ -0,0 +1,220 @@
+package team
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+const (
+	// storeTime is how the hub stores times: UTC, in SQLite's format
+	storeTime = "2006-01-02 15:04:05"
+	// generatedTime keeps a summary's generation time to the nanosecond at a
+	// fixed width, so stored times compare as strings
+	generatedTime = "2006-01-02T15:04:05.000000000Z"
+)
+
+var errNoDatabase = errors.New("database not initialized")
+
+// SaveSummary stores a member's summary unless the hub already has one
+// generated at the same time or later, so a replayed or delayed push can't
+// roll a member back. It reports whether the summary was stored.
+func SaveSummary(s Summary, receivedAt time.Time) (bool, error) {
+	if database.DB == nil {
+		return false, errNoDatabase
+	}
+	s.Member = strings.TrimSpace(s.Member)
+	slug := s.MemberSlug()
+	if slug == "" || len(slug) > 40 {
+		return false, fmt.Errorf("%w: member name must contain letters or digits and be at most 40 characters", ErrInvalid)
+	}
+	if s.GeneratedAt.IsZero() {
+		return false, fmt.Errorf("%w: summary has no generation time", ErrInvalid)
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode summary: %w", err)
+	}
+	res, err := database.DB.Exec(`
+		INSERT INTO team_members (slug, name, summary, generated_at, received_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(slug) DO UPDATE SET
+			name = excluded.name,
+			summary = excluded.summary,
+			generated_at = excluded.generated_at,
+			received_at = excluded.received_at
+		WHERE excluded.generated_at > team_members.generated_at
+	`, slug, s.Member, string(data), s.GeneratedAt.UTC().Format(generatedTime), receivedAt.UTC().Format(storeTime))
+	if err != nil {
+		return false, fmt.Errorf("failed to save summary: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// LoadMembers returns the latest summary of every member, by slug
+func LoadMembers() ([]Member, error) {
+	if database.DB == nil {
+		return nil, errNoDatabase
+	}
+	rows, err := database.DB.Query("SELECT slug, summary, received_at FROM team_members ORDER BY slug")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []Member{}
+	for rows.Next() {
+		var m Member
+		var summary string
+		var receivedAt sql.NullTime
+		if err := rows.Scan(&m.Slug, &summary, &receivedAt); err != nil {
+			return nil, fmt.Errorf("failed to read member: %w", err)
+		}
+		if err := json.Unmarshal([]byte(summary), &m.Summary); err != nil {
+			return nil, fmt.Errorf("failed to decode summary of %s: %w", m.Slug, err)
+		}
+		m.ReceivedAt = receivedAt.Time
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// DeleteMember removes a member and their assignments from the hub
+func DeleteMember(slug string) error {
+	if database.DB == nil {
+		return errNoDatabase
+	}
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM team_assignments WHERE member = ?", slug); err != nil {
+		return fmt.Errorf("failed to delete assignments: %w", err)
+	}
+	res, err := tx.Exec("DELETE FROM team_members WHERE slug = ?", slug)
+	if err != nil {
+		return fmt.Errorf("failed to delete member: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: member %s", ErrNotFound, slug)
+	}
+	return tx.Commit()
+}
+
+// Assign gives a member an exercise. Assigning the same exercise again
+// updates the note and due date.
+func Assign(a Assignment) (*Assignment, error) {
+	if database.DB == nil {
+		return nil, errNoDatabase
+	}
+	if a.DueDate != "" {
+		if _, err := time.Parse("2006-01-02", a.DueDate); err != nil {
+			return nil, fmt.Errorf("%w: due date %q is not YYYY-MM-DD", ErrInvalid, a.DueDate)
+		}
+	}
+	var member string
+	if err := database.DB.QueryRow("SELECT slug FROM team_members WHERE slug = ?", a.Member).Scan(&member); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: member %s hasn't synced with the hub", ErrNotFound, a.Member)
+		}
+		return nil, fmt.Errorf("failed to look up member: %w", err)
+	}
+	if err := database.DB.QueryRow("SELECT title FROM exercises WHERE slug = ?", a.Exercise).Scan(&a.Title); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: exercise %s", ErrNotFound, a.Exercise)
+		}
+		return nil, fmt.Errorf("failed to look up exercise: %w", err)
+	}
+
+	_, err := database.DB.Exec(`
+		INSERT INTO team_assignments (member, exercise, note, due_date, assigned_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(member, exercise) DO UPDATE SET note = excluded.note, due_date = excluded.due_date
+	`, a.Member, a.Exercise, nullString(a.Note), nullString(a.DueDate), time.Now().UTC().Format(storeTime))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save assignment: %w", err)
+	}
+
+	assignments, err := ListAssignments(a.Member, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, saved := range assignments {
+		if saved.Exercise == a.Exercise {
+			return &saved, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to read back assignment")
+}
+
+// ListAssignments returns the assignments of a member, or of everyone when
+// member is empty, with their status as of now
+func ListAssignments(member string, now time.Time) ([]Assignment, error) {
+	if database.DB == nil {
+		return nil, errNoDatabase
+	}
+	query := `
+		SELECT a.id, a.member, a.exercise, COALESCE(e.title, ''), COALESCE(a.note, ''),
+			COALESCE(a.due_date, ''), a.assigned_at, m.summary
+		FROM team_assignments a
+		JOIN team_members m ON m.slug = a.member
+		LEFT JOIN exercises e ON e.slug = a.exercise`
+	var args []any
+	if member != "" {
+		query += " WHERE a.member = ?"
+		args = append(args, member)
+	}
+	query += " ORDER BY a.member, COALESCE(a.due_date, '9999-12-31'), a.id"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load assignments: %w", err)
+	}
+	defer rows.Close()
+
+	today := now.UTC().Format("2006-01-02")
+	assignments := []Assignment{}
+	for rows.Next() {
+		var a Assignment
+		var assignedAt sql.NullTime
+		var data string
+		if err := rows.Scan(&a.ID, &a.Member, &a.Exercise, &a.Title, &a.Note, &a.DueDate, &assignedAt, &data); err != nil {
+			return nil, fmt.Errorf("failed to read assignment: %w", err)
+		}
+		a.AssignedAt = assignedAt.Time.UTC().Format(time.RFC3339)
+		var summary Summary
+		if json.Unmarshal([]byte(data), &summary) == nil {
+			a.resolveStatus(&summary, today)
+		} else {
+			a.resolveStatus(nil, today)
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
+
+// DeleteAssignment withdraws an assignment
+func DeleteAssignment(id int64) error {
+	if database.DB == nil {
+		return errNoDatabase
+	}
+	res, err := database.DB.Exec("DELETE FROM team_assignments WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete assignment: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: assignment %d", ErrNotFound, id)
+	}
+	return nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
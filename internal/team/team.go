@@ -0,0 +1,307 @@
+// Package team lets a study group compare progress on their own network.
+// One instance runs as the hub; the others push a summary of their
+// progress to it, and the hub ranks the members, compares them domain by
+// domain and hands out exercise assignments. Every request to the hub is
+// signed with HMAC-SHA256 under a secret the team shares, so no accounts or
+// outside services are needed.
+package team
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+const (
+	// SignatureHeader carries the hex HMAC of a request
+	SignatureHeader = "X-Team-Signature"
+	// TimestampHeader carries the Unix time a request was signed at
+	TimestampHeader = "X-Team-Timestamp"
+	// MaxClockSkew is how far a request's timestamp may be from the hub's
+	// clock; older requests are refused so they can't be replayed later
+	MaxClockSkew = 5 * time.Minute
+	// MinSecretLength is the shortest team secret accepted
+	MinSecretLength = 16
+)
+
+// Assignment statuses
+const (
+	StatusOpen      = "open"
+	StatusCompleted = "completed" // The member's last summary lists the exercise as completed
+	StatusOverdue   = "overdue"
+)
+
+var (
+	// ErrBadSignature marks a request that isn't signed with the team secret
+	ErrBadSignature = errors.New("invalid team signature")
+	// ErrNotFound marks an unknown member or assignment
+	ErrNotFound = errors.New("not found")
+	// ErrInvalid marks a summary or assignment that can't be stored
+	ErrInvalid = errors.New("invalid request")
+)
+
+// Summary is what a member shares with the hub: totals and per-domain
+// figures built from their export data, not the attempts themselves
+type Summary struct {
+	Member             string          `json:"member"` // Display name
+	GeneratedAt        time.Time       `json:"generatedAt"`
+	CurriculumVersion  string          `json:"curriculumVersion,omitempty"`
+	ScenariosCompleted int             `json:"scenariosCompleted"`
+	TotalScenarios     int             `json:"totalScenarios"`
+	Attempts           int             `json:"attempts"`
+	Passed             int             `json:"passed"`
+	AverageScore       float64         `json:"averageScore"` // Percent
+	PracticeMinutes    int             `json:"practiceMinutes"`
+	MockExamsTaken     int             `json:"mockExamsTaken"`
+	MockExamsPassed    int             `json:"mockExamsPassed"`
+	Domains            []DomainSummary `json:"domains"`
+	Completed          []string        `json:"completed"` // Slugs of completed exercises
+}
+
+// DomainSummary is a member's figures for one domain
+type DomainSummary struct {
+	Domain       string  `json:"domain"`
+	Completed    int     `json:"completed"`
+	Total        int     `json:"total"`
+	Attempts     int     `json:"attempts"`
+	Passed       int     `json:"passed"`
+	AverageScore float64 `json:"averageScore"` // Percent; 0 without attempts
+}
+
+// MemberSlug identifies the member on the hub
+func (s Summary) MemberSlug() string {
+	return database.ProfileSlug(s.Member)
+}
+
+// Member is the latest summary the hub has from a member
+type Member struct {
+	Slug       string
+	Summary    Summary
+	ReceivedAt time.Time
+}
+
+// Standing is a member's place on the leaderboard
+type Standing struct {
+	Rank                 int     `json:"rank"`
+	Member               string  `json:"member"`
+	Slug                 string  `json:"slug"`
+	ScenariosCompleted   int     `json:"scenariosCompleted"`
+	TotalScenarios       int     `json:"totalScenarios"`
+	CompletionPercentage float64 `json:"completionPercentage"`
+	Attempts             int     `json:"attempts"`
+	PassRate             float64 `json:"passRate"` // Percent of attempts passed
+	AverageScore         float64 `json:"averageScore"`
+	PracticeMinutes      int     `json:"practiceMinutes"`
+	MockExamsPassed      int     `json:"mockExamsPassed"`
+	LastSyncedAt         string  `json:"lastSyncedAt"`
+}
+
+// DomainComparison lines the members up in one domain
+type DomainComparison struct {
+	Domain           string         `json:"domain"`
+	DisplayName      string         `json:"displayName"`
+	Weight           int            `json:"weight"`
+	Members          []MemberDomain `json:"members"`
+	TeamAverageScore float64        `json:"teamAverageScore"` // Over the members with attempts
+	Leader           string         `json:"leader,omitempty"` // Slug of the best average score
+}
+
+// MemberDomain is one member's figures in a domain comparison
+type MemberDomain struct {
+	Member               string  `json:"member"`
+	Slug                 string  `json:"slug"`
+	Completed            int     `json:"completed"`
+	Total                int     `json:"total"`
+	CompletionPercentage float64 `json:"completionPercentage"`
+	Attempts             int     `json:"attempts"`
+	PassRate             float64 `json:"passRate"`
+	AverageScore         float64 `json:"averageScore"`
+}
+
+// Assignment is an exercise the hub asked a member to practise
+type Assignment struct {
+	ID         int64  `json:"id"`
+	Member     string `json:"member"`   // Slug
+	Exercise   string `json:"exercise"` // Slug
+	Title      string `json:"title,omitempty"`
+	Note       string `json:"note,omitempty"`
+	DueDate    string `json:"dueDate,omitempty"` // YYYY-MM-DD
+	AssignedAt string `json:"assignedAt"`
+	Status     string `json:"status"`
+}
+
+// PushResponse is the hub's answer to a summary
+type PushResponse struct {
+	Success     bool         `json:"success"`
+	Accepted    bool         `json:"accepted"` // False when the hub already has a newer summary
+	Member      string       `json:"member,omitempty"`
+	Assignments []Assignment `json:"assignments"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// Signature computes the HMAC of a request: the method, the path, the
+// timestamp and the SHA-256 of the body, one per line
+func Signature(secret []byte, method, path string, timestamp int64, body []byte) string {
+	digest := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%s\n%s\n%d\n%s", method, path, timestamp, hex.EncodeToString(digest[:]))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest sets the signature headers of a request with the given body
+func SignRequest(req *http.Request, secret, body []byte, now time.Time) {
+	timestamp := now.Unix()
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Signature(secret, req.Method, req.URL.Path, timestamp, body))
+}
+
+// VerifyRequest checks a request's signature and that it was signed within
+// MaxClockSkew of now
+func VerifyRequest(r *http.Request, secret, body []byte, now time.Time) error {
+	timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: missing timestamp", ErrBadSignature)
+	}
+	if skew := now.Sub(time.Unix(timestamp, 0)); skew > MaxClockSkew || skew < -MaxClockSkew {
+		return fmt.Errorf("%w: timestamp is %s off the hub's clock", ErrBadSignature, skew.Round(time.Second))
+	}
+	got, err := hex.DecodeString(r.Header.Get(SignatureHeader))
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrBadSignature)
+	}
+	want, _ := hex.DecodeString(Signature(secret, r.Method, r.URL.Path, timestamp, body))
+	if !hmac.Equal(got, want) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Leaderboard ranks the members by exercises completed, then average score,
+// then practice time. Members level on completions and score share a rank.
+func Leaderboard(members []Member) []Standing {
+	standings := make([]Standing, 0, len(members))
+	for _, m := range members {
+		s := m.Summary
+		standings = append(standings, Standing{
+			Member:               s.Member,
+			Slug:                 m.Slug,
+			ScenariosCompleted:   s.ScenariosCompleted,
+			TotalScenarios:       s.TotalScenarios,
+			CompletionPercentage: percent(s.ScenariosCompleted, s.TotalScenarios),
+			Attempts:             s.Attempts,
+			PassRate:             percent(s.Passed, s.Attempts),
+			AverageScore:         round(s.AverageScore),
+			PracticeMinutes:      s.PracticeMinutes,
+			MockExamsPassed:      s.MockExamsPassed,
+			LastSyncedAt:         m.ReceivedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		switch {
+		case a.ScenariosCompleted != b.ScenariosCompleted:
+			return a.ScenariosCompleted > b.ScenariosCompleted
+		case a.AverageScore != b.AverageScore:
+			return a.AverageScore > b.AverageScore
+		case a.PracticeMinutes != b.PracticeMinutes:
+			return a.PracticeMinutes > b.PracticeMinutes
+		}
+		return a.Slug < b.Slug
+	})
+	for i := range standings {
+		standings[i].Rank = i + 1
+		if i > 0 {
+			prev := standings[i-1]
+			if prev.ScenariosCompleted == standings[i].ScenariosCompleted && prev.AverageScore == standings[i].AverageScore {
+				standings[i].Rank = prev.Rank
+			}
+		}
+	}
+	return standings
+}
+
+// Compare lines the members up in each domain of the curriculum
+func Compare(members []Member, domains []database.CurriculumDomain) []DomainComparison {
+	comparisons := make([]DomainComparison, 0, len(domains))
+	for _, d := range domains {
+		c := DomainComparison{
+			Domain:      d.Slug,
+			DisplayName: d.DisplayName,
+			Weight:      d.Weight,
+			Members:     []MemberDomain{},
+		}
+		var scoreSum float64
+		scored := 0
+		best := -1.0
+		for _, m := range members {
+			var ds DomainSummary
+			for _, candidate := range m.Summary.Domains {
+				if candidate.Domain == d.Slug {
+					ds = candidate
+					break
+				}
+			}
+			c.Members = append(c.Members, MemberDomain{
+				Member:               m.Summary.Member,
+				Slug:                 m.Slug,
+				Completed:            ds.Completed,
+				Total:                ds.Total,
+				CompletionPercentage: percent(ds.Completed, ds.Total),
+				Attempts:             ds.Attempts,
+				PassRate:             percent(ds.Passed, ds.Attempts),
+				AverageScore:         round(ds.AverageScore),
+			})
+			if ds.Attempts > 0 {
+				scoreSum += ds.AverageScore
+				scored++
+				if ds.AverageScore > best {
+					best, c.Leader = ds.AverageScore, m.Slug
+				}
+			}
+		}
+		if scored > 0 {
+			c.TeamAverageScore = round(scoreSum / float64(scored))
+		}
+		comparisons = append(comparisons, c)
+	}
+	return comparisons
+}
+
+// resolveStatus sets an assignment's status from the member's summary and
+// the date today
+func (a *Assignment) resolveStatus(summary *Summary, today string) {
+	a.Status = StatusOpen
+	if summary != nil {
+		for _, slug := range summary.Completed {
+			if slug == a.Exercise {
+				a.Status = StatusCompleted
+				return
+			}
+		}
+	}
+	if a.DueDate != "" && a.DueDate < today {
+		a.Status = StatusOverdue
+	}
+}
+
+func percent(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return round(float64(part) / float64(whole) * 100)
+}
+
+// round keeps one decimal
+func round(v float64) float64 {
+	return math.Round(v*10) / 10
+}
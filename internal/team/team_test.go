@@ -0,0 +1,177 @@
+package team
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+var testSecret = []byte("correct horse battery staple")
+
+func setupHubDB(t *testing.T) {
+	t.Helper()
+	if err := database.Initialize(database.Config{Path: filepath.Join(t.TempDir(), "hub.db")}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := database.ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+	_, err := database.DB.Exec(`
+		INSERT INTO exercises (slug, title, description, category, difficulty, points)
+		VALUES ('audit-logs', 'Audit Logs', 'test', 'cluster-setup', 'easy', 10),
+			('apparmor', 'AppArmor', 'test', 'system-hardening', 'medium', 10)
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert exercises: %v", err)
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	body := []byte(`{"member":"Ada"}`)
+	req := httptest.NewRequest(http.MethodPost, SummariesPath, nil)
+	SignRequest(req, testSecret, body, now)
+
+	if err := VerifyRequest(req, testSecret, body, now.Add(time.Minute)); err != nil {
+		t.Fatalf("expected a valid signature, got %v", err)
+	}
+	for name, check := range map[string]func() error{
+		"tampered body": func() error { return VerifyRequest(req, testSecret, []byte(`{"member":"Eve"}`), now) },
+		"wrong secret":  func() error { return VerifyRequest(req, []byte("another team's secret"), body, now) },
+		"replayed late": func() error { return VerifyRequest(req, testSecret, body, now.Add(MaxClockSkew+time.Second)) },
+		"other path": func() error {
+			other := httptest.NewRequest(http.MethodPost, LeaderboardPath, nil)
+			other.Header = req.Header
+			return VerifyRequest(other, testSecret, body, now)
+		},
+	} {
+		if err := check(); !errors.Is(err, ErrBadSignature) {
+			t.Errorf("%s: expected ErrBadSignature, got %v", name, err)
+		}
+	}
+}
+
+func TestLeaderboardAndCompare(t *testing.T) {
+	members := []Member{
+		{Slug: "ada", Summary: Summary{Member: "Ada", ScenariosCompleted: 3, TotalScenarios: 10, AverageScore: 70, Attempts: 4, Passed: 3,
+			Domains: []DomainSummary{{Domain: "cluster-setup", Completed: 2, Total: 4, Attempts: 2, Passed: 2, AverageScore: 90}}}},
+		{Slug: "bob", Summary: Summary{Member: "Bob", ScenariosCompleted: 5, TotalScenarios: 10, AverageScore: 60,
+			Domains: []DomainSummary{{Domain: "cluster-setup", Completed: 1, Total: 4, Attempts: 3, Passed: 1, AverageScore: 50}}}},
+		{Slug: "cy", Summary: Summary{Member: "Cy", ScenariosCompleted: 3, TotalScenarios: 10, AverageScore: 70, PracticeMinutes: 5}},
+	}
+
+	standings := Leaderboard(members)
+	var order []string
+	for _, s := range standings {
+		order = append(order, s.Slug)
+	}
+	if strings.Join(order, ",") != "bob,cy,ada" {
+		t.Fatalf("unexpected order %v", order)
+	}
+	if standings[0].Rank != 1 || standings[1].Rank != 2 || standings[2].Rank != 2 {
+		t.Errorf("expected cy and ada to share second place, got %+v", standings)
+	}
+	if standings[2].PassRate != 75 || standings[0].CompletionPercentage != 50 {
+		t.Errorf("unexpected figures %+v", standings)
+	}
+
+	domains := []database.CurriculumDomain{{Slug: "cluster-setup", DisplayName: "Cluster Setup", Weight: 10}, {Slug: "system-hardening"}}
+	comparison := Compare(members, domains)
+	if len(comparison) != 2 || len(comparison[0].Members) != 3 {
+		t.Fatalf("unexpected comparison %+v", comparison)
+	}
+	if c := comparison[0]; c.Leader != "ada" || c.TeamAverageScore != 70 || c.Members[0].CompletionPercentage != 50 {
+		t.Errorf("unexpected cluster-setup comparison %+v", c)
+	}
+	if c := comparison[1]; c.Leader != "" || c.TeamAverageScore != 0 {
+		t.Errorf("expected no leader without attempts, got %+v", c)
+	}
+}
+
+func TestHubSummariesAndAssignments(t *testing.T) {
+	setupHubDB(t)
+	hub := httptest.NewServer(NewHub(testSecret))
+	defer hub.Close()
+
+	client, err := NewClient(hub.URL, testSecret)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	ctx := context.Background()
+	generated := time.Now().Add(-time.Minute)
+
+	ada := Summary{Member: "Ada Lovelace", GeneratedAt: generated, ScenariosCompleted: 1, TotalScenarios: 2,
+		Completed: []string{"audit-logs"}}
+	response, err := client.Push(ctx, ada)
+	if err != nil || !response.Accepted || response.Member != "ada-lovelace" || len(response.Assignments) != 0 {
+		t.Fatalf("unexpected push response %+v (%v)", response, err)
+	}
+
+	// The instructor assigns work; the member sees it on their next push
+	if _, err := Assign(Assignment{Member: "ada-lovelace", Exercise: "apparmor", DueDate: "2000-01-01"}); err != nil {
+		t.Fatalf("Assign failed: %v", err)
+	}
+	if _, err := Assign(Assignment{Member: "ada-lovelace", Exercise: "audit-logs", Note: "redo"}); err != nil {
+		t.Fatalf("Assign failed: %v", err)
+	}
+	if _, err := Assign(Assignment{Member: "nobody", Exercise: "apparmor"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected an unknown member to be refused, got %v", err)
+	}
+	if _, err := Assign(Assignment{Member: "ada-lovelace", Exercise: "apparmor", DueDate: "soon"}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected a bad due date to be refused, got %v", err)
+	}
+
+	ada.GeneratedAt = generated.Add(time.Second)
+	response, err = client.Push(ctx, ada)
+	if err != nil || len(response.Assignments) != 2 {
+		t.Fatalf("expected two assignments, got %+v (%v)", response, err)
+	}
+	statuses := map[string]string{}
+	for _, a := range response.Assignments {
+		statuses[a.Exercise] = a.Status
+	}
+	if statuses["audit-logs"] != StatusCompleted || statuses["apparmor"] != StatusOverdue {
+		t.Errorf("unexpected statuses %v", statuses)
+	}
+
+	// An older summary doesn't roll the member back
+	stale := ada
+	stale.GeneratedAt = generated.Add(-time.Hour)
+	stale.ScenariosCompleted = 0
+	if response, err = client.Push(ctx, stale); err != nil || response.Accepted {
+		t.Errorf("expected a stale summary to be ignored, got %+v (%v)", response, err)
+	}
+
+	if _, err := client.Push(ctx, Summary{Member: "Bob", GeneratedAt: generated, ScenariosCompleted: 2, TotalScenarios: 2}); err != nil {
+		t.Fatalf("push failed: %v", err)
+	}
+	standings, err := client.Leaderboard(ctx)
+	if err != nil || len(standings) != 2 || standings[0].Slug != "bob" || standings[1].ScenariosCompleted != 1 || strings.HasPrefix(standings[0].LastSyncedAt, "0001") {
+		t.Errorf("unexpected leaderboard %+v (%v)", standings, err)
+	}
+	domains, err := client.Compare(ctx)
+	if err != nil || len(domains) != 6 {
+		t.Errorf("expected a comparison per curriculum domain, got %d (%v)", len(domains), err)
+	}
+
+	// Requests signed with another secret are refused
+	intruder, _ := NewClient(hub.URL, []byte("not the team secret"))
+	if _, err := intruder.Leaderboard(ctx); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("expected the intruder to be refused, got %v", err)
+	}
+
+	if err := DeleteMember("ada-lovelace"); err != nil {
+		t.Fatalf("DeleteMember failed: %v", err)
+	}
+	if assignments, _ := ListAssignments("", time.Now()); len(assignments) != 0 {
+		t.Errorf("expected the member's assignments to go with them, got %+v", assignments)
+	}
+}
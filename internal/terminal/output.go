@@ -0,0 +1,147 @@
+package terminal
+
+import (
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// OutputOptions controls how PTY output is batched into WebSocket frames
+type OutputOptions struct {
+	ReadSize      int           // Bytes requested per PTY read
+	MaxFrame      int           // Largest frame sent to the client
+	FlushInterval time.Duration // How long output is coalesced before sending
+	QueueDepth    int           // Reads buffered before the PTY reader blocks
+}
+
+// DefaultOutputOptions returns batching suited to interactive use: a few
+// milliseconds of coalescing is invisible when typing but collapses bulk
+// output like `kubectl get events -A` into a handful of frames
+func DefaultOutputOptions() OutputOptions {
+	return OutputOptions{
+		ReadSize:      4096,
+		MaxFrame:      32 * 1024,
+		FlushInterval: 5 * time.Millisecond,
+		QueueDepth:    16,
+	}
+}
+
+// PumpOutput copies r to send in coalesced, UTF-8-safe frames until r is
+// exhausted or send fails. send is called from a single goroutine; when it
+// blocks (a slow client), queued reads fill up and the PTY reader stops
+// reading, which pushes back on the process producing output.
+//
+// It returns the error from send, or nil once r reaches EOF or fails (a
+// closed PTY reports an I/O error rather than EOF).
+func PumpOutput(r io.Reader, send func([]byte) error, opts OutputOptions) error {
+	defaults := DefaultOutputOptions()
+	if opts.ReadSize <= 0 {
+		opts.ReadSize = defaults.ReadSize
+	}
+	if opts.MaxFrame <= 0 {
+		opts.MaxFrame = defaults.MaxFrame
+	}
+	if opts.QueueDepth <= 0 {
+		opts.QueueDepth = defaults.QueueDepth
+	}
+
+	chunks := make(chan []byte, opts.QueueDepth)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		defer close(chunks)
+		for {
+			buf := make([]byte, opts.ReadSize)
+			n, err := r.Read(buf)
+			if n > 0 {
+				select {
+				case chunks <- buf[:n]:
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var pending []byte
+	var timer *time.Timer
+	var timerC <-chan time.Time
+
+	// flush sends pending output, holding back an incomplete UTF-8 sequence
+	// at the end unless final is set
+	flush := func(final bool) error {
+		for len(pending) > 0 {
+			n := len(pending)
+			if n > opts.MaxFrame {
+				n = opts.MaxFrame
+			}
+			n = utf8SafeLen(pending[:n], final && n == len(pending))
+			if n == 0 {
+				return nil
+			}
+			if err := send(pending[:n]); err != nil {
+				return err
+			}
+			pending = pending[n:]
+		}
+		return nil
+	}
+
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				return flush(true)
+			}
+			pending = append(pending, chunk...)
+			if len(pending) >= opts.MaxFrame || opts.FlushInterval <= 0 {
+				if err := flush(false); err != nil {
+					return err
+				}
+			}
+			if len(pending) > 0 && timerC == nil {
+				timer = time.NewTimer(opts.FlushInterval)
+				timerC = timer.C
+			}
+		case <-timerC:
+			timerC = nil
+			if err := flush(false); err != nil {
+				return err
+			}
+			// Compact so the backing array doesn't grow without bound
+			pending = append([]byte(nil), pending...)
+		}
+		if len(pending) == 0 && timer != nil {
+			timer.Stop()
+			timerC = nil
+		}
+	}
+}
+
+// utf8SafeLen returns how much of b can be sent without splitting a
+// multi-byte UTF-8 sequence. A trailing partial sequence is held back unless
+// final is set; bytes that can never form a valid rune are sent as-is.
+func utf8SafeLen(b []byte, final bool) int {
+	if final || len(b) == 0 {
+		return len(b)
+	}
+
+	// Look back at most UTFMax-1 bytes for the start of the last rune
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		c := b[len(b)-i]
+		if c < utf8.RuneSelf {
+			return len(b) // ASCII; nothing can be split after it
+		}
+		if utf8.RuneStart(c) {
+			if utf8.FullRune(b[len(b)-i:]) {
+				return len(b)
+			}
+			return len(b) - i
+		}
+	}
+	return len(b)
+}
@@ -0,0 +1,174 @@
+package terminal
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// chunkReader returns each chunk from a separate Read call
+type chunkReader struct {
+	chunks [][]byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks[0] = r.chunks[0][n:]
+	if len(r.chunks[0]) == 0 {
+		r.chunks = r.chunks[1:]
+	}
+	return n, nil
+}
+
+func collect(t *testing.T, r io.Reader, opts OutputOptions) [][]byte {
+	t.Helper()
+	var frames [][]byte
+	err := PumpOutput(r, func(b []byte) error {
+		frames = append(frames, append([]byte(nil), b...))
+		return nil
+	}, opts)
+	if err != nil {
+		t.Fatalf("PumpOutput failed: %v", err)
+	}
+	return frames
+}
+
+func TestUTF8SafeLen(t *testing.T) {
+	euro := []byte("€") // 3 bytes
+	tests := []struct {
+		name     string
+		input    []byte
+		final    bool
+		expected int
+	}{
+		{"ascii", []byte("abc"), false, 3},
+		{"complete rune", append([]byte("a"), euro...), false, 4},
+		{"one byte of three", append([]byte("a"), euro[:1]...), false, 1},
+		{"two bytes of three", append([]byte("a"), euro[:2]...), false, 1},
+		{"partial kept when final", append([]byte("a"), euro[:2]...), true, 3},
+		{"stray continuation bytes", []byte{'a', 0x80, 0x80, 0x80, 0x80}, false, 5},
+		{"empty", nil, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utf8SafeLen(tt.input, tt.final); got != tt.expected {
+				t.Errorf("utf8SafeLen(%v, %v) = %d, expected %d", tt.input, tt.final, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPumpOutputNeverSplitsRunes(t *testing.T) {
+	text := bytes.Repeat([]byte("│ pod-é ✓ 日本 "), 200)
+
+	// Split the input at every awkward offset the PTY could produce
+	var chunks [][]byte
+	for rest := text; len(rest) > 0; {
+		n := 7
+		if n > len(rest) {
+			n = len(rest)
+		}
+		chunks = append(chunks, rest[:n])
+		rest = rest[n:]
+	}
+
+	opts := DefaultOutputOptions()
+	opts.MaxFrame = 64
+	frames := collect(t, &chunkReader{chunks: chunks}, opts)
+
+	var joined []byte
+	for i, f := range frames {
+		if len(f) > opts.MaxFrame {
+			t.Errorf("frame %d is %d bytes, larger than MaxFrame %d", i, len(f), opts.MaxFrame)
+		}
+		if !utf8.Valid(f) {
+			t.Fatalf("frame %d splits a UTF-8 sequence: %q", i, f)
+		}
+		joined = append(joined, f...)
+	}
+	if !bytes.Equal(joined, text) {
+		t.Error("frames don't reassemble to the original output")
+	}
+}
+
+func TestPumpOutputCoalesces(t *testing.T) {
+	chunks := make([][]byte, 500)
+	for i := range chunks {
+		chunks[i] = []byte("line of kubectl output\r\n")
+	}
+
+	opts := DefaultOutputOptions()
+	opts.FlushInterval = 50 * time.Millisecond
+	frames := collect(t, &chunkReader{chunks: chunks}, opts)
+
+	if len(frames) >= len(chunks)/10 {
+		t.Errorf("expected output to be coalesced, got %d frames for %d reads", len(frames), len(chunks))
+	}
+}
+
+func TestPumpOutputStopsOnSendError(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+
+	sendErr := errors.New("client gone")
+	result := make(chan error, 1)
+	go func() {
+		result <- PumpOutput(pr, func([]byte) error { return sendErr }, DefaultOutputOptions())
+	}()
+
+	pw.Write([]byte("hello"))
+
+	select {
+	case err := <-result:
+		if err != sendErr {
+			t.Errorf("expected send error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("PumpOutput didn't return after send failed")
+	}
+}
+
+func TestPumpOutputBackpressure(t *testing.T) {
+	pr, pw := io.Pipe()
+
+	release := make(chan struct{})
+	opts := DefaultOutputOptions()
+	opts.QueueDepth = 1
+	opts.ReadSize = 16
+	opts.MaxFrame = 16
+
+	go PumpOutput(pr, func([]byte) error {
+		<-release // A client that has stopped reading
+		return nil
+	}, opts)
+
+	// With one frame stuck in send and a queue depth of one, the reader can
+	// accept only a few writes before the producer blocks
+	written := make(chan int, 1)
+	go func() {
+		n := 0
+		for i := 0; i < 100; i++ {
+			if _, err := pw.Write(bytes.Repeat([]byte("x"), 16)); err != nil {
+				break
+			}
+			n++
+		}
+		written <- n
+	}()
+
+	select {
+	case n := <-written:
+		t.Fatalf("producer wrote %d chunks without blocking", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	pw.Close()
+}
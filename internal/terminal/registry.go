@@ -0,0 +1,437 @@
+package terminal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/logger"
+)
+
+// Limits controls how long terminal sessions may live and how many may be open
+type Limits struct {
+	IdleTimeout    time.Duration // Close a session after this long without input
+	MaxDuration    time.Duration // Close a session after this long regardless of activity
+	WarnBefore     time.Duration // Warn the user this long before either timeout fires
+	MaxPerExercise int           // Concurrent sessions allowed for a single exercise
+	MaxTotal       int           // Concurrent sessions allowed across all exercises
+}
+
+// DefaultLimits returns the limits used when no overrides are configured
+func DefaultLimits() Limits {
+	return Limits{
+		IdleTimeout:    30 * time.Minute,
+		MaxDuration:    2 * time.Hour,
+		WarnBefore:     5 * time.Minute,
+		MaxPerExercise: 6, // One per node plus a few spare tabs
+		MaxTotal:       12,
+	}
+}
+
+// SessionError represents a terminal session operation error
+type SessionError struct {
+	Code    string
+	Message string
+}
+
+func (e *SessionError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Error codes
+const (
+	ErrCodeTooManySessions         = "TOO_MANY_SESSIONS"
+	ErrCodeTooManyExerciseSessions = "TOO_MANY_EXERCISE_SESSIONS"
+	ErrCodeSessionNotFound         = "SESSION_NOT_FOUND"
+)
+
+// Close reasons reported to the client and in the admin listing
+const (
+	ReasonIdleTimeout = "idle timeout"
+	ReasonMaxDuration = "maximum session duration reached"
+	ReasonAdminClose  = "closed by administrator"
+	ReasonShutdown    = "server is shutting down"
+)
+
+// Callbacks lets the registry talk to the connection that owns a session
+// without knowing anything about WebSockets or PTYs
+type Callbacks struct {
+	Warn  func(message string) // Show a warning in the user's terminal
+	Close func(reason string)  // Tear the session down
+}
+
+// Session is a single live terminal connection
+type Session struct {
+	ID         string
+	Slug       string
+	Node       string
+	Mode       string // "standard" or "secure"
+	RemoteAddr string
+	StartedAt  time.Time
+
+	mu           sync.Mutex
+	now          func() time.Time
+	lastActivity time.Time
+	idleWarned   bool
+	maxWarned    bool
+	closed       bool
+	callbacks    Callbacks
+}
+
+// SessionInfo is a point-in-time snapshot of a session for listing
+type SessionInfo struct {
+	ID           string    `json:"id"`
+	Slug         string    `json:"slug"`
+	Node         string    `json:"node,omitempty"`
+	Mode         string    `json:"mode"`
+	RemoteAddr   string    `json:"remoteAddr,omitempty"`
+	StartedAt    time.Time `json:"startedAt"`
+	LastActivity time.Time `json:"lastActivity"`
+	IdleSeconds  int       `json:"idleSeconds"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// Touch records user activity on the session, resetting the idle timer
+func (s *Session) Touch() {
+	s.mu.Lock()
+	s.lastActivity = s.now()
+	s.idleWarned = false
+	s.mu.Unlock()
+}
+
+// LastActivity returns when the session last saw user input
+func (s *Session) LastActivity() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastActivity
+}
+
+// SetCallbacks attaches the warn/close hooks once the connection is ready
+func (s *Session) SetCallbacks(cb Callbacks) {
+	s.mu.Lock()
+	s.callbacks = cb
+	s.mu.Unlock()
+}
+
+// warn sends a warning through the session's callback, if any
+func (s *Session) warn(message string) {
+	s.mu.Lock()
+	fn := s.callbacks.Warn
+	s.mu.Unlock()
+	if fn != nil {
+		fn(message)
+	}
+}
+
+// close tears the session down exactly once
+func (s *Session) close(reason string) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	fn := s.callbacks.Close
+	s.mu.Unlock()
+	if fn != nil {
+		fn(reason)
+	}
+}
+
+// Registry tracks live terminal sessions and enforces Limits
+type Registry struct {
+	limits   Limits
+	sessions map[string]*Session
+	mu       sync.RWMutex
+	now      func() time.Time
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewRegistry creates a registry and starts its reaper goroutine
+func NewRegistry(limits Limits) *Registry {
+	r := newRegistry(limits)
+	go r.reap(30 * time.Second)
+	return r
+}
+
+// newRegistry creates a registry without starting the reaper (used by tests)
+func newRegistry(limits Limits) *Registry {
+	return &Registry{
+		limits:   limits,
+		sessions: make(map[string]*Session),
+		now:      time.Now,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Limits returns the limits this registry enforces
+func (r *Registry) Limits() Limits {
+	return r.limits
+}
+
+// Register admits a new session if the concurrency caps allow it
+func (r *Registry) Register(slug, node, mode, remoteAddr string) (*Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.limits.MaxTotal > 0 && len(r.sessions) >= r.limits.MaxTotal {
+		return nil, &SessionError{
+			Code:    ErrCodeTooManySessions,
+			Message: fmt.Sprintf("Maximum of %d terminal sessions are already open. Close a terminal tab and try again.", r.limits.MaxTotal),
+		}
+	}
+
+	if r.limits.MaxPerExercise > 0 {
+		count := 0
+		for _, s := range r.sessions {
+			if s.Slug == slug {
+				count++
+			}
+		}
+		if count >= r.limits.MaxPerExercise {
+			return nil, &SessionError{
+				Code:    ErrCodeTooManyExerciseSessions,
+				Message: fmt.Sprintf("Maximum of %d terminal sessions are already open for %s. Close a terminal tab and try again.", r.limits.MaxPerExercise, slug),
+			}
+		}
+	}
+
+	now := r.now()
+	session := &Session{
+		ID:           newSessionID(),
+		Slug:         slug,
+		Node:         node,
+		Mode:         mode,
+		RemoteAddr:   remoteAddr,
+		StartedAt:    now,
+		now:          r.now,
+		lastActivity: now,
+	}
+	r.sessions[session.ID] = session
+
+	logger.Info("Terminal session %s opened for %s (node: %s, mode: %s, open: %d)", session.ID, slug, node, mode, len(r.sessions))
+	return session, nil
+}
+
+// Unregister removes a session once its connection has ended
+func (r *Registry) Unregister(id string) {
+	r.mu.Lock()
+	session, ok := r.sessions[id]
+	delete(r.sessions, id)
+	r.mu.Unlock()
+
+	if ok {
+		session.mu.Lock()
+		session.closed = true
+		session.mu.Unlock()
+		logger.Info("Terminal session %s closed for %s", id, session.Slug)
+	}
+}
+
+// List returns a snapshot of all live sessions, oldest first
+func (r *Registry) List() []SessionInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.now()
+	infos := make([]SessionInfo, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		last := s.LastActivity()
+		infos = append(infos, SessionInfo{
+			ID:           s.ID,
+			Slug:         s.Slug,
+			Node:         s.Node,
+			Mode:         s.Mode,
+			RemoteAddr:   s.RemoteAddr,
+			StartedAt:    s.StartedAt,
+			LastActivity: last,
+			IdleSeconds:  int(now.Sub(last).Seconds()),
+			ExpiresAt:    r.expiresAt(s, last),
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartedAt.Before(infos[j].StartedAt)
+	})
+	return infos
+}
+
+// Close force-closes a session by ID
+func (r *Registry) Close(id, reason string) error {
+	r.mu.RLock()
+	session, ok := r.sessions[id]
+	r.mu.RUnlock()
+
+	if !ok {
+		return &SessionError{
+			Code:    ErrCodeSessionNotFound,
+			Message: fmt.Sprintf("Terminal session %s not found", id),
+		}
+	}
+
+	logger.Info("Force-closing terminal session %s (%s)", id, reason)
+	session.close(reason)
+	return nil
+}
+
+// CloseAll closes every session, optionally only those for one exercise
+func (r *Registry) CloseAll(slug, reason string) int {
+	r.mu.RLock()
+	var targets []*Session
+	for _, s := range r.sessions {
+		if slug == "" || s.Slug == slug {
+			targets = append(targets, s)
+		}
+	}
+	r.mu.RUnlock()
+
+	for _, s := range targets {
+		s.close(reason)
+	}
+	return len(targets)
+}
+
+// Wait blocks until no sessions remain (for one exercise, or any when slug is
+// empty) or ctx is done, reporting whether every session ended. Handlers
+// unregister after their deferred cleanup has run, so a successful Wait
+// means their shells and containers are gone too.
+func (r *Registry) Wait(ctx context.Context, slug string) bool {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if r.count(slug) == 0 {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// count returns the number of live sessions, optionally for one exercise
+func (r *Registry) count(slug string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if slug == "" {
+		return len(r.sessions)
+	}
+	n := 0
+	for _, s := range r.sessions {
+		if s.Slug == slug {
+			n++
+		}
+	}
+	return n
+}
+
+// Stop halts the reaper goroutine
+func (r *Registry) Stop() {
+	r.stopOnce.Do(func() { close(r.stop) })
+}
+
+// expiresAt returns whichever timeout fires first for a session
+func (r *Registry) expiresAt(s *Session, last time.Time) time.Time {
+	var expiry time.Time
+	if r.limits.IdleTimeout > 0 {
+		expiry = last.Add(r.limits.IdleTimeout)
+	}
+	if r.limits.MaxDuration > 0 {
+		hard := s.StartedAt.Add(r.limits.MaxDuration)
+		if expiry.IsZero() || hard.Before(expiry) {
+			expiry = hard
+		}
+	}
+	return expiry
+}
+
+// reap periodically enforces the timeouts until Stop is called
+func (r *Registry) reap(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.enforce()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// enforce sends warnings and closes sessions that have hit a timeout
+func (r *Registry) enforce() {
+	r.mu.RLock()
+	sessions := make([]*Session, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		sessions = append(sessions, s)
+	}
+	r.mu.RUnlock()
+
+	now := r.now()
+	for _, s := range sessions {
+		s.mu.Lock()
+		idle := now.Sub(s.lastActivity)
+		age := now.Sub(s.StartedAt)
+		warnIdle := false
+		warnMax := false
+		closeReason := ""
+
+		if r.limits.MaxDuration > 0 && age >= r.limits.MaxDuration {
+			closeReason = ReasonMaxDuration
+		} else if r.limits.IdleTimeout > 0 && idle >= r.limits.IdleTimeout {
+			closeReason = ReasonIdleTimeout
+		} else {
+			if r.limits.MaxDuration > 0 && !s.maxWarned && age >= r.limits.MaxDuration-r.limits.WarnBefore {
+				s.maxWarned = true
+				warnMax = true
+			}
+			if r.limits.IdleTimeout > 0 && !s.idleWarned && idle >= r.limits.IdleTimeout-r.limits.WarnBefore {
+				s.idleWarned = true
+				warnIdle = true
+			}
+		}
+		s.mu.Unlock()
+
+		if closeReason != "" {
+			logger.Info("Closing terminal session %s for %s: %s", s.ID, s.Slug, closeReason)
+			s.close(closeReason)
+			continue
+		}
+		if warnMax {
+			remaining := r.limits.MaxDuration - age
+			s.warn(fmt.Sprintf("This terminal session will end in %s (maximum session duration reached)", formatRemaining(remaining)))
+		}
+		if warnIdle {
+			remaining := r.limits.IdleTimeout - idle
+			s.warn(fmt.Sprintf("This terminal session will close in %s due to inactivity", formatRemaining(remaining)))
+		}
+	}
+}
+
+// formatRemaining renders a duration rounded to the nearest minute
+func formatRemaining(d time.Duration) string {
+	minutes := int(d.Round(time.Minute).Minutes())
+	if minutes <= 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
+
+// newSessionID returns a short random identifier
+func newSessionID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,175 @@
+package terminal
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock lets tests move time forward without sleeping
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func newTestRegistry(limits Limits) (*Registry, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)}
+	r := newRegistry(limits)
+	r.now = clock.Now
+	return r, clock
+}
+
+func TestRegisterEnforcesConcurrencyCaps(t *testing.T) {
+	r, _ := newTestRegistry(Limits{MaxPerExercise: 2, MaxTotal: 3})
+
+	if _, err := r.Register("a", "", "standard", ""); err != nil {
+		t.Fatalf("first session rejected: %v", err)
+	}
+	if _, err := r.Register("a", "", "standard", ""); err != nil {
+		t.Fatalf("second session rejected: %v", err)
+	}
+
+	_, err := r.Register("a", "", "standard", "")
+	if sessErr, ok := err.(*SessionError); !ok || sessErr.Code != ErrCodeTooManyExerciseSessions {
+		t.Fatalf("expected %s, got %v", ErrCodeTooManyExerciseSessions, err)
+	}
+
+	if _, err := r.Register("b", "", "standard", ""); err != nil {
+		t.Fatalf("session for another exercise rejected: %v", err)
+	}
+
+	_, err = r.Register("c", "", "standard", "")
+	if sessErr, ok := err.(*SessionError); !ok || sessErr.Code != ErrCodeTooManySessions {
+		t.Fatalf("expected %s, got %v", ErrCodeTooManySessions, err)
+	}
+}
+
+func TestUnregisterFreesSlot(t *testing.T) {
+	r, _ := newTestRegistry(Limits{MaxTotal: 1})
+
+	s, err := r.Register("a", "", "standard", "")
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	r.Unregister(s.ID)
+
+	if _, err := r.Register("a", "", "standard", ""); err != nil {
+		t.Fatalf("slot was not freed: %v", err)
+	}
+	if got := len(r.List()); got != 1 {
+		t.Errorf("expected 1 listed session, got %d", got)
+	}
+}
+
+func TestEnforceWarnsThenClosesIdleSession(t *testing.T) {
+	r, clock := newTestRegistry(Limits{
+		IdleTimeout: 10 * time.Minute,
+		MaxDuration: time.Hour,
+		WarnBefore:  2 * time.Minute,
+	})
+
+	s, _ := r.Register("a", "", "standard", "")
+	var warnings []string
+	var closedWith string
+	s.SetCallbacks(Callbacks{
+		Warn:  func(msg string) { warnings = append(warnings, msg) },
+		Close: func(reason string) { closedWith = reason },
+	})
+
+	clock.Advance(7 * time.Minute)
+	r.enforce()
+	if len(warnings) != 0 {
+		t.Fatalf("warned too early: %v", warnings)
+	}
+
+	clock.Advance(2 * time.Minute)
+	r.enforce()
+	r.enforce()
+	if len(warnings) != 1 {
+		t.Fatalf("expected exactly one idle warning, got %v", warnings)
+	}
+
+	clock.Advance(time.Minute)
+	r.enforce()
+	if closedWith != ReasonIdleTimeout {
+		t.Errorf("expected close reason %q, got %q", ReasonIdleTimeout, closedWith)
+	}
+}
+
+func TestTouchResetsIdleTimer(t *testing.T) {
+	r, clock := newTestRegistry(Limits{IdleTimeout: 10 * time.Minute, MaxDuration: time.Hour})
+
+	s, _ := r.Register("a", "", "standard", "")
+	closed := false
+	s.SetCallbacks(Callbacks{Close: func(string) { closed = true }})
+
+	clock.Advance(9 * time.Minute)
+	s.Touch()
+	clock.Advance(9 * time.Minute)
+	r.enforce()
+	if closed {
+		t.Error("active session was closed for idleness")
+	}
+}
+
+func TestEnforceClosesAtMaxDuration(t *testing.T) {
+	r, clock := newTestRegistry(Limits{IdleTimeout: 30 * time.Minute, MaxDuration: time.Hour})
+
+	s, _ := r.Register("a", "", "standard", "")
+	var closedWith string
+	s.SetCallbacks(Callbacks{Close: func(reason string) { closedWith = reason }})
+
+	for i := 0; i < 6; i++ {
+		clock.Advance(10 * time.Minute)
+		s.Touch()
+		r.enforce()
+	}
+	if closedWith != ReasonMaxDuration {
+		t.Errorf("expected close reason %q, got %q", ReasonMaxDuration, closedWith)
+	}
+}
+
+func TestCloseUnknownSession(t *testing.T) {
+	r, _ := newTestRegistry(DefaultLimits())
+
+	err := r.Close("missing", ReasonAdminClose)
+	if sessErr, ok := err.(*SessionError); !ok || sessErr.Code != ErrCodeSessionNotFound {
+		t.Fatalf("expected %s, got %v", ErrCodeSessionNotFound, err)
+	}
+}
+
+func TestWaitReturnsWhenSessionsEnd(t *testing.T) {
+	r, _ := newTestRegistry(Limits{})
+
+	a, _ := r.Register("a", "", "standard", "")
+	r.Register("b", "", "standard", "")
+
+	// The handler for "a" unregisters once its session is closed
+	a.SetCallbacks(Callbacks{Close: func(string) { r.Unregister(a.ID) }})
+	r.CloseAll("a", ReasonAdminClose)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if !r.Wait(ctx, "a") {
+		t.Fatal("expected sessions for a to end")
+	}
+
+	short, cancelShort := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelShort()
+	if r.Wait(short, "") {
+		t.Error("expected Wait to time out while b is still open")
+	}
+}
@@ -0,0 +1,463 @@
+package timer
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/logger"
+)
+
+// entry is the mutable state behind a Timer
+type entry struct {
+	id           string
+	kind         Kind
+	subject      string
+	startedAt    time.Time
+	limit        time.Duration
+	elapsed      time.Duration // Accumulated before runningSince
+	runningSince *time.Time
+	state        State
+	pauses       []Pause
+
+	// gen invalidates scheduled callbacks when the timer changes state
+	gen   int
+	alarm *time.Timer
+}
+
+// elapsedAt returns how long the timer has run as of now
+func (e *entry) elapsedAt(now time.Time) time.Duration {
+	elapsed := e.elapsed
+	if e.runningSince != nil {
+		elapsed += now.Sub(*e.runningSince)
+	}
+	if e.limit > 0 && elapsed > e.limit {
+		elapsed = e.limit
+	}
+	return elapsed
+}
+
+// pausedAt returns the total pause time as of now
+func (e *entry) pausedAt(now time.Time) time.Duration {
+	var total time.Duration
+	for _, p := range e.pauses {
+		end := now
+		if p.End != nil {
+			end = *p.End
+		}
+		total += end.Sub(p.Start)
+	}
+	return total
+}
+
+// Service owns every timer. Callbacks registered with OnExpire run when a
+// timer reaches its deadline; subscribers receive every state change.
+type Service struct {
+	mu       sync.Mutex
+	timers   map[string]*entry
+	rules    map[Kind]Rules
+	handlers map[Kind]func(Timer)
+	subs     map[int]chan Timer
+	nextSub  int
+	now      func() time.Time
+
+	stopped  bool           // Set by Shutdown; no callback runs after it
+	handling sync.WaitGroup // Expiry handlers still running
+}
+
+// NewService creates a service that persists timers to the database
+func NewService() *Service {
+	return NewServiceWithClock(time.Now)
+}
+
+// NewServiceWithClock creates a service that reads the time from now. Only
+// reported times follow now; deadlines still fire on the wall clock.
+func NewServiceWithClock(now func() time.Time) *Service {
+	return &Service{
+		timers:   make(map[string]*entry),
+		rules:    DefaultRules,
+		handlers: make(map[Kind]func(Timer)),
+		subs:     make(map[int]chan Timer),
+		now:      now,
+	}
+}
+
+// OnExpire registers fn to run, in its own goroutine, when a timer of the
+// given kind reaches its deadline
+func (s *Service) OnExpire(kind Kind, fn func(Timer)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[kind] = fn
+}
+
+// Start starts a timer with the given limit (0 for no deadline). Starting a
+// timer that is already running or paused returns it unchanged, so clients
+// can call Start whenever they open a page.
+func (s *Service) Start(id string, kind Kind, subject string, limit time.Duration) Timer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.timers[id]; ok && (e.state == StateRunning || e.state == StatePaused) {
+		return s.snapshotLocked(e)
+	}
+
+	now := s.now()
+	e := &entry{
+		id:           id,
+		kind:         kind,
+		subject:      subject,
+		startedAt:    now,
+		limit:        limit,
+		runningSince: &now,
+		state:        StateRunning,
+	}
+	if old, ok := s.timers[id]; ok {
+		s.disarmLocked(old)
+	}
+	s.timers[id] = e
+	s.armLocked(e)
+	s.changedLocked(e)
+	logger.Info("Started timer %s (limit %s)", id, limit)
+	return s.snapshotLocked(e)
+}
+
+// Get returns a timer
+func (s *Service) Get(id string) (Timer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.timers[id]
+	if !ok {
+		return Timer{}, ErrNotFound
+	}
+	return s.snapshotLocked(e), nil
+}
+
+// List returns every timer, ordered by id
+func (s *Service) List() []Timer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timers := make([]Timer, 0, len(s.timers))
+	for _, e := range s.timers {
+		timers = append(timers, s.snapshotLocked(e))
+	}
+	sort.Slice(timers, func(i, j int) bool { return timers[i].ID < timers[j].ID })
+	return timers
+}
+
+// Pause stops the clock for reason, subject to the kind's rules. When the
+// pause budget runs out the timer resumes by itself.
+func (s *Service) Pause(id string, reason PauseReason) (Timer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.timers[id]
+	if !ok {
+		return Timer{}, ErrNotFound
+	}
+	if e.state != StateRunning {
+		return Timer{}, ErrNotRunning
+	}
+
+	now := s.now()
+	rules := s.rules[e.kind]
+	if !reasonAllowed(rules, reason) {
+		return Timer{}, ErrPauseNotAllowed
+	}
+	if rules.MaxPauses > 0 && len(e.pauses) >= rules.MaxPauses {
+		return Timer{}, ErrPauseLimit
+	}
+	if rules.MaxPaused > 0 && e.pausedAt(now) >= rules.MaxPaused {
+		return Timer{}, ErrPauseLimit
+	}
+
+	e.elapsed = e.elapsedAt(now)
+	e.runningSince = nil
+	e.state = StatePaused
+	e.pauses = append(e.pauses, Pause{Reason: reason, Start: now})
+	s.armLocked(e)
+	s.changedLocked(e)
+	logger.Info("Paused timer %s (%s)", id, reason)
+	return s.snapshotLocked(e), nil
+}
+
+// Resume restarts a paused timer
+func (s *Service) Resume(id string) (Timer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.timers[id]
+	if !ok {
+		return Timer{}, ErrNotFound
+	}
+	if e.state != StatePaused {
+		return Timer{}, ErrNotPaused
+	}
+	s.resumeLocked(e, s.now())
+	return s.snapshotLocked(e), nil
+}
+
+// resumeLocked ends the current pause at 'at' and restarts the clock from there
+func (s *Service) resumeLocked(e *entry, at time.Time) {
+	if n := len(e.pauses); n > 0 && e.pauses[n-1].End == nil {
+		e.pauses[n-1].End = &at
+	}
+	e.runningSince = &at
+	e.state = StateRunning
+	s.armLocked(e)
+	s.changedLocked(e)
+	logger.Info("Resumed timer %s", e.id)
+}
+
+// Stop freezes a timer before its deadline, e.g. when an exam is submitted
+// early. Stopping an expired or stopped timer returns it unchanged.
+func (s *Service) Stop(id string) (Timer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.timers[id]
+	if !ok {
+		return Timer{}, ErrNotFound
+	}
+	if e.state == StateRunning || e.state == StatePaused {
+		now := s.now()
+		if n := len(e.pauses); n > 0 && e.pauses[n-1].End == nil {
+			e.pauses[n-1].End = &now
+		}
+		e.elapsed = e.elapsedAt(now)
+		e.runningSince = nil
+		e.state = StateStopped
+		s.disarmLocked(e)
+		s.changedLocked(e)
+	}
+	return s.snapshotLocked(e), nil
+}
+
+// Remove forgets a timer and deletes its persisted state
+func (s *Service) Remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.timers[id]; ok {
+		s.disarmLocked(e)
+		delete(s.timers, id)
+		if err := deleteTimer(id); err != nil {
+			logger.Warn("Failed to delete timer %s: %v", id, err)
+		}
+	}
+}
+
+// Subscribe returns a channel that receives a timer snapshot on every state
+// change, and a function that ends the subscription. Slow subscribers miss
+// updates rather than blocking timers.
+func (s *Service) Subscribe() (<-chan Timer, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextSub
+	s.nextSub++
+	ch := make(chan Timer, 16)
+	s.subs[id] = ch
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subs[id]; ok {
+			delete(s.subs, id)
+			close(ch)
+		}
+	}
+}
+
+// Load restores persisted timers. Running timers whose deadline passed
+// while the app was down expire straight away; pauses that outlasted their
+// budget end at the moment it ran out.
+func (s *Service) Load() error {
+	entries, err := loadTimers()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for _, e := range entries {
+		s.timers[e.id] = e
+		if e.state == StatePaused {
+			rules := s.rules[e.kind]
+			if rules.MaxPaused > 0 {
+				if over := e.pausedAt(now) - rules.MaxPaused; over > 0 {
+					s.resumeLocked(e, now.Add(-over))
+					continue
+				}
+			}
+		}
+		s.armLocked(e)
+	}
+	logger.Info("Restored %d timers", len(entries))
+	return nil
+}
+
+// Shutdown cancels every pending callback and waits, until ctx is done, for
+// expiry handlers already running. Timers keep their persisted state, so
+// Load resumes them on the next start; it must run before the database is
+// closed.
+func (s *Service) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopped = true
+	for _, e := range s.timers {
+		s.disarmLocked(e)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.handling.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// armLocked schedules the next callback for a timer: its deadline while
+// running, the end of its pause budget while paused
+func (s *Service) armLocked(e *entry) {
+	s.disarmLocked(e)
+	if s.stopped {
+		return
+	}
+	gen := e.gen
+
+	var wait time.Duration
+	var fire func()
+	now := s.now()
+	switch e.state {
+	case StateRunning:
+		if e.limit == 0 {
+			return
+		}
+		wait = e.limit - e.elapsedAt(now)
+		fire = func() { s.expire(e.id, gen) }
+	case StatePaused:
+		rules := s.rules[e.kind]
+		if rules.MaxPaused == 0 {
+			return
+		}
+		wait = rules.MaxPaused - e.pausedAt(now)
+		fire = func() { s.pauseBudgetUsed(e.id, gen) }
+	default:
+		return
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	e.alarm = time.AfterFunc(wait, fire)
+}
+
+// disarmLocked cancels a timer's pending callback
+func (s *Service) disarmLocked(e *entry) {
+	e.gen++
+	if e.alarm != nil {
+		e.alarm.Stop()
+		e.alarm = nil
+	}
+}
+
+// expire marks a timer expired at its deadline and runs the kind's handler
+func (s *Service) expire(id string, gen int) {
+	s.mu.Lock()
+	e, ok := s.timers[id]
+	if !ok || e.gen != gen || e.state != StateRunning || s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.handling.Add(1)
+	defer s.handling.Done()
+	e.elapsed = e.limit
+	e.runningSince = nil
+	e.state = StateExpired
+	e.alarm = nil
+	s.changedLocked(e)
+	snapshot := s.snapshotLocked(e)
+	handler := s.handlers[e.kind]
+	s.mu.Unlock()
+
+	logger.Info("Timer %s reached its deadline", id)
+	if handler != nil {
+		handler(snapshot)
+	}
+}
+
+// pauseBudgetUsed resumes a timer whose pause allowance ran out
+func (s *Service) pauseBudgetUsed(id string, gen int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.timers[id]
+	if !ok || e.gen != gen || e.state != StatePaused || s.stopped {
+		return
+	}
+	logger.Info("Pause budget for timer %s used up, resuming", id)
+	s.resumeLocked(e, s.now())
+}
+
+// changedLocked persists a timer and notifies subscribers
+func (s *Service) changedLocked(e *entry) {
+	if err := saveTimer(e); err != nil {
+		logger.Warn("Failed to persist timer %s: %v", e.id, err)
+	}
+	snapshot := s.snapshotLocked(e)
+	for _, ch := range s.subs {
+		select {
+		case ch <- snapshot:
+		default:
+		}
+	}
+}
+
+// snapshotLocked builds the public view of a timer
+func (s *Service) snapshotLocked(e *entry) Timer {
+	now := s.now()
+	elapsed := e.elapsedAt(now)
+
+	t := Timer{
+		ID:             e.id,
+		Kind:           e.kind,
+		Subject:        e.subject,
+		State:          e.state,
+		StartedAt:      e.startedAt,
+		LimitSeconds:   int(e.limit.Seconds()),
+		ElapsedSeconds: int(elapsed.Seconds()),
+		PausedSeconds:  int(e.pausedAt(now).Seconds()),
+		Pauses:         append([]Pause{}, e.pauses...),
+	}
+	if e.limit > 0 {
+		remaining := e.limit - elapsed
+		t.RemainingSeconds = int((remaining + time.Second - 1) / time.Second)
+		if e.state == StateRunning {
+			deadline := now.Add(remaining)
+			t.Deadline = &deadline
+		}
+	}
+	if e.state == StatePaused && len(e.pauses) > 0 {
+		t.PauseReason = e.pauses[len(e.pauses)-1].Reason
+	}
+	return t
+}
+
+// reasonAllowed reports whether rules permit pausing for reason
+func reasonAllowed(rules Rules, reason PauseReason) bool {
+	for _, allowed := range rules.AllowedReasons {
+		if allowed == reason {
+			return true
+		}
+	}
+	return false
+}
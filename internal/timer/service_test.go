@@ -0,0 +1,244 @@
+package timer
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+// setupTimerDB creates a database with the timers table
+func setupTimerDB(t *testing.T) {
+	t.Helper()
+	if err := database.Initialize(database.Config{Path: filepath.Join(t.TempDir(), "test.db")}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := database.ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+}
+
+// fakeClock is a settable clock for the service
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestService(clock *fakeClock) *Service {
+	return NewServiceWithClock(clock.now)
+}
+
+func TestPauseRules(t *testing.T) {
+	setupTimerDB(t)
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)}
+	s := newTestService(clock)
+
+	s.Start("exam-1", KindExam, "1", time.Hour)
+	if _, err := s.Pause("exam-1", ReasonBreak); !errors.Is(err, ErrPauseNotAllowed) {
+		t.Fatalf("break during exam: got %v, want ErrPauseNotAllowed", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.Pause("exam-1", ReasonEnvironment); err != nil {
+			t.Fatalf("pause %d failed: %v", i+1, err)
+		}
+		clock.advance(time.Minute)
+		if _, err := s.Resume("exam-1"); err != nil {
+			t.Fatalf("resume %d failed: %v", i+1, err)
+		}
+	}
+	if _, err := s.Pause("exam-1", ReasonEnvironment); !errors.Is(err, ErrPauseLimit) {
+		t.Fatalf("fourth pause: got %v, want ErrPauseLimit", err)
+	}
+	if _, err := s.Resume("exam-1"); !errors.Is(err, ErrNotPaused) {
+		t.Fatalf("resume while running: got %v, want ErrNotPaused", err)
+	}
+
+	s.Start("exercise-a", KindExercise, "a", 0)
+	if _, err := s.Pause("exercise-a", ReasonBreak); err != nil {
+		t.Fatalf("break during exercise failed: %v", err)
+	}
+}
+
+func TestPauseStopsClock(t *testing.T) {
+	setupTimerDB(t)
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)}
+	s := newTestService(clock)
+
+	s.Start("exam-1", KindExam, "1", time.Hour)
+	clock.advance(10 * time.Minute)
+	s.Pause("exam-1", ReasonProvisioning)
+	clock.advance(5 * time.Minute)
+
+	tm, _ := s.Get("exam-1")
+	if tm.State != StatePaused || tm.PauseReason != ReasonProvisioning {
+		t.Errorf("got state %s reason %q, want paused for provisioning", tm.State, tm.PauseReason)
+	}
+	if tm.RemainingSeconds != 50*60 {
+		t.Errorf("remaining while paused = %d, want %d", tm.RemainingSeconds, 50*60)
+	}
+	if tm.PausedSeconds != 5*60 {
+		t.Errorf("paused = %d, want %d", tm.PausedSeconds, 5*60)
+	}
+
+	s.Resume("exam-1")
+	clock.advance(20 * time.Minute)
+	tm, _ = s.Get("exam-1")
+	if tm.RemainingSeconds != 30*60 {
+		t.Errorf("remaining after resume = %d, want %d", tm.RemainingSeconds, 30*60)
+	}
+	if tm.Deadline == nil || !tm.Deadline.Equal(clock.t.Add(30*time.Minute)) {
+		t.Errorf("deadline = %v, want %v", tm.Deadline, clock.t.Add(30*time.Minute))
+	}
+}
+
+func TestExpireRunsHandler(t *testing.T) {
+	setupTimerDB(t)
+	s := NewService()
+
+	expired := make(chan Timer, 1)
+	s.OnExpire(KindExam, func(tm Timer) { expired <- tm })
+	updates, cancel := s.Subscribe()
+	defer cancel()
+
+	s.Start("exam-7", KindExam, "7", 50*time.Millisecond)
+
+	select {
+	case tm := <-expired:
+		if tm.Subject != "7" || tm.State != StateExpired || tm.RemainingSeconds != 0 {
+			t.Errorf("unexpected expired timer: %+v", tm)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler never ran")
+	}
+
+	var states []State
+	for len(updates) > 0 {
+		states = append(states, (<-updates).State)
+	}
+	if len(states) != 2 || states[0] != StateRunning || states[1] != StateExpired {
+		t.Errorf("updates = %v, want [running expired]", states)
+	}
+}
+
+func TestStopPreventsExpiry(t *testing.T) {
+	setupTimerDB(t)
+	s := NewService()
+
+	expired := make(chan Timer, 1)
+	s.OnExpire(KindExam, func(tm Timer) { expired <- tm })
+
+	s.Start("exam-1", KindExam, "1", 50*time.Millisecond)
+	tm, err := s.Stop("exam-1")
+	if err != nil || tm.State != StateStopped {
+		t.Fatalf("Stop = %+v, %v", tm, err)
+	}
+
+	select {
+	case <-expired:
+		t.Fatal("stopped timer expired")
+	case <-time.After(150 * time.Millisecond):
+	}
+}
+
+func TestShutdownWaitsForHandlers(t *testing.T) {
+	setupTimerDB(t)
+	s := NewService()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	s.OnExpire(KindExam, func(tm Timer) {
+		close(started)
+		<-release
+	})
+	s.Start("exam-1", KindExam, "1", 20*time.Millisecond)
+	s.Start("exam-2", KindExam, "2", 200*time.Millisecond)
+	<-started
+
+	// A running handler holds up shutdown until it returns
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown with a running handler = %v, want DeadlineExceeded", err)
+	}
+	close(release)
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown = %v", err)
+	}
+
+	// Later deadlines don't fire, and the timer stays running for Load
+	time.Sleep(250 * time.Millisecond)
+	if tm, _ := s.Get("exam-2"); tm.State != StateRunning {
+		t.Errorf("timer after shutdown = %+v, want it still running", tm)
+	}
+}
+
+func TestPauseBudgetResumes(t *testing.T) {
+	setupTimerDB(t)
+	s := NewService()
+	s.rules = map[Kind]Rules{
+		KindExam: {AllowedReasons: []PauseReason{ReasonEnvironment}, MaxPaused: 50 * time.Millisecond},
+	}
+
+	s.Start("exam-1", KindExam, "1", time.Hour)
+	if _, err := s.Pause("exam-1", ReasonEnvironment); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if tm, _ := s.Get("exam-1"); tm.State == StateRunning {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("timer never resumed after its pause budget ran out")
+}
+
+func TestLoadRestoresTimers(t *testing.T) {
+	setupTimerDB(t)
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)}
+	s := newTestService(clock)
+
+	s.Start("exam-1", KindExam, "1", time.Hour)
+	s.Start("exam-2", KindExam, "2", time.Hour)
+	clock.advance(20 * time.Minute)
+	s.Pause("exam-2", ReasonEnvironment)
+	s.Start("exercise-a", KindExercise, "a", time.Minute)
+
+	// The app is down for two minutes
+	clock.advance(2 * time.Minute)
+	restarted := newTestService(clock)
+	expired := make(chan Timer, 1)
+	restarted.OnExpire(KindExercise, func(tm Timer) { expired <- tm })
+	if err := restarted.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tm, err := restarted.Get("exam-1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if tm.State != StateRunning || tm.RemainingSeconds != 38*60 {
+		t.Errorf("running exam: state %s remaining %d, want running %d", tm.State, tm.RemainingSeconds, 38*60)
+	}
+
+	tm, _ = restarted.Get("exam-2")
+	if tm.State != StatePaused || tm.RemainingSeconds != 40*60 {
+		t.Errorf("paused exam: state %s remaining %d, want paused %d", tm.State, tm.RemainingSeconds, 40*60)
+	}
+
+	// The exercise deadline passed while the app was down
+	select {
+	case tm := <-expired:
+		if tm.ID != "exercise-a" {
+			t.Errorf("expired %s, want exercise-a", tm.ID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("overdue timer did not expire on load")
+	}
+}
@@ -0,0 +1,111 @@
+package timer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+var errNoDatabase = errors.New("database not initialized")
+
+// saveTimer upserts a timer's state
+func saveTimer(e *entry) error {
+	if database.DB == nil {
+		return errNoDatabase
+	}
+
+	pauses, err := json.Marshal(e.pauses)
+	if err != nil {
+		return fmt.Errorf("failed to encode pauses: %w", err)
+	}
+	var runningSince sql.NullString
+	if e.runningSince != nil {
+		runningSince = sql.NullString{String: e.runningSince.UTC().Format(time.RFC3339Nano), Valid: true}
+	}
+
+	_, err = database.DB.Exec(`
+		INSERT INTO timers (id, kind, subject, state, started_at, limit_ms, elapsed_ms, running_since, pauses, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(id) DO UPDATE SET
+			kind = excluded.kind,
+			subject = excluded.subject,
+			state = excluded.state,
+			started_at = excluded.started_at,
+			limit_ms = excluded.limit_ms,
+			elapsed_ms = excluded.elapsed_ms,
+			running_since = excluded.running_since,
+			pauses = excluded.pauses,
+			updated_at = CURRENT_TIMESTAMP
+	`, e.id, string(e.kind), e.subject, string(e.state), e.startedAt.UTC().Format(time.RFC3339Nano),
+		e.limit.Milliseconds(), e.elapsed.Milliseconds(), runningSince, string(pauses))
+	if err != nil {
+		return fmt.Errorf("failed to save timer: %w", err)
+	}
+	return nil
+}
+
+// deleteTimer removes a timer's persisted state
+func deleteTimer(id string) error {
+	if database.DB == nil {
+		return errNoDatabase
+	}
+	_, err := database.DB.Exec("DELETE FROM timers WHERE id = ?", id)
+	return err
+}
+
+// loadTimers reads every persisted timer
+func loadTimers() ([]*entry, error) {
+	if database.DB == nil {
+		return nil, errNoDatabase
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id, kind, subject, state, started_at, limit_ms, elapsed_ms, running_since, pauses
+		FROM timers ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timers: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*entry
+	for rows.Next() {
+		var (
+			e            entry
+			kind, state  string
+			startedAt    string
+			limitMs      int64
+			elapsedMs    int64
+			runningSince sql.NullString
+			pauses       sql.NullString
+		)
+		if err := rows.Scan(&e.id, &kind, &e.subject, &state, &startedAt, &limitMs, &elapsedMs, &runningSince, &pauses); err != nil {
+			return nil, fmt.Errorf("failed to read timer: %w", err)
+		}
+		e.kind = Kind(kind)
+		e.state = State(state)
+		e.limit = time.Duration(limitMs) * time.Millisecond
+		e.elapsed = time.Duration(elapsedMs) * time.Millisecond
+		if e.startedAt, err = time.Parse(time.RFC3339Nano, startedAt); err != nil {
+			return nil, fmt.Errorf("invalid start time for timer %s: %w", e.id, err)
+		}
+		if runningSince.Valid {
+			t, err := time.Parse(time.RFC3339Nano, runningSince.String)
+			if err != nil {
+				return nil, fmt.Errorf("invalid running time for timer %s: %w", e.id, err)
+			}
+			e.runningSince = &t
+		}
+		if pauses.Valid && pauses.String != "" {
+			if err := json.Unmarshal([]byte(pauses.String), &e.pauses); err != nil {
+				return nil, fmt.Errorf("invalid pauses for timer %s: %w", e.id, err)
+			}
+		}
+		entries = append(entries, &e)
+	}
+	return entries, rows.Err()
+}
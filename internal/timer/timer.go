@@ -0,0 +1,104 @@
+// Package timer keeps exam and exercise timers on the server so every
+// client sees the same remaining time. Timers can be paused for a limited
+// set of reasons, fire a callback at their deadline and are persisted so a
+// restart resumes them where they were.
+package timer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Kind identifies what a timer measures
+type Kind string
+
+const (
+	KindExam     Kind = "exam"
+	KindExercise Kind = "exercise"
+)
+
+// State is the state of a timer
+type State string
+
+const (
+	StateRunning State = "running"
+	StatePaused  State = "paused"
+	StateExpired State = "expired" // Deadline reached
+	StateStopped State = "stopped" // Stopped before the deadline
+)
+
+// PauseReason explains why a timer is paused
+type PauseReason string
+
+const (
+	// ReasonEnvironment covers a broken environment: cluster down, terminal
+	// or IDE unavailable
+	ReasonEnvironment PauseReason = "environment-issue"
+	// ReasonProvisioning covers the environment being rebuilt, e.g. a reset
+	ReasonProvisioning PauseReason = "provisioning"
+	// ReasonBreak is a user break, allowed in practice only
+	ReasonBreak PauseReason = "break"
+)
+
+// Rules limit how a kind of timer may be paused
+type Rules struct {
+	AllowedReasons []PauseReason
+	MaxPauses      int           // 0 for unlimited
+	MaxPaused      time.Duration // Total pause time; 0 for unlimited. The timer resumes itself when it runs out.
+}
+
+// DefaultRules are the pause rules per timer kind. Like the real exam, an
+// exam clock only stops for environment problems, and not for long.
+var DefaultRules = map[Kind]Rules{
+	KindExam: {
+		AllowedReasons: []PauseReason{ReasonEnvironment, ReasonProvisioning},
+		MaxPauses:      3,
+		MaxPaused:      15 * time.Minute,
+	},
+	KindExercise: {
+		AllowedReasons: []PauseReason{ReasonEnvironment, ReasonProvisioning, ReasonBreak},
+	},
+}
+
+// Pause records one pause of a timer
+type Pause struct {
+	Reason PauseReason `json:"reason"`
+	Start  time.Time   `json:"start"`
+	End    *time.Time  `json:"end,omitempty"`
+}
+
+// Timer is a snapshot of a timer's state
+type Timer struct {
+	ID               string      `json:"id"`
+	Kind             Kind        `json:"kind"`
+	Subject          string      `json:"subject"` // Exam id or exercise slug
+	State            State       `json:"state"`
+	StartedAt        time.Time   `json:"startedAt"`
+	LimitSeconds     int         `json:"limitSeconds"` // 0 for a stopwatch without a deadline
+	ElapsedSeconds   int         `json:"elapsedSeconds"`
+	RemainingSeconds int         `json:"remainingSeconds"`
+	Deadline         *time.Time  `json:"deadline,omitempty"` // Only while running with a limit
+	PauseReason      PauseReason `json:"pauseReason,omitempty"`
+	PausedSeconds    int         `json:"pausedSeconds"`
+	Pauses           []Pause     `json:"pauses"`
+}
+
+// ExamTimerID returns the id of an exam's countdown
+func ExamTimerID(examID int64) string {
+	return fmt.Sprintf("exam-%d", examID)
+}
+
+// ExerciseTimerID returns the id of an exercise's timer
+func ExerciseTimerID(slug string) string {
+	return "exercise-" + slug
+}
+
+// Errors returned by the Service
+var (
+	ErrNotFound        = errors.New("timer not found")
+	ErrNotRunning      = errors.New("timer is not running")
+	ErrNotPaused       = errors.New("timer is not paused")
+	ErrPauseNotAllowed = errors.New("pause reason not allowed for this timer")
+	ErrPauseLimit      = errors.New("no pauses left for this timer")
+)
@@ -0,0 +1,69 @@
+package trends
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+var errNoDatabase = errors.New("database not initialized")
+
+// LoadAttempts reads the active profile's completed attempts with their
+// exercise, oldest first
+func LoadAttempts() ([]Attempt, error) {
+	if database.DB == nil {
+		return nil, errNoDatabase
+	}
+	profileID, err := database.ActiveProfileID()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT a.exercise_id, e.slug, e.title, e.category, CAST(strftime('%s', a.completed_at) AS INTEGER),
+			a.score, a.max_score, COALESCE(a.duration_seconds, 0), a.passed
+		FROM attempts a
+		JOIN exercises e ON e.id = a.exercise_id
+		WHERE a.profile_id = ? AND a.completed_at IS NOT NULL
+		ORDER BY a.completed_at, a.id
+	`, profileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []Attempt
+	for rows.Next() {
+		var a Attempt
+		var at int64
+		if err := rows.Scan(&a.ExerciseID, &a.Slug, &a.Title, &a.Domain, &at, &a.Score, &a.MaxScore, &a.DurationSeconds, &a.Passed); err != nil {
+			return nil, fmt.Errorf("failed to read attempt: %w", err)
+		}
+		a.At = time.Unix(at, 0)
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+// Current loads the history and the active curriculum and builds the
+// report; now sets what counts as today
+func Current(opts Options, now time.Time) (Report, error) {
+	attempts, err := LoadAttempts()
+	if err != nil {
+		return Report{}, err
+	}
+	if opts.Domains == nil {
+		curriculum, err := database.GetActiveCurriculum()
+		if err != nil {
+			return Report{}, err
+		}
+		opts.Domains = curriculum.Domains
+	}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	return Build(attempts, opts, Day(now, loc)), nil
+}
@@ -0,0 +1,416 @@
+// Package trends turns the attempt history into time series: practice per
+// calendar day and week in the user's time zone, a heatmap of practice
+// days, streaks, a rolling average score per domain and how solve times
+// develop per exercise.
+package trends
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+const (
+	// DateLayout is how calendar days are written
+	DateLayout = "2006-01-02"
+	// DefaultWindowDays is the span of the rolling average
+	DefaultWindowDays = 7
+	// HeatmapLevels is the number of non-empty heatmap intensities
+	HeatmapLevels = 4
+)
+
+// Attempt is one validated attempt
+type Attempt struct {
+	ExerciseID      int
+	Slug            string
+	Title           string
+	Domain          string
+	At              time.Time
+	Score           int
+	MaxScore        int
+	DurationSeconds int
+	Passed          bool
+}
+
+// Options select the range and bucketing of a report
+type Options struct {
+	From     time.Time // First calendar day, as returned by Day
+	To       time.Time // Last calendar day, inclusive
+	Location *time.Location
+	// WindowDays is the span of the rolling average per domain
+	WindowDays int
+	// Domains are the curriculum domains to report rolling scores for
+	Domains []database.CurriculumDomain
+}
+
+// Report is the time series over a range of days
+type Report struct {
+	TimeZone        string          `json:"timeZone"`
+	From            string          `json:"from"`
+	To              string          `json:"to"`
+	WindowDays      int             `json:"windowDays"`
+	Days            []Bucket        `json:"days"`  // Every day in the range, with its heatmap level
+	Weeks           []Bucket        `json:"weeks"` // Weeks starting on Monday that overlap the range
+	Streaks         Streaks         `json:"streaks"`
+	RollingScores   []DomainTrend   `json:"rollingScores"`
+	SolveTimeTrends []ExerciseTrend `json:"solveTimeTrends"`
+}
+
+// Bucket sums up the attempts of one day or week
+type Bucket struct {
+	Date            string  `json:"date"` // The day, or the Monday starting the week
+	Attempts        int     `json:"attempts"`
+	Passed          int     `json:"passed"`
+	PracticeSeconds int     `json:"practiceSeconds"`
+	AverageScore    float64 `json:"averageScore"`    // Percent; 0 without attempts
+	Level           int     `json:"level,omitempty"` // Heatmap intensity, 0 to HeatmapLevels; days only
+}
+
+// Streaks are runs of consecutive practice days, over the whole history
+type Streaks struct {
+	Current          int    `json:"current"` // Ending today, or yesterday if today has no practice yet
+	Longest          int    `json:"longest"`
+	LongestStart     string `json:"longestStart,omitempty"`
+	LongestEnd       string `json:"longestEnd,omitempty"`
+	LastPracticeDate string `json:"lastPracticeDate,omitempty"`
+	PracticeDays     int    `json:"practiceDays"` // In the range
+}
+
+// DomainTrend is the rolling average score of a domain
+type DomainTrend struct {
+	Domain      string       `json:"domain"`
+	DisplayName string       `json:"displayName"`
+	Points      []TrendPoint `json:"points"` // Only days with attempts in the window
+}
+
+// TrendPoint is one day of a rolling average
+type TrendPoint struct {
+	Date         string  `json:"date"`
+	AverageScore float64 `json:"averageScore"` // Percent
+	Attempts     int     `json:"attempts"`     // In the window
+}
+
+// ExerciseTrend is how long the passes of one exercise took over time
+type ExerciseTrend struct {
+	Slug          string       `json:"slug"`
+	Title         string       `json:"title"`
+	Domain        string       `json:"domain"`
+	Points        []SolvePoint `json:"points"`
+	FirstSeconds  int          `json:"firstSeconds"`
+	LastSeconds   int          `json:"lastSeconds"`
+	BestSeconds   int          `json:"bestSeconds"`
+	ChangePercent float64      `json:"changePercent"` // Last against first; negative is faster
+	SlopeSeconds  float64      `json:"slopeSeconds"`  // Least-squares change per pass
+}
+
+// SolvePoint is one pass
+type SolvePoint struct {
+	Date    string `json:"date"`
+	At      string `json:"at"` // RFC 3339 in the report's time zone
+	Seconds int    `json:"seconds"`
+}
+
+// Day returns the calendar day of t in loc, as midnight UTC so days can be
+// stepped with AddDate without daylight saving getting in the way
+func Day(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// weekStart returns the Monday of day's week
+func weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// percent is an attempt's score as a percentage
+func (a Attempt) percent() (float64, bool) {
+	if a.MaxScore <= 0 {
+		return 0, false
+	}
+	return float64(a.Score) / float64(a.MaxScore) * 100, true
+}
+
+// tally accumulates attempts into a bucket
+type tally struct {
+	Bucket
+	scoreSum float64
+	scored   int
+}
+
+func (t *tally) add(a Attempt) {
+	t.Attempts++
+	if a.Passed {
+		t.Passed++
+	}
+	if a.DurationSeconds > 0 {
+		t.PracticeSeconds += a.DurationSeconds
+	}
+	if p, ok := a.percent(); ok {
+		t.scoreSum += p
+		t.scored++
+	}
+}
+
+func (t *tally) bucket() Bucket {
+	b := t.Bucket
+	if t.scored > 0 {
+		b.AverageScore = t.scoreSum / float64(t.scored)
+	}
+	return b
+}
+
+// Build computes the report for attempts, which may span more than the
+// range: streaks look at the whole history, everything else only at the
+// days from opts.From to opts.To. today is the current calendar day.
+func Build(attempts []Attempt, opts Options, today time.Time) Report {
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	window := opts.WindowDays
+	if window <= 0 {
+		window = DefaultWindowDays
+	}
+
+	report := Report{
+		TimeZone:        loc.String(),
+		From:            opts.From.Format(DateLayout),
+		To:              opts.To.Format(DateLayout),
+		WindowDays:      window,
+		Days:            []Bucket{},
+		Weeks:           []Bucket{},
+		RollingScores:   []DomainTrend{},
+		SolveTimeTrends: []ExerciseTrend{},
+	}
+
+	sorted := make([]Attempt, len(attempts))
+	copy(sorted, attempts)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].At.Before(sorted[j].At) })
+
+	practiced := make(map[time.Time]bool)
+	days := make(map[time.Time]*tally)
+	weeks := make(map[time.Time]*tally)
+	var inRange []Attempt
+	for _, a := range sorted {
+		day := Day(a.At, loc)
+		practiced[day] = true
+		if day.Before(opts.From) || day.After(opts.To) {
+			continue
+		}
+		inRange = append(inRange, a)
+		if days[day] == nil {
+			days[day] = &tally{}
+		}
+		days[day].add(a)
+		week := weekStart(day)
+		if weeks[week] == nil {
+			weeks[week] = &tally{}
+		}
+		weeks[week].add(a)
+	}
+
+	maxSeconds := 0
+	for _, t := range days {
+		if t.PracticeSeconds > maxSeconds {
+			maxSeconds = t.PracticeSeconds
+		}
+	}
+	for day := opts.From; !day.After(opts.To); day = day.AddDate(0, 0, 1) {
+		t := days[day]
+		if t == nil {
+			t = &tally{}
+		} else {
+			report.Streaks.PracticeDays++
+		}
+		b := t.bucket()
+		b.Date = day.Format(DateLayout)
+		b.Level = heatmapLevel(b, maxSeconds)
+		report.Days = append(report.Days, b)
+	}
+	for week := weekStart(opts.From); !week.After(opts.To); week = week.AddDate(0, 0, 7) {
+		t := weeks[week]
+		if t == nil {
+			t = &tally{}
+		}
+		b := t.bucket()
+		b.Date = week.Format(DateLayout)
+		report.Weeks = append(report.Weeks, b)
+	}
+
+	streaks(practiced, today, &report.Streaks)
+	report.RollingScores = rollingScores(inRange, opts, loc, window)
+	report.SolveTimeTrends = solveTimeTrends(inRange, loc)
+	return report
+}
+
+// heatmapLevel scales a day's practice time against the busiest day. Days
+// with attempts but no recorded time still show up at the lowest level.
+func heatmapLevel(b Bucket, maxSeconds int) int {
+	if b.Attempts == 0 {
+		return 0
+	}
+	if maxSeconds == 0 || b.PracticeSeconds == 0 {
+		return 1
+	}
+	level := int(math.Ceil(float64(b.PracticeSeconds) / float64(maxSeconds) * HeatmapLevels))
+	if level < 1 {
+		level = 1
+	}
+	if level > HeatmapLevels {
+		level = HeatmapLevels
+	}
+	return level
+}
+
+// streaks finds the current and longest runs of practice days
+func streaks(practiced map[time.Time]bool, today time.Time, s *Streaks) {
+	if len(practiced) == 0 {
+		return
+	}
+	var days []time.Time
+	for day := range practiced {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	s.LastPracticeDate = days[len(days)-1].Format(DateLayout)
+	start, run := days[0], 1
+	record := func(end time.Time) {
+		if run > s.Longest {
+			s.Longest = run
+			s.LongestStart = start.Format(DateLayout)
+			s.LongestEnd = end.Format(DateLayout)
+		}
+	}
+	for i := 1; i < len(days); i++ {
+		if days[i].Equal(days[i-1].AddDate(0, 0, 1)) {
+			run++
+			continue
+		}
+		record(days[i-1])
+		start, run = days[i], 1
+	}
+	record(days[len(days)-1])
+
+	// A streak that ended yesterday is still alive until today is over
+	day := today
+	if !practiced[day] {
+		day = day.AddDate(0, 0, -1)
+	}
+	for practiced[day] {
+		s.Current++
+		day = day.AddDate(0, 0, -1)
+	}
+}
+
+// rollingScores averages each domain's scores over the window ending on
+// every day of the range
+func rollingScores(attempts []Attempt, opts Options, loc *time.Location, window int) []DomainTrend {
+	type dayScore struct {
+		sum   float64
+		count int
+	}
+	byDomain := make(map[string]map[time.Time]*dayScore)
+	for _, a := range attempts {
+		p, ok := a.percent()
+		if !ok {
+			continue
+		}
+		if byDomain[a.Domain] == nil {
+			byDomain[a.Domain] = make(map[time.Time]*dayScore)
+		}
+		day := Day(a.At, loc)
+		if byDomain[a.Domain][day] == nil {
+			byDomain[a.Domain][day] = &dayScore{}
+		}
+		byDomain[a.Domain][day].sum += p
+		byDomain[a.Domain][day].count++
+	}
+
+	trends := []DomainTrend{}
+	for _, domain := range opts.Domains {
+		trend := DomainTrend{Domain: domain.Slug, DisplayName: domain.DisplayName, Points: []TrendPoint{}}
+		scores := byDomain[domain.Slug]
+		for day := opts.From; !day.After(opts.To); day = day.AddDate(0, 0, 1) {
+			var sum float64
+			count := 0
+			for back := 0; back < window; back++ {
+				if s := scores[day.AddDate(0, 0, -back)]; s != nil {
+					sum += s.sum
+					count += s.count
+				}
+			}
+			if count > 0 {
+				trend.Points = append(trend.Points, TrendPoint{
+					Date:         day.Format(DateLayout),
+					AverageScore: sum / float64(count),
+					Attempts:     count,
+				})
+			}
+		}
+		trends = append(trends, trend)
+	}
+	return trends
+}
+
+// solveTimeTrends follows the timed passes of each exercise, most
+// practised exercises first
+func solveTimeTrends(attempts []Attempt, loc *time.Location) []ExerciseTrend {
+	byExercise := make(map[int]*ExerciseTrend)
+	var order []int
+	for _, a := range attempts {
+		if !a.Passed || a.DurationSeconds <= 0 {
+			continue
+		}
+		trend := byExercise[a.ExerciseID]
+		if trend == nil {
+			trend = &ExerciseTrend{Slug: a.Slug, Title: a.Title, Domain: a.Domain}
+			byExercise[a.ExerciseID] = trend
+			order = append(order, a.ExerciseID)
+		}
+		local := a.At.In(loc)
+		trend.Points = append(trend.Points, SolvePoint{
+			Date:    local.Format(DateLayout),
+			At:      local.Format(time.RFC3339),
+			Seconds: a.DurationSeconds,
+		})
+	}
+
+	trends := []ExerciseTrend{}
+	for _, id := range order {
+		trend := byExercise[id]
+		points := trend.Points
+		trend.FirstSeconds = points[0].Seconds
+		trend.LastSeconds = points[len(points)-1].Seconds
+		trend.BestSeconds = trend.FirstSeconds
+		for _, p := range points {
+			if p.Seconds < trend.BestSeconds {
+				trend.BestSeconds = p.Seconds
+			}
+		}
+		trend.ChangePercent = float64(trend.LastSeconds-trend.FirstSeconds) / float64(trend.FirstSeconds) * 100
+		trend.SlopeSeconds = slope(points)
+		trends = append(trends, *trend)
+	}
+	sort.SliceStable(trends, func(i, j int) bool { return len(trends[i].Points) > len(trends[j].Points) })
+	return trends
+}
+
+// slope fits seconds against the pass number by least squares
+func slope(points []SolvePoint) float64 {
+	n := float64(len(points))
+	if n < 2 {
+		return 0
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for i, p := range points {
+		x, y := float64(i), float64(p.Seconds)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	return (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+}
@@ -0,0 +1,152 @@
+package trends
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/patrickvassell/cks-weight-room/internal/database"
+)
+
+func date(s string) time.Time {
+	d, err := time.Parse(DateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestBuildBucketsInTimeZone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("no tz database: %v", err)
+	}
+
+	attempts := []Attempt{
+		// 23:30 UTC on the 2nd is the morning of the 3rd in Tokyo
+		{ExerciseID: 1, Domain: "a", At: time.Date(2026, 3, 2, 23, 30, 0, 0, time.UTC), Score: 5, MaxScore: 10, DurationSeconds: 600},
+		{ExerciseID: 1, Domain: "a", At: time.Date(2026, 3, 3, 1, 0, 0, 0, time.UTC), Score: 10, MaxScore: 10, DurationSeconds: 1200, Passed: true},
+	}
+	opts := Options{From: date("2026-03-02"), To: date("2026-03-08"), Location: tokyo}
+
+	report := Build(attempts, opts, date("2026-03-08"))
+	if report.TimeZone != "Asia/Tokyo" || len(report.Days) != 7 {
+		t.Fatalf("expected a week of days in Tokyo, got %s with %d days", report.TimeZone, len(report.Days))
+	}
+	if report.Days[0].Attempts != 0 || report.Days[1].Attempts != 2 {
+		t.Errorf("expected both attempts on the 3rd in Tokyo, got %+v", report.Days[:2])
+	}
+	day := report.Days[1]
+	if day.Passed != 1 || day.PracticeSeconds != 1800 || day.AverageScore != 75 || day.Level != HeatmapLevels {
+		t.Errorf("unexpected bucket %+v", day)
+	}
+
+	utc := Build(attempts, Options{From: date("2026-03-02"), To: date("2026-03-08"), Location: time.UTC}, date("2026-03-08"))
+	if utc.Days[0].Attempts != 1 || utc.Days[1].Attempts != 1 {
+		t.Errorf("expected the attempts on separate days in UTC, got %+v", utc.Days[:2])
+	}
+
+	// 2026-03-02 is a Monday, so the whole range is one week
+	if len(report.Weeks) != 1 || report.Weeks[0].Date != "2026-03-02" || report.Weeks[0].Attempts != 2 {
+		t.Errorf("unexpected weeks %+v", report.Weeks)
+	}
+}
+
+func TestBuildStreaks(t *testing.T) {
+	var attempts []Attempt
+	for _, d := range []string{"2026-02-01", "2026-02-02", "2026-02-03", "2026-02-04", "2026-02-10", "2026-02-11", "2026-02-12"} {
+		attempts = append(attempts, Attempt{At: date(d).Add(12 * time.Hour), MaxScore: 10})
+	}
+	opts := Options{From: date("2026-02-10"), To: date("2026-02-13"), Location: time.UTC}
+
+	report := Build(attempts, opts, date("2026-02-13"))
+	s := report.Streaks
+	if s.Current != 3 {
+		t.Errorf("expected yesterday's streak to still count, got %d", s.Current)
+	}
+	if s.Longest != 4 || s.LongestStart != "2026-02-01" || s.LongestEnd != "2026-02-04" {
+		t.Errorf("expected the longest streak from the full history, got %+v", s)
+	}
+	if s.PracticeDays != 3 || s.LastPracticeDate != "2026-02-12" {
+		t.Errorf("unexpected practice days %+v", s)
+	}
+
+	if broken := Build(attempts, opts, date("2026-02-14")); broken.Streaks.Current != 0 {
+		t.Errorf("expected a missed day to end the streak, got %d", broken.Streaks.Current)
+	}
+}
+
+func TestBuildRollingScoresAndSolveTimes(t *testing.T) {
+	at := func(d string) time.Time { return date(d).Add(10 * time.Hour) }
+	attempts := []Attempt{
+		{ExerciseID: 1, Slug: "x", Domain: "a", At: at("2026-01-01"), Score: 4, MaxScore: 10, DurationSeconds: 900},
+		{ExerciseID: 1, Slug: "x", Domain: "a", At: at("2026-01-02"), Score: 10, MaxScore: 10, DurationSeconds: 900, Passed: true},
+		{ExerciseID: 1, Slug: "x", Domain: "a", At: at("2026-01-05"), Score: 10, MaxScore: 10, DurationSeconds: 600, Passed: true},
+		{ExerciseID: 1, Slug: "x", Domain: "a", At: at("2026-01-20"), Score: 10, MaxScore: 10, DurationSeconds: 300, Passed: true},
+	}
+	opts := Options{
+		From:       date("2026-01-01"),
+		To:         date("2026-01-31"),
+		Location:   time.UTC,
+		WindowDays: 3,
+		Domains:    []database.CurriculumDomain{{Slug: "a", DisplayName: "A", Weight: 100}},
+	}
+	report := Build(attempts, opts, date("2026-01-31"))
+
+	points := report.RollingScores[0].Points
+	// Jan 1-4 see the early attempts, Jan 5-7 the third, Jan 20-22 the last
+	if len(points) != 10 {
+		t.Fatalf("expected 10 days with attempts in the window, got %+v", points)
+	}
+	if points[1].Date != "2026-01-02" || points[1].AverageScore != 70 || points[1].Attempts != 2 {
+		t.Errorf("unexpected rolling average %+v", points[1])
+	}
+	if points[3].Date != "2026-01-04" || points[3].Attempts != 1 {
+		t.Errorf("expected the first attempt to leave the window, got %+v", points[3])
+	}
+
+	trend := report.SolveTimeTrends[0]
+	if len(trend.Points) != 3 || trend.FirstSeconds != 900 || trend.LastSeconds != 300 || trend.BestSeconds != 300 {
+		t.Fatalf("unexpected solve trend %+v", trend)
+	}
+	if math.Abs(trend.ChangePercent+66.667) > 0.01 || trend.SlopeSeconds != -300 {
+		t.Errorf("expected solves to speed up by 300s a pass, got %+v", trend)
+	}
+}
+
+func TestCurrentFromDatabase(t *testing.T) {
+	if err := database.Initialize(database.Config{Path: filepath.Join(t.TempDir(), "test.db")}); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer database.Close()
+	if err := database.ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+	_, err := database.DB.Exec(`
+		INSERT INTO exercises (slug, title, description, category, difficulty, points)
+		VALUES ('setup', 'Setup', 'test', 'cluster-setup', 'easy', 10)
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert exercise: %v", err)
+	}
+	_, err = database.DB.Exec(`
+		INSERT INTO attempts (exercise_id, started_at, completed_at, duration_seconds, score, max_score, passed)
+		VALUES (1, '2026-03-01 08:50:00', '2026-03-01 09:00:00', 600, 10, 10, 1)
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert attempt: %v", err)
+	}
+
+	opts := Options{From: date("2026-03-01"), To: date("2026-03-01"), Location: time.UTC}
+	report, err := Current(opts, time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Current failed: %v", err)
+	}
+	if report.Days[0].Attempts != 1 || report.Streaks.Current != 1 || len(report.RollingScores) != 6 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if report.SolveTimeTrends[0].Slug != "setup" {
+		t.Errorf("expected the exercise slug on the trend, got %+v", report.SolveTimeTrends)
+	}
+}
@@ -0,0 +1,88 @@
+package workspace
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Change statuses
+const (
+	StatusAdded    = "added"
+	StatusModified = "modified"
+	StatusDeleted  = "deleted"
+)
+
+// FileChange describes how one file differs from the baseline
+type FileChange struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Diff   string `json:"diff,omitempty"` // Unified diff; empty for binary files and mode-only changes
+	Binary bool   `json:"binary,omitempty"`
+}
+
+// NodeDiff holds the changes on one node
+type NodeDiff struct {
+	Node    string       `json:"node"`
+	Changes []FileChange `json:"changes"`
+	Unified string       `json:"unified"` // All file diffs concatenated, like `diff -ruN`
+	Error   string       `json:"error,omitempty"`
+}
+
+// Compare diffs current against baseline, ordered by path
+func Compare(baseline, current *Snapshot) NodeDiff {
+	result := NodeDiff{Node: current.Node, Changes: []FileChange{}}
+
+	paths := make(map[string]bool, len(baseline.Files)+len(current.Files))
+	for p := range baseline.Files {
+		paths[p] = true
+	}
+	for p := range current.Files {
+		paths[p] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	var unified strings.Builder
+	for _, p := range sorted {
+		before, hadBefore := baseline.Files[p]
+		after, hasAfter := current.Files[p]
+
+		change := FileChange{Path: p}
+		oldName, newName := "a"+p, "b"+p
+		switch {
+		case !hadBefore:
+			change.Status = StatusAdded
+			oldName = "/dev/null"
+		case !hasAfter:
+			change.Status = StatusDeleted
+			newName = "/dev/null"
+		case before.SHA256 != after.SHA256 || before.Mode != after.Mode:
+			change.Status = StatusModified
+		default:
+			continue
+		}
+
+		if before.Binary || after.Binary {
+			change.Binary = true
+			fmt.Fprintf(&unified, "Binary files %s and %s differ\n", oldName, newName)
+		} else {
+			change.Diff = UnifiedDiff(oldName, newName, before.Content, after.Content)
+			switch {
+			case change.Diff != "":
+			case change.Status == StatusModified:
+				fmt.Fprintf(&unified, "Mode of %s changed from %04o to %04o\n", p, before.Mode, after.Mode)
+			default:
+				fmt.Fprintf(&unified, "Empty file %s %s\n", p, change.Status)
+			}
+			unified.WriteString(change.Diff)
+		}
+		result.Changes = append(result.Changes, change)
+	}
+
+	result.Unified = unified.String()
+	return result
+}
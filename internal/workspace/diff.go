@@ -0,0 +1,219 @@
+package workspace
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change
+const contextLines = 3
+
+// maxEdits bounds the Myers search. Files that differ by more than this many
+// line edits are shown as a full replacement instead.
+const maxEdits = 1000
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+// edit is one line of an edit script. a and b are the 0-based positions in
+// the old and new files before the line is applied.
+type edit struct {
+	kind opKind
+	a, b int
+	text string
+}
+
+// UnifiedDiff returns a unified diff turning oldText into newText, or an
+// empty string if they're identical. oldName and newName go in the ---/+++
+// headers; use /dev/null for a side that doesn't exist.
+func UnifiedDiff(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	edits := diffLines(splitLines(oldText), splitLines(newText))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	for _, h := range hunks(edits) {
+		writeHunk(&sb, edits[h[0]:h[1]])
+	}
+	return sb.String()
+}
+
+// splitLines splits text into lines without their trailing newlines
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script with Myers' algorithm
+func diffLines(a, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	// v[k] holds the furthest x reached on diagonal k; trace[d] keeps the
+	// slice of v for diagonals -d..d at the start of round d for backtracking
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		if d > maxEdits {
+			return replaceAll(a, b)
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	return replaceAll(a, b)
+}
+
+// backtrack walks the saved rounds from the end of both files to the start,
+// emitting the edit script in reverse
+func backtrack(trace [][]int, a, b []string) []edit {
+	x, y := len(a), len(b)
+	var rev []edit
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d] // index k+d holds diagonal k
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[k-1+d] < v[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = v[prevK+d]
+		}
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			rev = append(rev, edit{kind: opEqual, a: x, b: y, text: a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				rev = append(rev, edit{kind: opInsert, a: x, b: y, text: b[y]})
+			} else {
+				x--
+				rev = append(rev, edit{kind: opDelete, a: x, b: y, text: a[x]})
+			}
+		}
+	}
+
+	edits := make([]edit, len(rev))
+	for i := range rev {
+		edits[i] = rev[len(rev)-1-i]
+	}
+	return edits
+}
+
+// replaceAll is the fallback edit script: delete every old line, insert every new one
+func replaceAll(a, b []string) []edit {
+	edits := make([]edit, 0, len(a)+len(b))
+	for i, line := range a {
+		edits = append(edits, edit{kind: opDelete, a: i, b: 0, text: line})
+	}
+	for i, line := range b {
+		edits = append(edits, edit{kind: opInsert, a: len(a), b: i, text: line})
+	}
+	return edits
+}
+
+// hunks groups changes that are within 2*contextLines of each other and
+// returns [start, end) ranges into edits, including surrounding context
+func hunks(edits []edit) [][2]int {
+	var ranges [][2]int
+	for i, e := range edits {
+		if e.kind == opEqual {
+			continue
+		}
+		start := i - contextLines
+		if start < 0 {
+			start = 0
+		}
+		end := i + contextLines + 1
+		if end > len(edits) {
+			end = len(edits)
+		}
+		if n := len(ranges); n > 0 && start <= ranges[n-1][1] {
+			ranges[n-1][1] = end
+		} else {
+			ranges = append(ranges, [2]int{start, end})
+		}
+	}
+	return ranges
+}
+
+// writeHunk writes one @@ hunk
+func writeHunk(sb *strings.Builder, edits []edit) {
+	oldCount, newCount := 0, 0
+	for _, e := range edits {
+		if e.kind != opInsert {
+			oldCount++
+		}
+		if e.kind != opDelete {
+			newCount++
+		}
+	}
+
+	// Empty ranges point at the line before, per the unified format
+	oldStart, newStart := edits[0].a, edits[0].b
+	if oldCount > 0 {
+		oldStart++
+	}
+	if newCount > 0 {
+		newStart++
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+	for _, e := range edits {
+		sb.WriteByte(byte(e.kind))
+		sb.WriteString(e.text)
+		sb.WriteByte('\n')
+	}
+}
+
+// hunkRange formats a start,count pair, omitting a count of one
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,92 @@
+package workspace
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiffIdentical(t *testing.T) {
+	if diff := UnifiedDiff("a/x", "b/x", "same\n", "same\n"); diff != "" {
+		t.Errorf("expected no diff, got %q", diff)
+	}
+}
+
+func TestUnifiedDiffModifiedLine(t *testing.T) {
+	oldText := "apiVersion: v1\nkind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n  - name: web\n    image: nginx\n"
+	newText := "apiVersion: v1\nkind: Pod\nmetadata:\n  name: web\nspec:\n  containers:\n  - name: web\n    image: nginx:1.27\n    securityContext:\n      runAsNonRoot: true\n"
+
+	expected := `--- a/pod.yaml
++++ b/pod.yaml
+@@ -5,4 +5,6 @@
+ spec:
+   containers:
+   - name: web
+-    image: nginx
++    image: nginx:1.27
++    securityContext:
++      runAsNonRoot: true
+`
+	if diff := UnifiedDiff("a/pod.yaml", "b/pod.yaml", oldText, newText); diff != expected {
+		t.Errorf("unexpected diff:\n%s\nwant:\n%s", diff, expected)
+	}
+}
+
+func TestUnifiedDiffSeparateHunks(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 1; i <= 20; i++ {
+		line := "line" + strings.Repeat("x", i)
+		oldLines = append(oldLines, line)
+		switch i {
+		case 2:
+			newLines = append(newLines, "changed-top")
+		case 18:
+			// deleted
+		default:
+			newLines = append(newLines, line)
+		}
+	}
+
+	diff := UnifiedDiff("a/f", "b/f", strings.Join(oldLines, "\n")+"\n", strings.Join(newLines, "\n")+"\n")
+	if got := strings.Count(diff, "@@ -"); got != 2 {
+		t.Fatalf("expected 2 hunks, got %d:\n%s", got, diff)
+	}
+	if !strings.Contains(diff, "@@ -1,5 +1,5 @@") || !strings.Contains(diff, "@@ -15,6 +15,5 @@") {
+		t.Errorf("unexpected hunk headers:\n%s", diff)
+	}
+}
+
+func TestUnifiedDiffNewAndDeletedFiles(t *testing.T) {
+	added := UnifiedDiff("/dev/null", "b/new.yaml", "", "one\ntwo\n")
+	if !strings.Contains(added, "@@ -0,0 +1,2 @@\n+one\n+two\n") {
+		t.Errorf("unexpected diff for added file:\n%s", added)
+	}
+
+	deleted := UnifiedDiff("a/old.yaml", "/dev/null", "gone\n", "")
+	if !strings.Contains(deleted, "@@ -1 +0,0 @@\n-gone\n") {
+		t.Errorf("unexpected diff for deleted file:\n%s", deleted)
+	}
+}
+
+func TestDiffLinesRoundTrip(t *testing.T) {
+	cases := [][2]string{
+		{"a b c a b b a", "c b a b a c"},
+		{"", "x y"},
+		{"x y", ""},
+		{"same", "same"},
+	}
+	for _, c := range cases {
+		a, b := strings.Fields(c[0]), strings.Fields(c[1])
+		var gotA, gotB []string
+		for _, e := range diffLines(a, b) {
+			if e.kind != opInsert {
+				gotA = append(gotA, e.text)
+			}
+			if e.kind != opDelete {
+				gotB = append(gotB, e.text)
+			}
+		}
+		if strings.Join(gotA, " ") != c[0] || strings.Join(gotB, " ") != c[1] {
+			t.Errorf("edit script for %q -> %q doesn't reproduce inputs: %v / %v", c[0], c[1], gotA, gotB)
+		}
+	}
+}
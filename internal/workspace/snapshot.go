@@ -0,0 +1,154 @@
+// Package workspace tracks the files users edit inside exercise nodes, from
+// code-server or from vim in a terminal. A baseline snapshot of the watched
+// paths is taken right after exercise setup, and later snapshots are diffed
+// against it to show what the user changed.
+package workspace
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// WatchedPaths are the directories snapshotted on every node
+var WatchedPaths = []string{"/etc/kubernetes", "/root", "/etc/falco"}
+
+// excludedPaths are skipped inside WatchedPaths: tool state, caches and key
+// material that change on their own or shouldn't end up in attempt history
+var excludedPaths = []string{
+	"/etc/kubernetes/pki",
+	"/root/.cache",
+	"/root/.local",
+	"/root/.config/code-server",
+	"/root/.kube/cache",
+	"/root/.ssh",
+	"/root/.bash_history",
+	"/root/.viminfo",
+	"/root/.lesshst",
+}
+
+// MaxFileSize is the largest file whose content is kept for diffing. Larger
+// files are tracked by hash only.
+const MaxFileSize = 256 * 1024
+
+// maxFiles bounds how many files one node snapshot may hold
+const maxFiles = 5000
+
+// File is one regular file in a snapshot
+type File struct {
+	Mode    int64  `json:"mode"`
+	Size    int64  `json:"size"`
+	SHA256  string `json:"sha256"`
+	Content string `json:"content,omitempty"` // Only for text files up to MaxFileSize
+	Binary  bool   `json:"binary,omitempty"`  // Content omitted: binary or too large
+}
+
+// Snapshot is the state of the watched paths on one node
+type Snapshot struct {
+	Node    string          `json:"node"`
+	TakenAt time.Time       `json:"takenAt"`
+	Files   map[string]File `json:"files"` // Keyed by absolute path
+}
+
+// snapshotScript tars whichever watched paths exist. tar is given relative
+// paths so it doesn't warn about stripping the leading slash.
+func snapshotScript() string {
+	var includes, excludes []string
+	for _, p := range WatchedPaths {
+		includes = append(includes, shellQuote(strings.TrimPrefix(p, "/")))
+	}
+	for _, p := range excludedPaths {
+		excludes = append(excludes, "--exclude="+shellQuote(strings.TrimPrefix(p, "/")))
+	}
+	return fmt.Sprintf(`cd / || exit 1
+set --
+for p in %s; do [ -e "$p" ] && set -- "$@" "$p"; done
+[ $# -eq 0 ] && exit 0
+exec tar -cf - --ignore-failed-read %s "$@" 2>/dev/null`,
+		strings.Join(includes, " "), strings.Join(excludes, " "))
+}
+
+// TakeSnapshot reads the watched paths from a KIND node container
+func TakeSnapshot(ctx context.Context, nodeName string) (*Snapshot, error) {
+	cmd := exec.CommandContext(ctx, "docker", "exec", nodeName, "sh", "-c", snapshotScript())
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		// tar exits 1 when files change while being read; the archive is still usable
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+			return nil, fmt.Errorf("failed to read workspace from %s: %w (%s)", nodeName, err, strings.TrimSpace(stderr.String()))
+		}
+	}
+	return readSnapshot(nodeName, bytes.NewReader(output))
+}
+
+// readSnapshot builds a snapshot from a tar stream of the watched paths
+func readSnapshot(nodeName string, r io.Reader) (*Snapshot, error) {
+	snap := &Snapshot{
+		Node:    nodeName,
+		TakenAt: time.Now().UTC(),
+		Files:   make(map[string]File),
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse workspace archive from %s: %w", nodeName, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if len(snap.Files) >= maxFiles {
+			return nil, fmt.Errorf("workspace on %s has more than %d files", nodeName, maxFiles)
+		}
+
+		hash := sha256.New()
+		var content bytes.Buffer
+		body := io.TeeReader(tr, hash)
+		if hdr.Size <= MaxFileSize {
+			body = io.TeeReader(body, &content)
+		}
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			return nil, fmt.Errorf("failed to read %s from %s: %w", hdr.Name, nodeName, err)
+		}
+
+		file := File{
+			Mode:   hdr.Mode & 0o7777,
+			Size:   hdr.Size,
+			SHA256: hex.EncodeToString(hash.Sum(nil)),
+		}
+		if hdr.Size <= MaxFileSize && isText(content.Bytes()) {
+			file.Content = content.String()
+		} else {
+			file.Binary = true
+		}
+		snap.Files[path.Clean("/"+hdr.Name)] = file
+	}
+	return snap, nil
+}
+
+// isText reports whether data looks like editable text
+func isText(data []byte) bool {
+	return utf8.Valid(data) && !bytes.Contains(data, []byte{0})
+}
+
+// shellQuote wraps s in single quotes for sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
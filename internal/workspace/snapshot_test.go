@@ -0,0 +1,86 @@
+package workspace
+
+import (
+	"archive/tar"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func buildArchive(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "root/", Typeflag: tar.TypeDir, Mode: 0o700})
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	return &buf
+}
+
+func TestReadSnapshot(t *testing.T) {
+	archive := buildArchive(t, map[string]string{
+		"root/pod.yaml":                "kind: Pod\n",
+		"etc/kubernetes/kubelet.bin":   "\x00\x01\x02",
+		"etc/falco/falco_rules.yaml":   "- rule: shell\n",
+		"etc/kubernetes/big-file.yaml": strings.Repeat("a", MaxFileSize+1),
+	})
+
+	snap, err := readSnapshot("node1", archive)
+	if err != nil {
+		t.Fatalf("readSnapshot failed: %v", err)
+	}
+	if len(snap.Files) != 4 {
+		t.Fatalf("expected 4 files, got %d", len(snap.Files))
+	}
+	if f := snap.Files["/root/pod.yaml"]; f.Content != "kind: Pod\n" || f.Binary || f.Mode != 0o644 {
+		t.Errorf("unexpected text file entry: %+v", f)
+	}
+	if f := snap.Files["/etc/kubernetes/kubelet.bin"]; !f.Binary || f.Content != "" {
+		t.Errorf("expected binary file without content: %+v", f)
+	}
+	if f := snap.Files["/etc/kubernetes/big-file.yaml"]; !f.Binary || f.Size != MaxFileSize+1 || f.SHA256 == "" {
+		t.Errorf("expected oversized file tracked by hash only: %+v", f)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline, _ := readSnapshot("node1", buildArchive(t, map[string]string{
+		"root/pod.yaml":       "kind: Pod\nspec: {}\n",
+		"root/old.yaml":       "kind: Secret\n",
+		"root/unchanged.yaml": "kind: ConfigMap\n",
+	}))
+	current, _ := readSnapshot("node1", buildArchive(t, map[string]string{
+		"root/pod.yaml":       "kind: Pod\nspec:\n  hostPID: false\n",
+		"root/new.yaml":       "kind: NetworkPolicy\n",
+		"root/unchanged.yaml": "kind: ConfigMap\n",
+	}))
+
+	diff := Compare(baseline, current)
+	if diff.Node != "node1" {
+		t.Errorf("unexpected node %q", diff.Node)
+	}
+
+	var got []string
+	for _, c := range diff.Changes {
+		got = append(got, c.Status+" "+c.Path)
+	}
+	expected := "added /root/new.yaml,deleted /root/old.yaml,modified /root/pod.yaml"
+	if strings.Join(got, ",") != expected {
+		t.Fatalf("unexpected changes: %v", got)
+	}
+
+	for _, want := range []string{
+		"--- /dev/null\n+++ b/root/new.yaml\n",
+		"--- a/root/old.yaml\n+++ /dev/null\n",
+		"-spec: {}\n+spec:\n+  hostPID: false\n",
+	} {
+		if !strings.Contains(diff.Unified, want) {
+			t.Errorf("unified diff missing %q:\n%s", want, diff.Unified)
+		}
+	}
+}
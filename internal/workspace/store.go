@@ -0,0 +1,110 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/patrickvassell/cks-weight-room/internal/logger"
+)
+
+// GetBaselineDir returns the directory holding baseline snapshots for a cluster
+func GetBaselineDir(clusterName string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", "data", "workspaces", clusterName)
+	}
+	return filepath.Join(home, ".cks-weight-room", "workspaces", clusterName)
+}
+
+// baselinePath returns the file a node's baseline is stored in
+func baselinePath(clusterName, nodeName string) string {
+	return filepath.Join(GetBaselineDir(clusterName), nodeName+".json")
+}
+
+// CaptureBaseline snapshots every node and stores the result as the
+// cluster's baseline, replacing any earlier one
+func CaptureBaseline(ctx context.Context, clusterName string, nodeNames []string) error {
+	dir := GetBaselineDir(clusterName)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear old baseline: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create baseline directory: %w", err)
+	}
+
+	for _, node := range nodeNames {
+		snap, err := TakeSnapshot(ctx, node)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(snap)
+		if err != nil {
+			return fmt.Errorf("failed to encode baseline for %s: %w", node, err)
+		}
+		if err := os.WriteFile(baselinePath(clusterName, node), data, 0600); err != nil {
+			return fmt.Errorf("failed to write baseline for %s: %w", node, err)
+		}
+		logger.Info("Captured workspace baseline for %s (%d files)", node, len(snap.Files))
+	}
+	return nil
+}
+
+// LoadBaseline reads a node's stored baseline. It returns os.ErrNotExist
+// (wrapped) when no baseline was captured.
+func LoadBaseline(clusterName, nodeName string) (*Snapshot, error) {
+	data, err := os.ReadFile(baselinePath(clusterName, nodeName))
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("failed to decode baseline for %s: %w", nodeName, err)
+	}
+	return &snap, nil
+}
+
+// HasBaseline reports whether a baseline was captured for the cluster
+func HasBaseline(clusterName string) bool {
+	_, err := os.Stat(GetBaselineDir(clusterName))
+	return err == nil
+}
+
+// DiffCluster snapshots every node and compares it with the stored baseline.
+// Nodes are read in parallel; a node that can't be read gets an Error
+// instead of failing the whole diff.
+func DiffCluster(ctx context.Context, clusterName string, nodeNames []string) []NodeDiff {
+	diffs := make([]NodeDiff, len(nodeNames))
+
+	var wg sync.WaitGroup
+	for i, node := range nodeNames {
+		wg.Add(1)
+		go func(i int, node string) {
+			defer wg.Done()
+			diffs[i] = diffNode(ctx, clusterName, node)
+		}(i, node)
+	}
+	wg.Wait()
+	return diffs
+}
+
+// diffNode compares one node with its baseline
+func diffNode(ctx context.Context, clusterName, nodeName string) NodeDiff {
+	baseline, err := LoadBaseline(clusterName, nodeName)
+	if err != nil {
+		return NodeDiff{Node: nodeName, Changes: []FileChange{}, Error: fmt.Sprintf("no baseline: %v", err)}
+	}
+	current, err := TakeSnapshot(ctx, nodeName)
+	if err != nil {
+		return NodeDiff{Node: nodeName, Changes: []FileChange{}, Error: err.Error()}
+	}
+	return Compare(baseline, current)
+}
+
+// RemoveBaseline deletes the stored baselines for a cluster
+func RemoveBaseline(clusterName string) error {
+	return os.RemoveAll(GetBaselineDir(clusterName))
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"embed"
 	"flag"
 	"fmt"
@@ -8,10 +9,15 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
+	"syscall"
+	"time"
 
 	"github.com/patrickvassell/cks-weight-room/internal/api"
+	"github.com/patrickvassell/cks-weight-room/internal/auth"
 	"github.com/patrickvassell/cks-weight-room/internal/database"
+	"github.com/patrickvassell/cks-weight-room/internal/lifecycle"
 	"github.com/patrickvassell/cks-weight-room/internal/logger"
 )
 
@@ -21,10 +27,20 @@ var version = "dev"
 //go:embed all:web/out
 var webFS embed.FS
 
+// shutdownCleanupTimeout bounds how long lifecycle hooks get to tear down
+// sessions and containers on exit
+const shutdownCleanupTimeout = 30 * time.Second
+
 func main() {
 	// Command line flags
 	versionFlag := flag.Bool("version", false, "Display version information")
 	portFlag := flag.String("port", "3000", "Server port (default: 3000)")
+	drainFlag := flag.Duration("shutdown-timeout", 10*time.Second, "How long to wait for in-flight requests on shutdown")
+	importFlag := flag.String("import", "", "Import progress from an export file and exit")
+	importModeFlag := flag.String("import-mode", api.ImportModeMerge, "How -import treats existing progress: merge or replace")
+	importProfileFlag := flag.String("import-profile", "", "Profile -import writes to (default: the active profile)")
+	hubAddrFlag := flag.String("hub-addr", "", "Also run the team hub on this address, e.g. :3100 to serve the LAN (default: off)")
+	hubSecretFlag := flag.String("hub-secret", os.Getenv("CKS_TEAM_SECRET"), "Team secret the hub accepts (default: $CKS_TEAM_SECRET, or the stored or a generated one)")
 	flag.Parse()
 
 	// Handle --version flag
@@ -70,9 +86,38 @@ func main() {
 		logger.Info("Database not yet initialized (will be created on first setup)")
 	}
 
+	// Handle --import flag
+	if *importFlag != "" {
+		// os.Exit skips the deferred logger.Close, so close it first
+		code := runImport(*importFlag, *importModeFlag, *importProfileFlag)
+		logger.Close()
+		os.Exit(code)
+	}
+
+	// Resume exams and timers that were running when the app last stopped
+	api.StartTimers()
+
+	// Generate the per-launch session token that guards every API and
+	// WebSocket route; local tooling reads it from the token file
+	guard, err := auth.NewGuard(*portFlag)
+	if err != nil {
+		log.Fatalf("Failed to initialize session guard: %v", err)
+	}
+	// log.Fatalf skips deferred calls, so later fatal exits call removeToken
+	tokenPath := auth.GetTokenPath()
+	removeToken := func() {}
+	if err := guard.WriteTokenFile(tokenPath); err != nil {
+		logger.Warn("Failed to write session token file: %v", err)
+	} else {
+		logger.Debug("Session token written to %s", tokenPath)
+		removeToken = func() { os.Remove(tokenPath) }
+		defer removeToken()
+	}
+
 	// Serve embedded frontend
 	staticFS, err := fs.Sub(webFS, "web/out")
 	if err != nil {
+		removeToken()
 		log.Fatalf("Failed to load embedded frontend: %v", err)
 	}
 
@@ -82,8 +127,11 @@ func main() {
 	http.HandleFunc("/api/setup/initialize", api.InitializeDatabase)
 	http.HandleFunc("/api/setup/db-status", api.GetDatabaseStatus)
 	http.HandleFunc("/api/exercises", api.GetExercises)
+	http.HandleFunc("/api/exercises/search", api.SearchExercises)
 	http.HandleFunc("/api/exercises/", api.GetExerciseBySlug)
 	http.HandleFunc("/api/admin/seed", api.SeedExercises)
+	http.HandleFunc("/api/admin/terminals", api.ListTerminalSessions)
+	http.HandleFunc("/api/admin/terminals/", api.CloseTerminalSession)
 
 	// Cluster management routes
 	http.HandleFunc("/api/cluster/provision", api.ProvisionCluster)
@@ -91,6 +139,9 @@ func main() {
 	http.HandleFunc("/api/cluster/nodes/", api.GetClusterNodes)
 	http.HandleFunc("/api/cluster/", api.DeleteCluster)
 
+	// Exam realism routes
+	http.HandleFunc("/api/realism/network/", api.HandleNetworkRestriction)
+
 	// Terminal WebSocket route - use secure mode if enabled
 	if os.Getenv("SECURE_TERMINAL") == "true" {
 		logger.Info("Secure terminal mode enabled")
@@ -114,21 +165,68 @@ func main() {
 	http.HandleFunc("/api/ide/", ideHandler.HandleIDEProxy)
 	logger.Info("IDE (code-server) proxy enabled")
 
+	// Tear down IDE sessions, terminals and helper containers when a cluster
+	// is deleted or reset, and when the server exits
+	api.RegisterLifecycleHooks(ideHandler)
+
+	// Hint routes (one at a time, logged against the current attempt)
+	http.HandleFunc("/api/hints/", api.HandleHints)
+
 	// Validation route
 	http.HandleFunc("/api/validate/", api.ValidateSolution)
 
+	// Workspace diff route (changes on nodes since exercise setup)
+	http.HandleFunc("/api/workspace/", api.HandleWorkspaceDiff)
+
+	// Mock exam routes
+	http.HandleFunc("/api/exams", api.HandleExams)
+	http.HandleFunc("/api/exams/", api.HandleExams)
+
+	// Timer routes (server-side exam and exercise countdowns)
+	http.HandleFunc("/api/timers", api.HandleTimers)
+	http.HandleFunc("/api/timers/", api.HandleTimers)
+
+	// Practice scheduling routes (spaced repetition over attempt history)
+	http.HandleFunc("/api/practice/next", api.GetPracticeQueue)
+	http.HandleFunc("/api/practice/plan", api.GetPracticePlan)
+
 	// Progress statistics route
 	http.HandleFunc("/api/progress/stats", api.GetProgressStats)
 
 	// Analytics route
 	http.HandleFunc("/api/analytics", api.GetAnalytics)
 
+	// Curriculum registry routes (domains, weights and sub-competencies)
+	http.HandleFunc("/api/curriculum", api.HandleCurriculum)
+	http.HandleFunc("/api/curriculum/", api.HandleCurriculum)
+
+	// Exam readiness route (pass probability from attempts and mock exams)
+	http.HandleFunc("/api/readiness", api.GetReadiness)
+
 	// Export route
 	http.HandleFunc("/api/export", api.GetExportData)
 
+	// Import route (export files, merged or replacing existing progress)
+	http.HandleFunc("/api/import", api.ImportProgressData)
+
+	// Learner profile routes (select, switch, add and delete profiles)
+	http.HandleFunc("/api/profiles", api.HandleProfiles)
+	http.HandleFunc("/api/profiles/", api.HandleProfiles)
+
+	// Team mode routes (hub settings, sync, leaderboard and assignments)
+	http.HandleFunc("/api/team", api.HandleTeam)
+	http.HandleFunc("/api/team/", api.HandleTeam)
+
+	// Note and bookmark routes (Markdown notes, note search, tagged bookmarks)
+	http.HandleFunc("/api/notes", api.HandleNotes)
+	http.HandleFunc("/api/notes/", api.HandleNotes)
+	http.HandleFunc("/api/bookmarks", api.HandleBookmarks)
+	http.HandleFunc("/api/bookmarks/", api.HandleBookmarks)
+
 	// Reset routes
 	http.HandleFunc("/api/reset/stats", api.GetResetStats)
 	http.HandleFunc("/api/reset", api.ResetProgress)
+	http.HandleFunc("/api/reset/", api.HandleResetUndo)
 
 	// Activation routes
 	http.HandleFunc("/api/activation/machine-id", api.GetMachineID)
@@ -157,9 +255,132 @@ func main() {
 	logger.Info("Starting HTTP server on %s", addr)
 	logger.Debug("Server bound to localhost only (NFR-S1)")
 
-	// Start server (localhost-only binding as per NFR-S1)
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	// Start server (localhost-only binding as per NFR-S1), with every request
+	// passing through the session guard
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           guard.Wrap(http.DefaultServeMux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// The team hub listens on its own address, outside the session guard;
+	// members authenticate by signing their requests with the team secret
+	var hubSrv *http.Server
+	if *hubAddrFlag != "" {
+		var generated string
+		hubSrv, generated, err = api.StartTeamHub(*hubAddrFlag, *hubSecretFlag)
+		if err != nil {
+			removeToken()
+			log.Fatalf("Failed to start team hub: %v", err)
+		}
+		logger.Info("Team hub listening on %s", hubSrv.Addr)
+		fmt.Printf("Team hub listening on %s\n", hubSrv.Addr)
+		if generated != "" {
+			fmt.Printf("Generated team secret: %s (stored as config key team_hub_secret; share it with the team)\n", generated)
+		}
+	}
+
+	// Shut down gracefully on Ctrl+C / SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
 		logger.Error("Server failed: %v", err)
+		removeToken()
 		log.Fatalf("Server failed: %v", err)
+	case <-ctx.Done():
+		// Restore default signal handling so a second Ctrl+C exits immediately
+		stop()
+		fmt.Println("\nShutting down (press Ctrl+C again to force)...")
+		shutdown(*drainFlag, srv, hubSrv)
+	}
+}
+
+// runImport imports an export file into a profile, the active one when
+// profileSlug is empty, and returns the exit code
+func runImport(path, mode, profileSlug string) int {
+	if database.DB == nil {
+		fmt.Fprintln(os.Stderr, "No database to import into; start CKS Weight Room once to set it up")
+		return 1
+	}
+	defer database.Close()
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read %s: %v\n", path, err)
+		return 1
+	}
+	profile, err := database.GetActiveProfile()
+	if profileSlug != "" {
+		profile, err = database.GetProfile(profileSlug)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unknown profile %s: %v\n", profileSlug, err)
+		return 1
+	}
+	result, err := api.ImportProgress(body, mode, profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Import failed: %v\n", err)
+		return 1
+	}
+
+	logger.Info("Imported %s into %s (%s, schema v%d)", path, profile.Slug, mode, result.SchemaVersion)
+	fmt.Printf("Imported %s into %s (%s, schema v%d)\n", path, profile.Name, mode, result.SchemaVersion)
+	fmt.Printf("  Attempts:       %d imported, %d already present\n", result.AttemptsImported, result.AttemptsSkipped)
+	fmt.Printf("  Personal bests: %d improved\n", result.PersonalBestsImported)
+	fmt.Printf("  Mock exams:     %d imported, %d already present\n", result.MockExamsImported, result.MockExamsSkipped)
+	fmt.Printf("  Hint reveals:   %d imported\n", result.HintRevealsImported)
+	fmt.Printf("  Notes:          %d imported\n", result.NotesImported)
+	fmt.Printf("  Bookmarks:      %d imported\n", result.BookmarksImported)
+	for _, name := range result.UnknownExercises {
+		fmt.Printf("  Skipped records of unknown exercise %s\n", name)
+	}
+	return 0
+}
+
+// shutdown stops the servers and everything they started: in-flight
+// requests are drained, then lifecycle hooks close WebSockets with a close
+// frame, kill shell process groups, stop IDE sessions and remove helper
+// containers, and finally the database WAL is checkpointed. Nil servers
+// (the team hub when it isn't running) are skipped.
+func shutdown(drain time.Duration, servers ...*http.Server) {
+	logger.Info("Shutdown requested, draining requests for up to %s", drain)
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), drain)
+	for _, srv := range servers {
+		if srv == nil {
+			continue
+		}
+		if err := srv.Shutdown(drainCtx); err != nil {
+			logger.Warn("Requests still running after %s, closing connections: %v", drain, err)
+			srv.Close()
+		}
+	}
+	cancelDrain()
+
+	// WebSockets are hijacked connections that srv.Shutdown doesn't track;
+	// the lifecycle hooks tear them down
+	cleanupCtx, cancelCleanup := context.WithTimeout(context.Background(), shutdownCleanupTimeout)
+	if err := lifecycle.Emit(cleanupCtx, lifecycle.Event{Type: lifecycle.Shutdown}); err != nil {
+		logger.Warn("Shutdown cleanup incomplete: %v", err)
+	}
+	cancelCleanup()
+
+	if err := database.Checkpoint(); err != nil {
+		logger.Warn("Failed to checkpoint database: %v", err)
+	}
+	if err := database.Close(); err != nil {
+		logger.Warn("Failed to close database: %v", err)
+	}
+
+	logger.Info("Shutdown complete")
+	if err := logger.Sync(); err != nil {
+		log.Printf("Failed to flush log file: %v", err)
 	}
 }
@@ -12,11 +12,57 @@ interface AnalyticsData {
   personalBestsSet: number
   mockExamsTaken: number
   mockExamsPassed: number
+  curriculumVersion: string
   progressByDomain: DetailedDomain[]
   personalBests: PersonalBest[]
   practiceTimeBreakdown: PracticeTimeBreakdown
+  timeSeries: TimeSeries | null
 }
 
+interface TimeSeries {
+  timeZone: string
+  from: string
+  to: string
+  windowDays: number
+  days: DayBucket[]
+  weeks: DayBucket[]
+  streaks: Streaks
+  rollingScores: { domain: string; displayName: string; points: { date: string; averageScore: number; attempts: number }[] }[]
+  solveTimeTrends: SolveTimeTrend[]
+}
+
+interface DayBucket {
+  date: string
+  attempts: number
+  passed: number
+  practiceSeconds: number
+  averageScore: number
+  level?: number
+}
+
+interface Streaks {
+  current: number
+  longest: number
+  longestStart?: string
+  longestEnd?: string
+  lastPracticeDate?: string
+  practiceDays: number
+}
+
+interface SolveTimeTrend {
+  slug: string
+  title: string
+  domain: string
+  points: { date: string; at: string; seconds: number }[]
+  firstSeconds: number
+  lastSeconds: number
+  bestSeconds: number
+  changePercent: number
+  slopeSeconds: number
+}
+
+const heatmapColors = ['bg-gray-100', 'bg-green-200', 'bg-green-400', 'bg-green-600', 'bg-green-800']
+
 interface DetailedDomain {
   domain: string
   displayName: string
@@ -25,6 +71,14 @@ interface DetailedDomain {
   totalCount: number
   completionPercentage: number
   scenarios: ScenarioProgress[]
+  competencies: CompetencyProgress[]
+}
+
+interface CompetencyProgress {
+  slug: string
+  name: string
+  exerciseCount: number
+  completedCount: number
 }
 
 interface ScenarioProgress {
@@ -65,6 +119,12 @@ export default function AnalyticsPage() {
   const [sortBy, setSortBy] = useState<SortField>('time')
   const [showExportDialog, setShowExportDialog] = useState(false)
   const [exporting, setExporting] = useState(false)
+  const [exportFrom, setExportFrom] = useState('')
+  const [exportTo, setExportTo] = useState('')
+  const [exportDomain, setExportDomain] = useState('')
+  const [importMode, setImportMode] = useState<'merge' | 'replace'>('merge')
+  const [importing, setImporting] = useState(false)
+  const [importMessage, setImportMessage] = useState<string | null>(null)
   const [showResetDialog, setShowResetDialog] = useState(false)
   const [resetting, setResetting] = useState(false)
   const [resetConfirmation, setResetConfirmation] = useState('')
@@ -72,12 +132,17 @@ export default function AnalyticsPage() {
     attemptsCount: number
     personalBestsCount: number
     mockExamsCount: number
+    hintRevealsCount: number
+    exercisesCount: number
   } | null>(null)
+  const [resetScope, setResetScope] = useState<{ kind: 'all' | 'exercise' | 'domain' | 'before'; value: string }>({ kind: 'all', value: '' })
+  const [undoSnapshot, setUndoSnapshot] = useState<{ id: number; expiresAt: string; counts: { attemptsCount: number } } | null>(null)
 
   useEffect(() => {
     const fetchAnalytics = async () => {
       try {
-        const response = await fetch('/api/analytics')
+        const tz = Intl.DateTimeFormat().resolvedOptions().timeZone
+        const response = await fetch(`/api/analytics?tz=${encodeURIComponent(tz)}`)
         if (response.ok) {
           const analyticsData = await response.json()
           setData(analyticsData)
@@ -90,8 +155,21 @@ export default function AnalyticsPage() {
     }
 
     fetchAnalytics()
+    fetchUndoSnapshot()
   }, [])
 
+  const fetchUndoSnapshot = async () => {
+    try {
+      const response = await fetch('/api/reset/snapshots')
+      if (response.ok) {
+        const result = await response.json()
+        setUndoSnapshot(result.snapshots?.[0] ?? null)
+      }
+    } catch (error) {
+      console.error('Failed to fetch reset snapshots:', error)
+    }
+  }
+
   const formatTime = (seconds: number): string => {
     const hours = Math.floor(seconds / 3600)
     const minutes = Math.floor((seconds % 3600) / 60)
@@ -143,23 +221,37 @@ export default function AnalyticsPage() {
     }
   }) : []
 
-  const exportAsJSON = async () => {
+  const exportQuery = (format: string): string => {
+    const params = new URLSearchParams({ format, tz: Intl.DateTimeFormat().resolvedOptions().timeZone })
+    if (exportFrom) params.set('from', exportFrom)
+    if (exportTo) params.set('to', exportTo)
+    if (exportDomain) params.set('domain', exportDomain)
+    return params.toString()
+  }
+
+  const downloadExport = async (format: string) => {
     setExporting(true)
     try {
-      const response = await fetch('/api/export')
-      if (response.ok) {
-        const exportData = await response.json()
-        const blob = new Blob([JSON.stringify(exportData, null, 2)], { type: 'application/json' })
-        const url = URL.createObjectURL(blob)
-        const a = document.createElement('a')
-        a.href = url
-        a.download = `cks-weight-room-progress-${new Date().toISOString().split('T')[0]}.json`
-        document.body.appendChild(a)
-        a.click()
-        document.body.removeChild(a)
-        URL.revokeObjectURL(url)
-        setShowExportDialog(false)
+      const response = await fetch(`/api/export?${exportQuery(format)}`)
+      if (!response.ok) {
+        alert(await response.text())
+        return
+      }
+      let blob = await response.blob()
+      let filename = response.headers.get('Content-Disposition')?.match(/filename="(.+)"/)?.[1]
+      if (format === 'json') {
+        blob = new Blob([JSON.stringify(JSON.parse(await blob.text()), null, 2)], { type: 'application/json' })
+        filename = `cks-weight-room-progress-${new Date().toISOString().split('T')[0]}.json`
       }
+      const url = URL.createObjectURL(blob)
+      const a = document.createElement('a')
+      a.href = url
+      a.download = filename || `cks-weight-room-progress.${format}`
+      document.body.appendChild(a)
+      a.click()
+      document.body.removeChild(a)
+      URL.revokeObjectURL(url)
+      setShowExportDialog(false)
     } catch (error) {
       console.error('Export failed:', error)
       alert('Failed to export data')
@@ -168,67 +260,76 @@ export default function AnalyticsPage() {
     }
   }
 
-  const exportAsCSV = async () => {
-    setExporting(true)
+  const importFile = async (file: File) => {
+    if (importMode === 'replace' && !confirm('Replace all existing progress with the contents of this file?')) {
+      return
+    }
+    setImporting(true)
+    setImportMessage(null)
     try {
-      const response = await fetch('/api/export')
-      if (response.ok) {
-        const exportData = await response.json()
-
-        // Create CSV content
-        const headers = ['Attempt ID', 'Scenario ID', 'Scenario Name', 'Timestamp', 'Completion Time (MM:SS)', 'Score (%)', 'Status', 'Feedback']
-        const rows = exportData.attempts.map((attempt: any) => {
-          const minutes = Math.floor(attempt.completion_time_seconds / 60)
-          const seconds = attempt.completion_time_seconds % 60
-          const time = `${minutes}:${seconds.toString().padStart(2, '0')}`
-          const score = Math.round(attempt.score * 100)
-          return [
-            attempt.attempt_id,
-            attempt.scenario_id,
-            `"${attempt.scenario_name}"`,
-            attempt.timestamp,
-            time,
-            score,
-            attempt.status,
-            `"${attempt.feedback || ''}"`
-          ].join(',')
-        })
-
-        const csv = [headers.join(','), ...rows].join('\n')
-        const blob = new Blob([csv], { type: 'text/csv' })
-        const url = URL.createObjectURL(blob)
-        const a = document.createElement('a')
-        a.href = url
-        a.download = `cks-weight-room-progress-${new Date().toISOString().split('T')[0]}.csv`
-        document.body.appendChild(a)
-        a.click()
-        document.body.removeChild(a)
-        URL.revokeObjectURL(url)
-        setShowExportDialog(false)
+      const response = await fetch(`/api/import?mode=${importMode}`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: await file.text(),
+      })
+      const result = await response.json()
+      if (!response.ok || !result.success) {
+        setImportMessage(result.error || 'Import failed')
+        return
+      }
+      let message = `Imported ${result.attemptsImported} attempts and ${result.mockExamsImported} mock exams`
+      if (result.attemptsSkipped > 0) {
+        message += ` (${result.attemptsSkipped} attempts were already here)`
+      }
+      if (result.unknownExercises?.length) {
+        message += `. Skipped unknown exercises: ${result.unknownExercises.join(', ')}`
+      }
+      setImportMessage(message)
+      const refreshed = await fetch('/api/analytics')
+      if (refreshed.ok) {
+        setData(await refreshed.json())
       }
     } catch (error) {
-      console.error('Export failed:', error)
-      alert('Failed to export data')
+      console.error('Import failed:', error)
+      setImportMessage('Failed to import data')
     } finally {
-      setExporting(false)
+      setImporting(false)
+    }
+  }
+
+  const resetScopeBody = (scope = resetScope) => {
+    if (scope.kind === 'all' || !scope.value) return {}
+    if (scope.kind === 'before') {
+      return { before: scope.value, tz: Intl.DateTimeFormat().resolvedOptions().timeZone }
+    }
+    return { [scope.kind]: scope.value }
+  }
+
+  const fetchResetStats = async (scope = resetScope) => {
+    const params = new URLSearchParams(resetScopeBody(scope) as Record<string, string>)
+    const response = await fetch(`/api/reset/stats?${params}`)
+    if (response.ok) {
+      setResetStats(await response.json())
     }
   }
 
   const handleResetClick = async () => {
     try {
-      const response = await fetch('/api/reset/stats')
-      if (response.ok) {
-        const stats = await response.json()
-        setResetStats(stats)
-        setShowResetDialog(true)
-        setResetConfirmation('')
-      }
+      setResetScope({ kind: 'all', value: '' })
+      await fetchResetStats({ kind: 'all', value: '' })
+      setShowResetDialog(true)
+      setResetConfirmation('')
     } catch (error) {
       console.error('Failed to fetch reset stats:', error)
       alert('Failed to load reset statistics')
     }
   }
 
+  const changeResetScope = (scope: typeof resetScope) => {
+    setResetScope(scope)
+    fetchResetStats(scope).catch((error) => console.error('Failed to fetch reset stats:', error))
+  }
+
   const handleResetConfirm = async () => {
     if (resetConfirmation !== 'DELETE') {
       return
@@ -241,20 +342,24 @@ export default function AnalyticsPage() {
         headers: {
           'Content-Type': 'application/json',
         },
-        body: JSON.stringify({ confirmation: resetConfirmation }),
+        body: JSON.stringify({ confirmation: resetConfirmation, scope: resetScopeBody() }),
       })
 
-      if (response.ok) {
-        const result = await response.json()
-        if (result.success) {
-          setShowResetDialog(false)
-          alert(result.message)
+      const result = await response.json().catch(() => null)
+      if (response.ok && result?.success) {
+        setShowResetDialog(false)
+        if (resetScope.kind === 'all') {
+          alert(`${result.message} You can undo this from the analytics page for 24 hours.`)
           router.push('/exercises')
-        } else {
-          alert(result.message)
+          return
+        }
+        await fetchUndoSnapshot()
+        const refreshed = await fetch('/api/analytics')
+        if (refreshed.ok) {
+          setData(await refreshed.json())
         }
       } else {
-        alert('Failed to reset progress')
+        alert(result?.message || 'Failed to reset progress')
       }
     } catch (error) {
       console.error('Reset failed:', error)
@@ -264,6 +369,25 @@ export default function AnalyticsPage() {
     }
   }
 
+  const handleUndoReset = async () => {
+    if (!undoSnapshot) return
+    try {
+      const response = await fetch(`/api/reset/undo/${undoSnapshot.id}`, { method: 'POST' })
+      const result = await response.json().catch(() => null)
+      if (!response.ok || !result?.success) {
+        alert(result?.message || 'Failed to undo the reset')
+      }
+      await fetchUndoSnapshot()
+      const refreshed = await fetch('/api/analytics')
+      if (refreshed.ok) {
+        setData(await refreshed.json())
+      }
+    } catch (error) {
+      console.error('Undo failed:', error)
+      alert('Failed to undo the reset')
+    }
+  }
+
   if (loading) {
     return (
       <div className="min-h-screen bg-gray-50 flex items-center justify-center">
@@ -357,6 +481,63 @@ export default function AnalyticsPage() {
           </div>
         </div>
 
+        {/* Streaks and Heatmap */}
+        {data.timeSeries && (
+          <div className="bg-white rounded-lg shadow p-6 mb-8">
+            <div className="flex items-center justify-between mb-4">
+              <h2 className="text-xl font-bold text-gray-900">Practice Activity</h2>
+              <span className="text-xs text-gray-500">
+                {data.timeSeries.from} – {data.timeSeries.to} ({data.timeSeries.timeZone})
+              </span>
+            </div>
+            <div className="grid grid-cols-3 gap-4 mb-6">
+              <div>
+                <div className="text-sm text-gray-600">Current Streak</div>
+                <div className="text-lg font-semibold text-gray-900">{data.timeSeries.streaks.current} days</div>
+              </div>
+              <div>
+                <div className="text-sm text-gray-600">Longest Streak</div>
+                <div className="text-lg font-semibold text-gray-900">{data.timeSeries.streaks.longest} days</div>
+                {data.timeSeries.streaks.longestStart && (
+                  <div className="text-xs text-gray-500">
+                    {data.timeSeries.streaks.longestStart} – {data.timeSeries.streaks.longestEnd}
+                  </div>
+                )}
+              </div>
+              <div>
+                <div className="text-sm text-gray-600">Days Practiced</div>
+                <div className="text-lg font-semibold text-gray-900">
+                  {data.timeSeries.streaks.practiceDays}/{data.timeSeries.days.length}
+                </div>
+              </div>
+            </div>
+            <div className="flex flex-wrap gap-1">
+              {data.timeSeries.days.map((day) => (
+                <div
+                  key={day.date}
+                  className={`w-3 h-3 rounded-sm ${heatmapColors[day.level ?? 0]}`}
+                  title={`${day.date}: ${day.attempts} attempts, ${formatTime(day.practiceSeconds)}`}
+                />
+              ))}
+            </div>
+            {data.timeSeries.solveTimeTrends.filter((t) => t.points.length > 1).length > 0 && (
+              <div className="mt-6">
+                <h3 className="text-sm font-semibold text-gray-700 mb-2">Solve Time Trends</h3>
+                <div className="space-y-1">
+                  {data.timeSeries.solveTimeTrends.filter((t) => t.points.length > 1).map((trend) => (
+                    <div key={trend.slug} className="flex justify-between text-sm">
+                      <span className="text-gray-700">{trend.title}</span>
+                      <span className={trend.changePercent <= 0 ? 'text-green-600' : 'text-red-600'}>
+                        {formatDuration(trend.firstSeconds)} → {formatDuration(trend.lastSeconds)} ({trend.changePercent > 0 ? '+' : ''}{Math.round(trend.changePercent)}%)
+                      </span>
+                    </div>
+                  ))}
+                </div>
+              </div>
+            )}
+          </div>
+        )}
+
         {/* Practice Time Breakdown */}
         <div className="bg-white rounded-lg shadow p-6 mb-8">
           <h2 className="text-xl font-bold text-gray-900 mb-4">Practice Time Breakdown</h2>
@@ -400,7 +581,10 @@ export default function AnalyticsPage() {
 
         {/* Progress by Domain */}
         <div className="bg-white rounded-lg shadow p-6 mb-8">
-          <h2 className="text-xl font-bold text-gray-900 mb-4">Progress by Domain</h2>
+          <div className="flex items-baseline justify-between mb-4">
+            <h2 className="text-xl font-bold text-gray-900">Progress by Domain</h2>
+            <span className="text-xs text-gray-500">CKS curriculum {data.curriculumVersion}</span>
+          </div>
           <div className="space-y-6">
             {data.progressByDomain.map((domain) => (
               <div key={domain.domain}>
@@ -424,6 +608,25 @@ export default function AnalyticsPage() {
                     style={{ width: `${domain.completionPercentage}%` }}
                   ></div>
                 </div>
+                {domain.competencies.length > 0 && (
+                  <div className="ml-4 mb-2 flex flex-wrap gap-2">
+                    {domain.competencies.map((competency) => (
+                      <span
+                        key={competency.slug}
+                        title={competency.name}
+                        className={`text-xs px-2 py-0.5 rounded ${
+                          competency.exerciseCount === 0
+                            ? 'bg-gray-100 text-gray-400'
+                            : competency.completedCount === competency.exerciseCount
+                              ? 'bg-green-100 text-green-700'
+                              : 'bg-blue-50 text-blue-700'
+                        }`}
+                      >
+                        {competency.name} ({competency.completedCount}/{competency.exerciseCount})
+                      </span>
+                    ))}
+                  </div>
+                )}
                 <div className="ml-4 space-y-1">
                   {domain.scenarios.map((scenario) => (
                     <div key={scenario.slug} className="flex items-center justify-between text-sm py-1">
@@ -538,13 +741,27 @@ export default function AnalyticsPage() {
         <div className="bg-red-50 border border-red-200 rounded-lg p-6 mt-8">
           <h2 className="text-xl font-bold text-red-900 mb-2">Danger Zone</h2>
           <p className="text-red-700 mb-4">
-            Permanently delete all your progress data. This action cannot be undone.
+            Delete all your progress data, or just one exercise, domain or date range. A reset can be undone for 24 hours.
           </p>
+          {undoSnapshot && (
+            <div className="bg-white border border-red-200 rounded p-3 mb-4 flex items-center justify-between">
+              <span className="text-sm text-gray-700">
+                The last reset deleted {undoSnapshot.counts.attemptsCount} attempts. It can be undone until{' '}
+                {new Date(undoSnapshot.expiresAt).toLocaleString()}.
+              </span>
+              <button
+                onClick={handleUndoReset}
+                className="ml-4 bg-gray-700 hover:bg-gray-800 text-white text-sm font-semibold py-1 px-4 rounded-lg transition-colors"
+              >
+                Undo
+              </button>
+            </div>
+          )}
           <button
             onClick={handleResetClick}
             className="bg-red-600 hover:bg-red-700 text-white font-semibold py-2 px-6 rounded-lg transition-colors"
           >
-            Reset All Progress
+            Reset Progress
           </button>
         </div>
 
@@ -552,19 +769,51 @@ export default function AnalyticsPage() {
         {showResetDialog && resetStats && (
           <div className="fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50">
             <div className="bg-white rounded-lg shadow-xl p-6 max-w-md w-full mx-4">
-              <h2 className="text-2xl font-bold text-red-600 mb-4">⚠️ Reset All Progress</h2>
+              <h2 className="text-2xl font-bold text-red-600 mb-4">⚠️ Reset Progress</h2>
+              <div className="grid grid-cols-2 gap-2 mb-4 text-sm">
+                <select
+                  value={resetScope.kind}
+                  onChange={(e) => changeResetScope({ kind: e.target.value as typeof resetScope.kind, value: '' })}
+                  disabled={resetting}
+                  className="border border-gray-300 rounded px-2 py-1"
+                >
+                  <option value="all">Everything</option>
+                  <option value="exercise">One exercise</option>
+                  <option value="domain">One domain</option>
+                  <option value="before">Attempts before</option>
+                </select>
+                {resetScope.kind === 'exercise' && (
+                  <select value={resetScope.value} onChange={(e) => changeResetScope({ ...resetScope, value: e.target.value })} className="border border-gray-300 rounded px-2 py-1">
+                    <option value="">Choose an exercise</option>
+                    {data.progressByDomain.flatMap((domain) => domain.scenarios).map((scenario) => (
+                      <option key={scenario.slug} value={scenario.slug}>{scenario.title}</option>
+                    ))}
+                  </select>
+                )}
+                {resetScope.kind === 'domain' && (
+                  <select value={resetScope.value} onChange={(e) => changeResetScope({ ...resetScope, value: e.target.value })} className="border border-gray-300 rounded px-2 py-1">
+                    <option value="">Choose a domain</option>
+                    {data.progressByDomain.map((domain) => (
+                      <option key={domain.domain} value={domain.domain}>{domain.displayName}</option>
+                    ))}
+                  </select>
+                )}
+                {resetScope.kind === 'before' && (
+                  <input type="date" value={resetScope.value} onChange={(e) => changeResetScope({ ...resetScope, value: e.target.value })} className="border border-gray-300 rounded px-2 py-1" />
+                )}
+              </div>
               <p className="text-gray-700 mb-4">
-                Are you sure you want to reset <strong>ALL</strong> progress data? This will permanently delete:
+                {resetScope.kind === 'all' ? <>Are you sure you want to reset <strong>ALL</strong> progress data? This will delete:</> : 'This will delete:'}
               </p>
               <ul className="text-gray-700 mb-4 space-y-2">
                 <li>• <strong>{resetStats.attemptsCount}</strong> scenario attempts</li>
                 <li>• <strong>{resetStats.personalBestsCount}</strong> personal best records</li>
                 <li>• <strong>{resetStats.mockExamsCount}</strong> mock exam results</li>
-                <li>• All progress statistics</li>
+                <li>• Progress of <strong>{resetStats.exercisesCount}</strong> exercises</li>
               </ul>
               <div className="bg-yellow-50 border border-yellow-200 rounded p-3 mb-4">
                 <p className="text-sm text-yellow-800">
-                  <strong>This action CANNOT be undone.</strong> Consider exporting your data first.
+                  <strong>A reset can be undone for 24 hours.</strong> Consider exporting your data first.
                 </p>
               </div>
               <div className="mb-4">
@@ -590,10 +839,10 @@ export default function AnalyticsPage() {
                 </button>
                 <button
                   onClick={handleResetConfirm}
-                  disabled={resetting || resetConfirmation !== 'DELETE'}
+                  disabled={resetting || resetConfirmation !== 'DELETE' || (resetScope.kind !== 'all' && !resetScope.value)}
                   className="flex-1 bg-red-600 hover:bg-red-700 disabled:bg-gray-400 text-white font-semibold py-2 px-4 rounded-lg transition-colors"
                 >
-                  {resetting ? 'Resetting...' : 'Reset All Progress'}
+                  {resetting ? 'Resetting...' : resetScope.kind === 'all' ? 'Reset All Progress' : 'Reset'}
                 </button>
               </div>
             </div>
@@ -619,28 +868,76 @@ export default function AnalyticsPage() {
                     <li>• Overall practice statistics</li>
                   </ul>
                 </div>
+                <div className="grid grid-cols-2 gap-3 text-sm">
+                  <label className="text-gray-700">
+                    From
+                    <input type="date" value={exportFrom} onChange={(e) => setExportFrom(e.target.value)} className="block w-full border border-gray-300 rounded px-2 py-1" />
+                  </label>
+                  <label className="text-gray-700">
+                    To
+                    <input type="date" value={exportTo} onChange={(e) => setExportTo(e.target.value)} className="block w-full border border-gray-300 rounded px-2 py-1" />
+                  </label>
+                  <label className="text-gray-700 col-span-2">
+                    Domain
+                    <select value={exportDomain} onChange={(e) => setExportDomain(e.target.value)} className="block w-full border border-gray-300 rounded px-2 py-1">
+                      <option value="">All domains</option>
+                      {data.progressByDomain.map((domain) => (
+                        <option key={domain.domain} value={domain.domain}>{domain.displayName}</option>
+                      ))}
+                    </select>
+                  </label>
+                </div>
               </div>
 
-              <div className="flex gap-3">
-                <button
-                  onClick={exportAsJSON}
-                  disabled={exporting}
-                  className="flex-1 bg-blue-600 hover:bg-blue-700 disabled:bg-gray-400 text-white font-semibold py-3 px-4 rounded-lg transition-colors"
-                >
-                  {exporting ? 'Exporting...' : 'Export as JSON'}
-                </button>
-                <button
-                  onClick={exportAsCSV}
-                  disabled={exporting}
-                  className="flex-1 bg-green-600 hover:bg-green-700 disabled:bg-gray-400 text-white font-semibold py-3 px-4 rounded-lg transition-colors"
-                >
-                  {exporting ? 'Exporting...' : 'Export as CSV'}
-                </button>
+              <div className="grid grid-cols-2 gap-3">
+                {[
+                  { format: 'json', label: 'JSON', color: 'bg-blue-600 hover:bg-blue-700' },
+                  { format: 'csv', label: 'CSV (zip)', color: 'bg-green-600 hover:bg-green-700' },
+                  { format: 'markdown', label: 'Markdown report', color: 'bg-purple-600 hover:bg-purple-700' },
+                  { format: 'html', label: 'HTML report', color: 'bg-indigo-600 hover:bg-indigo-700' },
+                  { format: 'junit', label: 'JUnit XML', color: 'bg-gray-700 hover:bg-gray-800' },
+                ].map(({ format, label, color }) => (
+                  <button
+                    key={format}
+                    onClick={() => downloadExport(format)}
+                    disabled={exporting}
+                    className={`${color} disabled:bg-gray-400 text-white font-semibold py-3 px-4 rounded-lg transition-colors`}
+                  >
+                    {exporting ? 'Exporting...' : label}
+                  </button>
+                ))}
+              </div>
+
+              <div className="border-t border-gray-200 mt-6 pt-4">
+                <h3 className="font-semibold text-gray-900 mb-2">Import from an export file</h3>
+                <div className="flex gap-4 text-sm text-gray-700 mb-3">
+                  <label className="flex items-center gap-1">
+                    <input type="radio" checked={importMode === 'merge'} onChange={() => setImportMode('merge')} />
+                    Merge with existing progress
+                  </label>
+                  <label className="flex items-center gap-1">
+                    <input type="radio" checked={importMode === 'replace'} onChange={() => setImportMode('replace')} />
+                    Replace existing progress
+                  </label>
+                </div>
+                <input
+                  type="file"
+                  accept="application/json,.json"
+                  disabled={importing}
+                  onChange={(e) => {
+                    const file = e.target.files?.[0]
+                    if (file) importFile(file)
+                    e.target.value = ''
+                  }}
+                  className="text-sm"
+                />
+                {importing && <p className="text-sm text-gray-600 mt-2">Importing...</p>}
+                {importMessage && <p className="text-sm text-gray-700 mt-2">{importMessage}</p>}
               </div>
 
               <button
                 onClick={() => setShowExportDialog(false)}
-                disabled={exporting}
+                disabled={exporting || importing}
                 className="w-full mt-3 bg-gray-200 hover:bg-gray-300 disabled:bg-gray-100 text-gray-700 font-semibold py-2 px-4 rounded-lg transition-colors"
               >
                 Cancel
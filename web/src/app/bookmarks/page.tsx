@@ -1,9 +1,123 @@
 'use client'
 
+import { useCallback, useEffect, useState } from 'react'
+import Link from 'next/link'
 import AppLayout from '@/components/AppLayout'
-import { Bookmark } from 'lucide-react'
+import { Bookmark, Search, StickyNote, Trash2 } from 'lucide-react'
+
+interface BookmarkItem {
+  id: number
+  exerciseSlug: string
+  exerciseTitle: string
+  domain: string
+  difficulty: string
+  tags: string[]
+  hasNote: boolean
+  createdAt: string
+}
+
+interface BookmarkTag {
+  tag: string
+  count: number
+}
+
+interface Note {
+  exerciseSlug: string
+  exerciseTitle: string
+  domain: string
+  notes: string
+  updatedAt?: string
+}
+
+interface NoteMatch extends Note {
+  snippet: string
+}
+
+interface NotesResponse {
+  success: boolean
+  notes?: Note[]
+  matches?: NoteMatch[]
+  bookmarks?: BookmarkItem[]
+  tags?: BookmarkTag[]
+  error?: string
+}
+
+async function call(path: string, method = 'GET'): Promise<NotesResponse> {
+  const response = await fetch(path, { method })
+  const data: NotesResponse = await response.json()
+  if (!response.ok || !data.success) {
+    throw new Error(data.error || `Request failed (${response.status})`)
+  }
+  return data
+}
+
+// Renders a search snippet, whose matches are wrapped in ** as in Markdown
+function Snippet({ text }: { text: string }) {
+  return (
+    <>
+      {text.split('**').map((part, i) =>
+        i % 2 === 1 ? <mark key={i} className="bg-yellow-100">{part}</mark> : <span key={i}>{part}</span>
+      )}
+    </>
+  )
+}
 
 export default function BookmarksPage() {
+  const [bookmarks, setBookmarks] = useState<BookmarkItem[]>([])
+  const [tags, setTags] = useState<BookmarkTag[]>([])
+  const [tag, setTag] = useState('')
+  const [notes, setNotes] = useState<Note[]>([])
+  const [query, setQuery] = useState('')
+  const [matches, setMatches] = useState<NoteMatch[] | null>(null)
+  const [error, setError] = useState('')
+
+  const load = useCallback(async () => {
+    try {
+      const [b, t, n] = await Promise.all([
+        call(`/api/bookmarks${tag ? `?tag=${encodeURIComponent(tag)}` : ''}`),
+        call('/api/bookmarks/tags'),
+        call('/api/notes'),
+      ])
+      setBookmarks(b.bookmarks || [])
+      setTags(t.tags || [])
+      setNotes(n.notes || [])
+      setError('')
+    } catch (e) {
+      setError((e as Error).message)
+    }
+  }, [tag])
+
+  useEffect(() => {
+    load()
+  }, [load])
+
+  useEffect(() => {
+    if (!query.trim()) {
+      setMatches(null)
+      return
+    }
+    const timeout = setTimeout(async () => {
+      try {
+        const data = await call(`/api/notes?q=${encodeURIComponent(query)}`)
+        setMatches(data.matches || [])
+      } catch {
+        setMatches([])
+      }
+    }, 250)
+    return () => clearTimeout(timeout)
+  }, [query])
+
+  const remove = async (slug: string) => {
+    try {
+      await call(`/api/bookmarks/${slug}`, 'DELETE')
+      load()
+    } catch (e) {
+      setError((e as Error).message)
+    }
+  }
+
+  const shownNotes: (Note | NoteMatch)[] = matches ?? notes
+
   return (
     <AppLayout>
       <div className="max-w-7xl mx-auto">
@@ -14,15 +128,99 @@ export default function BookmarksPage() {
           </p>
         </div>
 
-        <div className="flex items-center justify-center min-h-[400px]">
-          <div className="text-center">
-            <Bookmark className="w-16 h-16 text-gray-300 mx-auto mb-4" />
-            <p className="text-gray-500">No bookmarks yet</p>
-            <p className="text-sm text-gray-400 mt-2">
-              Bookmark lessons and labs to access them quickly
-            </p>
+        {error && (
+          <div className="mb-6 rounded-lg border border-red-200 bg-red-50 p-4 text-sm text-red-700">{error}</div>
+        )}
+
+        {tags.length > 0 && (
+          <div className="mb-4 flex flex-wrap gap-2">
+            <button
+              onClick={() => setTag('')}
+              className={`rounded-full px-3 py-1 text-sm ${tag === '' ? 'bg-blue-600 text-white' : 'bg-gray-100 text-gray-700'}`}
+            >
+              All
+            </button>
+            {tags.map((t) => (
+              <button
+                key={t.tag}
+                onClick={() => setTag(t.tag)}
+                className={`rounded-full px-3 py-1 text-sm ${tag === t.tag ? 'bg-blue-600 text-white' : 'bg-gray-100 text-gray-700'}`}
+              >
+                {t.tag} ({t.count})
+              </button>
+            ))}
+          </div>
+        )}
+
+        {bookmarks.length === 0 ? (
+          <div className="flex items-center justify-center min-h-[240px]">
+            <div className="text-center">
+              <Bookmark className="w-16 h-16 text-gray-300 mx-auto mb-4" />
+              <p className="text-gray-500">No bookmarks yet</p>
+              <p className="text-sm text-gray-400 mt-2">
+                Bookmark lessons and labs to access them quickly
+              </p>
+            </div>
+          </div>
+        ) : (
+          <div className="mb-10 grid gap-4 md:grid-cols-2">
+            {bookmarks.map((b) => (
+              <div key={b.id} className="rounded-lg border border-gray-200 bg-white p-4">
+                <div className="flex items-start justify-between">
+                  <div>
+                    <Link href={`/exercises/${b.exerciseSlug}`} className="font-semibold text-gray-900 hover:text-blue-600">
+                      {b.exerciseTitle}
+                    </Link>
+                    <p className="text-sm text-gray-500">
+                      {b.domain} · {b.difficulty}
+                      {b.hasNote && <StickyNote className="ml-2 inline w-4 h-4 text-amber-500" />}
+                    </p>
+                  </div>
+                  <button onClick={() => remove(b.exerciseSlug)} className="text-gray-400 hover:text-red-600" title="Remove bookmark">
+                    <Trash2 className="w-4 h-4" />
+                  </button>
+                </div>
+                {b.tags.length > 0 && (
+                  <div className="mt-2 flex flex-wrap gap-1">
+                    {b.tags.map((t) => (
+                      <span key={t} className="rounded bg-blue-50 px-2 py-0.5 text-xs text-blue-700">{t}</span>
+                    ))}
+                  </div>
+                )}
+              </div>
+            ))}
+          </div>
+        )}
+
+        <div className="mb-4 flex items-center justify-between">
+          <h2 className="text-xl font-semibold text-gray-900">Notes</h2>
+          <div className="relative w-72">
+            <Search className="absolute left-3 top-2.5 w-4 h-4 text-gray-400" />
+            <input
+              value={query}
+              onChange={(e) => setQuery(e.target.value)}
+              placeholder="Search notes"
+              className="w-full rounded-lg border border-gray-300 py-2 pl-9 pr-3 text-sm"
+            />
           </div>
         </div>
+
+        {shownNotes.length === 0 ? (
+          <p className="text-sm text-gray-500">{matches ? 'No notes match your search' : 'No notes yet'}</p>
+        ) : (
+          <div className="space-y-3">
+            {shownNotes.map((n) => (
+              <div key={n.exerciseSlug} className="rounded-lg border border-gray-200 bg-white p-4">
+                <Link href={`/exercises/${n.exerciseSlug}`} className="font-semibold text-gray-900 hover:text-blue-600">
+                  {n.exerciseTitle}
+                </Link>
+                <p className="mt-1 whitespace-pre-wrap text-sm text-gray-700">
+                  {'snippet' in n ? <Snippet text={n.snippet} /> : n.notes}
+                </p>
+              </div>
+            ))}
+          </div>
+        )}
       </div>
     </AppLayout>
   )
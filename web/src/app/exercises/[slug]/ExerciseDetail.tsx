@@ -3,6 +3,8 @@
 import { useState } from 'react'
 import { useRouter } from 'next/navigation'
 import type { Exercise } from '@/types/exercise'
+import type { APIError } from '@/types/setup'
+import { revealHint } from '@/lib/api'
 import { CategoryLabels, DifficultyColors } from '@/types/exercise'
 import ActionableError from '@/components/ActionableError'
 
@@ -19,10 +21,25 @@ export default function ExerciseDetail({ exercise }: { exercise: Exercise }) {
   const router = useRouter()
   const [showHints, setShowHints] = useState(false)
   const [showSolution, setShowSolution] = useState(false)
-  const [revealedHints, setRevealedHints] = useState<number>(0)
+  const [hints, setHints] = useState<string[]>(exercise.hints)
+  const [revealingHint, setRevealingHint] = useState(false)
+  const [hintError, setHintError] = useState<string | null>(null)
   const [provisioning, setProvisioning] = useState(false)
   const [provisionError, setProvisionError] = useState<ActionableErrorData | null>(null)
 
+  const handleRevealHint = async () => {
+    setRevealingHint(true)
+    setHintError(null)
+    try {
+      const data = await revealHint(exercise.slug)
+      setHints((data.revealed || []).map((reveal) => reveal.hint))
+    } catch (err) {
+      setHintError((err as APIError).message || 'Failed to reveal hint')
+    } finally {
+      setRevealingHint(false)
+    }
+  }
+
   const handleStartScenario = async () => {
     setProvisioning(true)
     setProvisionError(null)
@@ -130,15 +147,12 @@ export default function ExerciseDetail({ exercise }: { exercise: Exercise }) {
         </div>
 
         {/* Hints Section */}
-        {exercise.hints.length > 0 && (
+        {exercise.hintCount > 0 && (
           <div className="bg-white rounded-lg shadow-md p-8 mb-6">
             <div className="flex items-center justify-between mb-4">
               <h2 className="text-2xl font-semibold text-gray-900">Hints</h2>
               <button
-                onClick={() => {
-                  setShowHints(!showHints)
-                  if (!showHints) setRevealedHints(0)
-                }}
+                onClick={() => setShowHints(!showHints)}
                 className="text-blue-600 hover:text-blue-700 font-medium"
               >
                 {showHints ? 'Hide' : 'Show'} Hints
@@ -147,49 +161,40 @@ export default function ExerciseDetail({ exercise }: { exercise: Exercise }) {
 
             {showHints && (
               <div className="space-y-3">
-                {revealedHints === 0 ? (
-                  <div className="text-center py-4">
-                    <p className="text-gray-600 mb-4">
-                      There {exercise.hints.length === 1 ? 'is' : 'are'} {exercise.hints.length} hint
-                      {exercise.hints.length === 1 ? '' : 's'} available.
-                    </p>
-                    <div className="flex gap-3 justify-center">
-                      <button
-                        onClick={() => setRevealedHints(1)}
-                        className="bg-blue-600 hover:bg-blue-700 text-white font-semibold py-2 px-4 rounded-lg"
-                      >
-                        Reveal one hint at a time
-                      </button>
-                      <button
-                        onClick={() => setRevealedHints(exercise.hints.length)}
-                        className="bg-gray-600 hover:bg-gray-700 text-white font-semibold py-2 px-4 rounded-lg"
-                      >
-                        Show all hints
-                      </button>
+                {hints.map((hint, idx) => (
+                  <div key={idx} className="p-4 bg-blue-50 border border-blue-200 rounded-lg">
+                    <div className="flex items-start">
+                      <span className="flex-shrink-0 w-6 h-6 bg-blue-600 text-white rounded-full flex items-center justify-center text-sm font-semibold mr-3">
+                        {idx + 1}
+                      </span>
+                      <p className="text-gray-700">{hint}</p>
                     </div>
                   </div>
-                ) : (
-                  <>
-                    {exercise.hints.slice(0, revealedHints).map((hint, idx) => (
-                      <div key={idx} className="p-4 bg-blue-50 border border-blue-200 rounded-lg">
-                        <div className="flex items-start">
-                          <span className="flex-shrink-0 w-6 h-6 bg-blue-600 text-white rounded-full flex items-center justify-center text-sm font-semibold mr-3">
-                            {idx + 1}
-                          </span>
-                          <p className="text-gray-700">{hint}</p>
-                        </div>
-                      </div>
-                    ))}
-                    {revealedHints < exercise.hints.length && (
-                      <button
-                        onClick={() => setRevealedHints(revealedHints + 1)}
-                        className="text-blue-600 hover:text-blue-700 font-medium"
-                      >
-                        Show next hint ({revealedHints} of {exercise.hints.length} revealed)
-                      </button>
+                ))}
+                {hints.length < exercise.hintCount && (
+                  <div className={hints.length === 0 ? 'text-center py-4' : ''}>
+                    {hints.length === 0 && (
+                      <p className="text-gray-600 mb-4">
+                        There {exercise.hintCount === 1 ? 'is' : 'are'} {exercise.hintCount} hint
+                        {exercise.hintCount === 1 ? '' : 's'} available. Revealed hints are recorded with your attempt.
+                      </p>
                     )}
-                  </>
+                    <button
+                      onClick={handleRevealHint}
+                      disabled={revealingHint}
+                      className={
+                        hints.length === 0
+                          ? 'bg-blue-600 hover:bg-blue-700 disabled:bg-gray-400 text-white font-semibold py-2 px-4 rounded-lg'
+                          : 'text-blue-600 hover:text-blue-700 disabled:text-gray-400 font-medium'
+                      }
+                    >
+                      {hints.length === 0
+                        ? 'Reveal one hint'
+                        : `Show next hint (${hints.length} of ${exercise.hintCount} revealed)`}
+                    </button>
+                  </div>
                 )}
+                {hintError && <p className="text-sm text-red-600">{hintError}</p>}
               </div>
             )}
           </div>
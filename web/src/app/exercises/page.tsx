@@ -10,11 +10,17 @@ import {
   FlaskConical,
   Clock,
   CheckCircle,
-  ArrowRight
+  ArrowRight,
+  Search
 } from 'lucide-react'
 
 type DifficultyFilter = 'all' | 'beginner' | 'intermediate' | 'advanced'
 
+interface FacetCount {
+  value: string
+  count: number
+}
+
 export default function ExercisesPage() {
   const router = useRouter()
   const [exercises, setExercises] = useState<Exercise[]>([])
@@ -22,6 +28,32 @@ export default function ExercisesPage() {
   const [error, setError] = useState<string | null>(null)
   const [difficultyFilter, setDifficultyFilter] = useState<DifficultyFilter>('all')
   const [provisioningSlug, setProvisioningSlug] = useState<string | null>(null)
+  const [query, setQuery] = useState('')
+  const [tool, setTool] = useState('')
+  const [tools, setTools] = useState<FacetCount[]>([])
+  const [matches, setMatches] = useState<string[] | null>(null)
+
+  // Full-text and tool search; the results keep their relevance order
+  useEffect(() => {
+    const timeout = setTimeout(async () => {
+      const params = new URLSearchParams({ pageSize: '100' })
+      if (query.trim()) params.set('q', query)
+      if (tool) params.set('tool', tool)
+      try {
+        const response = await fetch(`/api/exercises/search?${params}`)
+        const data = await response.json()
+        if (!data.success) {
+          setMatches([])
+          return
+        }
+        setTools(data.facets.tool)
+        setMatches(query.trim() || tool ? data.exercises.map((ex: Exercise) => ex.slug) : null)
+      } catch {
+        setMatches(null)
+      }
+    }, 250)
+    return () => clearTimeout(timeout)
+  }, [query, tool])
 
   useEffect(() => {
     let mounted = true
@@ -97,9 +129,12 @@ export default function ExercisesPage() {
     }
   }
 
-  const filteredExercises = difficultyFilter === 'all'
+  const searchedExercises = matches === null
     ? exercises
-    : exercises.filter(ex => ex.difficulty.toLowerCase() === difficultyFilter)
+    : matches.flatMap(slug => exercises.filter(ex => ex.slug === slug))
+  const filteredExercises = difficultyFilter === 'all'
+    ? searchedExercises
+    : searchedExercises.filter(ex => ex.difficulty.toLowerCase() === difficultyFilter)
 
   const difficultyBadgeStyles: Record<string, string> = {
     beginner: 'bg-green-50 text-green-700 border-green-200',
@@ -144,6 +179,33 @@ export default function ExercisesPage() {
           </p>
         </div>
 
+        {/* Search */}
+        <div className="relative mb-4 max-w-md">
+          <Search className="absolute left-3 top-2.5 w-4 h-4 text-gray-400" />
+          <input
+            value={query}
+            onChange={(e) => setQuery(e.target.value)}
+            placeholder="Search titles, descriptions and hints"
+            className="w-full rounded-lg border border-gray-300 py-2 pl-9 pr-3 text-sm"
+          />
+        </div>
+
+        {tools.length > 0 && (
+          <div className="flex flex-wrap gap-2 mb-4">
+            {tools.map((t) => (
+              <button
+                key={t.value}
+                onClick={() => setTool(tool === t.value ? '' : t.value)}
+                className={`rounded-full px-3 py-1 text-xs ${
+                  tool === t.value ? 'bg-blue-600 text-white' : 'bg-gray-100 text-gray-700 hover:bg-gray-200'
+                }`}
+              >
+                {t.value} ({t.count})
+              </button>
+            ))}
+          </div>
+        )}
+
         {/* Difficulty Filter */}
         <div className="flex gap-2 mb-6">
           {(['all', 'beginner', 'intermediate', 'advanced'] as DifficultyFilter[]).map((level) => (
@@ -2,8 +2,9 @@
 
 import { useEffect, useState } from 'react'
 import { useRouter } from 'next/navigation'
-import { getExerciseBySlug } from '@/lib/api'
+import { getExerciseBySlug, revealHint } from '@/lib/api'
 import type { Exercise } from '@/types/exercise'
+import type { APIError } from '@/types/setup'
 import { DifficultyColors } from '@/types/exercise'
 import RightPanel from '@/components/RightPanel'
 import Timer from '@/components/Timer'
@@ -34,7 +35,8 @@ export default function PracticeView({ slug }: { slug: string }) {
   const [loading, setLoading] = useState(true)
   const [error, setError] = useState<string | null>(null)
   const [showHints, setShowHints] = useState(false)
-  const [revealedHints, setRevealedHints] = useState<number>(0)
+  const [revealingHint, setRevealingHint] = useState(false)
+  const [hintError, setHintError] = useState<string | null>(null)
   const [personalBest, setPersonalBest] = useState<number | undefined>(undefined)
   const [resetting, setResetting] = useState(false)
   const [timerKey, setTimerKey] = useState(0)
@@ -125,8 +127,8 @@ export default function PracticeView({ slug }: { slug: string }) {
     setResetError(null)
 
     try {
-      // Delete existing cluster
-      const deleteResponse = await fetch(`/api/cluster/${slug}`, {
+      // Delete existing cluster (reset=true tells the server it will be recreated)
+      const deleteResponse = await fetch(`/api/cluster/${slug}?reset=true`, {
         method: 'DELETE',
       })
 
@@ -186,6 +188,20 @@ export default function PracticeView({ slug }: { slug: string }) {
     }
   }
 
+  const handleRevealHint = async () => {
+    if (!exercise) return
+    setRevealingHint(true)
+    setHintError(null)
+    try {
+      const data = await revealHint(exercise.slug)
+      setExercise({ ...exercise, hints: (data.revealed || []).map((reveal) => reveal.hint) })
+    } catch (err) {
+      setHintError((err as APIError).message || 'Failed to reveal hint')
+    } finally {
+      setRevealingHint(false)
+    }
+  }
+
   const handleValidate = async () => {
     setValidating(true)
     setValidationResult(null)
@@ -361,15 +377,12 @@ export default function PracticeView({ slug }: { slug: string }) {
             )}
 
             {/* Hints Section */}
-            {exercise.hints.length > 0 && (
+            {exercise.hintCount > 0 && (
               <div className="mb-6">
                 <div className="flex items-center justify-between mb-3">
                   <h2 className="text-xl font-semibold text-gray-900">Hints</h2>
                   <button
-                    onClick={() => {
-                      setShowHints(!showHints)
-                      if (!showHints) setRevealedHints(0)
-                    }}
+                    onClick={() => setShowHints(!showHints)}
                     className="text-blue-600 hover:text-blue-700 font-medium text-sm"
                   >
                     {showHints ? 'Hide' : 'Show'} Hints
@@ -378,49 +391,40 @@ export default function PracticeView({ slug }: { slug: string }) {
 
                 {showHints && (
                   <div className="space-y-3">
-                    {revealedHints === 0 ? (
-                      <div className="text-center py-4 bg-gray-50 rounded-lg">
-                        <p className="text-gray-600 mb-4 text-sm">
-                          There {exercise.hints.length === 1 ? 'is' : 'are'} {exercise.hints.length} hint
-                          {exercise.hints.length === 1 ? '' : 's'} available.
-                        </p>
-                        <div className="flex gap-3 justify-center">
-                          <button
-                            onClick={() => setRevealedHints(1)}
-                            className="bg-blue-600 hover:bg-blue-700 text-white font-semibold py-2 px-4 rounded-lg text-sm"
-                          >
-                            Reveal one hint
-                          </button>
-                          <button
-                            onClick={() => setRevealedHints(exercise.hints.length)}
-                            className="bg-gray-600 hover:bg-gray-700 text-white font-semibold py-2 px-4 rounded-lg text-sm"
-                          >
-                            Show all
-                          </button>
+                    {exercise.hints.map((hint, idx) => (
+                      <div key={idx} className="p-3 bg-blue-50 border border-blue-200 rounded-lg">
+                        <div className="flex items-start">
+                          <span className="flex-shrink-0 w-6 h-6 bg-blue-600 text-white rounded-full flex items-center justify-center text-xs font-semibold mr-3">
+                            {idx + 1}
+                          </span>
+                          <p className="text-gray-700 text-sm">{hint}</p>
                         </div>
                       </div>
-                    ) : (
-                      <>
-                        {exercise.hints.slice(0, revealedHints).map((hint, idx) => (
-                          <div key={idx} className="p-3 bg-blue-50 border border-blue-200 rounded-lg">
-                            <div className="flex items-start">
-                              <span className="flex-shrink-0 w-6 h-6 bg-blue-600 text-white rounded-full flex items-center justify-center text-xs font-semibold mr-3">
-                                {idx + 1}
-                              </span>
-                              <p className="text-gray-700 text-sm">{hint}</p>
-                            </div>
-                          </div>
-                        ))}
-                        {revealedHints < exercise.hints.length && (
-                          <button
-                            onClick={() => setRevealedHints(revealedHints + 1)}
-                            className="text-blue-600 hover:text-blue-700 font-medium text-sm"
-                          >
-                            Show next hint ({revealedHints} of {exercise.hints.length} revealed)
-                          </button>
+                    ))}
+                    {exercise.hints.length < exercise.hintCount && (
+                      <div className={exercise.hints.length === 0 ? 'text-center py-4 bg-gray-50 rounded-lg' : ''}>
+                        {exercise.hints.length === 0 && (
+                          <p className="text-gray-600 mb-4 text-sm">
+                            There {exercise.hintCount === 1 ? 'is' : 'are'} {exercise.hintCount} hint
+                            {exercise.hintCount === 1 ? '' : 's'} available. Revealed hints are recorded with your attempt.
+                          </p>
                         )}
-                      </>
+                        <button
+                          onClick={handleRevealHint}
+                          disabled={revealingHint}
+                          className={
+                            exercise.hints.length === 0
+                              ? 'bg-blue-600 hover:bg-blue-700 disabled:bg-gray-400 text-white font-semibold py-2 px-4 rounded-lg text-sm'
+                              : 'text-blue-600 hover:text-blue-700 disabled:text-gray-400 font-medium text-sm'
+                          }
+                        >
+                          {exercise.hints.length === 0
+                            ? 'Reveal one hint'
+                            : `Show next hint (${exercise.hints.length} of ${exercise.hintCount} revealed)`}
+                        </button>
+                      </div>
                     )}
+                    {hintError && <p className="text-sm text-red-600">{hintError}</p>}
                   </div>
                 )}
               </div>
@@ -0,0 +1,431 @@
+'use client'
+
+import { useCallback, useEffect, useState } from 'react'
+import AppLayout from '@/components/AppLayout'
+import { RefreshCw, Trash2, Users } from 'lucide-react'
+
+interface Assignment {
+  id: number
+  member: string
+  exercise: string
+  title?: string
+  note?: string
+  dueDate?: string
+  assignedAt: string
+  status: 'open' | 'completed' | 'overdue'
+}
+
+interface TeamStatus {
+  hubUrl: string
+  member: string
+  secretSet: boolean
+  configured: boolean
+  hubAddress?: string
+  lastSyncedAt?: string
+  assignments: Assignment[]
+}
+
+interface Standing {
+  rank: number
+  member: string
+  slug: string
+  scenariosCompleted: number
+  totalScenarios: number
+  completionPercentage: number
+  attempts: number
+  passRate: number
+  averageScore: number
+  practiceMinutes: number
+  mockExamsPassed: number
+  lastSyncedAt: string
+}
+
+interface MemberDomain {
+  member: string
+  slug: string
+  completed: number
+  total: number
+  completionPercentage: number
+  attempts: number
+  passRate: number
+  averageScore: number
+}
+
+interface DomainComparison {
+  domain: string
+  displayName: string
+  weight: number
+  members: MemberDomain[]
+  teamAverageScore: number
+  leader?: string
+}
+
+const statusStyles: Record<Assignment['status'], string> = {
+  open: 'bg-blue-100 text-blue-800',
+  completed: 'bg-green-100 text-green-800',
+  overdue: 'bg-red-100 text-red-800',
+}
+
+async function teamRequest(path: string, init?: RequestInit) {
+  const response = await fetch(`/api/team${path}`, {
+    ...init,
+    headers: init?.body ? { 'Content-Type': 'application/json' } : undefined,
+  })
+  const result = await response.json().catch(() => null)
+  if (!response.ok || !result?.success) {
+    throw new Error(result?.error || `Request failed (${response.status})`)
+  }
+  return result
+}
+
+export default function TeamPage() {
+  const [status, setStatus] = useState<TeamStatus | null>(null)
+  const [form, setForm] = useState({ hubUrl: '', secret: '', member: '' })
+  const [leaderboard, setLeaderboard] = useState<Standing[]>([])
+  const [domains, setDomains] = useState<DomainComparison[]>([])
+  const [assignments, setAssignments] = useState<Assignment[]>([])
+  const [newAssignment, setNewAssignment] = useState({ member: '', exercise: '', note: '', dueDate: '' })
+  const [error, setError] = useState<string | null>(null)
+  const [busy, setBusy] = useState(false)
+
+  const isHub = Boolean(status?.hubAddress)
+
+  const loadBoards = useCallback(async (current: TeamStatus) => {
+    if (!current.configured && !current.hubAddress) return
+    try {
+      const [board, compared, assigned] = await Promise.all([
+        teamRequest('/leaderboard'),
+        teamRequest('/compare'),
+        teamRequest('/assignments'),
+      ])
+      setLeaderboard(board.leaderboard ?? [])
+      setDomains(compared.domains ?? [])
+      setAssignments(assigned.assignments ?? [])
+      setError(null)
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to reach the team hub')
+    }
+  }, [])
+
+  const loadStatus = useCallback(async () => {
+    try {
+      const result = await teamRequest('')
+      setStatus(result.status)
+      setAssignments(result.status.assignments ?? [])
+      setForm({ hubUrl: result.status.hubUrl, secret: '', member: result.status.member })
+      await loadBoards(result.status)
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Failed to load team settings')
+    }
+  }, [loadBoards])
+
+  useEffect(() => {
+    loadStatus()
+  }, [loadStatus])
+
+  const run = async (action: () => Promise<void>) => {
+    setBusy(true)
+    try {
+      await action()
+      setError(null)
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'Request failed')
+    } finally {
+      setBusy(false)
+    }
+  }
+
+  const handleSave = () =>
+    run(async () => {
+      await teamRequest('', { method: 'PUT', body: JSON.stringify(form) })
+      await loadStatus()
+    })
+
+  const handleSync = () =>
+    run(async () => {
+      await teamRequest('/sync', { method: 'POST' })
+      await loadStatus()
+    })
+
+  const handleAssign = () =>
+    run(async () => {
+      await teamRequest('/assignments', { method: 'POST', body: JSON.stringify(newAssignment) })
+      setNewAssignment({ member: newAssignment.member, exercise: '', note: '', dueDate: '' })
+      if (status) await loadBoards(status)
+    })
+
+  const handleWithdraw = (id: number) =>
+    run(async () => {
+      await teamRequest(`/assignments/${id}`, { method: 'DELETE' })
+      if (status) await loadBoards(status)
+    })
+
+  const handleRemoveMember = (slug: string) =>
+    run(async () => {
+      if (!confirm(`Remove ${slug} and their assignments from the hub?`)) return
+      await teamRequest(`/members/${encodeURIComponent(slug)}`, { method: 'DELETE' })
+      if (status) await loadBoards(status)
+    })
+
+  return (
+    <AppLayout>
+      <div className="max-w-7xl mx-auto">
+        <div className="mb-8 flex items-start justify-between">
+          <div>
+            <h1 className="text-3xl font-bold text-gray-900 mb-2">Team</h1>
+            <p className="text-gray-600">
+              Compare progress with your study group through a hub on your own network
+            </p>
+          </div>
+          {status?.configured && (
+            <button
+              onClick={handleSync}
+              disabled={busy}
+              className="flex items-center gap-2 px-4 py-2 bg-blue-600 text-white rounded-lg hover:bg-blue-700 disabled:opacity-50"
+            >
+              <RefreshCw className={`w-4 h-4 ${busy ? 'animate-spin' : ''}`} />
+              Sync now
+            </button>
+          )}
+        </div>
+
+        {error && (
+          <div className="mb-6 p-4 bg-red-50 border border-red-200 rounded-lg text-red-800">{error}</div>
+        )}
+
+        <div className="bg-white rounded-lg shadow p-6 mb-8">
+          <h2 className="text-xl font-semibold text-gray-900 mb-4">Hub Connection</h2>
+          {isHub && (
+            <p className="text-sm text-gray-600 mb-4">
+              This instance runs the hub on <code className="font-mono">{status?.hubAddress}</code>. Share its
+              address and the team secret printed at startup with your group.
+            </p>
+          )}
+          <div className="grid grid-cols-1 md:grid-cols-3 gap-4">
+            <label className="text-sm text-gray-700">
+              Hub URL
+              <input
+                value={form.hubUrl}
+                onChange={(e) => setForm({ ...form, hubUrl: e.target.value })}
+                placeholder="http://192.168.1.20:3100"
+                className="mt-1 w-full border border-gray-300 rounded-lg px-3 py-2"
+              />
+            </label>
+            <label className="text-sm text-gray-700">
+              Team secret
+              <input
+                type="password"
+                value={form.secret}
+                onChange={(e) => setForm({ ...form, secret: e.target.value })}
+                placeholder={status?.secretSet ? 'Unchanged' : 'Shared by the hub'}
+                className="mt-1 w-full border border-gray-300 rounded-lg px-3 py-2"
+              />
+            </label>
+            <label className="text-sm text-gray-700">
+              Your name on the leaderboard
+              <input
+                value={form.member}
+                onChange={(e) => setForm({ ...form, member: e.target.value })}
+                className="mt-1 w-full border border-gray-300 rounded-lg px-3 py-2"
+              />
+            </label>
+          </div>
+          <div className="mt-4 flex items-center gap-4">
+            <button
+              onClick={handleSave}
+              disabled={busy}
+              className="px-4 py-2 bg-gray-900 text-white rounded-lg hover:bg-gray-700 disabled:opacity-50"
+            >
+              Save
+            </button>
+            {status?.lastSyncedAt && (
+              <span className="text-sm text-gray-500">
+                Last synced {new Date(status.lastSyncedAt).toLocaleString()}
+              </span>
+            )}
+          </div>
+        </div>
+
+        {!status?.configured && !isHub ? (
+          <div className="flex items-center justify-center min-h-[200px]">
+            <div className="text-center">
+              <Users className="w-16 h-16 text-gray-300 mx-auto mb-4" />
+              <p className="text-gray-500">Not connected to a team hub</p>
+              <p className="text-sm text-gray-400 mt-2">Enter the hub URL and team secret to join your group</p>
+            </div>
+          </div>
+        ) : (
+          <>
+            <div className="bg-white rounded-lg shadow p-6 mb-8">
+              <h2 className="text-xl font-semibold text-gray-900 mb-4">Leaderboard</h2>
+              {leaderboard.length === 0 ? (
+                <p className="text-gray-500">Nobody has synced yet</p>
+              ) : (
+                <table className="w-full">
+                  <thead>
+                    <tr className="border-b border-gray-200">
+                      <th className="text-left py-3 px-4 font-semibold text-gray-700">#</th>
+                      <th className="text-left py-3 px-4 font-semibold text-gray-700">Member</th>
+                      <th className="text-left py-3 px-4 font-semibold text-gray-700">Completed</th>
+                      <th className="text-left py-3 px-4 font-semibold text-gray-700">Avg Score</th>
+                      <th className="text-left py-3 px-4 font-semibold text-gray-700">Pass Rate</th>
+                      <th className="text-left py-3 px-4 font-semibold text-gray-700">Practice</th>
+                      <th className="text-left py-3 px-4 font-semibold text-gray-700">Mocks Passed</th>
+                      <th className="text-left py-3 px-4 font-semibold text-gray-700">Synced</th>
+                      {isHub && <th />}
+                    </tr>
+                  </thead>
+                  <tbody>
+                    {leaderboard.map((s) => (
+                      <tr key={s.slug} className="border-b border-gray-100">
+                        <td className="py-3 px-4 font-semibold">{s.rank}</td>
+                        <td className="py-3 px-4">{s.member}</td>
+                        <td className="py-3 px-4">
+                          {s.scenariosCompleted}/{s.totalScenarios} ({s.completionPercentage}%)
+                        </td>
+                        <td className="py-3 px-4">{s.averageScore}%</td>
+                        <td className="py-3 px-4">{s.passRate}%</td>
+                        <td className="py-3 px-4">{s.practiceMinutes} min</td>
+                        <td className="py-3 px-4">{s.mockExamsPassed}</td>
+                        <td className="py-3 px-4 text-sm text-gray-500">
+                          {new Date(s.lastSyncedAt).toLocaleString()}
+                        </td>
+                        {isHub && (
+                          <td className="py-3 px-4">
+                            <button
+                              onClick={() => handleRemoveMember(s.slug)}
+                              className="text-gray-400 hover:text-red-600"
+                              title="Remove member"
+                            >
+                              <Trash2 className="w-4 h-4" />
+                            </button>
+                          </td>
+                        )}
+                      </tr>
+                    ))}
+                  </tbody>
+                </table>
+              )}
+            </div>
+
+            {domains.length > 0 && leaderboard.length > 0 && (
+              <div className="bg-white rounded-lg shadow p-6 mb-8">
+                <h2 className="text-xl font-semibold text-gray-900 mb-4">Domain Comparison</h2>
+                <div className="space-y-6">
+                  {domains.map((d) => (
+                    <div key={d.domain}>
+                      <div className="flex justify-between mb-2">
+                        <span className="font-medium text-gray-900">
+                          {d.displayName} <span className="text-sm text-gray-500">({d.weight}%)</span>
+                        </span>
+                        <span className="text-sm text-gray-600">Team average {d.teamAverageScore}%</span>
+                      </div>
+                      <div className="space-y-1">
+                        {d.members.map((m) => (
+                          <div key={m.slug} className="flex items-center gap-3 text-sm">
+                            <span className="w-32 truncate text-gray-700">
+                              {m.member}
+                              {d.leader === m.slug && ' ★'}
+                            </span>
+                            <div className="flex-1 bg-gray-100 rounded-full h-2">
+                              <div
+                                className="bg-blue-600 h-2 rounded-full"
+                                style={{ width: `${Math.min(m.averageScore, 100)}%` }}
+                              />
+                            </div>
+                            <span className="w-40 text-right text-gray-600">
+                              {m.averageScore}% · {m.completed}/{m.total} done
+                            </span>
+                          </div>
+                        ))}
+                      </div>
+                    </div>
+                  ))}
+                </div>
+              </div>
+            )}
+
+            <div className="bg-white rounded-lg shadow p-6 mb-8">
+              <h2 className="text-xl font-semibold text-gray-900 mb-4">
+                {isHub ? 'Assignments' : 'Your Assignments'}
+              </h2>
+              {isHub && (
+                <div className="grid grid-cols-1 md:grid-cols-5 gap-3 mb-6">
+                  <select
+                    value={newAssignment.member}
+                    onChange={(e) => setNewAssignment({ ...newAssignment, member: e.target.value })}
+                    className="border border-gray-300 rounded-lg px-3 py-2"
+                  >
+                    <option value="">Member…</option>
+                    {leaderboard.map((s) => (
+                      <option key={s.slug} value={s.slug}>
+                        {s.member}
+                      </option>
+                    ))}
+                  </select>
+                  <input
+                    value={newAssignment.exercise}
+                    onChange={(e) => setNewAssignment({ ...newAssignment, exercise: e.target.value })}
+                    placeholder="Exercise slug"
+                    className="border border-gray-300 rounded-lg px-3 py-2"
+                  />
+                  <input
+                    value={newAssignment.note}
+                    onChange={(e) => setNewAssignment({ ...newAssignment, note: e.target.value })}
+                    placeholder="Note"
+                    className="border border-gray-300 rounded-lg px-3 py-2"
+                  />
+                  <input
+                    type="date"
+                    value={newAssignment.dueDate}
+                    onChange={(e) => setNewAssignment({ ...newAssignment, dueDate: e.target.value })}
+                    className="border border-gray-300 rounded-lg px-3 py-2"
+                  />
+                  <button
+                    onClick={handleAssign}
+                    disabled={busy || !newAssignment.member || !newAssignment.exercise}
+                    className="px-4 py-2 bg-blue-600 text-white rounded-lg hover:bg-blue-700 disabled:opacity-50"
+                  >
+                    Assign
+                  </button>
+                </div>
+              )}
+              {assignments.length === 0 ? (
+                <p className="text-gray-500">No assignments</p>
+              ) : (
+                <ul className="divide-y divide-gray-100">
+                  {assignments.map((a) => (
+                    <li key={a.id} className="py-3 flex items-center justify-between">
+                      <div>
+                        <a href={`/exercises/${a.exercise}`} className="font-medium text-blue-700 hover:underline">
+                          {a.title || a.exercise}
+                        </a>
+                        {isHub && <span className="ml-2 text-sm text-gray-500">for {a.member}</span>}
+                        {a.note && <p className="text-sm text-gray-600">{a.note}</p>}
+                      </div>
+                      <div className="flex items-center gap-3">
+                        {a.dueDate && <span className="text-sm text-gray-500">due {a.dueDate}</span>}
+                        <span className={`px-2 py-1 rounded text-xs font-medium ${statusStyles[a.status]}`}>
+                          {a.status}
+                        </span>
+                        {isHub && (
+                          <button
+                            onClick={() => handleWithdraw(a.id)}
+                            className="text-gray-400 hover:text-red-600"
+                            title="Withdraw assignment"
+                          >
+                            <Trash2 className="w-4 h-4" />
+                          </button>
+                        )}
+                      </div>
+                    </li>
+                  ))}
+                </ul>
+              )}
+            </div>
+          </>
+        )}
+      </div>
+    </AppLayout>
+  )
+}
@@ -45,6 +45,10 @@ export default function MultiTerminal({
   const [splitDirection, setSplitDirection] = useState<SplitDirection>(null)
   const [nodes, setNodes] = useState<Node[]>([])
   const [internalSelectedNode, setInternalSelectedNode] = useState<string>('')
+  // Exam-style terminals start on a candidate host and reach nodes over ssh
+  const [candidateMode, setCandidateMode] = useState(false)
+  // Exam realism enforces the exercise's paste policy in the terminal
+  const [examRealism, setExamRealism] = useState(false)
 
   // Use controlled prop if provided, otherwise use internal state
   const selectedNode = controlledSelectedNode !== undefined ? controlledSelectedNode : internalSelectedNode
@@ -125,7 +129,7 @@ export default function MultiTerminal({
 
     if (!selectedNode || panes.length === 0) return
 
-    console.log('Node changed to:', selectedNode, '- reconnecting terminals')
+    console.log('Node changed to:', selectedNode, 'candidate mode:', candidateMode, 'exam realism:', examRealism, '- reconnecting terminals')
 
     // Close all existing terminals and reconnect to new node
     panes.forEach(pane => {
@@ -148,7 +152,7 @@ export default function MultiTerminal({
         })
       })
     }, 100)
-  }, [selectedNode])
+  }, [selectedNode, candidateMode, examRealism])
 
   // Cleanup on unmount
   useEffect(() => {
@@ -212,13 +216,24 @@ export default function MultiTerminal({
       term.focus() // Give terminal focus to receive keyboard input
     }, 50)
 
-    // Connect WebSocket (include target node if specified)
+    // Connect WebSocket (candidate host, or the target node if specified)
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
+    const params = new URLSearchParams()
+    if (candidateMode) {
+      params.set('mode', 'candidate')
+    } else if (nodeForTerminal) {
+      params.set('node', nodeForTerminal)
+    }
+    if (examRealism) {
+      params.set('realism', 'exam')
+    }
     let wsUrl = `${protocol}//${window.location.host}/api/terminal/${exerciseSlug}`
-    if (nodeForTerminal) {
-      wsUrl += `?node=${encodeURIComponent(nodeForTerminal)}`
+    if (params.toString()) {
+      wsUrl += `?${params}`
     }
     const ws = new WebSocket(wsUrl)
+    // PTY output arrives as binary frames; status messages stay text
+    ws.binaryType = 'arraybuffer'
 
     ws.onopen = () => {
       term.writeln('\x1b[32m✓ Connected to terminal\x1b[0m')
@@ -233,7 +248,11 @@ export default function MultiTerminal({
     }
 
     ws.onmessage = (event) => {
-      term.write(event.data)
+      if (event.data instanceof ArrayBuffer) {
+        term.write(new Uint8Array(event.data))
+      } else {
+        term.write(event.data)
+      }
     }
 
     ws.onerror = () => {
@@ -441,7 +460,8 @@ export default function MultiTerminal({
             <select
               value={selectedNode}
               onChange={(e) => setSelectedNode(e.target.value)}
-              className="bg-gray-700 text-gray-200 text-sm px-3 py-1 rounded border border-gray-600 focus:outline-none focus:border-blue-500"
+              disabled={candidateMode}
+              className="bg-gray-700 text-gray-200 text-sm px-3 py-1 rounded border border-gray-600 focus:outline-none focus:border-blue-500 disabled:opacity-50"
             >
               {nodes.map((node) => (
                 <option key={node.name} value={node.name}>
@@ -449,6 +469,30 @@ export default function MultiTerminal({
                 </option>
               ))}
             </select>
+            <label
+              className="flex items-center gap-1 text-xs text-gray-400 ml-2 cursor-pointer"
+              title="Start on a candidate host and reach nodes with ssh, as in the CKS exam"
+            >
+              <input
+                type="checkbox"
+                checked={candidateMode}
+                onChange={(e) => setCandidateMode(e.target.checked)}
+                className="accent-blue-500"
+              />
+              Exam-style SSH
+            </label>
+            <label
+              className="flex items-center gap-1 text-xs text-gray-400 ml-2 cursor-pointer"
+              title="Block large pastes into the terminal, following the exercise's exam policy"
+            >
+              <input
+                type="checkbox"
+                checked={examRealism}
+                onChange={(e) => setExamRealism(e.target.checked)}
+                className="accent-blue-500"
+              />
+              Exam realism
+            </label>
           </div>
         )}
 
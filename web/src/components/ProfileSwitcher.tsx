@@ -0,0 +1,78 @@
+'use client'
+
+import { useEffect, useState } from 'react'
+import { UserCircle } from 'lucide-react'
+
+interface Profile {
+  id: number
+  slug: string
+  name: string
+  active: boolean
+}
+
+const NEW_PROFILE = '__new__'
+
+export default function ProfileSwitcher() {
+  const [profiles, setProfiles] = useState<Profile[]>([])
+  const [switching, setSwitching] = useState(false)
+
+  useEffect(() => {
+    fetch('/api/profiles')
+      .then((response) => (response.ok ? response.json() : null))
+      .then((result) => setProfiles(result?.profiles ?? []))
+      .catch((error) => console.error('Failed to load profiles:', error))
+  }, [])
+
+  const active = profiles.find((profile) => profile.active)
+
+  const handleChange = async (slug: string) => {
+    setSwitching(true)
+    try {
+      let response: Response
+      if (slug === NEW_PROFILE) {
+        const name = window.prompt('Name of the new profile')
+        if (!name) return
+        response = await fetch('/api/profiles', {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({ name, activate: true }),
+        })
+      } else {
+        response = await fetch(`/api/profiles/${slug}/activate`, { method: 'POST' })
+      }
+      const result = await response.json().catch(() => null)
+      if (!response.ok || !result?.success) {
+        alert(result?.error || 'Failed to switch profiles')
+        return
+      }
+      // Every page shows the active profile's progress
+      window.location.reload()
+    } finally {
+      setSwitching(false)
+    }
+  }
+
+  if (profiles.length === 0) {
+    return null
+  }
+
+  return (
+    <label className="mt-4 flex items-center gap-2 text-sm text-slate-600 dark:text-slate-300">
+      <UserCircle className="w-5 h-5 flex-shrink-0" />
+      <select
+        value={active?.slug ?? ''}
+        onChange={(e) => handleChange(e.target.value)}
+        disabled={switching}
+        aria-label="Learner profile"
+        className="flex-1 bg-transparent border border-slate-200 dark:border-slate-600 rounded px-2 py-1"
+      >
+        {profiles.map((profile) => (
+          <option key={profile.slug} value={profile.slug}>
+            {profile.name}
+          </option>
+        ))}
+        <option value={NEW_PROFILE}>+ New profile…</option>
+      </select>
+    </label>
+  )
+}
@@ -9,8 +9,10 @@ import {
   FileCheck,
   BarChart3,
   Bookmark,
-  Shield
+  Shield,
+  Users
 } from 'lucide-react'
+import ProfileSwitcher from './ProfileSwitcher'
 
 const navItems = [
   { id: 'dashboard', label: 'Dashboard', icon: LayoutDashboard, href: '/dashboard' },
@@ -19,6 +21,7 @@ const navItems = [
   { id: 'exams', label: 'Mock Exams', icon: FileCheck, href: '/exams' },
   { id: 'bookmarks', label: 'Bookmarks', icon: Bookmark, href: '/bookmarks' },
   { id: 'analytics', label: 'Analytics', icon: BarChart3, href: '/analytics' },
+  { id: 'team', label: 'Team', icon: Users, href: '/team' },
 ]
 
 export default function Sidebar() {
@@ -37,6 +40,7 @@ export default function Sidebar() {
               <p className="text-xs text-slate-500 dark:text-slate-400">Security Specialist</p>
             </div>
           </div>
+          <ProfileSwitcher />
         </div>
 
         <nav className="flex-1 p-4 space-y-1 overflow-y-auto">
@@ -64,6 +64,8 @@ export default function Terminal({ exerciseSlug, clusterReady }: TerminalProps)
     const protocol = window.location.protocol === 'https:' ? 'wss:' : 'ws:'
     const wsUrl = `${protocol}//${window.location.host}/api/terminal/${exerciseSlug}`
     const ws = new WebSocket(wsUrl)
+    // PTY output arrives as binary frames; status messages stay text
+    ws.binaryType = 'arraybuffer'
 
     ws.onopen = () => {
       term.writeln('\x1b[32m✓ Connected to terminal\x1b[0m')
@@ -78,7 +80,11 @@ export default function Terminal({ exerciseSlug, clusterReady }: TerminalProps)
     }
 
     ws.onmessage = (event) => {
-      term.write(event.data)
+      if (event.data instanceof ArrayBuffer) {
+        term.write(new Uint8Array(event.data))
+      } else {
+        term.write(event.data)
+      }
     }
 
     ws.onerror = () => {
@@ -1,5 +1,5 @@
 import type { ValidationResponse, APIError, InitializeResponse, DatabaseStatus } from '@/types/setup'
-import type { ExercisesResponse, Exercise } from '@/types/exercise'
+import type { ExercisesResponse, Exercise, HintResponse } from '@/types/exercise'
 
 /**
  * Validates prerequisites by calling the backend API
@@ -172,3 +172,24 @@ export async function getExerciseBySlug(slug: string): Promise<Exercise> {
     } as APIError
   }
 }
+
+/**
+ * Reveals the next hint of an exercise. The reveal is logged against the
+ * current attempt and may cost points when the attempt is validated.
+ * @param slug - Exercise slug
+ * @returns Promise with the revealed hint and every hint revealed so far
+ * @throws APIError if request fails
+ */
+export async function revealHint(slug: string): Promise<HintResponse> {
+  const response = await fetch(`/api/hints/${slug}/reveal`, { method: 'POST' })
+  const data: HintResponse = await response.json()
+
+  if (!response.ok || !data.success) {
+    throw {
+      code: data.errorCode || 'HTTP_ERROR',
+      message: data.error || `HTTP ${response.status}: ${response.statusText}`,
+    } as APIError
+  }
+
+  return data
+}
@@ -9,10 +9,28 @@ export interface Exercise {
   points: number
   estimatedMinutes: number
   prerequisites: string[]
-  hints: string[]
+  hints: string[] // Only the hints revealed for the current attempt
+  hintCount: number
+  competencies: string[] // Curriculum competency slugs
   solution: string
 }
 
+export interface HintReveal {
+  index: number
+  hint: string
+  revealedAt: string
+}
+
+export interface HintResponse {
+  success: boolean
+  hint?: HintReveal
+  revealed?: HintReveal[]
+  total: number
+  penaltyPercent: number
+  errorCode?: string
+  error?: string
+}
+
 export interface ExercisesResponse {
   success: boolean
   exercises?: Exercise[]